@@ -13,20 +13,172 @@ import (
 type Config struct {
 	// KubeConfigSecretName is the name of the kubernetes secret which has the kubeconfig to connect to the shoot control plane API server via internal domain
 	KubeConfigSecretName string `json:"kubeConfigSecretName"`
+	// KubeConfigTokenSecretName is the name of a kubernetes secret whose token is used to authenticate at the shoot control plane API server instead of
+	// the credentials of the kubeconfig, e.g. a secret whose token is requested and renewed by the gardener-resource-manager. The secret referenced
+	// by KubeConfigSecretName then only has to provide the connection information, e.g. the generic token kubeconfig. This avoids long-lived credentials.
+	KubeConfigTokenSecretName *string `json:"kubeConfigTokenSecretName,omitempty"`
 	// ProbeInterval is the interval with which the probe will be run
 	ProbeInterval *metav1.Duration `json:"probeInterval,omitempty"`
 	// InitialDelay is the initial delay in running a probe for the first time
 	InitialDelay *metav1.Duration `json:"initialDelay,omitempty"`
 	// ProbeTimeout is the timeout that is set on the client which is used to reach the shoot control plane API server
 	ProbeTimeout *metav1.Duration `json:"probeTimeout,omitempty"`
+	// APIServerProbeTimeout is the timeout for the probe of the shoot control plane API server. If not specified then ProbeTimeout is used.
+	APIServerProbeTimeout *metav1.Duration `json:"apiServerProbeTimeout,omitempty"`
+	// APIServerProbeEndpoints are additional endpoints via which the shoot control plane API server is probed, e.g. the services in front of the
+	// individual replicas of a highly available API server. If specified then the API server is probed via the server of the kubeconfig as well as
+	// via each of these endpoints and the API server probe only fails if at least APIServerProbeFailureQuorum of these probes have failed.
+	APIServerProbeEndpoints []APIServerProbeEndpoint `json:"apiServerProbeEndpoints,omitempty"`
+	// APIServerProbeFailureQuorum is the number of failed probes via the server of the kubeconfig and the APIServerProbeEndpoints at or above which the
+	// API server probe fails. It is only considered if APIServerProbeEndpoints are specified. If not specified then a majority of the probes has to fail.
+	APIServerProbeFailureQuorum *int `json:"apiServerProbeFailureQuorum,omitempty"`
+	// APIServerProbeTarget overrides how the shoot control plane API server is reached and which endpoint is requested during the API server probe,
+	// e.g. if the API server is fronted by an SNI-routing gateway which is not exercised by probing the server of the kubeconfig.
+	APIServerProbeTarget *APIServerProbeTarget `json:"apiServerProbeTarget,omitempty"`
+	// LeaseProbeTimeout is the timeout for listing nodes and node leases of the shoot during the node lease probe. If not specified then ProbeTimeout is used.
+	LeaseProbeTimeout *metav1.Duration `json:"leaseProbeTimeout,omitempty"`
+	// ShootClientQPS is the maximum number of queries per second from the clients of a probe to the shoot control plane API server. If not specified
+	// then the value of the --shoot-kube-api-qps flag of the prober is used.
+	ShootClientQPS *float64 `json:"shootClientQPS,omitempty"`
+	// ShootClientBurst is the maximum burst over the ShootClientQPS. If not specified then the value of the --shoot-kube-api-burst flag of the prober is used.
+	ShootClientBurst *int `json:"shootClientBurst,omitempty"`
+	// ShootClientDialTimeout is the timeout for establishing a TCP connection to the shoot control plane API server. If not specified then 30s is used.
+	ShootClientDialTimeout *metav1.Duration `json:"shootClientDialTimeout,omitempty"`
+	// ShootClientTLSHandshakeTimeout is the timeout for the TLS handshake with the shoot control plane API server. If not specified then 10s is used.
+	ShootClientTLSHandshakeTimeout *metav1.Duration `json:"shootClientTLSHandshakeTimeout,omitempty"`
+	// ShootThrottlingBackOff is the duration for which the prober backs off after a request to the shoot control plane API server has been throttled.
+	// If not specified then 10s is used.
+	ShootThrottlingBackOff *metav1.Duration `json:"shootThrottlingBackOff,omitempty"`
+	// SeedThrottlingBackOff is the duration for which the prober backs off after a request to the seed API server has been throttled.
+	// If not specified then 10s is used.
+	SeedThrottlingBackOff *metav1.Duration `json:"seedThrottlingBackOff,omitempty"`
+	// HonorRetryAfter if set to true makes the prober back off for the duration suggested by the Retry-After information of a throttled request
+	// instead of ShootThrottlingBackOff or SeedThrottlingBackOff. These are still used for throttled requests without such information.
+	// If not specified then the Retry-After information is honored.
+	HonorRetryAfter *bool `json:"honorRetryAfter,omitempty"`
 	// BackoffJitterFactor is the jitter with which a probe is run
 	BackoffJitterFactor *float64 `json:"backoffJitterFactor,omitempty"`
 	// DependentResourceInfos are the dependent resources that should be considered for scaling in case the shoot control API server cannot be reached via external domain
 	DependentResourceInfos []DependentResourceInfo `json:"dependentResourceInfos"`
 	// KCMNodeMonitorGraceDuration is the node-monitor-grace-period set in the kcm flags.
 	KCMNodeMonitorGraceDuration *metav1.Duration `json:"kcmNodeMonitorGraceDuration,omitempty"`
+	// WarmUpDuration is the duration after the creation of a prober during which only scale-ups of the dependent resources are allowed. Right after
+	// a prober has been started, e.g. after a restart of dependency-watchdog or of the seed, node leases may appear to be expired because of the
+	// downtime of the control plane itself and must not lead to a scale-down. If not specified then KCMNodeMonitorGraceDuration is used.
+	WarmUpDuration *metav1.Duration `json:"warmUpDuration,omitempty"`
 	// NodeLeaseFailureFraction is used to determine the maximum number of leases that can be expired for a lease probe to succeed.
 	NodeLeaseFailureFraction *float64 `json:"nodeLeaseFailureFraction,omitempty"`
+	// MinNodeAge is the minimum age of a node for its lease to be considered by the lease probe. Brand-new nodes may not have renewed their first lease
+	// yet and would otherwise skew the fraction of expired leases during scale-out events.
+	MinNodeAge *metav1.Duration `json:"minNodeAge,omitempty"`
+	// MinNodeCountForScaling is the minimum number of candidate nodes, i.e. nodes managed by MCM which are not excluded from the lease probe, below which
+	// the prober does not scale any dependent resources as the fraction of expired leases of very small clusters is prone to false positives. A shoot
+	// without any candidate nodes is exempted so that dependent resources can still be scaled up. It can be overridden per shoot via the
+	// dependency-watchdog.gardener.cloud/min-node-count-for-scaling annotation on the Shoot. If not specified then 2 is used.
+	MinNodeCountForScaling *int `json:"minNodeCountForScaling,omitempty"`
+	// ExcludedNodeTaintKeys are the keys of taints which exclude a node from the lease probe. Nodes which are cordoned or about to be deleted often stop
+	// renewing their leases legitimately and would otherwise push the fraction of expired leases over the threshold during drain operations.
+	// A node which is marked unschedulable is considered to have the node.kubernetes.io/unschedulable taint. If not specified then
+	// node.kubernetes.io/unschedulable and ToBeDeletedByClusterAutoscaler are used. An empty list disables the exclusion by taints.
+	ExcludedNodeTaintKeys []string `json:"excludedNodeTaintKeys,omitempty"`
+	// ExcludedNodeAnnotationKeys are the keys of annotations which exclude a node from the lease probe. If not specified then
+	// node.machine.sapcloud.io/trigger-deletion-by-mcm is used. An empty list disables the exclusion by annotations.
+	ExcludedNodeAnnotationKeys []string `json:"excludedNodeAnnotationKeys,omitempty"`
+	// ExcludedWorkerPools are the names of the worker pools whose nodes are excluded from the lease probe, e.g. pools of batch nodes which are
+	// hibernated aggressively and whose lease behavior should therefore not influence the scaling of the control plane. It can be overridden per
+	// shoot via the dependency-watchdog.gardener.cloud/excluded-worker-pools annotation on the Shoot. If not specified then no worker pool is excluded.
+	ExcludedWorkerPools []string `json:"excludedWorkerPools,omitempty"`
+	// UnmanagedNodes disables the filtering of the nodes via the machines of the machine-controller-manager (MCM), so that nodes which are not managed
+	// by MCM are considered by the lease probe as well, e.g. for clusters whose nodes are provisioned otherwise. Machines are then not read at all and
+	// the nodes are only filtered via their age, conditions, taints, annotations and worker pools. If not specified then only nodes managed by MCM
+	// whose machines are neither failed nor terminating are considered.
+	UnmanagedNodes *bool `json:"unmanagedNodes,omitempty"`
+	// KubeletHealthProbeSampleSize is the number of nodes with expired node leases, sampled at random, whose kubelet health endpoint is probed via the
+	// API server proxy before the dependent resources are scaled down. Kubelets which respond are alive but cannot reach the API server, which is
+	// what a scale-down is meant to mitigate. If none of the sampled kubelets responds then the nodes are considered to be actually dead, in which
+	// case the scale-down is skipped. If not specified or set to 0 then kubelets are not probed.
+	KubeletHealthProbeSampleSize *int `json:"kubeletHealthProbeSampleSize,omitempty"`
+	// ScaleDecisionLogSize is the number of most recent scale decisions which are recorded, along with the inputs that led to them, in a ConfigMap
+	// in the shoot control plane namespace. If not specified or set to 0 then scale decisions are not recorded.
+	ScaleDecisionLogSize *int `json:"scaleDecisionLogSize,omitempty"`
+	// PersistCheckpoint enables to persist the state of the prober, i.e. its most recent scale decision, the time at which the shoot has most recently
+	// been found to be healthy, its backoff and the start of its warm-up, in a ConfigMap in the shoot control plane namespace. The state is restored
+	// once a prober for the shoot is started again, e.g. after a restart of dependency-watchdog, so that the warm-up and the backoff are not reset
+	// by it. If not specified then the state of the prober is not persisted.
+	PersistCheckpoint *bool `json:"persistCheckpoint,omitempty"`
+	// SeedMeltdownFailureFraction is the fraction of probed shoots on the seed with a failed node lease probe at or above which scale-downs are suppressed
+	// for all shoots. A simultaneous failure of the node lease probes of many shoots indicates a network or infrastructure problem of the seed rather than
+	// a problem of the kubelets of the individual shoots. If not specified then scale-downs are never suppressed.
+	SeedMeltdownFailureFraction *float64 `json:"seedMeltdownFailureFraction,omitempty"`
+	// SeedMeltdownMinShoots is the minimum number of probed shoots on the seed for SeedMeltdownFailureFraction to be considered.
+	SeedMeltdownMinShoots *int `json:"seedMeltdownMinShoots,omitempty"`
+	// ReplicasAnnotationKey is the key of the annotation on a dependent resource which captures its replicas prior to a scale-down so that they can be restored by a
+	// subsequent scale-up. This allows integrating with gitops controllers which expect the replicas to be preserved in a specific annotation.
+	// If not specified then dependency-watchdog.gardener.cloud/replicas is used.
+	ReplicasAnnotationKey *string `json:"replicasAnnotationKey,omitempty"`
+	// DualWriteReplicasAnnotation if set to true will additionally capture the replicas in the dependency-watchdog.gardener.cloud/replicas annotation during a scale-down
+	// when a different ReplicasAnnotationKey is configured. This is meant to be used while migrating from one annotation key to another.
+	DualWriteReplicasAnnotation *bool `json:"dualWriteReplicasAnnotation,omitempty"`
+	// MaxConcurrentScalesPerLevel is the maximum number of dependent resources on the same level which are scaled concurrently. It limits the burst of
+	// requests to the scale subresources when there are many dependent resources on a level. If not specified or set to 0 then all dependent resources
+	// on a level are scaled concurrently.
+	MaxConcurrentScalesPerLevel *int `json:"maxConcurrentScalesPerLevel,omitempty"`
+	// ScaleFlowTimeout is the maximum duration of a complete scale-up or scale-down flow. Once it has expired, all scale operations of the flow which are
+	// still running are cancelled, so that a stuck scale operation cannot block the prober for the sum of the timeouts of all levels. If not specified
+	// then a flow runs until all dependent resources have been scaled or their individual timeouts have expired.
+	ScaleFlowTimeout *metav1.Duration `json:"scaleFlowTimeout,omitempty"`
+	// DryRunScaleUpdates enables a server-side dry-run of every update of the scale subresource of a dependent resource before the actual update,
+	// and before a scale-down annotates the resource. An update which is rejected by the dry-run, e.g. by an admission webhook, is not retried and
+	// fails the scale flow with the ERR_SCALE_REJECTED error code, as a webhook which rejects an update usually keeps on rejecting it. If not
+	// specified then the scale subresources are updated without a dry-run.
+	DryRunScaleUpdates *bool `json:"dryRunScaleUpdates,omitempty"`
+	// DisableScaleDown disables all scale-downs of dependent resources if set to true, e.g. as an emergency switch during incidents in which the
+	// behavior of dependency-watchdog is suspected. Scale-ups, which also remove the annotations set by scale-downs, are still run, so that
+	// dependent resources which have been scaled down before are restored. If not specified then scale-downs are enabled.
+	DisableScaleDown *bool `json:"disableScaleDown,omitempty"`
+	// ScaleDownLateOptionalResources scales down optional dependent resources which are created while the dependent resources of a shoot are
+	// scaled down, e.g. a cluster-autoscaler which is only deployed after the scale-down. Without it such a resource is neither scaled down nor
+	// annotated and therefore not restored consistently by the next scale-up. If not specified then late optional resources are not scaled down.
+	ScaleDownLateOptionalResources *bool `json:"scaleDownLateOptionalResources,omitempty"`
+	// ReassertScaleDownMinInterval enables to scale down dependent resources again which have been scaled up by someone else, e.g. an operator or
+	// the gardener-resource-manager, while the dependent resources of a shoot are scaled down. A change of the spec of a dependent resource is
+	// then followed by a run of the scale-down flow, at most once per ReassertScaleDownMinInterval to prevent flapping in case another actor
+	// keeps on scaling the resource up. It also rate limits the scale-downs of late optional resources, see ScaleDownLateOptionalResources.
+	// If not specified then dependent resources which are scaled up by someone else are only scaled down again once their shoot is scaled down
+	// again.
+	ReassertScaleDownMinInterval *metav1.Duration `json:"reassertScaleDownMinInterval,omitempty"`
+	// ReportCareConditions enables to report the outcome of the lease probe as the EveryNodeReady condition in the status of the Workers of the
+	// shoot, from where gardenlet merges it into the EveryNodeReady care condition of the shoot, so that expired node leases are reflected in the
+	// shoot status. It requires the permission to patch the status of Workers. If not specified then no care conditions are reported.
+	ReportCareConditions *bool `json:"reportCareConditions,omitempty"`
+	// ProbeDuringMigration keeps the prober of a shoot running in a read-only mode while the control plane of the shoot is migrated, i.e. while the
+	// last operation of the shoot is Migrate or an incomplete Restore. The probes are run and their metrics and status are published, but no
+	// dependent resource is scaled. If not specified then the prober of a shoot is removed once its control plane is migrated and is only started
+	// again once the restoration has succeeded.
+	ProbeDuringMigration *bool `json:"probeDuringMigration,omitempty"`
+}
+
+// APIServerProbeEndpoint identifies a service in the shoot control plane namespace via which the shoot control plane API server can be reached.
+type APIServerProbeEndpoint struct {
+	// ServiceName is the name of the service in the shoot control plane namespace.
+	ServiceName string `json:"serviceName"`
+	// Port is the port of the service.
+	Port int32 `json:"port"`
+}
+
+// APIServerProbeTarget captures overrides for the connection to the shoot control plane API server and the endpoint requested during the API server probe.
+type APIServerProbeTarget struct {
+	// Host replaces the server of the kubeconfig, e.g. https://api.my-shoot.example.com:443. The APIServerProbeEndpoints are not affected by it.
+	// If not specified then the server of the kubeconfig is used.
+	Host string `json:"host,omitempty"`
+	// ServerName is the server name which is sent via TLS SNI and against which the serving certificate is verified. If not specified then the
+	// host name of the server of the kubeconfig is used.
+	ServerName string `json:"serverName,omitempty"`
+	// CABundle is the PEM encoded bundle of CA certificates used to verify the serving certificate. If not specified then the CA of the kubeconfig is used.
+	CABundle string `json:"caBundle,omitempty"`
+	// Path is the path of the endpoint which is requested, e.g. /readyz. Any response with a status code other than 2xx fails the probe.
+	// If not specified then the version of the API server is requested.
+	Path string `json:"path,omitempty"`
 }
 
 // DependentResourceInfo captures a dependent resource which should be scaled
@@ -36,6 +188,10 @@ type DependentResourceInfo struct {
 	// Optional should be false if this resource should be present. If the resource is optional then it should be true
 	// If this field is not specified, then its zero value (false for boolean) will be assumed.
 	Optional bool `json:"optional"`
+	// VerifyUID enables the verification of the UID of the resource before it is scaled up. The UID is captured when the resource is scaled
+	// down. If the resource has been deleted and re-created in the meantime, then it is not scaled up, as the captured replicas do not belong
+	// to it. If this field is not specified, then its default value of false will be assumed.
+	VerifyUID *bool `json:"verifyUID,omitempty"`
 	// ScaleUpInfo captures the configuration to scale up the resource identified by Ref
 	ScaleUpInfo *ScaleInfo `json:"scaleUp,omitempty"`
 	// ScaleDownInfo captures the configuration to scale down the resource identified by Ref
@@ -51,4 +207,7 @@ type ScaleInfo struct {
 	InitialDelay *metav1.Duration `json:"initialDelay,omitempty"`
 	// ScaleTimeout is the time timeout duration to wait for when attempting to update the scaling sub-resource.
 	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Replicas is the number of replicas the resource is scaled down to, which allows to reduce a resource without stopping it. It is only honored
+	// for the scale down of a resource. If not specified its default value of 0 will be assumed.
+	Replicas *int32 `json:"replicas,omitempty"`
 }
@@ -5,6 +5,7 @@
 package weeder
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -13,8 +14,20 @@ type Config struct {
 	// WatchDuration is the duration for which all dependent pods for a service under surveillance will be watched after the service has recovered.
 	// If the dependent pods have not transitioned to CrashLoopBackOff in this duration then it is assumed that they will not enter that state.
 	WatchDuration *metav1.Duration `json:"watchDuration,omitempty"`
+	// GracePeriod is the duration after the service has recovered during which dependant pods in CrashLoopBackOff are not weeded. Dependants which
+	// recover on their own within the grace period are thereby not restarted unnecessarily, the ones which are still in CrashLoopBackOff once it has
+	// expired are weeded. It must be shorter than the watch duration. If not specified then dependant pods are weeded right away.
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
 	// ServicesAndDependantSelectors is a map whose key is the service name and the value is a DependantSelectors
 	ServicesAndDependantSelectors map[string]DependantSelectors `json:"servicesAndDependantSelectors"`
+	// MaxPriorityClassName is the name of the PriorityClass whose value is the highest priority of dependant pods which are weeded. Dependant pods
+	// with a higher priority, e.g. the ones of the system-cluster-critical PriorityClass, are never weeded unless their PriorityClass is listed in
+	// WeedablePriorityClassNames. This is a safety belt against pod selectors which accidentally select critical components.
+	// If not specified then dependant pods of any priority are weeded.
+	MaxPriorityClassName *string `json:"maxPriorityClassName,omitempty"`
+	// WeedablePriorityClassNames are the names of the PriorityClasses of dependant pods which are weeded even though their priority is higher
+	// than the one of MaxPriorityClassName.
+	WeedablePriorityClassNames []string `json:"weedablePriorityClassNames,omitempty"`
 }
 
 // DependantSelectors encapsulates LabelSelector's used to identify dependants for a service.
@@ -22,4 +35,55 @@ type Config struct {
 type DependantSelectors struct {
 	// PodSelectors is a slice of LabelSelector's used to identify dependant pods
 	PodSelectors []*metav1.LabelSelector `json:"podSelectors"`
+	// Namespace if specified is the namespace of the dependant pods, which is then watched instead of the namespace of the service. It allows to
+	// weed dependants of a service which run in a shared namespace, e.g. istio-ingress. Its pod selectors must not select all pods of the namespace.
+	Namespace *string `json:"namespace,omitempty"`
+	// WatchDuration if specified overrides Config.WatchDuration for the dependants of this service. This allows to account for dependants
+	// which take longer (or shorter) to recover after the service has recovered.
+	WatchDuration *metav1.Duration `json:"watchDuration,omitempty"`
+	// GracePeriod if specified overrides Config.GracePeriod for the dependants of this service.
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+	// OwnerFilters optionally restricts the dependant pods selected via PodSelectors to the ones which are controlled, directly or transitively
+	// via their controller chain (e.g. Pod -> ReplicaSet -> Deployment), by one of the given owners. If not specified then all selected pods are considered.
+	OwnerFilters []OwnerFilter `json:"ownerFilters,omitempty"`
+	// WeedingStrategy defines how dependant pods in CrashLoopBackOff are dealt with. Deleting a single pod is not sufficient for dependants which
+	// are stuck, e.g. due to informers which do not recover, in which case the owning Deployment can be restarted instead.
+	// If not specified then DeletePod is used.
+	WeedingStrategy *WeedingStrategy `json:"weedingStrategy,omitempty"`
+	// Predicates define when a dependant pod is considered unhealthy and is therefore weeded. If not specified then dependant pods are weeded
+	// if any of their containers is in CrashLoopBackOff.
+	Predicates *PodPredicates `json:"predicates,omitempty"`
+}
+
+// PodPredicates define when a dependant pod is considered unhealthy. A pod is considered unhealthy if it matches all predicates.
+type PodPredicates struct {
+	// ContainerStateReasons are the reasons of the waiting or terminated state of a container, e.g. CrashLoopBackOff or CreateContainerConfigError,
+	// any of which makes the pod unhealthy. If not specified then CrashLoopBackOff is used.
+	ContainerStateReasons []string `json:"containerStateReasons,omitempty"`
+	// MinRestartCount is the minimum number of restarts of a container with one of the ContainerStateReasons. It allows to only weed pods which
+	// have failed repeatedly. If not specified then the restart count is not considered.
+	MinRestartCount *int32 `json:"minRestartCount,omitempty"`
+	// Phases restricts unhealthy pods to the ones in one of the given phases. If not specified then pods in any phase are considered.
+	Phases []corev1.PodPhase `json:"phases,omitempty"`
+}
+
+// WeedingStrategy defines how dependant pods in CrashLoopBackOff are dealt with.
+type WeedingStrategy string
+
+const (
+	// WeedingStrategyDeletePod deletes the pods in CrashLoopBackOff.
+	WeedingStrategyDeletePod WeedingStrategy = "DeletePod"
+	// WeedingStrategyRolloutRestart performs a rollout restart of the Deployment owning the pods in CrashLoopBackOff. Pods which are not owned by
+	// a Deployment are deleted instead.
+	WeedingStrategyRolloutRestart WeedingStrategy = "RolloutRestart"
+	// WeedingStrategyDeletePodAndRolloutRestart deletes the pods in CrashLoopBackOff and additionally performs a rollout restart of the Deployment owning them.
+	WeedingStrategyDeletePodAndRolloutRestart WeedingStrategy = "DeletePodAndRolloutRestart"
+)
+
+// OwnerFilter identifies a controller (e.g. the kube-apiserver Deployment) which owns dependant pods.
+type OwnerFilter struct {
+	// Kind is the kind of the owner, e.g. Deployment
+	Kind string `json:"kind"`
+	// Name is the name of the owner
+	Name string `json:"name"`
 }
@@ -5,13 +5,40 @@
 package cmd
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
+	overridescontroller "github.com/gardener/dependency-watchdog/controllers/overrides"
+	"github.com/gardener/dependency-watchdog/internal/claim"
+	"github.com/gardener/dependency-watchdog/internal/features"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/gardener/dependency-watchdog/internal/version"
+	multierr "github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apiversion "k8s.io/apimachinery/pkg/version"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/go-logr/logr"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/utils/clock"
 )
 
 const (
@@ -22,30 +49,43 @@ const (
 	defaultLeaseDuration        = 15 * time.Second
 	defaultRenewDeadline        = 10 * time.Second
 	defaultRetryPeriod          = 2 * time.Second
+	// defaultNamespaceClaimLeaseDuration is the default duration after which the claim of a namespace which has not been renewed can be taken over.
+	defaultNamespaceClaimLeaseDuration = time.Minute
+	// logLevelEndpointPath is the path of the endpoint of the metrics server which serves the LogLevel if it is enabled.
+	logLevelEndpointPath = "/debug/loglevel"
 )
 
 var (
+	// LogLevel is the level of the logger of all commands. It can be changed at runtime via the runtime overrides and via the log level endpoint.
+	LogLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 	// Commands is a list of possible commands that could be run
 	Commands = []*Command{
 		ProberCmd,
 		WeederCmd,
+		ProbeOnceCmd,
+		RenderScaleFlowsCmd,
+		DashboardsCmd,
 	}
 )
 
 // Command defines a command with all its required properties
 type Command struct {
 	Name      string
-	UsageLine string
 	ShortDesc string
 	LongDesc  string
 	AddFlags  func(fs *flag.FlagSet)
-	Run       func(logger logr.Logger) (manager.Manager, error)
+	// FlagValues are the supported values of the flags of the command by flag name. They are offered by the shell completion.
+	FlagValues map[string][]string
+	// Run runs the command. If it returns a manager then the manager is started, else the command is considered to be complete.
+	Run func(logger logr.Logger) (manager.Manager, error)
 }
 
 // SharedOpts are the flags which bother prober and weeder have in common
 type SharedOpts struct {
 	// ConfigFile is the command specific configuration file path which is typically a mounted config-map YAML file
 	ConfigFile string
+	// AllowUnknownConfigFields relaxes the decoding of the ConfigFile. By default, any field which is not known will result in an error.
+	AllowUnknownConfigFields bool
 	// ConcurrentReconciles is the maximum number of concurrent reconciles which can be run
 	ConcurrentReconciles int
 	// leaderElection defines the configuration of leader election client.
@@ -54,12 +94,37 @@ type SharedOpts struct {
 	KubeApiBurst int
 	// KubeApiQps indicates the maximum QPS to the API server from this client
 	KubeApiQps float64
+	// KubeApiUserAgent is the user agent which is sent with every request to the API server. It allows to tell the requests of dependency-watchdog apart,
+	// e.g. in audit logs or when matching them to an API priority and fairness flow schema. Defaults to a command specific user agent.
+	KubeApiUserAgent string
+	// SeedName is the name of the seed the command runs for. It is part of the default user agents, so that the requests of dependency-watchdog
+	// can be attributed to a seed, e.g. in the audit logs of a shoot.
+	SeedName string
 	// MetricsBindAddress is the TCP address that the controller should bind to for serving prometheus metrics
 	MetricsBindAddress string
 	// HealthBindAddress is the TCP address that the controller should bind to for serving health probes
 	HealthBindAddress string
 	// PprofBindAddress is the TCP address that the controller should bind to for serving profiling endpoint.
 	PprofBindAddress string
+	// SkipPermissionCheck disables the check of the permissions which are required by the command at startup.
+	SkipPermissionCheck bool
+	// EnableLogLevelEndpoint enables the endpoint of the metrics server which serves the LogLevel, so that it can be changed without a restart.
+	EnableLogLevelEndpoint bool
+	// RuntimeOverridesObject identifies the object whose annotations hold the runtime overrides as <kind>/<namespace>/<name>, the kind is either
+	// deployment or configmap. Runtime overrides are not supported if it is empty.
+	RuntimeOverridesObject string
+	// Namespace restricts the command to a single shoot control namespace, e.g. to run a second instance for debugging next to the regular one.
+	// If it is empty then all namespaces are considered.
+	Namespace string
+	// NamespaceClaims defines the configuration of the claims of the shoot control namespaces.
+	NamespaceClaims NamespaceClaimOpts
+	// UncachedKinds is a comma-separated list of kinds in the form <kind>.<group>, e.g. Deployment.apps, which are read directly from the API
+	// server instead of from the cache of the controller manager. It allows to trade a higher load on the API server for more consistent reads.
+	UncachedKinds string
+	// uncachedGroupKinds are the parsed UncachedKinds. They are set by Complete.
+	uncachedGroupKinds []schema.GroupKind
+	// runtimeOverridesObject is the parsed RuntimeOverridesObject. It is set by Complete and is nil if runtime overrides are not configured.
+	runtimeOverridesObject client.Object
 }
 
 // LeaderElectionOpts defines the configuration of leader election
@@ -89,16 +154,310 @@ type LeaderElectionOpts struct {
 	RetryPeriod time.Duration
 }
 
+// NamespaceClaimOpts defines the configuration of the claims of the shoot control namespaces. A namespace is claimed via a Lease in it before
+// dependency-watchdog acts upon it, so that a second instance of dependency-watchdog which has accidentally been deployed next to the regular
+// one backs off instead of scaling dependent resources or weeding pods as well.
+type NamespaceClaimOpts struct {
+	// Enable enables the claims of the shoot control namespaces. By default, it is false.
+	Enable bool
+	// Identity is the identity on behalf of which the namespaces are claimed. It has to be the same for all replicas of an instance of
+	// dependency-watchdog and must differ between instances. Defaults to <leader-election-namespace>/<leader election ID> if leader election is
+	// enabled, else to the host name.
+	Identity string
+	// LeaseDuration is the duration after which the claim of a namespace which has not been renewed can be taken over by another identity.
+	LeaseDuration time.Duration
+}
+
 // SetSharedOpts helps in defining the location where the command flag values would be stored, it also defines default values for the flags.
 func SetSharedOpts(fs *flag.FlagSet, opts *SharedOpts) {
 	fs.StringVar(&opts.ConfigFile, "config-file", "", "Path of the config file containing the configuration")
+	fs.BoolVar(&opts.AllowUnknownConfigFields, "allow-unknown-config-fields", false, "Ignore fields in the config file which are not known instead of failing to load the config file")
 	fs.IntVar(&opts.ConcurrentReconciles, "concurrent-reconciles", defaultConcurrentReconciles, "Maximum number of concurrent reconciles")
 	fs.IntVar(&opts.KubeApiBurst, "kube-api-burst", rest.DefaultBurst, "Maximum burst to throttle the calls to the API server.")
 	fs.Float64Var(&opts.KubeApiQps, "kube-api-qps", float64(rest.DefaultQPS), "Maximum QPS (queries per second) allowed from the client to the API server")
+	fs.StringVar(&opts.KubeApiUserAgent, "kube-api-user-agent", "", "User agent which is sent with every request to the API server. Defaults to <command specific name>/<version> seed=<seed-name>")
+	fs.StringVar(&opts.SeedName, "seed-name", "", "Name of the seed the command runs for. It is part of the default user agents")
 	fs.StringVar(&opts.MetricsBindAddress, "metrics-bind-addr", defaultMetricsBindAddress, "The TCP address that the controller should bind to for serving prometheus metrics")
 	fs.StringVar(&opts.HealthBindAddress, "health-bind-addr", defaultHealthBindAddress, "The TCP address that the controller should bind to for serving health probes")
 	fs.StringVar(&opts.PprofBindAddress, "pprof-bind-addr", defaultPprofBindAddress, "The TCP address that the controller should bind to for serving profiling endpoint")
+	fs.BoolVar(&opts.SkipPermissionCheck, "skip-permission-check", false, "Skip the check of the required permissions at startup")
+	fs.BoolVar(&opts.EnableLogLevelEndpoint, "enable-log-level-endpoint", false, "Serve the log level via "+logLevelEndpointPath+" on the metrics server, a PUT request with e.g. {\"level\":\"debug\"} changes it")
+	fs.StringVar(&opts.RuntimeOverridesObject, "runtime-overrides-object", "", "Object whose annotations hold the runtime overrides as <kind>/<namespace>/<name>, kind is either deployment or configmap. Runtime overrides are disabled by default")
+	fs.StringVar(&opts.Namespace, "namespace", "", "Restrict the command to a single shoot control namespace. Defaults to all namespaces")
+	fs.Var(features.DefaultFeatureGate, "feature-gates", "Comma-separated list of key=value pairs which enable or disable features, e.g. AsyncScaling=false. Known features are: "+strings.Join(features.DefaultFeatureGate.KnownFeatures(), ", "))
+	fs.StringVar(&opts.UncachedKinds, "uncached-kinds", "", "Comma-separated list of kinds in the form <kind>.<group>, e.g. Machine.machine.sapcloud.io,Deployment.apps, which are read directly from the API server instead of from the cache. Defaults to reading all kinds from the cache")
 	bindLeaderElectionFlags(fs, opts)
+	bindNamespaceClaimFlags(fs, opts)
+}
+
+// Complete derives the fields of the options which are not set via flags directly. It has to be called after the flags have been parsed.
+func (opts *SharedOpts) Complete() error {
+	runtimeOverridesObject, err := opts.getRuntimeOverridesObject()
+	if err != nil {
+		return err
+	}
+	opts.runtimeOverridesObject = runtimeOverridesObject
+	opts.uncachedGroupKinds = nil
+	for _, kind := range strings.Split(opts.UncachedKinds, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			opts.uncachedGroupKinds = append(opts.uncachedGroupKinds, schema.ParseGroupKind(kind))
+		}
+	}
+	return nil
+}
+
+// Validate validates the options. All invalid flags are reported at once.
+func (opts *SharedOpts) Validate() error {
+	v := new(util.Validator)
+	opts.validate(v)
+	return v.Error
+}
+
+func (opts *SharedOpts) validate(v *util.Validator) {
+	v.MustNotBeEmpty("config-file", opts.ConfigFile)
+	if opts.ConcurrentReconciles < 1 {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key concurrent-reconciles must be at least 1, found %d", opts.ConcurrentReconciles))
+	}
+	v.MustNotBeNegativeFloat("kube-api-qps", opts.KubeApiQps)
+	v.MustNotBeNegative("kube-api-burst", opts.KubeApiBurst)
+	opts.LeaderElection.validate(v)
+	if opts.NamespaceClaims.Enable && opts.NamespaceClaims.LeaseDuration < time.Second {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("namespace-claim-lease-duration must be at least 1s, found %s", opts.NamespaceClaims.LeaseDuration))
+	}
+}
+
+// validate validates the leader election options. They are only validated if leader election is enabled as they are ignored otherwise.
+func (opts *LeaderElectionOpts) validate(v *util.Validator) {
+	if !opts.Enable {
+		return
+	}
+	v.MustNotBeEmpty("leader-election-namespace", opts.Namespace)
+	validDurations := v.MustBePositiveDuration("leader-elect-lease-duration", metav1.Duration{Duration: opts.LeaseDuration})
+	validDurations = v.MustBePositiveDuration("leader-elect-renew-deadline", metav1.Duration{Duration: opts.RenewDeadline}) && validDurations
+	validDurations = v.MustBePositiveDuration("leader-elect-retry-period", metav1.Duration{Duration: opts.RetryPeriod}) && validDurations
+	if !validDurations {
+		return
+	}
+	// these are the constraints which are enforced by the leader elector once the manager is started
+	if opts.LeaseDuration <= opts.RenewDeadline {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("leader-elect-lease-duration %s must be greater than leader-elect-renew-deadline %s", opts.LeaseDuration, opts.RenewDeadline))
+	}
+	if float64(opts.RenewDeadline) <= leaderelection.JitterFactor*float64(opts.RetryPeriod) {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("leader-elect-renew-deadline %s must be greater than %.1f times leader-elect-retry-period %s", opts.RenewDeadline, leaderelection.JitterFactor, opts.RetryPeriod))
+	}
+}
+
+// applyToRestConfig sets the client-side rate limits and the user agent on the given rest.Config. If no user agent has been configured then the
+// default user agent of the given component is used, see defaultUserAgent.
+func (opts *SharedOpts) applyToRestConfig(restConf *rest.Config, component string) {
+	restConf.QPS = float32(opts.KubeApiQps)
+	restConf.Burst = opts.KubeApiBurst
+	restConf.UserAgent = opts.defaultUserAgent(component)
+	if opts.KubeApiUserAgent != "" {
+		restConf.UserAgent = opts.KubeApiUserAgent
+	}
+}
+
+// defaultUserAgent returns the user agent of the given component as <component>/<version>, followed by seed=<seed-name> if the seed name is known.
+func (opts *SharedOpts) defaultUserAgent(component string) string {
+	userAgent := fmt.Sprintf("%s/%s", component, version.Version)
+	if opts.SeedName != "" {
+		userAgent += " seed=" + opts.SeedName
+	}
+	return userAgent
+}
+
+// cacheOptions returns the options of the cache of the controller manager. If the command is restricted to a namespace then namespaced objects
+// are only cached for that namespace, for the namespace of the runtime overrides object, if any, and for the given additional namespaces.
+func (opts *SharedOpts) cacheOptions(additionalNamespaces ...string) cache.Options {
+	if opts.Namespace == "" {
+		return cache.Options{}
+	}
+	namespaces := map[string]cache.Config{opts.Namespace: {}}
+	for _, namespace := range additionalNamespaces {
+		namespaces[namespace] = cache.Config{}
+	}
+	if opts.runtimeOverridesObject != nil {
+		namespaces[opts.runtimeOverridesObject.GetNamespace()] = cache.Config{}
+	}
+	return cache.Options{DefaultNamespaces: namespaces}
+}
+
+// metricsServerOptions returns the options of the metrics server of the controller manager, which also serves the log level endpoint if it is
+// enabled.
+func (opts *SharedOpts) metricsServerOptions(logger logr.Logger) server.Options {
+	serverOpts := server.Options{BindAddress: opts.MetricsBindAddress}
+	if opts.EnableLogLevelEndpoint {
+		serverOpts.ExtraHandlers = map[string]http.Handler{logLevelEndpointPath: logLevelHandler(logger)}
+	}
+	return serverOpts
+}
+
+// logLevelHandler serves the LogLevel. A GET request returns the current level, e.g. {"level":"info"}, and a PUT request changes it, e.g. with
+// the body {"level":"debug"}. Every change is logged, so that it can be told apart from a change via the runtime overrides.
+func logLevelHandler(logger logr.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from := LogLevel.Level()
+		LogLevel.ServeHTTP(w, r)
+		if to := LogLevel.Level(); to != from {
+			logger.Info("Changed log level via the log level endpoint", "from", from, "to", to)
+		}
+	})
+}
+
+// clientOptions returns the options of the client of the controller manager. The objects of the UncachedKinds are read directly from the API
+// server. All UncachedKinds have to be registered in the given scheme.
+func (opts *SharedOpts) clientOptions(scheme *runtime.Scheme) (client.Options, error) {
+	if len(opts.uncachedGroupKinds) == 0 {
+		return client.Options{}, nil
+	}
+	uncachedObjects := make([]client.Object, 0, len(opts.uncachedGroupKinds))
+	for _, groupKind := range opts.uncachedGroupKinds {
+		gvk, ok := preferredGroupVersionKind(scheme, groupKind)
+		if !ok {
+			return client.Options{}, fmt.Errorf("uncached kind %q is not known", groupKind)
+		}
+		obj, err := scheme.New(gvk)
+		if err != nil {
+			return client.Options{}, fmt.Errorf("uncached kind %q cannot be created: %w", groupKind, err)
+		}
+		clientObj, ok := obj.(client.Object)
+		if !ok {
+			return client.Options{}, fmt.Errorf("uncached kind %q is not an object", groupKind)
+		}
+		uncachedObjects = append(uncachedObjects, clientObj)
+	}
+	return client.Options{Cache: &client.CacheOptions{DisableFor: uncachedObjects}}, nil
+}
+
+// isUncached checks if the objects of the given kind are read directly from the API server as it is one of the UncachedKinds.
+func (opts *SharedOpts) isUncached(groupKind schema.GroupKind) bool {
+	return slices.Contains(opts.uncachedGroupKinds, groupKind)
+}
+
+// preferredGroupVersionKind returns the most stable version of the given kind which is registered in the scheme, e.g. v1 over v1beta1.
+func preferredGroupVersionKind(scheme *runtime.Scheme, groupKind schema.GroupKind) (schema.GroupVersionKind, bool) {
+	var preferred schema.GroupVersionKind
+	for _, gv := range scheme.PrioritizedVersionsForGroup(groupKind.Group) {
+		gvk := gv.WithKind(groupKind.Kind)
+		if scheme.Recognizes(gvk) && (preferred.Empty() || apiversion.CompareKubeAwareVersionStrings(gvk.Version, preferred.Version) > 0) {
+			preferred = gvk
+		}
+	}
+	return preferred, !preferred.Empty()
+}
+
+// getRuntimeOverridesObject parses the object whose annotations hold the runtime overrides. It returns nil if runtime overrides are not configured.
+func (opts *SharedOpts) getRuntimeOverridesObject() (client.Object, error) {
+	if opts.RuntimeOverridesObject == "" {
+		return nil, nil
+	}
+	parts := strings.Split(opts.RuntimeOverridesObject, "/")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("runtime overrides object %q is not of the form <kind>/<namespace>/<name>", opts.RuntimeOverridesObject)
+	}
+	meta := metav1.ObjectMeta{Namespace: parts[1], Name: parts[2]}
+	switch strings.ToLower(parts[0]) {
+	case "deployment":
+		return &appsv1.Deployment{ObjectMeta: meta}, nil
+	case "configmap":
+		return &corev1.ConfigMap{ObjectMeta: meta}, nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %q of the runtime overrides object, supported kinds are deployment and configmap", parts[0])
+	}
+}
+
+// setupRuntimeOverrides registers a controller which applies the runtime overrides set via the annotations of the given object. It returns nil
+// if the object is nil.
+func setupRuntimeOverrides(mgr manager.Manager, runtimeOverridesObject client.Object) (*overrides.Overrides, error) {
+	if runtimeOverridesObject == nil {
+		return nil, nil
+	}
+	runtimeOverrides := overrides.New(LogLevel)
+	if err := (&overridescontroller.Reconciler{
+		Client:           mgr.GetClient(),
+		RuntimeOverrides: runtimeOverrides,
+		Object:           runtimeOverridesObject,
+	}).SetupWithManager(mgr); err != nil {
+		return nil, fmt.Errorf("failed to register runtime overrides reconciler with the controller manager %w", err)
+	}
+	return runtimeOverrides, nil
+}
+
+// runtimeOverridesPermissions returns the permissions which are required to watch the given runtime overrides object, if any.
+func runtimeOverridesPermissions(runtimeOverridesObject client.Object) []util.ResourcePermission {
+	if runtimeOverridesObject == nil {
+		return nil
+	}
+	group, resource := "", "configmaps"
+	if _, ok := runtimeOverridesObject.(*appsv1.Deployment); ok {
+		group, resource = appsv1.GroupName, "deployments"
+	}
+	var permissions []util.ResourcePermission
+	for _, verb := range []string{"get", "list", "watch"} {
+		permissions = append(permissions, util.ResourcePermission{Verb: verb, Group: group, Resource: resource, Namespace: runtimeOverridesObject.GetNamespace()})
+	}
+	return permissions
+}
+
+// leaderElectionID returns the given leader election ID, suffixed with the namespace the command is restricted to, if any, so that an instance
+// which is restricted to a namespace does not compete for leadership with the regular instance.
+func (opts *SharedOpts) leaderElectionID(id string) string {
+	if opts.Namespace == "" {
+		return id
+	}
+	return id + "-" + opts.Namespace
+}
+
+// newNamespaceClaimer creates a claim.Claimer which claims the shoot control namespaces via the Lease with the given name. It returns nil if
+// the claims of the namespaces are not enabled.
+func (opts *SharedOpts) newNamespaceClaimer(mgr manager.Manager, leaseName, leaderElectionID string) (*claim.Claimer, error) {
+	if !opts.NamespaceClaims.Enable {
+		return nil, nil
+	}
+	identity, err := opts.namespaceClaimIdentity(leaderElectionID)
+	if err != nil {
+		return nil, err
+	}
+	return claim.New(mgr.GetClient(), mgr.GetAPIReader(), leaseName, identity, opts.NamespaceClaims.LeaseDuration, clock.RealClock{}), nil
+}
+
+// namespaceClaimIdentity returns the identity on behalf of which the namespaces are claimed. Unless it is configured, the leader election
+// namespace and ID identify the instance if leader election is enabled, as they are shared by all of its replicas, else its host name does.
+func (opts *SharedOpts) namespaceClaimIdentity(leaderElectionID string) (string, error) {
+	if opts.NamespaceClaims.Identity != "" {
+		return opts.NamespaceClaims.Identity, nil
+	}
+	if opts.LeaderElection.Enable {
+		return opts.LeaderElection.Namespace + "/" + opts.leaderElectionID(leaderElectionID), nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the identity for the claims of the namespaces: %w", err)
+	}
+	return hostname, nil
+}
+
+// namespaceClaimPermissions returns the permissions which are required to claim the namespaces, if the claims are enabled.
+func (opts *SharedOpts) namespaceClaimPermissions() []util.ResourcePermission {
+	if !opts.NamespaceClaims.Enable {
+		return nil
+	}
+	var permissions []util.ResourcePermission
+	for _, verb := range []string{"get", "create", "update"} {
+		permissions = append(permissions, util.ResourcePermission{Verb: verb, Group: coordinationv1.GroupName, Resource: "leases", Namespace: opts.Namespace})
+	}
+	return permissions
+}
+
+// checkPermissions verifies that the identity used by the client has been granted all the given permissions. It fails fast with an error listing
+// all the missing permissions, instead of failing later with Forbidden errors.
+func checkPermissions(ctx context.Context, cl client.Client, permissions []util.ResourcePermission, logger logr.Logger) error {
+	if err := util.CheckPermissions(ctx, cl, permissions); err != nil {
+		return err
+	}
+	logger.Info("All required permissions are granted", "permissions", len(permissions))
+	return nil
 }
 
 func bindLeaderElectionFlags(fs *flag.FlagSet, opts *SharedOpts) {
@@ -117,3 +476,13 @@ func bindLeaderElectionFlags(fs *flag.FlagSet, opts *SharedOpts) {
 	fs.DurationVar(&opts.LeaderElection.RetryPeriod, "leader-elect-retry-period", defaultRetryPeriod, "The duration the clients should wait between attempting acquisition and renewal "+
 		"of a leadership. This is only applicable if leader election is enabled.")
 }
+
+func bindNamespaceClaimFlags(fs *flag.FlagSet, opts *SharedOpts) {
+	fs.BoolVar(&opts.NamespaceClaims.Enable, "enable-namespace-claims", false, "Claim each shoot control namespace via a Lease before acting upon it, so that "+
+		"a second instance of dependency-watchdog which has accidentally been deployed does not act upon the same namespaces.")
+	fs.StringVar(&opts.NamespaceClaims.Identity, "namespace-claim-identity", "", "Identity on behalf of which the namespaces are claimed. It must be the same for "+
+		"all replicas of an instance. Defaults to <leader-election-namespace>/<leader election ID> if leader election is enabled, else to the host name. "+
+		"This is only applicable if namespace claims are enabled.")
+	fs.DurationVar(&opts.NamespaceClaims.LeaseDuration, "namespace-claim-lease-duration", defaultNamespaceClaimLeaseDuration, "The duration after which the claim "+
+		"of a namespace which has not been renewed can be taken over by another instance. This is only applicable if namespace claims are enabled.")
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics/dashboards"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var (
+	// DashboardsCmd stores info about using the dashboards command
+	DashboardsCmd = &Command{
+		Name:      "dashboards",
+		ShortDesc: "Exports the Grafana dashboards for the metrics of dependency-watchdog",
+		LongDesc: `Exports the Grafana dashboards for the metrics exposed by the prober and the weeder. The dashboard given via the dashboard
+flag is printed, e.g. 'dwd dashboards --dashboard=dependency-watchdog-prober > prober.json'. If an output directory is given,
+the dashboards are written as <name>.json files into it instead, e.g. to be provisioned via a ConfigMap. If neither is given,
+the names of the dashboards are printed, as a JSON list if the output flag is set to json. No cluster is accessed.`,
+		AddFlags:   addDashboardsFlags,
+		FlagValues: map[string][]string{"dashboard": dashboards.Names(), outputFlagName: outputFormats},
+		Run:        exportDashboards,
+	}
+	dashboardsOpts = dashboardsOptions{}
+)
+
+type dashboardsOptions struct {
+	// Dashboard is the name of the dashboard which is exported. All dashboards are exported if it is empty and an OutputDir is given.
+	Dashboard string
+	// OutputDir is the directory into which the dashboards are written. The dashboard is printed if it is empty.
+	OutputDir string
+	// Output is the format in which the names of the dashboards are printed.
+	Output string
+}
+
+func addDashboardsFlags(fs *flag.FlagSet) {
+	fs.StringVar(&dashboardsOpts.Dashboard, "dashboard", "", "Name of the dashboard which is exported. Defaults to all dashboards if output-dir is set")
+	fs.StringVar(&dashboardsOpts.OutputDir, "output-dir", "", "Directory into which the dashboards are written as <name>.json files. The dashboard is printed if it is not set")
+	addOutputFlag(fs, &dashboardsOpts.Output)
+}
+
+// exportDashboards prints or writes the dashboards. It does not return a manager as there is nothing to be started.
+func exportDashboards(_ logr.Logger) (manager.Manager, error) {
+	if err := validateOutputFormat(dashboardsOpts.Output); err != nil {
+		return nil, err
+	}
+	names := dashboards.Names()
+	if dashboardsOpts.Dashboard != "" {
+		names = []string{dashboardsOpts.Dashboard}
+	}
+	if dashboardsOpts.OutputDir == "" && dashboardsOpts.Dashboard == "" {
+		if dashboardsOpts.Output == outputFormatJSON {
+			return nil, printJSON(os.Stdout, names)
+		}
+		for _, name := range names {
+			_, _ = fmt.Fprintln(os.Stdout, name)
+		}
+		return nil, nil
+	}
+	for _, name := range names {
+		dashboardBytes, err := dashboards.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if dashboardsOpts.OutputDir == "" {
+			_, _ = os.Stdout.Write(dashboardBytes)
+			continue
+		}
+		path := filepath.Join(dashboardsOpts.OutputDir, dashboards.FileName(name))
+		if err = os.WriteFile(path, dashboardBytes, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write dashboard %s: %w", path, err)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package cmd
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gardener/dependency-watchdog/internal/features"
+	"github.com/gardener/dependency-watchdog/internal/version"
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zapcore"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+)
+
+func TestProberOptionsValidate(t *testing.T) {
+	table := []struct {
+		description string
+		args        []string
+		errMessages []string
+	}{
+		{"defaults with a config file should be valid", []string{"--config-file=config.yaml"}, nil},
+		{"missing config file should be invalid", nil, []string{"config-file"}},
+		{"zero concurrent reconciles should be invalid", []string{"--config-file=config.yaml", "--concurrent-reconciles=0"}, []string{"concurrent-reconciles"}},
+		{"negative qps should be invalid", []string{"--config-file=config.yaml", "--kube-api-qps=-1", "--shoot-kube-api-qps=-1"}, []string{"kube-api-qps", "shoot-kube-api-qps"}},
+		{"negative bursts should be invalid", []string{"--config-file=config.yaml", "--kube-api-burst=-1", "--shoot-kube-api-burst=-1"}, []string{"kube-api-burst", "shoot-kube-api-burst"}},
+		{"invalid leader election durations should be ignored if leader election is disabled", []string{"--config-file=config.yaml", "--leader-elect-lease-duration=1s"}, nil},
+		{"lease duration not greater than renew deadline should be invalid", []string{"--config-file=config.yaml", "--enable-leader-election", "--leader-elect-lease-duration=10s"}, []string{"leader-elect-lease-duration 10s must be greater than leader-elect-renew-deadline 10s"}},
+		{"renew deadline not greater than the jittered retry period should be invalid", []string{"--config-file=config.yaml", "--enable-leader-election", "--leader-elect-retry-period=9s"}, []string{"leader-elect-renew-deadline 10s must be greater than"}},
+		{"non-positive leader election durations should be invalid", []string{"--config-file=config.yaml", "--enable-leader-election", "--leader-elect-retry-period=0s"}, []string{"leader-elect-retry-period must be a positive duration"}},
+		{"empty leader election namespace should be invalid", []string{"--config-file=config.yaml", "--enable-leader-election", "--leader-election-namespace="}, []string{"leader-election-namespace"}},
+		{"namespace claim lease duration below 1s should be invalid", []string{"--config-file=config.yaml", "--enable-namespace-claims", "--namespace-claim-lease-duration=500ms"}, []string{"namespace-claim-lease-duration must be at least 1s"}},
+		{"invalid namespace claim lease duration should be ignored if namespace claims are disabled", []string{"--config-file=config.yaml", "--namespace-claim-lease-duration=0s"}, nil},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			opts := &proberOptions{}
+			fs := flag.NewFlagSet("prober", flag.ContinueOnError)
+			opts.AddFlags(fs)
+			g.Expect(fs.Parse(entry.args)).To(Succeed())
+			g.Expect(opts.Complete()).To(Succeed())
+			err := opts.Validate()
+			if entry.errMessages == nil {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			for _, errMessage := range entry.errMessages {
+				g.Expect(err.Error()).To(ContainSubstring(errMessage))
+			}
+		})
+	}
+}
+
+func TestProberOptionsCompleteShouldResolveShootNamespaceTemplate(t *testing.T) {
+	g := NewWithT(t)
+	opts := &proberOptions{}
+	fs := flag.NewFlagSet("prober", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	g.Expect(fs.Parse([]string{"--config-file=config.yaml"})).To(Succeed())
+	g.Expect(opts.Complete()).To(Succeed())
+	g.Expect(opts.shootNamespaceResolver).ToNot(BeNil(), "the technical ID should be used by default")
+
+	g.Expect(fs.Parse([]string{"--shoot-namespace-template={{ .Name "})).To(Succeed())
+	g.Expect(opts.Complete()).To(MatchError(ContainSubstring("invalid shoot namespace template")))
+}
+
+func TestWeederOptionsValidateShouldRejectUnsupportedEndpointsSource(t *testing.T) {
+	g := NewWithT(t)
+	opts := &weederOptions{}
+	fs := flag.NewFlagSet("weeder", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	g.Expect(fs.Parse([]string{"--config-file=config.yaml", "--endpoints-source=Pods"})).To(Succeed())
+	g.Expect(opts.Complete()).To(Succeed())
+	err := opts.Validate()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(`unsupported endpoints source "Pods"`))
+}
+
+func TestFeatureGatesFlag(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.AsyncScaling, false)
+	g := NewWithT(t)
+	opts := &SharedOpts{}
+	fs := flag.NewFlagSet("prober", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	SetSharedOpts(fs, opts)
+	g.Expect(fs.Parse([]string{"--feature-gates=AsyncScaling=true"})).To(Succeed())
+	g.Expect(features.Enabled(features.AsyncScaling)).To(BeTrue())
+	g.Expect(features.Enabled(features.KubeletHealthProbe)).To(BeFalse(), "features which are not set should keep their defaults")
+	g.Expect(fs.Parse([]string{"--feature-gates=Unknown=true"})).To(MatchError(ContainSubstring("unrecognized feature gate: Unknown")))
+}
+
+func TestSharedOptsComplete(t *testing.T) {
+	g := NewWithT(t)
+	opts := &SharedOpts{RuntimeOverridesObject: "deployment/garden/dependency-watchdog", Namespace: "shoot--foo--bar"}
+	g.Expect(opts.Complete()).To(Succeed())
+	g.Expect(opts.runtimeOverridesObject).To(BeAssignableToTypeOf(&appsv1.Deployment{}))
+	g.Expect(opts.cacheOptions().DefaultNamespaces).To(HaveKey("garden"))
+	g.Expect(opts.cacheOptions("istio-ingress").DefaultNamespaces).To(SatisfyAll(HaveKey("shoot--foo--bar"), HaveKey("garden"), HaveKey("istio-ingress")))
+
+	opts = &SharedOpts{RuntimeOverridesObject: "deployment/garden"}
+	g.Expect(opts.Complete()).ToNot(Succeed())
+}
+
+func TestSharedOptsClientOptionsShouldDisableCacheForUncachedKinds(t *testing.T) {
+	g := NewWithT(t)
+	opts := &SharedOpts{UncachedKinds: "Machine.machine.sapcloud.io, Deployment.apps,Lease.coordination.k8s.io"}
+	g.Expect(opts.Complete()).To(Succeed())
+	clientOpts, err := opts.clientOptions(scheme)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(clientOpts.Cache).ToNot(BeNil())
+	g.Expect(clientOpts.Cache.DisableFor).To(HaveExactElements(
+		BeAssignableToTypeOf(&machinev1alpha1.Machine{}),
+		BeAssignableToTypeOf(&appsv1.Deployment{}),
+		BeAssignableToTypeOf(&coordinationv1.Lease{}),
+	))
+	g.Expect(opts.isUncached(appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind())).To(BeTrue())
+	g.Expect(opts.isUncached(corev1.SchemeGroupVersion.WithKind("Secret").GroupKind())).To(BeFalse())
+
+	opts = &SharedOpts{}
+	g.Expect(opts.Complete()).To(Succeed())
+	clientOpts, err = opts.clientOptions(scheme)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(clientOpts.Cache).To(BeNil())
+
+	opts = &SharedOpts{UncachedKinds: "Deployment.example.com"}
+	g.Expect(opts.Complete()).To(Succeed())
+	_, err = opts.clientOptions(scheme)
+	g.Expect(err).To(MatchError(ContainSubstring(`uncached kind "Deployment.example.com" is not known`)))
+}
+
+func TestProberOptionsUserAgents(t *testing.T) {
+	g := NewWithT(t)
+	opts := &proberOptions{}
+	fs := flag.NewFlagSet("prober", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	g.Expect(fs.Parse([]string{"--config-file=config.yaml", "--seed-name=aws-eu1"})).To(Succeed())
+	expectedUserAgent := "dependency-watchdog-prober/" + version.Version + " seed=aws-eu1"
+
+	restConf := &rest.Config{}
+	opts.applyToRestConfig(restConf, proberUserAgentComponent)
+	g.Expect(restConf.UserAgent).To(Equal(expectedUserAgent))
+	g.Expect(opts.shootUserAgent()).To(Equal(expectedUserAgent))
+
+	g.Expect(fs.Parse([]string{"--kube-api-user-agent=seed-agent", "--shoot-kube-api-user-agent=shoot-agent"})).To(Succeed())
+	opts.applyToRestConfig(restConf, proberUserAgentComponent)
+	g.Expect(restConf.UserAgent).To(Equal("seed-agent"))
+	g.Expect(opts.shootUserAgent()).To(Equal("shoot-agent"))
+}
+
+func TestNamespaceClaimIdentity(t *testing.T) {
+	g := NewWithT(t)
+	opts := &SharedOpts{}
+	fs := flag.NewFlagSet("prober", flag.ContinueOnError)
+	SetSharedOpts(fs, opts)
+	g.Expect(fs.Parse([]string{"--enable-namespace-claims", "--namespace=shoot--foo--bar"})).To(Succeed())
+	hostname, err := os.Hostname()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(opts.namespaceClaimIdentity(proberLeaderElectionID)).To(Equal(hostname))
+	g.Expect(opts.namespaceClaimPermissions()).To(HaveEach(HaveField("Namespace", "shoot--foo--bar")))
+
+	g.Expect(fs.Parse([]string{"--enable-leader-election", "--leader-election-namespace=garden"})).To(Succeed())
+	g.Expect(opts.namespaceClaimIdentity(proberLeaderElectionID)).To(Equal("garden/" + proberLeaderElectionID + "-shoot--foo--bar"))
+
+	g.Expect(fs.Parse([]string{"--namespace-claim-identity=dwd-a"})).To(Succeed())
+	g.Expect(opts.namespaceClaimIdentity(proberLeaderElectionID)).To(Equal("dwd-a"))
+}
+
+func TestSharedOptsMetricsServerOptionsShouldServeLogLevelIfEnabled(t *testing.T) {
+	g := NewWithT(t)
+	defaultLevel := LogLevel.Level()
+	t.Cleanup(func() { LogLevel.SetLevel(defaultLevel) })
+
+	opts := &SharedOpts{MetricsBindAddress: ":9643"}
+	g.Expect(opts.metricsServerOptions(logr.Discard()).ExtraHandlers).To(BeEmpty())
+
+	opts.EnableLogLevelEndpoint = true
+	handler := opts.metricsServerOptions(logr.Discard()).ExtraHandlers[logLevelEndpointPath]
+	g.Expect(handler).ToNot(BeNil())
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, logLevelEndpointPath, strings.NewReader(`{"level":"warn"}`)))
+	g.Expect(recorder.Code).To(Equal(http.StatusOK))
+	g.Expect(LogLevel.Level()).To(Equal(zapcore.WarnLevel))
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, logLevelEndpointPath, nil))
+	g.Expect(recorder.Body.String()).To(MatchJSON(`{"level":"warn"}`))
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// outputFlagName is the name of the flag which sets the format of the output of the commands which print a result.
+const outputFlagName = "output"
+
+// addOutputFlag adds the output flag to the given flag set.
+func addOutputFlag(fs *flag.FlagSet, output *string) {
+	fs.StringVar(output, outputFlagName, outputFormatText, fmt.Sprintf("Format of the output, one of %v", outputFormats))
+}
+
+// validateOutputFormat checks if the given output format is supported.
+func validateOutputFormat(output string) error {
+	if !slices.Contains(outputFormats, output) {
+		return fmt.Errorf("unsupported output format %q, supported formats are %v", output, outputFormats)
+	}
+	return nil
+}
+
+// printJSON prints the given value as indented JSON to the given writer.
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to print the output as JSON: %w", err)
+	}
+	return nil
+}
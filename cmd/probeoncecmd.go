@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/prober"
+	"github.com/gardener/dependency-watchdog/internal/prober/shoot"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var (
+	// ProbeOnceCmd stores info about using the probe-once command
+	ProbeOnceCmd = &Command{
+		Name:      "probe-once",
+		ShortDesc: "Runs a single probe cycle against a shoot without scaling any dependent resources",
+		LongDesc: `Runs a single probe cycle consisting of the API server probe, the node lease probe and the scale decision for one shoot
+and prints its outcome. No dependent resource is scaled and no scale decision is recorded. This can be used to validate the
+connectivity and the permissions of the prober, e.g. when onboarding a new seed.`,
+		AddFlags:   addProbeOnceFlags,
+		FlagValues: map[string][]string{outputFlagName: outputFormats},
+		Run:        probeOnce,
+	}
+	probeOnceOpts = probeOnceOptions{}
+)
+
+type probeOnceOptions struct {
+	// ConfigFile is the prober configuration file path
+	ConfigFile string
+	// AllowUnknownConfigFields relaxes the decoding of the ConfigFile. By default, any field which is not known will result in an error.
+	AllowUnknownConfigFields bool
+	// SeedKubeConfig is the path to the kubeconfig file of the seed cluster
+	SeedKubeConfig string
+	// ShootKubeConfig is the path to the kubeconfig file of the shoot cluster
+	ShootKubeConfig string
+	// ShootNamespace is the shoot control plane namespace in the seed cluster
+	ShootNamespace string
+	// SkipPermissionCheck disables the check of the permissions which are required in the seed and in the shoot cluster
+	SkipPermissionCheck bool
+	// Output is the format in which the outcome of the probe cycle is printed
+	Output string
+}
+
+// probeOutcomeOutput is the outcome of a probe cycle as it is printed in the JSON output format.
+type probeOutcomeOutput struct {
+	APIServerProbeFailed bool   `json:"apiServerProbeFailed"`
+	LeaseProbeFailed     bool   `json:"leaseProbeFailed"`
+	ScaleOperation       string `json:"scaleOperation"`
+	Error                string `json:"error,omitempty"`
+}
+
+func addProbeOnceFlags(fs *flag.FlagSet) {
+	fs.StringVar(&probeOnceOpts.ConfigFile, "config-file", "", "Path of the config file containing the configuration")
+	fs.BoolVar(&probeOnceOpts.AllowUnknownConfigFields, "allow-unknown-config-fields", false, "Ignore fields in the config file which are not known instead of failing to load the config file")
+	fs.StringVar(&probeOnceOpts.SeedKubeConfig, "seed-kubeconfig", "", "Path to the kubeconfig file of the seed cluster")
+	fs.StringVar(&probeOnceOpts.ShootKubeConfig, "shoot-kubeconfig", "", "Path to the kubeconfig file of the shoot cluster")
+	fs.StringVar(&probeOnceOpts.ShootNamespace, "shoot-namespace", "", "Shoot control plane namespace in the seed cluster")
+	fs.BoolVar(&probeOnceOpts.SkipPermissionCheck, "skip-permission-check", false, "Skip the check of the required permissions in the seed and in the shoot cluster")
+	addOutputFlag(fs, &probeOnceOpts.Output)
+}
+
+// probeOnce runs a single probe cycle and prints its outcome. It does not return a manager as there is nothing to be started.
+func probeOnce(logger logr.Logger) (manager.Manager, error) {
+	if probeOnceOpts.SeedKubeConfig == "" || probeOnceOpts.ShootKubeConfig == "" || probeOnceOpts.ShootNamespace == "" {
+		return nil, fmt.Errorf("seed-kubeconfig, shoot-kubeconfig and shoot-namespace must be specified")
+	}
+	if err := validateOutputFormat(probeOnceOpts.Output); err != nil {
+		return nil, err
+	}
+	proberConfig, err := prober.LoadConfig(probeOnceOpts.ConfigFile, scheme, !probeOnceOpts.AllowUnknownConfigFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prober config file %s : %w", probeOnceOpts.ConfigFile, err)
+	}
+	seedRestConfig, err := clientcmd.BuildConfigFromFlags("", probeOnceOpts.SeedKubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load seed kubeconfig %s : %w", probeOnceOpts.SeedKubeConfig, err)
+	}
+	seedClient, err := client.New(seedRestConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create seed client %w", err)
+	}
+
+	ctx := context.Background()
+	shootClientCreator := shoot.NewKubeConfigFileClientCreator(probeOnceOpts.ShootKubeConfig)
+	if !probeOnceOpts.SkipPermissionCheck {
+		if err = checkProbeOncePermissions(ctx, seedClient, shootClientCreator, proberConfig, logger); err != nil {
+			return nil, err
+		}
+	}
+	p := prober.NewProber(ctx, seedClient, seedClient, probeOnceOpts.ShootNamespace, proberConfig, nil, nil, shootClientCreator, nil, nil, nil, logger.WithName("probe-once"))
+	outcome := p.ProbeOnce(ctx)
+	if err = printProbeOutcome(os.Stdout, outcome, probeOnceOpts.Output); err != nil {
+		return nil, err
+	}
+	if outcome.Err != nil {
+		return nil, fmt.Errorf("probe cycle failed %w", outcome.Err)
+	}
+	return nil, nil
+}
+
+// checkProbeOncePermissions verifies that the permissions required by the prober are granted in the seed and in the shoot cluster.
+func checkProbeOncePermissions(ctx context.Context, seedClient client.Client, shootClientCreator shoot.ClientCreator, proberConfig *papi.Config, logger logr.Logger) error {
+	// the seed client of the probe-once command is not backed by a cache
+	seedPermissions, err := prober.RequiredSeedPermissions(proberConfig, seedClient.RESTMapper(), func(schema.GroupKind) bool { return true })
+	if err != nil {
+		return fmt.Errorf("failed to determine the permissions required by the prober %w", err)
+	}
+	if err = checkPermissions(ctx, seedClient, seedPermissions, logger); err != nil {
+		return fmt.Errorf("seed permission check failed: %w", err)
+	}
+	shootClient, err := shootClientCreator.CreateClient(ctx, logger, proberConfig.ProbeTimeout.Duration)
+	if err != nil {
+		return fmt.Errorf("failed to create shoot client %w", err)
+	}
+	if err = checkPermissions(ctx, shootClient, prober.RequiredShootPermissions(proberConfig), logger); err != nil {
+		return fmt.Errorf("shoot permission check failed: %w", err)
+	}
+	return nil
+}
+
+// printProbeOutcome prints the outcome of the probe cycle in the given output format.
+func printProbeOutcome(w io.Writer, outcome prober.ProbeOutcome, output string) error {
+	scaleOperation := outcome.ScaleOperation
+	if scaleOperation == "" {
+		scaleOperation = "None"
+	}
+	if output == outputFormatJSON {
+		o := probeOutcomeOutput{APIServerProbeFailed: outcome.APIServerProbeFailed, LeaseProbeFailed: outcome.LeaseProbeFailed, ScaleOperation: scaleOperation}
+		if outcome.Err != nil {
+			o.Error = outcome.Err.Error()
+		}
+		return printJSON(w, o)
+	}
+	_, _ = fmt.Fprintf(w, "API server probe failed: %t\n", outcome.APIServerProbeFailed)
+	_, _ = fmt.Fprintf(w, "Lease probe failed:      %t\n", outcome.LeaseProbeFailed)
+	_, _ = fmt.Fprintf(w, "Scale operation:         %s (dry-run)\n", scaleOperation)
+	if outcome.Err != nil {
+		_, _ = fmt.Fprintf(w, "Error:                   %v\n", outcome.Err)
+	}
+	return nil
+}
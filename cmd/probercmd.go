@@ -5,72 +5,89 @@
 package cmd
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
-	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"slices"
 
 	"github.com/gardener/dependency-watchdog/controllers/cluster"
+	"github.com/gardener/dependency-watchdog/controllers/dependent"
+	"github.com/gardener/dependency-watchdog/internal/claim"
+	"github.com/gardener/dependency-watchdog/internal/features"
 	"github.com/gardener/dependency-watchdog/internal/prober"
 	"github.com/gardener/dependency-watchdog/internal/util"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcluster "sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 const (
 	proberLeaderElectionID = "dwd-prober-leader-election"
 	weederLeaderElectionID = "dwd-weeder-leader-election"
+	// seedProbeSummaryPath is the path on the metrics server at which the seed-wide probe summary is served.
+	seedProbeSummaryPath = "/debug/probe-summary"
+	// shootSeedNameField is the field of a Shoot which holds the name of the seed the Shoot is scheduled to.
+	shootSeedNameField = "spec.seedName"
+	// proberEventRecorderName is the name of the event recorder used by the prober to record events.
+	proberEventRecorderName = "dependency-watchdog-prober"
+	// weederEventRecorderName is the name of the event recorder used by the weeder to record events.
+	weederEventRecorderName = "dependency-watchdog-weeder"
+	// proberUserAgentComponent is the component of the default user agent of the requests of the prober unless it is overridden via the flags.
+	proberUserAgentComponent = "dependency-watchdog-prober"
+	// weederUserAgentComponent is the component of the default user agent of the requests of the weeder unless it is overridden via the flags.
+	weederUserAgentComponent = "dependency-watchdog-weeder"
 )
 
 var (
 	// ProberCmd stores info about using the prober command
 	ProberCmd = &Command{
 		Name:      "prober",
-		UsageLine: "",
 		ShortDesc: "Probes Kubernetes API and Scales Up/Down dependent resources based on its reachability",
 		LongDesc: `For each shoot cluster it will start a probe which periodically probes the API server via an internal and an external endpoint. 
 If the API server continues to be un-reachable beyond a threshold then it scales down the dependent controllers. Once the API 
-server is again reachable then it will restore by scaling up the dependent controllers.
-
-Flags:
-	--config-file
-		Path of the configuration file containing probe configuration and scaling controller-reference information
-	--kubeconfig
-		Path to the kubeconfig file. If not specified, then it will default to the service account token to connect to the kube-api-server
-	--concurrent-reconciles
-		Maximum number of concurrent reconciles which can be run. <optional>
-	--leader-election-namespace
-		Namespace in which leader election namespace will be created. This is typically the same namespace where DWD controllers are deployed.
-	--enable-leader-election
-		Determines if the leader election needs to be enabled.
-	--leader-elect-renew-deadline
-		Interval between attempts by the acting master to renew a leadership slot
-	--leader-elect-retry-period
-		The duration the clients should wait between attempting acquisition and renewal
-	--kube-api-qps
-		Maximum QPS to the API server from this client.
-	--kube-api-burst
-		Maximum burst over the QPS
-	--metrics-bind-address
-		TCP address that the controller should bind to for serving prometheus metrics
-	--health-bind-address
-		TCP address that the controller should bind to for serving health probes
-`,
-		AddFlags: addProbeFlags,
+server is again reachable then it will restore by scaling up the dependent controllers.`,
+		AddFlags: proberOpts.AddFlags,
 		Run:      startClusterControllerMgr,
 	}
-	proberOpts = proberOptions{}
+	proberOpts = &proberOptions{}
 	scheme     = runtime.NewScheme()
+	// gardenScheme is the scheme of the client of the garden cluster whose Shoots are watched if the garden-kubeconfig flag is set.
+	gardenScheme = runtime.NewScheme()
 )
 
 type proberOptions struct {
 	SharedOpts
+	// ShootKubeApiQps indicates the maximum QPS to the API server of a shoot from the clients of its prober
+	ShootKubeApiQps float64
+	// ShootKubeApiBurst is the maximum burst over the ShootKubeApiQps
+	ShootKubeApiBurst int
+	// ShootKubeApiUserAgent is the user agent which is sent with every request to the API server of a shoot. It allows to attribute the probe
+	// traffic of dependency-watchdog in the audit logs of the shoot. Defaults to the default user agent of the prober.
+	ShootKubeApiUserAgent string
+	// DisableScaleDown disables all scale-downs of dependent resources, irrespective of the DisableScaleDown of the probe config.
+	DisableScaleDown bool
+	// GardenKubeConfig is the path to the kubeconfig file of a garden cluster. If it is set, the Shoots in the garden cluster are watched instead
+	// of the Clusters in the seed.
+	GardenKubeConfig string
+	// ShootNamespaceTemplate is a Go template which resolves the control namespace of a Shoot watched in the garden cluster. If it is empty then
+	// the technical ID of the Shoot is used.
+	ShootNamespaceTemplate string
+
+	shootNamespaceResolver cluster.ShootNamespaceResolver
 }
 
 func init() {
@@ -80,26 +97,82 @@ func init() {
 		machinev1alpha1.AddToScheme,
 	)
 	utilruntime.Must(localSchemeBuilder.AddToScheme(scheme))
+	utilruntime.Must(gardencorev1beta1.AddToScheme(gardenScheme))
+}
+
+// AddFlags binds the flags of the prober command to the options.
+func (o *proberOptions) AddFlags(fs *flag.FlagSet) {
+	SetSharedOpts(fs, &o.SharedOpts)
+	fs.Float64Var(&o.ShootKubeApiQps, "shoot-kube-api-qps", 0, "Maximum QPS (queries per second) allowed from the clients of a prober to the API server of its shoot. Defaults to the client-go default")
+	fs.IntVar(&o.ShootKubeApiBurst, "shoot-kube-api-burst", 0, "Maximum burst to throttle the calls of the clients of a prober to the API server of its shoot. Defaults to the client-go default")
+	fs.StringVar(&o.ShootKubeApiUserAgent, "shoot-kube-api-user-agent", "", "User agent which is sent with every request to the API server of a shoot. Defaults to dependency-watchdog-prober/<version> seed=<seed-name>")
+	fs.BoolVar(&o.DisableScaleDown, "disable-scale-down", false, "Disable all scale-downs of dependent resources. Scale-ups are still run")
+	fs.StringVar(&o.GardenKubeConfig, "garden-kubeconfig", "", "Path to the kubeconfig file of a garden cluster. If set, the Shoots in the garden cluster, restricted to the ones scheduled to the seed given via seed-name, are watched instead of the Clusters in the seed")
+	fs.StringVar(&o.ShootNamespaceTemplate, "shoot-namespace-template", "", "Go template which resolves the control namespace of a Shoot watched in the garden cluster, e.g. 'shoot--{{ trimPrefix \"garden-\" .Namespace }}--{{ .Name }}'. Defaults to the technical ID of the Shoot")
+}
+
+// Complete derives the fields of the options which are not set via flags directly. It has to be called after the flags have been parsed.
+func (o *proberOptions) Complete() error {
+	o.shootNamespaceResolver = cluster.TechnicalIDNamespaceResolver
+	if o.ShootNamespaceTemplate != "" {
+		resolver, err := cluster.NewTemplateNamespaceResolver(o.ShootNamespaceTemplate)
+		if err != nil {
+			return err
+		}
+		o.shootNamespaceResolver = resolver
+	}
+	return o.SharedOpts.Complete()
 }
 
-func addProbeFlags(fs *flag.FlagSet) {
-	SetSharedOpts(fs, &proberOpts.SharedOpts)
+// Validate validates the options. All invalid flags are reported at once.
+func (o *proberOptions) Validate() error {
+	v := new(util.Validator)
+	o.SharedOpts.validate(v)
+	v.MustNotBeNegativeFloat("shoot-kube-api-qps", o.ShootKubeApiQps)
+	v.MustNotBeNegative("shoot-kube-api-burst", o.ShootKubeApiBurst)
+	return v.Error
+}
+
+// shootUserAgent returns the user agent of the requests of the prober to the API servers of the shoots.
+func (o *proberOptions) shootUserAgent() string {
+	if o.ShootKubeApiUserAgent != "" {
+		return o.ShootKubeApiUserAgent
+	}
+	return o.defaultUserAgent(proberUserAgentComponent)
 }
 
 func startClusterControllerMgr(logger logr.Logger) (manager.Manager, error) {
 	proberLogger := logger.WithName("cluster-controller")
-	proberConfig, err := prober.LoadConfig(proberOpts.ConfigFile, scheme)
+	if err := proberOpts.Complete(); err != nil {
+		return nil, err
+	}
+	if err := proberOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid flags of the prober command: %w", err)
+	}
+	proberConfig, err := prober.LoadConfig(proberOpts.ConfigFile, scheme, !proberOpts.AllowUnknownConfigFields)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse prober config file %s : %w", proberOpts.ConfigFile, err)
 	}
 
+	if proberOpts.DisableScaleDown {
+		proberLogger.Info("Scale-downs of dependent resources have been disabled via the disable-scale-down flag")
+		proberConfig.DisableScaleDown = pointer.Bool(true)
+	}
+
+	runtimeOverridesObject := proberOpts.runtimeOverridesObject
+
 	restConf := ctrl.GetConfigOrDie()
-	restConf.QPS = float32(proberOpts.KubeApiQps)
-	restConf.Burst = proberOpts.KubeApiBurst
+	proberOpts.applyToRestConfig(restConf, proberUserAgentComponent)
 
+	clientOpts, err := proberOpts.clientOptions(scheme)
+	if err != nil {
+		return nil, err
+	}
 	mgr, err := ctrl.NewManager(restConf, ctrl.Options{
 		Scheme:                     scheme,
-		Metrics:                    server.Options{BindAddress: proberOpts.SharedOpts.MetricsBindAddress},
+		Cache:                      proberOpts.cacheOptions(),
+		Client:                     clientOpts,
+		Metrics:                    proberOpts.metricsServerOptions(proberLogger),
 		HealthProbeBindAddress:     proberOpts.SharedOpts.HealthBindAddress,
 		LeaderElection:             proberOpts.SharedOpts.LeaderElection.Enable,
 		LeaseDuration:              &proberOpts.SharedOpts.LeaderElection.LeaseDuration,
@@ -107,7 +180,7 @@ func startClusterControllerMgr(logger logr.Logger) (manager.Manager, error) {
 		RetryPeriod:                &proberOpts.SharedOpts.LeaderElection.RetryPeriod,
 		LeaderElectionNamespace:    proberOpts.SharedOpts.LeaderElection.Namespace,
 		LeaderElectionResourceLock: resourcelock.LeasesResourceLock,
-		LeaderElectionID:           proberLeaderElectionID,
+		LeaderElectionID:           proberOpts.leaderElectionID(proberLeaderElectionID),
 		Logger:                     proberLogger,
 		PprofBindAddress:           proberOpts.SharedOpts.PprofBindAddress,
 	})
@@ -115,20 +188,141 @@ func startClusterControllerMgr(logger logr.Logger) (manager.Manager, error) {
 		return nil, fmt.Errorf("failed to start the prober controller manager %w", err)
 	}
 
-	scalesGetter, err := util.CreateScalesGetter(ctrl.GetConfigOrDie())
+	if !proberOpts.SkipPermissionCheck {
+		permissions, err := prober.RequiredSeedPermissions(proberConfig, mgr.GetRESTMapper(), proberOpts.isUncached)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the permissions required by the prober %w", err)
+		}
+		if proberOpts.GardenKubeConfig != "" {
+			// the Clusters in the seed are not read if the Shoots are watched in the garden cluster
+			permissions = slices.DeleteFunc(permissions, func(p util.ResourcePermission) bool { return p.Resource == "clusters" && p.Verb != "patch" })
+		}
+		permissions = append(permissions, runtimeOverridesPermissions(runtimeOverridesObject)...)
+		permissions = append(permissions, proberOpts.namespaceClaimPermissions()...)
+		if err := checkPermissions(context.Background(), mgr.GetClient(), permissions, proberLogger); err != nil {
+			return nil, fmt.Errorf("prober permission check failed: %w", err)
+		}
+	}
+
+	// the status of the probers is reported on the Clusters which are only reconciled if the Shoots are not watched in the garden cluster
+	reportProberStatus := proberOpts.GardenKubeConfig == "" && canReportProberStatus(context.Background(), mgr.GetClient(), proberLogger)
+
+	scalesGetter, err := util.CreateScalesGetter(restConf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clientSet for scalesGetter %w", err)
 	}
 
-	if err := (&cluster.Reconciler{
+	proberMgr := prober.NewManager()
+	if err := ctrlmetrics.Registry.Register(prober.NewSeedProbeSummaryCollector(proberMgr)); err != nil {
+		return nil, fmt.Errorf("failed to register seed probe summary metrics %w", err)
+	}
+	if err := mgr.AddMetricsServerExtraHandler(seedProbeSummaryPath, prober.NewSeedProbeSummaryHandler(proberMgr)); err != nil {
+		return nil, fmt.Errorf("failed to register seed probe summary handler %w", err)
+	}
+
+	runtimeOverrides, err := setupRuntimeOverrides(mgr, runtimeOverridesObject)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceClaimer, err := proberOpts.newNamespaceClaimer(mgr, claim.ProberLeaseName, proberLeaderElectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventRecorder := mgr.GetEventRecorderFor(proberEventRecorderName)
+	var seedMeltdownCircuitBreaker, runtimeOverridesCircuitBreaker prober.ScaleDownCircuitBreaker
+	if proberConfig.SeedMeltdownFailureFraction != nil && features.Enabled(features.SeedMeltdownCircuitBreaker) {
+		seedMeltdownCircuitBreaker = prober.NewSeedMeltdownCircuitBreaker(proberMgr, *proberConfig.SeedMeltdownFailureFraction, *proberConfig.SeedMeltdownMinShoots, eventRecorder, proberLogger)
+	}
+	if runtimeOverrides != nil {
+		runtimeOverridesCircuitBreaker = prober.NewRuntimeOverridesCircuitBreaker(runtimeOverrides, eventRecorder)
+	}
+	scaleDownCircuitBreaker := prober.CombineScaleDownCircuitBreakers(runtimeOverridesCircuitBreaker, seedMeltdownCircuitBreaker)
+
+	clusterReconciler := &cluster.Reconciler{
 		Client:                  mgr.GetClient(),
+		APIReader:               mgr.GetAPIReader(),
 		Scheme:                  mgr.GetScheme(),
 		ScaleGetter:             scalesGetter,
-		ProberMgr:               prober.NewManager(),
+		ProberMgr:               proberMgr,
 		DefaultProbeConfig:      proberConfig,
+		ScaleDownCircuitBreaker: scaleDownCircuitBreaker,
+		NamespaceClaimer:        namespaceClaimer,
+		EventRecorder:           eventRecorder,
+		ShootClientRateLimits:   util.RateLimits{QPS: float32(proberOpts.ShootKubeApiQps), Burst: proberOpts.ShootKubeApiBurst},
+		ShootClientUserAgent:    proberOpts.shootUserAgent(),
 		MaxConcurrentReconciles: proberOpts.ConcurrentReconciles,
-	}).SetupWithManager(mgr); err != nil {
+		RuntimeOverrides:        runtimeOverrides,
+		Namespace:               proberOpts.Namespace,
+		ReportProberStatus:      reportProberStatus,
+	}
+	if proberOpts.GardenKubeConfig != "" {
+		if err := setupShootReconciler(mgr, clusterReconciler, proberLogger); err != nil {
+			return nil, err
+		}
+	} else if err := clusterReconciler.SetupWithManager(mgr); err != nil {
 		return nil, fmt.Errorf("failed to register cluster reconciler with the prober controller manager %w", err)
 	}
+
+	if dependent.IsEnabled(proberConfig) {
+		if err := (&dependent.Reconciler{
+			ProberMgr:          proberMgr,
+			DefaultProbeConfig: proberConfig,
+			Namespace:          proberOpts.Namespace,
+		}).SetupWithManager(mgr, proberLogger); err != nil {
+			return nil, fmt.Errorf("failed to register dependent reconciler with the prober controller manager %w", err)
+		}
+	}
 	return mgr, nil
 }
+
+// setupShootReconciler sets up the reconciler of the Shoots in the garden cluster given via the garden-kubeconfig flag. The probers are managed by
+// the given cluster reconciler. If a seed name is given, only the Shoots scheduled to the seed are cached.
+func setupShootReconciler(mgr manager.Manager, clusterReconciler *cluster.Reconciler, logger logr.Logger) error {
+	gardenRestConf, err := clientcmd.BuildConfigFromFlags("", proberOpts.GardenKubeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to load garden kubeconfig %s : %w", proberOpts.GardenKubeConfig, err)
+	}
+	proberOpts.applyToRestConfig(gardenRestConf, proberUserAgentComponent)
+	gardenCluster, err := ctrlcluster.New(gardenRestConf, func(o *ctrlcluster.Options) {
+		o.Scheme = gardenScheme
+		o.Logger = logger.WithName("garden")
+		if proberOpts.SeedName != "" {
+			o.Cache.ByObject = map[client.Object]cache.ByObject{
+				&gardencorev1beta1.Shoot{}: {Field: fields.OneTermEqualSelector(shootSeedNameField, proberOpts.SeedName)},
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the garden cluster %w", err)
+	}
+	if !proberOpts.SkipPermissionCheck {
+		permissions := util.NewResourcePermissions(gardencorev1beta1.SchemeGroupVersion.Group, "shoots", "get", "list", "watch")
+		if err := checkPermissions(context.Background(), gardenCluster.GetClient(), permissions, logger); err != nil {
+			return fmt.Errorf("prober permission check in the garden cluster failed: %w", err)
+		}
+	}
+	if err := (&cluster.ShootReconciler{
+		Reconciler:        clusterReconciler,
+		GardenCluster:     gardenCluster,
+		SeedName:          proberOpts.SeedName,
+		NamespaceResolver: proberOpts.shootNamespaceResolver,
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to register shoot reconciler with the prober controller manager %w", err)
+	}
+	logger.Info("Watching the shoots in the garden cluster instead of the clusters in the seed", "seedName", proberOpts.SeedName)
+	return nil
+}
+
+// canReportProberStatus checks if the prober is permitted to patch Clusters, which is required to report the status of the probers via annotations
+// on the Clusters. As the permission is optional, the status is not reported if it is not granted or cannot be reviewed.
+func canReportProberStatus(ctx context.Context, cl client.Client, logger logr.Logger) bool {
+	permission := util.ResourcePermission{Verb: "patch", Group: extensionsv1alpha1.SchemeGroupVersion.Group, Resource: "clusters"}
+	if err := util.CheckPermissions(ctx, cl, []util.ResourcePermission{permission}); err != nil {
+		logger.Info("The status of the probers is not reported on the clusters", "reason", err.Error())
+		return false
+	}
+	logger.Info("The status of the probers is reported on the clusters")
+	return true
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gardener/dependency-watchdog/internal/prober"
+	"github.com/gardener/dependency-watchdog/internal/prober/scaler"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const defaultRenderScaleFlowsNamespace = "shoot-namespace"
+
+var (
+	// RenderScaleFlowsCmd stores info about using the render-scale-flows command
+	RenderScaleFlowsCmd = &Command{
+		Name:      "render-scale-flows",
+		ShortDesc: "Renders the scale-up and scale-down flows of a prober configuration as a DOT graph",
+		LongDesc: `Renders the scale-up and scale-down flows which the prober creates for the dependent resources of a prober configuration
+as a DOT graph and prints it. The graph shows the levels, the resources scaled by each task and which tasks wait for
+which. It can be rendered with graphviz, e.g. 'dwd render-scale-flows --config-file=config.yaml | dot -Tsvg > flows.svg',
+to verify complex multi-level configurations. No cluster is accessed.`,
+		AddFlags: addRenderScaleFlowsFlags,
+		Run:      renderScaleFlows,
+	}
+	renderScaleFlowsOpts = renderScaleFlowsOptions{}
+)
+
+type renderScaleFlowsOptions struct {
+	// ConfigFile is the prober configuration file path
+	ConfigFile string
+	// AllowUnknownConfigFields relaxes the decoding of the ConfigFile. By default, any field which is not known will result in an error.
+	AllowUnknownConfigFields bool
+	// ShootNamespace is the shoot control plane namespace which is used in the names of the flows
+	ShootNamespace string
+}
+
+func addRenderScaleFlowsFlags(fs *flag.FlagSet) {
+	fs.StringVar(&renderScaleFlowsOpts.ConfigFile, "config-file", "", "Path of the config file containing the configuration")
+	fs.BoolVar(&renderScaleFlowsOpts.AllowUnknownConfigFields, "allow-unknown-config-fields", false, "Ignore fields in the config file which are not known instead of failing to load the config file")
+	fs.StringVar(&renderScaleFlowsOpts.ShootNamespace, "shoot-namespace", defaultRenderScaleFlowsNamespace, "Shoot control plane namespace used in the names of the flows")
+}
+
+// renderScaleFlows prints the scale flows of the prober config as a DOT graph. It does not return a manager as there is nothing to be started.
+func renderScaleFlows(_ logr.Logger) (manager.Manager, error) {
+	if renderScaleFlowsOpts.ConfigFile == "" {
+		return nil, fmt.Errorf("config-file must be specified")
+	}
+	proberConfig, err := prober.LoadConfig(renderScaleFlowsOpts.ConfigFile, scheme, !renderScaleFlowsOpts.AllowUnknownConfigFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prober config file %s : %w", renderScaleFlowsOpts.ConfigFile, err)
+	}
+	_, _ = fmt.Fprint(os.Stdout, scaler.RenderFlows(renderScaleFlowsOpts.ShootNamespace, proberConfig.DependentResourceInfos))
+	return nil, nil
+}
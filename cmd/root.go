@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/gardener/dependency-watchdog/internal/features"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	rootCmdName = "dwd"
+	// kubeConfigFlagName is the name of the flag which is registered by controller-runtime for the path to the kubeconfig file.
+	kubeConfigFlagName = "kubeconfig"
+	// logFormatFlagName is the name of the flag which sets the format of the logs.
+	logFormatFlagName = "log-format"
+	// zapEncoderFlagName is the name of the flag which is registered by controller-runtime for the encoder of the logs.
+	zapEncoderFlagName = "zap-encoder"
+
+	// outputFormatText and outputFormatJSON are the formats of the logs and of the output of the commands which print a result.
+	outputFormatText = "text"
+	outputFormatJSON = "json"
+)
+
+// outputFormats are the supported formats of the logs and of the output of the commands.
+var outputFormats = []string{outputFormatJSON, outputFormatText}
+
+// fileFlagExtensions are the extensions of the files which are completed for the flags of the commands whose values are paths to files. Any file
+// is completed if no extensions are given.
+var fileFlagExtensions = map[string][]string{
+	"config-file":      {"yaml", "yml"},
+	"seed-kubeconfig":  nil,
+	"shoot-kubeconfig": nil,
+}
+
+// NewRootCommand creates the root command of the CLI with one sub command per Command. The flags which are registered on flag.CommandLine, e.g.
+// the kubeconfig flag of controller-runtime, and the flags of the logger are available to all sub commands. A manager which is returned by a
+// command is run until the given context is cancelled. Shell completion scripts are generated via the completion sub command.
+func NewRootCommand(ctx context.Context) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use: rootCmdName,
+		Short: "dwd is a watch-dog which keeps an eye on kubernetes resources and uses a pre-defined configuration to scale up, scale down or " +
+			"stop pods (forcing a restart) based on watches/probes which monitor the health/reachability of defined kubernetes resources.",
+	}
+	zapOpts := &zap.Options{
+		Level:       LogLevel,
+		TimeEncoder: zapcore.RFC3339TimeEncoder,
+	}
+	zapFlags := flag.NewFlagSet("zap", flag.ContinueOnError)
+	zapOpts.BindFlags(zapFlags)
+	zapFlags.Var(&logFormatFlag{format: outputFormatJSON, encoderFlag: zapFlags.Lookup(zapEncoderFlagName).Value}, logFormatFlagName,
+		fmt.Sprintf("Format of the logs, one of %v. JSON logs can be consumed by the log pipelines of the seed", outputFormats))
+	rootCmd.PersistentFlags().AddGoFlagSet(zapFlags)
+	_ = rootCmd.RegisterFlagCompletionFunc(logFormatFlagName, cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	if rootCmd.PersistentFlags().Lookup(kubeConfigFlagName) != nil {
+		_ = rootCmd.MarkPersistentFlagFilename(kubeConfigFlagName)
+	}
+	for _, command := range Commands {
+		rootCmd.AddCommand(newCobraCommand(ctx, command, zapOpts))
+	}
+	return rootCmd
+}
+
+// logFormatFlag sets the format of the logs via the zap-encoder flag of controller-runtime, whose console encoder is used for the text format.
+type logFormatFlag struct {
+	format      string
+	encoderFlag flag.Value
+}
+
+func (f *logFormatFlag) String() string {
+	return f.format
+}
+
+func (f *logFormatFlag) Set(format string) error {
+	encoder := ""
+	switch format {
+	case outputFormatJSON:
+		encoder = "json"
+	case outputFormatText:
+		encoder = "console"
+	default:
+		return fmt.Errorf("unsupported log format %q, supported formats are %v", format, outputFormats)
+	}
+	if err := f.encoderFlag.Set(encoder); err != nil {
+		return err
+	}
+	f.format = format
+	return nil
+}
+
+// newCobraCommand creates the cobra command which runs the given command.
+func newCobraCommand(ctx context.Context, command *Command, zapOpts *zap.Options) *cobra.Command {
+	c := &cobra.Command{
+		Use:   command.Name,
+		Short: command.ShortDesc,
+		Long:  command.LongDesc,
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			// the flags have been parsed successfully, errors from here on are not caused by a wrong usage of the command
+			c.SilenceUsage = true
+			ctrl.SetLogger(zap.New(zap.UseFlagOptions(zapOpts)))
+			logger := ctrl.Log.WithName(rootCmdName)
+			mgr, err := command.Run(logger)
+			if err != nil {
+				return fmt.Errorf("failed to run command %s: %w", command.Name, err)
+			}
+			if mgr == nil {
+				return nil
+			}
+			logger.Info("Starting manager", "featureGates", features.DefaultFeatureGate.String())
+			if err = mgr.Start(ctx); err != nil {
+				return fmt.Errorf("failed to run the manager: %w", err)
+			}
+			return nil
+		},
+	}
+	if command.AddFlags != nil {
+		fs := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+		command.AddFlags(fs)
+		c.Flags().AddGoFlagSet(fs)
+	}
+	for name, extensions := range fileFlagExtensions {
+		if c.Flags().Lookup(name) != nil {
+			_ = c.MarkFlagFilename(name, extensions...)
+		}
+	}
+	for name, values := range command.FlagValues {
+		_ = c.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+	}
+	return c
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics/dashboards"
+	"github.com/gardener/dependency-watchdog/internal/prober"
+	. "github.com/onsi/gomega"
+)
+
+func TestRootCommandShouldHaveASubCommandPerCommand(t *testing.T) {
+	g := NewWithT(t)
+	rootCmd := NewRootCommand(context.Background())
+	for _, command := range Commands {
+		c, _, err := rootCmd.Find([]string{command.Name})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(c.Name()).To(Equal(command.Name))
+	}
+}
+
+func TestRootCommandShouldCompleteFlagValues(t *testing.T) {
+	g := NewWithT(t)
+	rootCmd := NewRootCommand(context.Background())
+	out := &bytes.Buffer{}
+	rootCmd.SetOut(out)
+	rootCmd.SetArgs([]string{"__complete", WeederCmd.Name, "--endpoints-source", ""})
+	g.Expect(rootCmd.Execute()).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("EndpointSlices"))
+}
+
+func TestDashboardsCommandShouldWriteDashboards(t *testing.T) {
+	g := NewWithT(t)
+	outputDir := t.TempDir()
+	rootCmd := NewRootCommand(context.Background())
+	rootCmd.SetArgs([]string{DashboardsCmd.Name, "--output-dir", outputDir})
+	g.Expect(rootCmd.Execute()).To(Succeed())
+	for _, name := range dashboards.Names() {
+		g.Expect(filepath.Join(outputDir, dashboards.FileName(name))).To(BeARegularFile())
+	}
+}
+
+func TestRootCommandShouldRejectUnknownCommands(t *testing.T) {
+	g := NewWithT(t)
+	rootCmd := NewRootCommand(context.Background())
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"unknown"})
+	g.Expect(rootCmd.Execute()).ToNot(Succeed())
+}
+
+func TestRootCommandShouldSetLogFormat(t *testing.T) {
+	g := NewWithT(t)
+	rootCmd := NewRootCommand(context.Background())
+	logFormat := rootCmd.PersistentFlags().Lookup(logFormatFlagName)
+	g.Expect(logFormat.DefValue).To(Equal(outputFormatJSON))
+
+	g.Expect(rootCmd.PersistentFlags().Set(logFormatFlagName, outputFormatText)).To(Succeed())
+	g.Expect(rootCmd.PersistentFlags().Lookup(zapEncoderFlagName).Value.String()).To(ContainSubstring("console"))
+	g.Expect(rootCmd.PersistentFlags().Set(logFormatFlagName, "yaml")).To(MatchError(ContainSubstring(`unsupported log format "yaml"`)))
+}
+
+func TestPrintProbeOutcomeShouldPrintJSON(t *testing.T) {
+	g := NewWithT(t)
+	out := &bytes.Buffer{}
+	outcome := prober.ProbeOutcome{LeaseProbeFailed: true, ScaleOperation: "ScaleDown", Err: errors.New("lease probe failed")}
+	g.Expect(printProbeOutcome(out, outcome, outputFormatJSON)).To(Succeed())
+	g.Expect(out.String()).To(MatchJSON(`{"apiServerProbeFailed": false, "leaseProbeFailed": true, "scaleOperation": "ScaleDown", "error": "lease probe failed"}`))
+
+	out.Reset()
+	g.Expect(printProbeOutcome(out, prober.ProbeOutcome{}, outputFormatText)).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("Scale operation:         None (dry-run)"))
+}
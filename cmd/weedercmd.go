@@ -5,11 +5,13 @@
 package cmd
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"slices"
 
 	"github.com/gardener/dependency-watchdog/controllers/endpoint"
+	"github.com/gardener/dependency-watchdog/internal/claim"
 	internalutils "github.com/gardener/dependency-watchdog/internal/util"
 	"github.com/gardener/dependency-watchdog/internal/weeder"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -17,70 +19,88 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/go-logr/logr"
+	multierr "github.com/hashicorp/go-multierror"
 )
 
 var (
 	// WeederCmd stores info about the weeder command
 	WeederCmd = &Command{
 		Name:      "weeder",
-		UsageLine: "",
 		ShortDesc: "Restarts CrashLooping pods which are dependant on a service , for quick recovery ensuring maximum availability",
 		LongDesc: `Watches for Kubernetes endpoints for a service. If the endpoints transition from being
 unavailable to now being available, it checks all dependent pods for CrashLoopBackOff condition. It attempts to
 restore these dependent pods by deleting them so that they are started again by respective controller. In essence
-it weeds out the bad pods.
-
-Flags:
-	--kubeconfig
-		Path to the kubeconfig file. If not specified, then it will default to the service account token to connect to the kube-api-server	
-	--config-file
-		Path of the configuration file containing probe configuration and scaling controller-reference information
-	--concurrent-reconciles
-		Maximum number of concurrent reconciles which can be run. <optional>
-	--leader-election-namespace
-		Namespace in which leader election namespace will be created. This is typically the same namespace where DWD controllers are deployed.
-	--enable-leader-election
-		Determines if the leader election needs to be enabled.
-	--leader-elect-renew-deadline
-		Interval between attempts by the acting master to renew a leadership slot
-	--leader-elect-retry-period
-		The duration the clients should wait between attempting acquisition and renewal
-	--kube-api-qps
-		Maximum QPS to the API server from this client.
-	--kube-api-burst
-		Maximum burst over the QPS
-	--metrics-bind-address
-		TCP address that the controller should bind to for serving prometheus metrics
-	--health-bind-address
-		TCP address that the controller should bind to for serving health probes
-`,
-		AddFlags: addWeederFlags,
-		Run:      startEndpointsControllerMgr,
+it weeds out the bad pods.`,
+		AddFlags:   weederOpts.AddFlags,
+		FlagValues: map[string][]string{"endpoints-source": endpointsSourceValues()},
+		Run:        startEndpointsControllerMgr,
 	}
-	weederOpts = weederOptions{}
+	weederOpts = &weederOptions{}
 )
 
 type weederOptions struct {
 	SharedOpts
+	// EndpointsSource is the kind of resources from which the readiness of a service is determined
+	EndpointsSource string
 }
 
-func addWeederFlags(fs *flag.FlagSet) {
-	SetSharedOpts(fs, &weederOpts.SharedOpts)
+// AddFlags binds the flags of the weeder command to the options.
+func (o *weederOptions) AddFlags(fs *flag.FlagSet) {
+	SetSharedOpts(fs, &o.SharedOpts)
+	fs.StringVar(&o.EndpointsSource, "endpoints-source", string(weeder.EndpointsSourceEndpoints), "Resources from which the readiness of a service is determined, one of Endpoints, EndpointSlices or Both")
+}
+
+// Complete derives the fields of the options which are not set via flags directly. It has to be called after the flags have been parsed.
+func (o *weederOptions) Complete() error {
+	return o.SharedOpts.Complete()
+}
+
+// Validate validates the options. All invalid flags are reported at once.
+func (o *weederOptions) Validate() error {
+	v := new(internalutils.Validator)
+	o.SharedOpts.validate(v)
+	if !slices.Contains(weeder.SupportedEndpointsSources, weeder.EndpointsSource(o.EndpointsSource)) {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("unsupported endpoints source %q, supported values are %v", o.EndpointsSource, weeder.SupportedEndpointsSources))
+	}
+	return v.Error
+}
+
+// endpointsSourceValues returns the supported values of the endpoints-source flag.
+func endpointsSourceValues() []string {
+	values := make([]string, 0, len(weeder.SupportedEndpointsSources))
+	for _, source := range weeder.SupportedEndpointsSources {
+		values = append(values, string(source))
+	}
+	return values
 }
 
 func startEndpointsControllerMgr(logger logr.Logger) (manager.Manager, error) {
 	weederLogger := logger.WithName("endpoints-controller")
-	weederConfig, err := weeder.LoadConfig(weederOpts.ConfigFile)
+	if err := weederOpts.Complete(); err != nil {
+		return nil, err
+	}
+	if err := weederOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid flags of the weeder command: %w", err)
+	}
+	weederConfig, err := weeder.LoadConfig(weederOpts.ConfigFile, !weederOpts.AllowUnknownConfigFields)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse weeder config file %s : %w", weederOpts.ConfigFile, err)
 	}
 
+	endpointsSource := weeder.EndpointsSource(weederOpts.EndpointsSource)
+	runtimeOverridesObject := weederOpts.runtimeOverridesObject
+
 	restConf := ctrl.GetConfigOrDie()
-	restConf.QPS = float32(weederOpts.KubeApiQps)
-	restConf.Burst = weederOpts.KubeApiBurst
+	weederOpts.applyToRestConfig(restConf, weederUserAgentComponent)
+	clientOpts, err := weederOpts.clientOptions(scheme)
+	if err != nil {
+		return nil, err
+	}
 	mgr, err := ctrl.NewManager(restConf, ctrl.Options{
 		Scheme:                     scheme,
-		Metrics:                    server.Options{BindAddress: weederOpts.SharedOpts.MetricsBindAddress},
+		Cache:                      weederOpts.cacheOptions(weeder.DependantNamespaces(weederConfig)...),
+		Client:                     clientOpts,
+		Metrics:                    weederOpts.metricsServerOptions(weederLogger),
 		HealthProbeBindAddress:     weederOpts.SharedOpts.HealthBindAddress,
 		LeaderElection:             weederOpts.SharedOpts.LeaderElection.Enable,
 		LeaseDuration:              &weederOpts.SharedOpts.LeaderElection.LeaseDuration,
@@ -88,7 +108,7 @@ func startEndpointsControllerMgr(logger logr.Logger) (manager.Manager, error) {
 		RetryPeriod:                &weederOpts.SharedOpts.LeaderElection.RetryPeriod,
 		LeaderElectionNamespace:    weederOpts.SharedOpts.LeaderElection.Namespace,
 		LeaderElectionResourceLock: resourcelock.LeasesResourceLock,
-		LeaderElectionID:           weederLeaderElectionID,
+		LeaderElectionID:           weederOpts.leaderElectionID(weederLeaderElectionID),
 		Logger:                     weederLogger,
 		PprofBindAddress:           weederOpts.SharedOpts.PprofBindAddress,
 	})
@@ -96,17 +116,38 @@ func startEndpointsControllerMgr(logger logr.Logger) (manager.Manager, error) {
 		return nil, fmt.Errorf("failed to start the weeder controller manager %w", err)
 	}
 
+	if !weederOpts.SkipPermissionCheck {
+		if err := checkPermissions(context.Background(), mgr.GetClient(), slices.Concat(weeder.RequiredSeedPermissions(weederConfig, endpointsSource), runtimeOverridesPermissions(runtimeOverridesObject), weederOpts.namespaceClaimPermissions()), weederLogger); err != nil {
+			return nil, fmt.Errorf("weeder permission check failed: %w", err)
+		}
+	}
+
 	// create clientSet
 	clientSet, err := internalutils.CreateClientSetFromRestConfig(restConf)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating clientset for dwd-weeder %w", err)
 	}
 
+	runtimeOverrides, err := setupRuntimeOverrides(mgr, runtimeOverridesObject)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceClaimer, err := weederOpts.newNamespaceClaimer(mgr, claim.WeederLeaseName, weederLeaderElectionID)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := (&endpoint.Reconciler{
-		Client:       mgr.GetClient(),
-		SeedClient:   clientSet,
-		WeederConfig: weederConfig,
-		WeederMgr:    weeder.NewManager(),
+		Client:           mgr.GetClient(),
+		SeedClient:       clientSet,
+		WeederConfig:     weederConfig,
+		WeederMgr:        weeder.NewManager(),
+		EndpointsSource:  endpointsSource,
+		Namespace:        weederOpts.Namespace,
+		RuntimeOverrides: runtimeOverrides,
+		EventRecorder:    mgr.GetEventRecorderFor(weederEventRecorderName),
+		NamespaceClaimer: namespaceClaimer,
 	}).SetupWithManager(mgr); err != nil {
 		return nil, fmt.Errorf("failed to register endpoint reconciler with weeder controller manager %w", err)
 	}
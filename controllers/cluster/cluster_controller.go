@@ -7,7 +7,14 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/gardener/dependency-watchdog/internal/claim"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
 	"github.com/gardener/dependency-watchdog/internal/util"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
@@ -16,28 +23,43 @@ import (
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
 
 	"github.com/gardener/dependency-watchdog/internal/prober"
-	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
 	v1beta1helper "github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/scale"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-const controllerName = "cluster"
+const (
+	controllerName = "cluster"
+	// minNodeCountForScalingAnnotationKey is the key of the annotation on a Shoot which overrides the MinNodeCountForScaling of the probe config.
+	minNodeCountForScalingAnnotationKey = "dependency-watchdog.gardener.cloud/min-node-count-for-scaling"
+	// excludedWorkerPoolsAnnotationKey is the key of the annotation on a Shoot whose comma-separated worker pool names override the
+	// ExcludedWorkerPools of the probe config. An empty value excludes no worker pool of the shoot.
+	excludedWorkerPoolsAnnotationKey = "dependency-watchdog.gardener.cloud/excluded-worker-pools"
+	// disableProberAnnotationKey is the key of the annotation on a Shoot which, if set to true, prevents a prober from being created for the
+	// shoot, e.g. because the meltdown handling of the shoot is managed by its owner.
+	disableProberAnnotationKey = "dependency-watchdog.gardener.cloud/disable-prober"
+)
 
 // Reconciler reconciles a Cluster object
 type Reconciler struct {
 	Client client.Client
+	// APIReader reads directly from the API server of the seed. The probers use it for the objects which are not cached by the Client.
+	APIReader client.Reader
 	// Scheme is the controller-runtime scheme used to initialize the controller manager and to validate the probe config
 	Scheme *runtime.Scheme
 	// ProberMgr is interface to manage lifecycle of probers.
@@ -48,12 +70,49 @@ type Reconciler struct {
 	// when the shoot's spec.Kubernetes.KubeControllerManager.NodeMonitorGracePeriod is not set. If it is set, then a new config is generated from
 	// the default config with the updated KCMNodeMonitorGraceDuration.
 	DefaultProbeConfig *papi.Config
+	// ScaleDownCircuitBreaker is shared by all probers to suppress scale-downs of dependent resources. It is optional and can be nil.
+	ScaleDownCircuitBreaker prober.ScaleDownCircuitBreaker
+	// NamespaceClaimer is used by the probers to claim the shoot control namespaces before scaling any dependent resources. It is optional and
+	// can be nil, in which case the namespaces are not claimed.
+	NamespaceClaimer *claim.Claimer
+	// EventRecorder is used by the probers to record events for the shoot control namespaces. It is optional and can be nil.
+	EventRecorder record.EventRecorder
+	// ShootClientRateLimits are the client-side rate limits of the clients which the probers use to connect to the API servers of the shoots.
+	// They can be overridden via the ShootClientQPS and ShootClientBurst of the probe config.
+	ShootClientRateLimits util.RateLimits
+	// ShootClientUserAgent is the user agent of the clients which the probers use to connect to the API servers of the shoots. If it is empty
+	// then the user agent of client-go is used.
+	ShootClientUserAgent string
 	// MaxConcurrentReconciles is the maximum number of concurrent Reconciles which can be run. Defaults to 1.
 	MaxConcurrentReconciles int
+	// RuntimeOverrides are honored by the scalers of the probers. They are optional and can be nil. Scale-downs which have been disabled via the
+	// runtime overrides are suppressed via the ScaleDownCircuitBreaker.
+	RuntimeOverrides *overrides.Overrides
+	// Namespace restricts the reconciler to the Cluster of the shoot with the given control namespace. If it is empty then all Clusters are
+	// reconciled.
+	Namespace string
+	// ReportProberStatus enables reporting the status of the prober of a shoot via annotations on its Cluster. It requires the permission to
+	// patch Clusters.
+	ReportProberStatus bool
+	// shoots caches the Shoots decoded from the Clusters.
+	shoots shootCache
+	// readOnlyNamespaces are the shoot control namespaces whose probers run in the read-only mode as the control plane of the shoot is migrated,
+	// see ProbeDuringMigration of the probe config.
+	readOnlyNamespaces sync.Map
 }
 
-//+kubebuilder:rbac:groups=gardener.cloud,resources=clusters,verbs=get;list;watch
-//+kubebuilder:rbac:groups=gardener.cloud,resources=clusters/status,verbs=get
+//+kubebuilder:rbac:groups=extensions.gardener.cloud,resources=clusters,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=extensions.gardener.cloud,resources=clusters/status,verbs=get
+//+kubebuilder:rbac:groups=extensions.gardener.cloud,resources=workers,verbs=list;watch
+//+kubebuilder:rbac:groups=extensions.gardener.cloud,resources=workers/status,verbs=patch
+//+kubebuilder:rbac:resources=configmaps,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:resources=events,verbs=create;patch
+//+kubebuilder:rbac:resources=namespaces;secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=machine.sapcloud.io,resources=machines,verbs=list;watch
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=apps,resources=deployments/scale;statefulsets/scale,verbs=get;update
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 
 // Reconcile listens to create/update/delete events for `Cluster` resources and
 // manages probes for the shoot control namespace for these clusters by looking at the cluster state.
@@ -65,30 +124,57 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	}
 	// If the cluster is not found then any existing probes if present will be unregistered
 	if notFound {
-		if r.ProberMgr.Unregister(req.Name) {
+		r.shoots.delete(req.Name)
+		r.readOnlyNamespaces.Delete(req.Name)
+		if r.ProberMgr.Unregister(req.Name, metrics.ReasonClusterNotFound) {
 			log.Info("Cluster not found, existing prober has been removed")
 		}
 		return ctrl.Result{}, nil
 	}
 
-	shoot, err := extensionscontroller.ShootFromCluster(cluster)
+	shoot, err := r.shoots.get(cluster)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("error extracting shoot from cluster: %w", err)
+		r.reportProberStatus(ctx, cluster, proberStatusFailed, err.Error(), log)
+		return ctrl.Result{}, err
 	}
 
-	shootControlNamespace := cluster.Name
+	r.reconcileShoot(ctx, cluster.Name, shoot, func(status, message string) {
+		r.reportProberStatus(ctx, cluster, status, message, log)
+	}, log)
+	return ctrl.Result{}, nil
+}
 
-	if shouldStopProber(shoot, log) {
-		if r.ProberMgr.Unregister(shootControlNamespace) {
+// reconcileShoot starts, updates or stops the prober of the shoot with the given control namespace depending on the state of the shoot. The
+// resulting status of the prober is passed to reportStatus.
+func (r *Reconciler) reconcileShoot(ctx context.Context, shootControlNamespace string, shoot *v1beta1.Shoot, reportStatus func(status, message string), log logr.Logger) {
+	probeDuringMigration := r.DefaultProbeConfig != nil && pointer.BoolDeref(r.DefaultProbeConfig.ProbeDuringMigration, false)
+	if stop, reason := shouldStopProber(shoot, probeDuringMigration, log); stop {
+		r.readOnlyNamespaces.Delete(shootControlNamespace)
+		if r.ProberMgr.Unregister(shootControlNamespace, reason) {
 			log.Info("Existing prober has been removed")
 		}
-		return ctrl.Result{}, nil
+		reportStatus(proberStatusStopped, reason)
+		return
+	}
+
+	if probeDuringMigration && isControlPlaneMigrating(shoot) {
+		if _, loaded := r.readOnlyNamespaces.LoadOrStore(shootControlNamespace, struct{}{}); !loaded {
+			log.Info("Control plane is migrated, prober runs in the read-only mode and does not scale any dependent resources")
+		}
+		r.startProber(ctx, shootControlNamespace, shoot, log)
+		reportStatus(proberStatusRunning, readOnlyProberMessage)
+		return
+	}
+	if _, loaded := r.readOnlyNamespaces.LoadAndDelete(shootControlNamespace); loaded {
+		log.Info("Control plane is no longer migrated, prober leaves the read-only mode")
 	}
 
 	if canStartProber(shoot, log) {
 		r.startProber(ctx, shootControlNamespace, shoot, log)
+		reportStatus(proberStatusRunning, "")
+	} else if _, ok := r.ProberMgr.GetProber(shootControlNamespace); !ok {
+		reportStatus(proberStatusPending, pendingProberMessage)
 	}
-	return ctrl.Result{}, nil
 }
 
 // getCluster will retrieve the cluster object given the namespace and name. Cluster not found is not treated as an error and is handled differently in the caller
@@ -104,29 +190,78 @@ func (r *Reconciler) getCluster(ctx context.Context, namespace string, name stri
 }
 
 // startProber sets up a new probe against a given key which uniquely identifies the probe.
-// Typically, the key in case of a shoot cluster is the shoot namespace
+// Typically, the key in case of a shoot cluster is the shoot namespace. If a prober is already running and only its effective probe config has
+// changed, then the config is swapped in place so that the prober retains its state. Otherwise, the prober is restarted.
 func (r *Reconciler) startProber(ctx context.Context, shootControlNs string, shoot *v1beta1.Shoot, logger logr.Logger) {
 	workerNodeConditions := util.GetEffectiveNodeConditionsForWorkers(shoot)
 	existingProber, ok := r.ProberMgr.GetProber(shootControlNs)
 	if !ok {
-		r.createAndRunProber(ctx, shootControlNs, shoot, workerNodeConditions, logger)
+		r.createAndRunProber(ctx, shootControlNs, shoot, workerNodeConditions, "", logger)
 	} else {
 		if existingProber.AreWorkerNodeConditionsStale(workerNodeConditions) {
 			logger.Info("Restarting prober due to change in node conditions for workers")
-			_ = r.ProberMgr.Unregister(shootControlNs)
-			r.createAndRunProber(ctx, shootControlNs, shoot, workerNodeConditions, logger)
+			r.createAndRunProber(ctx, shootControlNs, shoot, workerNodeConditions, metrics.ReasonNodeConditionsChange, logger)
+		} else if probeConfig := r.getEffectiveProbeConfig(shoot, logr.Discard()); !reflect.DeepEqual(existingProber.GetConfig(), probeConfig) {
+			if r.ProberMgr.UpdateConfig(shootControlNs, probeConfig) {
+				logger.Info("Updated the probe config of the running prober")
+				return
+			}
+			logger.Info("Restarting prober due to change in probe config")
+			r.createAndRunProber(ctx, shootControlNs, shoot, workerNodeConditions, metrics.ReasonConfigChange, logger)
 		}
 	}
 }
 
-func (r *Reconciler) createAndRunProber(ctx context.Context, shootNamespace string, shoot *v1beta1.Shoot, workerNodeConditions map[string][]string, logger logr.Logger) {
+// createAndRunProber creates and starts a new prober for the shoot. If a restart reason is given, then the new prober replaces the running prober
+// of the shoot, else it is registered as an additional prober.
+func (r *Reconciler) createAndRunProber(ctx context.Context, shootNamespace string, shoot *v1beta1.Shoot, workerNodeConditions map[string][]string, restartReason string, logger logr.Logger) {
 	probeConfig := r.getEffectiveProbeConfig(shoot, logger)
-	deploymentScaler := scaler.NewScaler(shootNamespace, probeConfig.DependentResourceInfos, r.Client, r.ScaleGetter, logger)
-	shootClientCreator := shootclient.NewClientCreator(shootNamespace, probeConfig.KubeConfigSecretName, r.Client)
-	p := prober.NewProber(ctx, r.Client, shootNamespace, probeConfig, workerNodeConditions, deploymentScaler, shootClientCreator, logger)
-	r.ProberMgr.Register(*p)
+	deploymentScaler := scaler.NewScaler(shootNamespace, probeConfig.DependentResourceInfos, r.Client, r.ScaleGetter, logger,
+		scaler.WithReplicasAnnotationKey(*probeConfig.ReplicasAnnotationKey, *probeConfig.DualWriteReplicasAnnotation),
+		scaler.WithMaxConcurrentScalesPerLevel(pointer.IntDeref(probeConfig.MaxConcurrentScalesPerLevel, 0)),
+		scaler.WithFlowTimeout(util.GetValOrDefault(probeConfig.ScaleFlowTimeout, metav1.Duration{}).Duration),
+		scaler.WithDryRunScaleUpdates(pointer.BoolDeref(probeConfig.DryRunScaleUpdates, false)),
+		scaler.WithHonorRetryAfter(pointer.BoolDeref(probeConfig.HonorRetryAfter, true)),
+		scaler.WithDryRun(func() bool { return r.RuntimeOverrides.IsDryRun() || r.isReadOnly(shootNamespace) }))
+	var shootClientCreator shootclient.ClientCreator
+	if probeConfig.KubeConfigTokenSecretName != nil {
+		shootClientCreator = shootclient.NewTokenClientCreator(shootNamespace, probeConfig.KubeConfigSecretName, *probeConfig.KubeConfigTokenSecretName, r.Client, r.getShootClientOptions(probeConfig))
+	} else {
+		shootClientCreator = shootclient.NewClientCreator(shootNamespace, probeConfig.KubeConfigSecretName, r.Client, r.getShootClientOptions(probeConfig))
+	}
+	p := prober.NewProber(ctx, r.Client, r.APIReader, shootNamespace, probeConfig, workerNodeConditions, deploymentScaler, shootClientCreator, r.ScaleDownCircuitBreaker, r.NamespaceClaimer, r.EventRecorder, logger)
+	if restartReason != "" {
+		r.ProberMgr.Replace(*p, restartReason)
+	} else {
+		r.ProberMgr.Register(*p)
+	}
 	logger.Info("Starting a new prober")
-	go p.Run()
+	p.Start()
+}
+
+// isReadOnly checks if the prober of the shoot with the given control namespace runs in the read-only mode, see ProbeDuringMigration.
+func (r *Reconciler) isReadOnly(shootControlNamespace string) bool {
+	_, ok := r.readOnlyNamespaces.Load(shootControlNamespace)
+	return ok
+}
+
+// getShootClientOptions returns the options of the clients which the prober uses to connect to the API server of the shoot. The rate limits of the
+// probe config take precedence over ShootClientRateLimits.
+func (r *Reconciler) getShootClientOptions(probeConfig *papi.Config) util.ClientOptions {
+	opts := util.ClientOptions{RateLimits: r.ShootClientRateLimits, UserAgent: r.ShootClientUserAgent}
+	if probeConfig.ShootClientQPS != nil {
+		opts.QPS = float32(*probeConfig.ShootClientQPS)
+	}
+	if probeConfig.ShootClientBurst != nil {
+		opts.Burst = *probeConfig.ShootClientBurst
+	}
+	if probeConfig.ShootClientDialTimeout != nil {
+		opts.DialTimeout = probeConfig.ShootClientDialTimeout.Duration
+	}
+	if probeConfig.ShootClientTLSHandshakeTimeout != nil {
+		opts.TLSHandshakeTimeout = probeConfig.ShootClientTLSHandshakeTimeout.Duration
+	}
+	return opts
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -141,7 +276,8 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err != nil {
 		return err
 	}
-	return c.Watch(source.Kind[client.Object](mgr.GetCache(), &extensionsv1alpha1.Cluster{}, &handler.EnqueueRequestForObject{}, workerLessShoot(c.GetLogger())))
+	return c.Watch(source.Kind[client.Object](mgr.GetCache(), &extensionsv1alpha1.Cluster{}, &handler.EnqueueRequestForObject{},
+		predicate.And[client.Object](shootControlNamespace(r.Namespace), workerLessShoot(c.GetLogger()))))
 }
 
 // getEffectiveProbeConfig returns the updated probe config after checking the shoot KCM configuration for NodeMonitorGracePeriod.
@@ -153,34 +289,103 @@ func (r *Reconciler) getEffectiveProbeConfig(shoot *v1beta1.Shoot, logger logr.L
 		logger.Info("Using the NodeMonitorGracePeriod set in the shoot as KCMNodeMonitorGraceDuration in the probe config", "nodeMonitorGraceDuration", *kcmConfig.NodeMonitorGracePeriod)
 		probeConfig.KCMNodeMonitorGraceDuration = kcmConfig.NodeMonitorGracePeriod
 	}
+	if minNodeCountForScaling, ok := getMinNodeCountForScalingFromAnnotation(shoot, logger); ok {
+		logger.Info("Using the minimum node count for scaling set on the shoot", "minNodeCountForScaling", minNodeCountForScaling)
+		probeConfig.MinNodeCountForScaling = &minNodeCountForScaling
+	}
+	if excludedWorkerPools, ok := getExcludedWorkerPoolsFromAnnotation(shoot); ok {
+		logger.Info("Using the excluded worker pools set on the shoot", "excludedWorkerPools", excludedWorkerPools)
+		probeConfig.ExcludedWorkerPools = excludedWorkerPools
+	}
 	return &probeConfig
 }
 
-func shouldStopProber(shoot *v1beta1.Shoot, logger logr.Logger) bool {
+// getExcludedWorkerPoolsFromAnnotation returns the names of the worker pools set via excludedWorkerPoolsAnnotationKey on the shoot.
+func getExcludedWorkerPoolsFromAnnotation(shoot *v1beta1.Shoot) ([]string, bool) {
+	value, ok := shoot.Annotations[excludedWorkerPoolsAnnotationKey]
+	if !ok {
+		return nil, false
+	}
+	excludedWorkerPools := []string{}
+	for _, poolName := range strings.Split(value, ",") {
+		if poolName = strings.TrimSpace(poolName); poolName != "" {
+			excludedWorkerPools = append(excludedWorkerPools, poolName)
+		}
+	}
+	return excludedWorkerPools, true
+}
+
+// getMinNodeCountForScalingFromAnnotation returns the minimum node count for scaling set via minNodeCountForScalingAnnotationKey on the shoot.
+// An invalid value is logged and ignored.
+func getMinNodeCountForScalingFromAnnotation(shoot *v1beta1.Shoot, logger logr.Logger) (int, bool) {
+	value, ok := shoot.Annotations[minNodeCountForScalingAnnotationKey]
+	if !ok {
+		return 0, false
+	}
+	minNodeCountForScaling, err := strconv.Atoi(value)
+	if err != nil || minNodeCountForScaling < 0 {
+		logger.Error(err, "Ignoring invalid value of annotation on the shoot, it should be a non-negative integer", "annotation", minNodeCountForScalingAnnotationKey, "value", value)
+		return 0, false
+	}
+	return minNodeCountForScaling, true
+}
+
+// shouldStopProber checks if the prober of the shoot has to be stopped and returns the reason for it, which is captured by the prober metrics.
+// If probeDuringMigration is set then the prober is not stopped as the control plane of the shoot is migrated.
+func shouldStopProber(shoot *v1beta1.Shoot, probeDuringMigration bool, logger logr.Logger) (bool, string) {
 	// If shoot is marked for deletion then any existing probes will be unregistered
 	if shoot.DeletionTimestamp != nil {
 		logger.Info("Cluster has been marked for deletion, existing prober if any will be removed")
-		return true
+		return true, metrics.ReasonShootDeletion
 	}
 
 	// if hibernation is enabled then we will remove any existing prober. Any resource scaling that is required in case of hibernation will now be handled as part of worker reconciliation in extension controllers.
 	if v1beta1helper.HibernationIsEnabled(shoot) {
 		logger.Info("Cluster hibernation is enabled, existing prober if any will be removed")
-		return true
+		return true, metrics.ReasonHibernation
 	}
 
 	// if control plane migration has started for a shoot, then any existing probe should be removed as it is no longer needed.
-	if shoot.Status.LastOperation != nil && shoot.Status.LastOperation.Type == v1beta1.LastOperationTypeMigrate {
+	if !probeDuringMigration && shoot.Status.LastOperation != nil && shoot.Status.LastOperation.Type == v1beta1.LastOperationTypeMigrate {
 		logger.Info("Cluster migration is enabled, existing prober if any will be removed")
-		return true
+		return true, metrics.ReasonMigration
 	}
 
 	// if a shoot is created without any workers (this can only happen for control-plane-as-a-service use case), then any existing probe should be removed as it is no longer needed.
 	if len(shoot.Spec.Provider.Workers) == 0 {
 		logger.Info("Cluster does not have any workers, existing prober if any will be removed")
-		return true
+		return true, metrics.ReasonNoWorkers
 	}
-	return false
+
+	// if the prober has been disabled for the shoot then any existing probe should be removed and no new probe should be created.
+	if isProberDisabledByAnnotation(shoot, logger) {
+		logger.Info("Prober has been disabled for the shoot via annotation, existing prober if any will be removed", "annotation", disableProberAnnotationKey)
+		return true, metrics.ReasonProberDisabled
+	}
+	return false, ""
+}
+
+// isProberDisabledByAnnotation checks if the prober has been disabled via disableProberAnnotationKey on the shoot. An invalid value is logged and
+// ignored.
+func isProberDisabledByAnnotation(shoot *v1beta1.Shoot, logger logr.Logger) bool {
+	value, ok := shoot.Annotations[disableProberAnnotationKey]
+	if !ok {
+		return false
+	}
+	disabled, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid value of annotation on the shoot, it should be a boolean", "annotation", disableProberAnnotationKey, "value", value)
+		return false
+	}
+	return disabled
+}
+
+// isControlPlaneMigrating checks if the control plane of the shoot is migrated, i.e. if its last operation is Migrate or a Restore which has not
+// succeeded yet.
+func isControlPlaneMigrating(shoot *v1beta1.Shoot) bool {
+	lastOperation := shoot.Status.LastOperation
+	return lastOperation != nil && (lastOperation.Type == v1beta1.LastOperationTypeMigrate ||
+		(lastOperation.Type == v1beta1.LastOperationTypeRestore && lastOperation.State != v1beta1.LastOperationStateSucceeded))
 }
 
 // canStartProber checks if a probe can be registered and started.
@@ -23,20 +23,25 @@ import (
 
 	"k8s.io/utils/pointer"
 
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 	proberpackage "github.com/gardener/dependency-watchdog/internal/prober"
 	testutil "github.com/gardener/dependency-watchdog/internal/test"
 	"github.com/gardener/dependency-watchdog/internal/util"
 	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	gardenerv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakescale "k8s.io/client-go/scale/fake"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -84,11 +89,12 @@ func setupProberEnv(ctx context.Context, g *WithT) (client.Client, *envtest.Envi
 
 	probeConfigPath := filepath.Join(testdataPath, "prober-config.yaml")
 	validateIfFileExists(probeConfigPath, g)
-	proberConfig, err := proberpackage.LoadConfig(probeConfigPath, scheme)
+	proberConfig, err := proberpackage.LoadConfig(probeConfigPath, scheme, true)
 	g.Expect(err).ToNot(HaveOccurred())
 
 	clusterReconciler := &Reconciler{
 		Client:                  mgr.GetClient(),
+		APIReader:               mgr.GetAPIReader(),
 		Scheme:                  mgr.GetScheme(),
 		ScaleGetter:             scalesGetter,
 		ProberMgr:               proberpackage.NewManager(),
@@ -106,6 +112,74 @@ func setupProberEnv(ctx context.Context, g *WithT) (client.Client, *envtest.Envi
 	return crClient, testEnv, clusterReconciler, mgr
 }
 
+func TestGetShootClientOptionsShouldPreferProbeConfig(t *testing.T) {
+	g := NewWithT(t)
+	r := &Reconciler{ShootClientRateLimits: util.RateLimits{QPS: 10, Burst: 20}, ShootClientUserAgent: "dependency-watchdog-prober/v1.4.0"}
+
+	g.Expect(r.getShootClientOptions(&papi.Config{})).To(Equal(util.ClientOptions{RateLimits: util.RateLimits{QPS: 10, Burst: 20}, UserAgent: "dependency-watchdog-prober/v1.4.0"}))
+	g.Expect(r.getShootClientOptions(&papi.Config{
+		ShootClientQPS:                 pointer.Float64(50),
+		ShootClientBurst:               pointer.Int(100),
+		ShootClientDialTimeout:         &metav1.Duration{Duration: 5 * time.Second},
+		ShootClientTLSHandshakeTimeout: &metav1.Duration{Duration: 3 * time.Second},
+	})).To(Equal(util.ClientOptions{RateLimits: util.RateLimits{QPS: 50, Burst: 100}, DialTimeout: 5 * time.Second, TLSHandshakeTimeout: 3 * time.Second, UserAgent: "dependency-watchdog-prober/v1.4.0"}))
+}
+
+func TestGetEffectiveProbeConfigShouldPreferExcludedWorkerPoolsOfShoot(t *testing.T) {
+	g := NewWithT(t)
+	r := &Reconciler{DefaultProbeConfig: &papi.Config{ExcludedWorkerPools: []string{"gpu-batch"}}}
+	_, shoot, err := testutil.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(r.getEffectiveProbeConfig(shoot, logr.Discard()).ExcludedWorkerPools).To(Equal([]string{"gpu-batch"}))
+	metav1.SetMetaDataAnnotation(&shoot.ObjectMeta, excludedWorkerPoolsAnnotationKey, "spot, gpu-training,")
+	g.Expect(r.getEffectiveProbeConfig(shoot, logr.Discard()).ExcludedWorkerPools).To(Equal([]string{"spot", "gpu-training"}))
+	metav1.SetMetaDataAnnotation(&shoot.ObjectMeta, excludedWorkerPoolsAnnotationKey, "")
+	g.Expect(r.getEffectiveProbeConfig(shoot, logr.Discard()).ExcludedWorkerPools).To(BeEmpty(), "an empty annotation should exclude no worker pool")
+	g.Expect(r.DefaultProbeConfig.ExcludedWorkerPools).To(Equal([]string{"gpu-batch"}), "the default probe config should not be changed")
+}
+
+func TestReconcileShouldRunProberReadOnlyDuringMigrationIfEnabled(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scheme := buildScheme()
+	cluster, shoot, err := testutil.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	setShootLastOperationStatus(cluster, shoot, gardencorev1beta1.LastOperationTypeMigrate, gardencorev1beta1.LastOperationStateProcessing)
+	crClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	probeConfig, err := proberpackage.LoadConfig(filepath.Join(testdataPath, "prober-config.yaml"), scheme, true)
+	g.Expect(err).ToNot(HaveOccurred())
+	probeConfig.ProbeDuringMigration = pointer.Bool(true)
+	reconciler := &Reconciler{Client: crClient, ScaleGetter: &fakescale.FakeScaleClient{}, ProberMgr: proberpackage.NewManager(), DefaultProbeConfig: probeConfig, ReportProberStatus: true}
+	t.Cleanup(func() { reconciler.ProberMgr.Unregister(cluster.Name, metrics.ReasonClusterNotFound) })
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cluster.Name}}
+
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, ok := reconciler.ProberMgr.GetProber(cluster.Name)
+	g.Expect(ok).To(BeTrue(), "the prober should be started although the control plane is migrated")
+	g.Expect(reconciler.isReadOnly(cluster.Name)).To(BeTrue())
+	g.Expect(crClient.Get(ctx, req.NamespacedName, cluster)).To(Succeed())
+	g.Expect(cluster.Annotations).To(HaveKeyWithValue(proberStatusMessageAnnotationKey, readOnlyProberMessage))
+
+	setShootLastOperationStatus(cluster, shoot, gardencorev1beta1.LastOperationTypeRestore, gardencorev1beta1.LastOperationStateSucceeded)
+	g.Expect(crClient.Update(ctx, cluster)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, ok = reconciler.ProberMgr.GetProber(cluster.Name)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(reconciler.isReadOnly(cluster.Name)).To(BeFalse(), "the prober should leave the read-only mode once the restoration has succeeded")
+
+	probeConfig.ProbeDuringMigration = nil
+	g.Expect(crClient.Get(ctx, req.NamespacedName, cluster)).To(Succeed())
+	setShootLastOperationStatus(cluster, shoot, gardencorev1beta1.LastOperationTypeMigrate, gardencorev1beta1.LastOperationStateProcessing)
+	g.Expect(crClient.Update(ctx, cluster)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, ok = reconciler.ProberMgr.GetProber(cluster.Name)
+	g.Expect(ok).To(BeFalse(), "the prober should be removed during the migration if it is not enabled")
+}
+
 func TestClusterControllerSuite(t *testing.T) {
 	tests := []struct {
 		title string
@@ -121,6 +195,52 @@ func TestClusterControllerSuite(t *testing.T) {
 	}
 }
 
+func TestReconcileShouldReportProberStatusOnCluster(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	g.Expect(gardenerv1alpha1.AddToScheme(scheme)).To(Succeed())
+	cluster, shoot, err := testutil.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	shoot.Spec.Hibernation = &gardencorev1beta1.Hibernation{Enabled: pointer.Bool(true)}
+	crClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	reconciler := &Reconciler{Client: crClient, ProberMgr: proberpackage.NewManager(), ReportProberStatus: true}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: cluster.Name}}
+
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(crClient.Get(ctx, req.NamespacedName, cluster)).To(Succeed())
+	g.Expect(cluster.Annotations).To(HaveKeyWithValue(proberStatusAnnotationKey, proberStatusStopped))
+	g.Expect(cluster.Annotations).To(HaveKeyWithValue(proberStatusMessageAnnotationKey, metrics.ReasonHibernation))
+
+	shoot.Spec.Hibernation = nil
+	shoot.Status.LastOperation = nil
+	cluster.Spec.Shoot = runtime.RawExtension{Object: shoot}
+	g.Expect(crClient.Update(ctx, cluster)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(crClient.Get(ctx, req.NamespacedName, cluster)).To(Succeed())
+	g.Expect(cluster.Annotations).To(HaveKeyWithValue(proberStatusAnnotationKey, proberStatusPending))
+	g.Expect(cluster.Annotations).To(HaveKeyWithValue(proberStatusMessageAnnotationKey, pendingProberMessage))
+
+	cluster.Spec.Shoot = runtime.RawExtension{Raw: []byte(`{"apiVersion": 8}`)}
+	g.Expect(crClient.Update(ctx, cluster)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(crClient.Get(ctx, req.NamespacedName, cluster)).To(Succeed())
+	g.Expect(cluster.Annotations).To(HaveKeyWithValue(proberStatusAnnotationKey, proberStatusFailed))
+	g.Expect(cluster.Annotations).To(HaveKeyWithValue(proberStatusMessageAnnotationKey, err.Error()))
+
+	reconciler.ReportProberStatus = false
+	delete(cluster.Annotations, proberStatusAnnotationKey)
+	cluster.Spec.Shoot = runtime.RawExtension{Object: shoot}
+	g.Expect(crClient.Update(ctx, cluster)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(crClient.Get(ctx, req.NamespacedName, cluster)).To(Succeed())
+	g.Expect(cluster.Annotations).ToNot(HaveKey(proberStatusAnnotationKey), "the status should not be reported if it is not enabled")
+}
+
 // testProberDedicatedEnvTest creates a new envTest at the start of each subtest and destroys it at the end of each subtest.
 func testProberDedicatedEnvTest(t *testing.T) {
 	g := NewWithT(t)
@@ -176,6 +296,8 @@ func testProberSharedEnvTest(t *testing.T) {
 		{"start prober if last operation is reconciliation of shoot", testLastOperationIsShootReconciliation},
 		{"no prober if shoot has no workers", testShootHasNoWorkers},
 		{"prober should start with correct worker node conditions mapping", testShootWorkerNodeConditions},
+		{"prober should be restarted with the minimum node count for scaling set on the shoot", testShootMinNodeCountForScaling},
+		{"prober should be removed if it is disabled on the shoot", testShootProberDisabledByAnnotation},
 	}
 
 	for _, test := range tests {
@@ -205,6 +327,58 @@ func testShootWorkerNodeConditions(g *WithT, crClient client.Client, reconciler
 	deleteClusterAndCheckIfProberRemoved(g, crClient, reconciler, cluster)
 }
 
+func testShootMinNodeCountForScaling(g *WithT, crClient client.Client, reconciler *Reconciler) {
+	cluster, shoot, err := testutil.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	createCluster(g, crClient, cluster)
+	proberShouldHaveMinNodeCountForScaling(g, reconciler, cluster, proberpackage.DefaultMinNodeCountForScaling)
+	// set a valid annotation
+	metav1.SetMetaDataAnnotation(&shoot.ObjectMeta, minNodeCountForScalingAnnotationKey, "3")
+	cluster.Spec.Shoot = runtime.RawExtension{
+		Object: shoot,
+	}
+	updateCluster(g, crClient, cluster)
+	proberShouldHaveMinNodeCountForScaling(g, reconciler, cluster, 3)
+	// an invalid annotation should be ignored
+	metav1.SetMetaDataAnnotation(&shoot.ObjectMeta, minNodeCountForScalingAnnotationKey, "three")
+	cluster.Spec.Shoot = runtime.RawExtension{
+		Object: shoot,
+	}
+	updateCluster(g, crClient, cluster)
+	proberShouldHaveMinNodeCountForScaling(g, reconciler, cluster, proberpackage.DefaultMinNodeCountForScaling)
+	deleteClusterAndCheckIfProberRemoved(g, crClient, reconciler, cluster)
+}
+
+func testShootProberDisabledByAnnotation(g *WithT, crClient client.Client, reconciler *Reconciler) {
+	cluster, shoot, err := testutil.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	createCluster(g, crClient, cluster)
+	expectedWorkerNodeConditions := util.GetEffectiveNodeConditionsForWorkers(shoot)
+	proberShouldBePresent(g, reconciler, cluster, defaultKCMNodeMonitorGracePeriod, expectedWorkerNodeConditions)
+	// disabling the prober should remove it
+	metav1.SetMetaDataAnnotation(&shoot.ObjectMeta, disableProberAnnotationKey, "true")
+	cluster.Spec.Shoot = runtime.RawExtension{
+		Object: shoot,
+	}
+	updateCluster(g, crClient, cluster)
+	proberShouldNotBePresent(g, reconciler, cluster)
+	// an invalid annotation should be ignored
+	metav1.SetMetaDataAnnotation(&shoot.ObjectMeta, disableProberAnnotationKey, "yes please")
+	cluster.Spec.Shoot = runtime.RawExtension{
+		Object: shoot,
+	}
+	updateCluster(g, crClient, cluster)
+	proberShouldBePresent(g, reconciler, cluster, defaultKCMNodeMonitorGracePeriod, expectedWorkerNodeConditions)
+	deleteClusterAndCheckIfProberRemoved(g, crClient, reconciler, cluster)
+}
+
+func proberShouldHaveMinNodeCountForScaling(g *WithT, reconciler *Reconciler, cluster *gardenerv1alpha1.Cluster, expectedMinNodeCountForScaling int) {
+	prober, ok := reconciler.ProberMgr.GetProber(cluster.ObjectMeta.Name)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(prober.GetConfig().MinNodeCountForScaling).ToNot(BeNil())
+	g.Expect(*prober.GetConfig().MinNodeCountForScaling).To(Equal(expectedMinNodeCountForScaling))
+}
+
 func deleteAllClusters(g *WithT, crClient client.Client) {
 	err := crClient.DeleteAllOf(context.Background(), &gardenerv1alpha1.Cluster{})
 	g.Expect(err).ToNot(HaveOccurred())
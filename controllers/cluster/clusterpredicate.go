@@ -6,11 +6,14 @@ package cluster
 
 import (
 	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
 
 	"github.com/go-logr/logr"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -41,16 +44,51 @@ func workerLessShoot(logger logr.Logger) predicate.Predicate {
 	}
 }
 
-// shootHasWorkers extracts the shoot from the cluster and checks if shoot has workers.
+// shootControlNamespace creates a predicate which only allows events for the Cluster of the shoot with the given control namespace. Clusters are
+// named after the control namespace of their shoot. If the namespace is empty then events for all Clusters are allowed.
+func shootControlNamespace(namespace string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return namespace == "" || obj.GetName() == namespace
+	})
+}
+
+// shootHasWorkers checks if the shoot has workers. The shoot is either given directly or extracted from the given cluster.
 func shootHasWorkers(obj runtime.Object, logger logr.Logger) bool {
-	cluster, ok := obj.(*extensionsv1alpha1.Cluster)
-	if !ok {
+	switch o := obj.(type) {
+	case *v1beta1.Shoot:
+		return len(o.Spec.Provider.Workers) > 0
+	case *extensionsv1alpha1.Cluster:
+		shoot, err := extensionscontroller.ShootFromCluster(o)
+		if err != nil {
+			logger.Error(err, "Failed to extract shoot from cluster event", "cluster", o.Name)
+			return false
+		}
+		return len(shoot.Spec.Provider.Workers) > 0
+	default:
 		return false
 	}
-	shoot, err := extensionscontroller.ShootFromCluster(cluster)
-	if err != nil {
-		logger.Error(err, "Failed to extract shoot from cluster event", "cluster", cluster.Name)
-		return false
+}
+
+// shootOnSeed creates a predicate which only allows events for Shoots which are scheduled to the seed with the given name. Update events are
+// allowed if either the old or the new Shoot is scheduled to the seed, so that the prober of a shoot which is moved to another seed is removed.
+// If the seed name is empty then events for all Shoots are allowed.
+func shootOnSeed(seedName string) predicate.Predicate {
+	isOnSeed := func(obj client.Object) bool {
+		shoot, ok := obj.(*v1beta1.Shoot)
+		return ok && (seedName == "" || pointer.StringDeref(shoot.Spec.SeedName, "") == seedName)
+	}
+	return predicate.Funcs{
+		CreateFunc: func(event event.CreateEvent) bool {
+			return isOnSeed(event.Object)
+		},
+		DeleteFunc: func(event event.DeleteEvent) bool {
+			return isOnSeed(event.Object)
+		},
+		UpdateFunc: func(updateEvent event.UpdateEvent) bool {
+			return isOnSeed(updateEvent.ObjectOld) || isOnSeed(updateEvent.ObjectNew)
+		},
+		GenericFunc: func(event event.GenericEvent) bool {
+			return isOnSeed(event.Object)
+		},
 	}
-	return len(shoot.Spec.Provider.Workers) > 0
 }
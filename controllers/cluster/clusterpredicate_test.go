@@ -15,6 +15,7 @@ import (
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	"sigs.k8s.io/controller-runtime/pkg/event"
 )
@@ -126,3 +127,34 @@ func TestShootHasWorkersForInvalidShootResource(t *testing.T) {
 	result := shootHasWorkers(cluster, logr.Discard())
 	g.Expect(result).To(BeFalse())
 }
+
+func TestShootControlNamespacePredicate(t *testing.T) {
+	g := NewWithT(t)
+	cluster, _, err := test.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(shootControlNamespace("").Create(event.CreateEvent{Object: cluster})).To(BeTrue(), "all clusters should be allowed if no namespace is given")
+	g.Expect(shootControlNamespace(cluster.Name).Create(event.CreateEvent{Object: cluster})).To(BeTrue())
+	g.Expect(shootControlNamespace(cluster.Name + "-other").Create(event.CreateEvent{Object: cluster})).To(BeFalse())
+}
+
+func TestShootOnSeedPredicate(t *testing.T) {
+	g := NewWithT(t)
+	shoot := &gardencorev1beta1.Shoot{Spec: gardencorev1beta1.ShootSpec{SeedName: pointer.String("aws-eu1")}}
+	movedShoot := shoot.DeepCopy()
+	movedShoot.Spec.SeedName = pointer.String("aws-eu2")
+
+	g.Expect(shootOnSeed("").Create(event.CreateEvent{Object: movedShoot})).To(BeTrue(), "all shoots should be allowed if no seed name is given")
+	g.Expect(shootOnSeed("aws-eu1").Create(event.CreateEvent{Object: shoot})).To(BeTrue())
+	g.Expect(shootOnSeed("aws-eu1").Create(event.CreateEvent{Object: movedShoot})).To(BeFalse())
+	g.Expect(shootOnSeed("aws-eu1").Update(event.UpdateEvent{ObjectOld: shoot, ObjectNew: movedShoot})).To(BeTrue(), "a shoot which is moved away from the seed should be allowed")
+}
+
+func TestShootHasWorkersForShoot(t *testing.T) {
+	g := NewWithT(t)
+	_, shoot, err := test.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(shootHasWorkers(shoot, logr.Discard())).To(BeTrue())
+	shoot.Spec.Provider.Workers = nil
+	g.Expect(shootHasWorkers(shoot, logr.Discard())).To(BeFalse())
+}
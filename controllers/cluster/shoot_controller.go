@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcluster "sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const shootControllerName = "shoot"
+
+// ShootNamespaceResolver resolves the control namespace of a shoot in the seed. It returns false if the namespace cannot be resolved (yet), e.g.
+// because the shoot has not been scheduled to a seed.
+type ShootNamespaceResolver func(shoot *v1beta1.Shoot) (string, bool)
+
+// TechnicalIDNamespaceResolver resolves the control namespace of a shoot via its technical ID, which Gardener uses as the name of the control
+// namespace.
+func TechnicalIDNamespaceResolver(shoot *v1beta1.Shoot) (string, bool) {
+	return shoot.Status.TechnicalID, shoot.Status.TechnicalID != ""
+}
+
+// NewTemplateNamespaceResolver creates a ShootNamespaceResolver which resolves the control namespace of a shoot by executing the given Go template
+// with the Shoot, e.g. `shoot--{{ trimPrefix "garden-" .Namespace }}--{{ .Name }}`. The functions trimPrefix and trimSuffix are available.
+func NewTemplateNamespaceResolver(text string) (ShootNamespaceResolver, error) {
+	tmpl, err := template.New("shoot-namespace").Option("missingkey=error").Funcs(template.FuncMap{
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shoot namespace template: %w", err)
+	}
+	return func(shoot *v1beta1.Shoot) (string, bool) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, shoot); err != nil {
+			return "", false
+		}
+		namespace := strings.TrimSpace(buf.String())
+		return namespace, namespace != ""
+	}, nil
+}
+
+// ShootReconciler reconciles the Shoot objects in a garden cluster instead of the Cluster objects in the seed, e.g. for a central deployment of
+// dependency-watchdog. The probers are managed by the embedded Reconciler, whose Client is the client of the seed. The status of the probers is not
+// reported as the Shoots are not modified.
+type ShootReconciler struct {
+	*Reconciler
+	// GardenCluster is the garden cluster whose Shoots are watched.
+	GardenCluster ctrlcluster.Cluster
+	// SeedName restricts the reconciler to the Shoots which are scheduled to the seed with the given name. If it is empty then all Shoots are
+	// reconciled.
+	SeedName string
+	// NamespaceResolver resolves the control namespaces of the shoots in the seed.
+	NamespaceResolver ShootNamespaceResolver
+	// controlNamespaces maps the Shoots which have been reconciled to their control namespaces, so that their probers can be removed once the
+	// Shoots have been deleted and the namespaces cannot be resolved anymore.
+	controlNamespaces sync.Map
+}
+
+//+kubebuilder:rbac:groups=core.gardener.cloud,resources=shoots,verbs=get;list;watch
+
+// Reconcile listens to create/update/delete events for Shoot resources in the garden cluster and manages the probers for the control namespaces of
+// the shoots.
+func (r *ShootReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	shoot := &v1beta1.Shoot{}
+	if err := r.GardenCluster.GetClient().Get(ctx, req.NamespacedName, shoot); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("unable to get shoot resource: %w", err)
+		}
+		r.unregister(req.NamespacedName, metrics.ReasonShootNotFound, log)
+		return ctrl.Result{}, nil
+	}
+	if r.SeedName != "" && pointer.StringDeref(shoot.Spec.SeedName, "") != r.SeedName {
+		r.unregister(req.NamespacedName, metrics.ReasonSeedChange, log)
+		return ctrl.Result{}, nil
+	}
+	shootControlNamespace, ok := r.NamespaceResolver(shoot)
+	if !ok {
+		log.Info("Cannot resolve the control namespace of the shoot yet")
+		return ctrl.Result{}, nil
+	}
+	if r.Namespace != "" && shootControlNamespace != r.Namespace {
+		return ctrl.Result{}, nil
+	}
+	r.controlNamespaces.Store(req.NamespacedName, shootControlNamespace)
+	r.reconcileShoot(ctx, shootControlNamespace, shoot, func(string, string) {}, log.WithValues("shootNamespace", shootControlNamespace))
+	return ctrl.Result{}, nil
+}
+
+// unregister removes the prober of the given Shoot, if any.
+func (r *ShootReconciler) unregister(shootKey types.NamespacedName, reason string, log logr.Logger) {
+	shootControlNamespace, ok := r.controlNamespaces.LoadAndDelete(shootKey)
+	if ok {
+		r.readOnlyNamespaces.Delete(shootControlNamespace)
+	}
+	if ok && r.ProberMgr.Unregister(shootControlNamespace.(string), reason) {
+		log.Info("Existing prober has been removed", "shootNamespace", shootControlNamespace, "reason", reason)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager. The GardenCluster is added to the Manager, so that its cache is started along with
+// the Manager.
+func (r *ShootReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.Add(r.GardenCluster); err != nil {
+		return err
+	}
+	c, err := controller.New(
+		shootControllerName,
+		mgr,
+		controller.Options{
+			Reconciler:              r,
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles},
+	)
+	if err != nil {
+		return err
+	}
+	return c.Watch(source.Kind[client.Object](r.GardenCluster.GetCache(), &v1beta1.Shoot{}, &handler.EnqueueRequestForObject{},
+		predicate.And[client.Object](shootOnSeed(r.SeedName), workerLessShoot(c.GetLogger()))))
+}
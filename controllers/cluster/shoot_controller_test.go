@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	proberpackage "github.com/gardener/dependency-watchdog/internal/prober"
+	testutil "github.com/gardener/dependency-watchdog/internal/test"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlcluster "sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeGardenCluster is a garden cluster which only provides a client.
+type fakeGardenCluster struct {
+	ctrlcluster.Cluster
+	client client.Client
+}
+
+func (c *fakeGardenCluster) GetClient() client.Client {
+	return c.client
+}
+
+func TestTechnicalIDNamespaceResolver(t *testing.T) {
+	g := NewWithT(t)
+	shoot := &gardencorev1beta1.Shoot{}
+	_, ok := TechnicalIDNamespaceResolver(shoot)
+	g.Expect(ok).To(BeFalse(), "the namespace of a shoot without technical ID should not be resolved")
+
+	shoot.Status.TechnicalID = "shoot--foo--bar"
+	namespace, ok := TechnicalIDNamespaceResolver(shoot)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(namespace).To(Equal("shoot--foo--bar"))
+}
+
+func TestTemplateNamespaceResolver(t *testing.T) {
+	g := NewWithT(t)
+	resolver, err := NewTemplateNamespaceResolver(`shoot--{{ trimPrefix "garden-" .Namespace }}--{{ .Name }}`)
+	g.Expect(err).ToNot(HaveOccurred())
+	shoot := &gardencorev1beta1.Shoot{ObjectMeta: metav1.ObjectMeta{Namespace: "garden-foo", Name: "bar"}}
+	namespace, ok := resolver(shoot)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(namespace).To(Equal("shoot--foo--bar"))
+
+	resolver, err = NewTemplateNamespaceResolver(`{{ .Status.TechnicalID }}`)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, ok = resolver(shoot)
+	g.Expect(ok).To(BeFalse(), "an empty namespace should not be resolved")
+
+	_, err = NewTemplateNamespaceResolver(`{{ .Name `)
+	g.Expect(err).To(MatchError(ContainSubstring("invalid shoot namespace template")))
+}
+
+func TestShootReconcilerShouldForgetShootsWhichAreDeletedOrMovedToAnotherSeed(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	g.Expect(gardencorev1beta1.AddToScheme(scheme)).To(Succeed())
+	_, shoot, err := testutil.NewClusterBuilder().WithWorkerCount(1).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	shoot.ResourceVersion = ""
+	shoot.Namespace = "garden-foo"
+	shoot.Spec.SeedName = pointer.String("aws-eu1")
+	shoot.Spec.Hibernation = &gardencorev1beta1.Hibernation{Enabled: pointer.Bool(true)}
+	shoot.Status.TechnicalID = "shoot--foo--bar"
+	gardenClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(shoot).Build()
+	reconciler := &ShootReconciler{
+		Reconciler:        &Reconciler{ProberMgr: proberpackage.NewManager()},
+		GardenCluster:     &fakeGardenCluster{client: gardenClient},
+		SeedName:          "aws-eu1",
+		NamespaceResolver: TechnicalIDNamespaceResolver,
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: shoot.Namespace, Name: shoot.Name}}
+
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	shootControlNamespace, ok := reconciler.controlNamespaces.Load(req.NamespacedName)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(shootControlNamespace).To(Equal("shoot--foo--bar"))
+	_, ok = reconciler.ProberMgr.GetProber("shoot--foo--bar")
+	g.Expect(ok).To(BeFalse(), "no prober should be started for a hibernated shoot")
+
+	shoot.Spec.SeedName = pointer.String("aws-eu2")
+	g.Expect(gardenClient.Update(ctx, shoot)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, ok = reconciler.controlNamespaces.Load(req.NamespacedName)
+	g.Expect(ok).To(BeFalse(), "a shoot which is moved to another seed should be forgotten")
+
+	shoot.Spec.SeedName = pointer.String("aws-eu1")
+	g.Expect(gardenClient.Update(ctx, shoot)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(gardenClient.Delete(ctx, shoot)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, ok = reconciler.controlNamespaces.Load(req.NamespacedName)
+	g.Expect(ok).To(BeFalse(), "a deleted shoot should be forgotten")
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+)
+
+// decodedShoot is the outcome of decoding the Shoot embedded in a Cluster with a specific resource version.
+type decodedShoot struct {
+	resourceVersion string
+	shoot           *v1beta1.Shoot
+	err             error
+}
+
+// shootCache caches the Shoots decoded from the Clusters per resource version of a Cluster, so that the Shoot of a Cluster which has not changed
+// is not decoded again by every reconcile, e.g. during event storms. The zero value is ready to use.
+type shootCache struct {
+	mu     sync.Mutex
+	shoots map[string]decodedShoot
+}
+
+// get returns the Shoot embedded in the given Cluster. It is only decoded if the resource version of the Cluster differs from the one of the
+// cached Shoot. A decode failure is cached as well and counted once per resource version of the Cluster. The returned Shoot is a copy which can
+// be modified by the caller.
+func (c *shootCache) get(cluster *extensionsv1alpha1.Cluster) (*v1beta1.Shoot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.shoots[cluster.Name]
+	if !ok || cached.resourceVersion != cluster.ResourceVersion || cluster.ResourceVersion == "" {
+		shoot, err := extensionscontroller.ShootFromCluster(cluster)
+		if err != nil {
+			metrics.ShootDecodeFailuresTotal.Inc()
+			err = fmt.Errorf("error extracting shoot from cluster: %w", err)
+		}
+		cached = decodedShoot{resourceVersion: cluster.ResourceVersion, shoot: shoot, err: err}
+		if c.shoots == nil {
+			c.shoots = make(map[string]decodedShoot)
+		}
+		c.shoots[cluster.Name] = cached
+	}
+	if cached.err != nil {
+		return nil, cached.err
+	}
+	return cached.shoot.DeepCopy(), nil
+}
+
+// delete removes the cached Shoot of the Cluster with the given name.
+func (c *shootCache) delete(clusterName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.shoots, clusterName)
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	testutil "github.com/gardener/dependency-watchdog/internal/test"
+	. "github.com/onsi/gomega"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestShootCacheShouldDecodeShootOncePerResourceVersion(t *testing.T) {
+	g := NewWithT(t)
+	cluster, _, err := testutil.NewClusterBuilder().WithWorkerCount(1).WithRawShoot(true).Build()
+	g.Expect(err).ToNot(HaveOccurred())
+	cluster.ResourceVersion = "1"
+	cache := &shootCache{}
+
+	shoot, err := cache.get(cluster)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(shoot.Spec.Provider.Workers).To(HaveLen(1))
+	shoot.Spec.Provider.Workers = nil
+	validShoot := cluster.Spec.Shoot
+	cluster.Spec.Shoot = runtime.RawExtension{Raw: []byte(`{"apiVersion": 8}`)}
+	shoot, err = cache.get(cluster)
+	g.Expect(err).ToNot(HaveOccurred(), "the shoot of an unchanged resource version should not be decoded again")
+	g.Expect(shoot.Spec.Provider.Workers).To(HaveLen(1), "the cached shoot should not be changed via a returned shoot")
+
+	failuresBefore := promtestutil.ToFloat64(metrics.ShootDecodeFailuresTotal)
+	cluster.ResourceVersion = "2"
+	for range 2 {
+		_, err = cache.get(cluster)
+		g.Expect(err).To(MatchError(ContainSubstring("error extracting shoot from cluster")))
+	}
+	g.Expect(promtestutil.ToFloat64(metrics.ShootDecodeFailuresTotal)).To(Equal(failuresBefore+1), "a decode failure should be counted once per resource version")
+
+	cluster.ResourceVersion = "3"
+	cluster.Spec.Shoot = validShoot
+	_, err = cache.get(cluster)
+	g.Expect(err).ToNot(HaveOccurred())
+	cache.delete(cluster.Name)
+	g.Expect(cache.shoots).To(BeEmpty())
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// proberStatusAnnotationKey is the key of the annotation on a Cluster which reflects the status of the prober of the shoot.
+	proberStatusAnnotationKey = "dependency-watchdog.gardener.cloud/prober-status"
+	// proberStatusMessageAnnotationKey is the key of the annotation on a Cluster which explains the status of the prober of the shoot, e.g. the
+	// reason why it has been stopped or the last reconciliation error. It is removed if there is nothing to explain.
+	proberStatusMessageAnnotationKey = "dependency-watchdog.gardener.cloud/prober-status-message"
+
+	// proberStatusRunning indicates that a prober has been set up for the shoot.
+	proberStatusRunning = "Running"
+	// proberStatusPending indicates that no prober has been set up for the shoot yet, e.g. because the shoot is still being created.
+	proberStatusPending = "Pending"
+	// proberStatusStopped indicates that no prober is run for the shoot on purpose, e.g. because the shoot is hibernated.
+	proberStatusStopped = "Stopped"
+	// proberStatusFailed indicates that the prober of the shoot could not be set up due to an error.
+	proberStatusFailed = "Failed"
+
+	// pendingProberMessage explains why the prober of a shoot is pending.
+	pendingProberMessage = "waiting for the creation, restoration or wake-up of the shoot to complete"
+	// readOnlyProberMessage explains that the prober of a shoot does not scale any dependent resources as its control plane is migrated.
+	readOnlyProberMessage = "read-only as the control plane of the shoot is migrated, no dependent resources are scaled"
+)
+
+// reportProberStatus reflects the status of the prober of the shoot via annotations on its Cluster, if ReportProberStatus is enabled. The Cluster
+// is only patched if the status has changed. Reporting the status is best-effort, a failure is only logged and does not affect the prober.
+func (r *Reconciler) reportProberStatus(ctx context.Context, cluster *extensionsv1alpha1.Cluster, status, message string, logger logr.Logger) {
+	if !r.ReportProberStatus {
+		return
+	}
+	annotations := cluster.GetAnnotations()
+	if annotations[proberStatusAnnotationKey] == status && annotations[proberStatusMessageAnnotationKey] == message {
+		return
+	}
+	patch := client.MergeFrom(cluster.DeepCopy())
+	if annotations == nil {
+		annotations = make(map[string]string, 2)
+	}
+	annotations[proberStatusAnnotationKey] = status
+	if message != "" {
+		annotations[proberStatusMessageAnnotationKey] = message
+	} else {
+		delete(annotations, proberStatusMessageAnnotationKey)
+	}
+	cluster.SetAnnotations(annotations)
+	if err := r.Client.Patch(ctx, cluster, patch); err != nil {
+		logger.Error(err, "Failed to report the prober status on the cluster", "status", status)
+	}
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dependent
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/prober"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const controllerName = "dependent"
+
+// Reconciler watches the dependent resources of the probe config and re-asserts the scale-down of the dependent resources of a shoot while they
+// are scaled down. This scales down optional dependent resources which are created after the scale-down, see
+// papi.Config.ScaleDownLateOptionalResources, as well as dependent resources which are scaled up by someone else, see
+// papi.Config.ReassertScaleDownMinInterval.
+type Reconciler struct {
+	// ProberMgr gives access to the probers of the shoots whose dependent resources are reconciled.
+	ProberMgr prober.Manager
+	// DefaultProbeConfig is the seed level probe config. Its dependent resources are watched.
+	DefaultProbeConfig *papi.Config
+	// Namespace restricts the reconciler to the dependent resources in the given shoot control namespace. If it is empty then dependent
+	// resources in all namespaces are reconciled.
+	Namespace string
+	// lastReassertions are the times at which the scale-down has last been re-asserted per shoot control namespace. It is only accessed by the
+	// single worker of the controller.
+	lastReassertions map[string]time.Time
+}
+
+// Reconcile re-asserts the scale-down of the dependent resources of the shoot by running the scale-down flow of its prober again, if they are
+// scaled down. The scale-down is re-asserted at most once per ReassertScaleDownMinInterval. If a scale flow is in flight, then the reconciliation
+// is retried once the next probe has been run. The scale-down is only re-asserted if the prober would scale down the dependent resources as well,
+// see prober.Prober.ReassertScaleDown. Otherwise the reconciliation is retried once the next probe has been run, so that an optional dependent
+// resource which has been created in the meantime is scaled down once the scale-down is allowed again.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	p, ok := r.ProberMgr.GetProber(req.Namespace)
+	if !ok || !p.AreDependentsScaledDown() {
+		delete(r.lastReassertions, req.Namespace)
+		return ctrl.Result{}, nil
+	}
+	config := p.GetConfig()
+	minInterval := util.GetValOrDefault(config.ReassertScaleDownMinInterval, metav1.Duration{}).Duration
+	if lastReassertion, ok := r.lastReassertions[req.Namespace]; ok {
+		if remaining := minInterval - time.Since(lastReassertion); remaining > 0 {
+			log.V(4).Info("Scale-down has been re-asserted recently, delaying it", "remaining", remaining)
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+	if p.ReassertScaleDown(ctx) {
+		if r.lastReassertions == nil {
+			r.lastReassertions = make(map[string]time.Time)
+		}
+		r.lastReassertions[req.Namespace] = time.Now()
+		metrics.ScaleDownReassertionsTotal.Inc()
+		log.Info("Dependent resource has changed while the dependent resources are scaled down, re-asserting the scale-down")
+		return ctrl.Result{}, nil
+	}
+	if inFlightOperation := p.ScaleOperationInFlight(); inFlightOperation != "" {
+		log.V(4).Info("Scale flow is in flight, retrying to re-assert the scale-down later", "inFlightOperation", inFlightOperation)
+		return ctrl.Result{RequeueAfter: config.ProbeInterval.Duration}, nil
+	}
+	if p.AreDependentsScaledDown() {
+		log.V(4).Info("Scale-down is not allowed, retrying to re-assert the scale-down later")
+		return ctrl.Result{RequeueAfter: config.ProbeInterval.Duration}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// IsEnabled checks if any of the dependent resources of the given config have to be watched.
+func IsEnabled(config *papi.Config) bool {
+	return pointer.BoolDeref(config.ScaleDownLateOptionalResources, false) || config.ReassertScaleDownMinInterval != nil
+}
+
+// SetupWithManager sets up the controller with the Manager. The metadata of every kind of the dependent resources of the default probe config
+// which is known to the API server is watched, dependent resources of unknown kinds are skipped.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, logger logr.Logger) error {
+	c, err := controller.New(
+		controllerName,
+		mgr,
+		controller.Options{
+			MaxConcurrentReconciles: 1,
+			Reconciler:              r},
+	)
+	if err != nil {
+		return err
+	}
+	resourcesByGVK, err := getDependentResourcesByGVK(r.DefaultProbeConfig)
+	if err != nil {
+		return err
+	}
+	for gvk, resources := range resourcesByGVK {
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				logger.Info("Kind of dependent resources is not known, not watching them", "gvk", gvk.String())
+				continue
+			}
+			return err
+		}
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), obj, &handler.EnqueueRequestForObject{}, r.getPredicate(resources))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getPredicate returns the predicate for the events of the given dependent resources of a kind.
+func (r *Reconciler) getPredicate(resources dependentResources) predicate.Predicate {
+	var predicates []predicate.Predicate
+	if pointer.BoolDeref(r.DefaultProbeConfig.ScaleDownLateOptionalResources, false) {
+		predicates = append(predicates, createdOptionalResource(r.Namespace, resources.optionalNames))
+	}
+	if r.DefaultProbeConfig.ReassertScaleDownMinInterval != nil {
+		predicates = append(predicates, changedSpecOfResource(r.Namespace, resources.names))
+	}
+	return predicate.Or(predicates...)
+}
+
+// dependentResources are the names of the dependent resources of a kind.
+type dependentResources struct {
+	names         []string
+	optionalNames []string
+}
+
+// getDependentResourcesByGVK returns the names of the dependent resources of the config per GroupVersionKind.
+func getDependentResourcesByGVK(config *papi.Config) (map[schema.GroupVersionKind]dependentResources, error) {
+	resourcesByGVK := make(map[schema.GroupVersionKind]dependentResources)
+	for _, resInfo := range config.DependentResourceInfos {
+		gv, err := schema.ParseGroupVersion(resInfo.Ref.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		gvk := gv.WithKind(resInfo.Ref.Kind)
+		resources := resourcesByGVK[gvk]
+		if !slices.Contains(resources.names, resInfo.Ref.Name) {
+			resources.names = append(resources.names, resInfo.Ref.Name)
+		}
+		if resInfo.Optional && !slices.Contains(resources.optionalNames, resInfo.Ref.Name) {
+			resources.optionalNames = append(resources.optionalNames, resInfo.Ref.Name)
+		}
+		resourcesByGVK[gvk] = resources
+	}
+	return resourcesByGVK, nil
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package dependent
+
+import (
+	"context"
+	"testing"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/prober"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestGetDependentResourcesByGVK(t *testing.T) {
+	g := NewWithT(t)
+	config := &papi.Config{DependentResourceInfos: []papi.DependentResourceInfo{
+		{Ref: &autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: "kube-controller-manager", APIVersion: "apps/v1"}},
+		{Ref: &autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: "cluster-autoscaler", APIVersion: "apps/v1"}, Optional: true},
+		{Ref: &autoscalingv1.CrossVersionObjectReference{Kind: "Etcd", Name: "etcd-main", APIVersion: "druid.gardener.cloud/v1alpha1"}, Optional: true},
+	}}
+
+	resourcesByGVK, err := getDependentResourcesByGVK(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(resourcesByGVK).To(Equal(map[schema.GroupVersionKind]dependentResources{
+		appsv1.SchemeGroupVersion.WithKind("Deployment"):                   {names: []string{"kube-controller-manager", "cluster-autoscaler"}, optionalNames: []string{"cluster-autoscaler"}},
+		{Group: "druid.gardener.cloud", Version: "v1alpha1", Kind: "Etcd"}: {names: []string{"etcd-main"}, optionalNames: []string{"etcd-main"}},
+	}))
+}
+
+func TestCreatedOptionalResourcePredicate(t *testing.T) {
+	g := NewWithT(t)
+	newDeployment := func(namespace, name string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	p := createdOptionalResource("shoot--test", []string{"cluster-autoscaler"})
+
+	g.Expect(p.Create(event.CreateEvent{Object: newDeployment("shoot--test", "cluster-autoscaler")})).To(BeTrue())
+	g.Expect(p.Create(event.CreateEvent{Object: newDeployment("shoot--other", "cluster-autoscaler")})).To(BeFalse(), "resources in other namespaces should be ignored")
+	g.Expect(p.Create(event.CreateEvent{Object: newDeployment("shoot--test", "kube-controller-manager")})).To(BeFalse(), "resources which are not optional should be ignored")
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newDeployment("shoot--test", "cluster-autoscaler"), ObjectNew: newDeployment("shoot--test", "cluster-autoscaler")})).To(BeFalse())
+	g.Expect(p.Delete(event.DeleteEvent{Object: newDeployment("shoot--test", "cluster-autoscaler")})).To(BeFalse())
+}
+
+func TestChangedSpecOfResourcePredicate(t *testing.T) {
+	g := NewWithT(t)
+	newDeployment := func(name string, generation int64) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "shoot--test", Name: name, Generation: generation}}
+	}
+	p := changedSpecOfResource("", []string{"kube-controller-manager"})
+
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newDeployment("kube-controller-manager", 1), ObjectNew: newDeployment("kube-controller-manager", 2)})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newDeployment("kube-controller-manager", 1), ObjectNew: newDeployment("kube-controller-manager", 1)})).To(BeFalse(), "status updates should be ignored")
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newDeployment("grafana", 1), ObjectNew: newDeployment("grafana", 2)})).To(BeFalse(), "resources which are not dependent resources should be ignored")
+	g.Expect(p.Create(event.CreateEvent{Object: newDeployment("kube-controller-manager", 1)})).To(BeFalse())
+}
+
+func TestReconcileShouldIgnoreShootsWithoutProber(t *testing.T) {
+	g := NewWithT(t)
+	r := &Reconciler{ProberMgr: prober.NewManager(), DefaultProbeConfig: &papi.Config{}}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "shoot--test", Name: "cluster-autoscaler"}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+}
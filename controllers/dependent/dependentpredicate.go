@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dependent
+
+import (
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// createdOptionalResource creates a predicate which only allows create events for the optional dependent resources with the given names. If the
+// namespace is not empty then only create events for resources in this namespace are allowed.
+func createdOptionalResource(namespace string, names []string) predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(event event.CreateEvent) bool {
+			return isDependentResource(event.Object, namespace, names)
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		UpdateFunc: func(_ event.UpdateEvent) bool {
+			return false
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// changedSpecOfResource creates a predicate which only allows update events for the dependent resources with the given names whose spec has
+// changed, e.g. as they have been scaled, as indicated by a change of their generation. If the namespace is not empty then only update events for
+// resources in this namespace are allowed.
+func changedSpecOfResource(namespace string, names []string) predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(_ event.CreateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		UpdateFunc: func(event event.UpdateEvent) bool {
+			return isDependentResource(event.ObjectNew, namespace, names) && event.ObjectNew.GetGeneration() != event.ObjectOld.GetGeneration()
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func isDependentResource(obj client.Object, namespace string, names []string) bool {
+	return (namespace == "" || obj.GetNamespace() == namespace) && slices.Contains(names, obj.GetName())
+}
@@ -8,7 +8,9 @@ import (
 	wapi "github.com/gardener/dependency-watchdog/api/weeder"
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -22,10 +24,8 @@ func ReadyEndpoints(logger logr.Logger) predicate.Predicate {
 		if !ok || ep == nil {
 			return false
 		}
-		for _, subset := range ep.Subsets {
-			if len(subset.Addresses) > 0 {
-				return true
-			}
+		if IsEndpointsReady(ep) {
+			return true
 		}
 		log.Info("Endpoint does not have any IP address. Skipping processing this endpoint", "namespace", ep.Namespace, "endpoint", ep.Name)
 		return false
@@ -47,8 +47,10 @@ func ReadyEndpoints(logger logr.Logger) predicate.Predicate {
 			return false
 		},
 
-		DeleteFunc: func(_ event.DeleteEvent) bool {
-			return false
+		// Delete events are always allowed, irrespective of the readiness of the endpoint, so that a weeder which is still running for the endpoint can be cancelled.
+		DeleteFunc: func(event event.DeleteEvent) bool {
+			ep, ok := event.Object.(*v1.Endpoints)
+			return ok && ep != nil
 		},
 
 		GenericFunc: func(event event.GenericEvent) bool {
@@ -57,6 +59,86 @@ func ReadyEndpoints(logger logr.Logger) predicate.Predicate {
 	}
 }
 
+// IsEndpointsReady checks if the endpoints resource has at least one endpoint subset that has at least one IP address assigned.
+func IsEndpointsReady(ep *v1.Endpoints) bool {
+	for _, subset := range ep.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadyEndpointSlices is the counterpart of ReadyEndpoints for EndpointSlices. An EndpointSlice is considered ready when it has at least one
+// ready endpoint.
+func ReadyEndpointSlices(logger logr.Logger) predicate.Predicate {
+	log := logger.WithValues("predicate", "ReadyEndpointSlicesPredicate")
+	isEndpointSliceReady := func(obj runtime.Object) bool {
+		slice, ok := obj.(*discoveryv1.EndpointSlice)
+		if !ok || slice == nil {
+			return false
+		}
+		if AreEndpointSlicesReady([]discoveryv1.EndpointSlice{*slice}) {
+			return true
+		}
+		log.Info("EndpointSlice does not have any ready endpoint. Skipping processing this EndpointSlice", "namespace", slice.Namespace, "endpointSlice", slice.Name)
+		return false
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(event event.CreateEvent) bool {
+			return isEndpointSliceReady(event.Object)
+		},
+
+		UpdateFunc: func(event event.UpdateEvent) bool {
+			return isEndpointSliceReady(event.ObjectNew) && !isEndpointSliceReady(event.ObjectOld)
+		},
+
+		// Delete events are always allowed so that a weeder which is still running for the service can be cancelled once its last EndpointSlice is gone.
+		DeleteFunc: func(event event.DeleteEvent) bool {
+			slice, ok := event.Object.(*discoveryv1.EndpointSlice)
+			return ok && slice != nil
+		},
+
+		GenericFunc: func(event event.GenericEvent) bool {
+			return isEndpointSliceReady(event.Object)
+		},
+	}
+}
+
+// AreEndpointSlicesReady checks if any of the EndpointSlices has at least one ready endpoint. An endpoint whose readiness is unknown is
+// considered ready, as mandated by the EndpointConditions API.
+func AreEndpointSlicesReady(slices []discoveryv1.EndpointSlice) bool {
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchingEndpointSlices is a predicate to allow events for only EndpointSlices which belong to the configured services.
+func MatchingEndpointSlices(epMap map[string]wapi.DependantSelectors) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		slice, ok := obj.(*discoveryv1.EndpointSlice)
+		if !ok || slice == nil {
+			return false
+		}
+		_, exists := epMap[slice.Labels[discoveryv1.LabelServiceName]]
+		return exists
+	})
+}
+
+// InNamespace is a predicate to allow events for only objects in the given namespace. If the namespace is empty then events for objects in all
+// namespaces are allowed.
+func InNamespace(namespace string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return namespace == "" || obj.GetNamespace() == namespace
+	})
+}
+
 // MatchingEndpoints is a predicate to allow events for only configured endpoints
 func MatchingEndpoints(epMap map[string]wapi.DependantSelectors) predicate.Predicate {
 	isMatchingEndpoints := func(obj runtime.Object, epMap map[string]wapi.DependantSelectors) bool {
@@ -77,8 +159,8 @@ func MatchingEndpoints(epMap map[string]wapi.DependantSelectors) predicate.Predi
 			return isMatchingEndpoints(event.ObjectNew, epMap)
 		},
 
-		DeleteFunc: func(_ event.DeleteEvent) bool {
-			return false
+		DeleteFunc: func(event event.DeleteEvent) bool {
+			return isMatchingEndpoints(event.Object, epMap)
 		},
 
 		GenericFunc: func(event event.GenericEvent) bool {
@@ -86,3 +168,38 @@ func MatchingEndpoints(epMap map[string]wapi.DependantSelectors) predicate.Predi
 		},
 	}
 }
+
+// MatchingServices is a predicate to allow events for only the services backing the configured endpoints. A service and the endpoints resource
+// backing it have the same name.
+func MatchingServices(epMap map[string]wapi.DependantSelectors) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		svc, ok := obj.(*v1.Service)
+		if !ok || svc == nil {
+			return false
+		}
+		_, exists := epMap[svc.Name]
+		return exists
+	})
+}
+
+// DeletedServices is a predicate to allow events for only services which have been deleted or whose deletion has been requested, so that weeders
+// are not started or kept running based on an endpoints resource which is awaiting garbage collection.
+func DeletedServices() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(_ event.CreateEvent) bool {
+			return false
+		},
+
+		UpdateFunc: func(event event.UpdateEvent) bool {
+			return event.ObjectOld.GetDeletionTimestamp() == nil && event.ObjectNew.GetDeletionTimestamp() != nil
+		},
+
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return true
+		},
+
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
@@ -8,11 +8,13 @@ package endpoint
 
 import (
 	"testing"
+	"time"
 
 	v12 "github.com/gardener/dependency-watchdog/api/weeder"
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -56,7 +58,7 @@ func TestReadyEndpoints(t *testing.T) {
 			ep:                               readyEp,
 			expectedCreateEventFilterOutput:  true,
 			expectedUpdateEventFilterOutput:  true,
-			expectedDeleteEventFilterOutput:  false,
+			expectedDeleteEventFilterOutput:  true,
 			expectedGenericEventFilterOutput: true,
 		},
 		{
@@ -64,7 +66,7 @@ func TestReadyEndpoints(t *testing.T) {
 			ep:                               notReadyEp,
 			expectedCreateEventFilterOutput:  false,
 			expectedUpdateEventFilterOutput:  false,
-			expectedDeleteEventFilterOutput:  false,
+			expectedDeleteEventFilterOutput:  true,
 			expectedGenericEventFilterOutput: false,
 		},
 		{
@@ -73,7 +75,7 @@ func TestReadyEndpoints(t *testing.T) {
 			oldEp:                            notReadyEp,
 			expectedCreateEventFilterOutput:  true,
 			expectedUpdateEventFilterOutput:  true,
-			expectedDeleteEventFilterOutput:  false,
+			expectedDeleteEventFilterOutput:  true,
 			expectedGenericEventFilterOutput: true,
 		},
 		{
@@ -82,7 +84,7 @@ func TestReadyEndpoints(t *testing.T) {
 			oldEp:                            readyEp,
 			expectedCreateEventFilterOutput:  true,
 			expectedUpdateEventFilterOutput:  false,
-			expectedDeleteEventFilterOutput:  false,
+			expectedDeleteEventFilterOutput:  true,
 			expectedGenericEventFilterOutput: true,
 		},
 		{
@@ -127,6 +129,25 @@ func TestReadyEndpoints(t *testing.T) {
 	}
 }
 
+func TestAreEndpointSlicesReady(t *testing.T) {
+	g := NewWithT(t)
+	newSlice := func(conditions ...discoveryv1.EndpointConditions) discoveryv1.EndpointSlice {
+		slice := discoveryv1.EndpointSlice{}
+		for _, c := range conditions {
+			slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{Addresses: []string{"10.1.0.52"}, Conditions: c})
+		}
+		return slice
+	}
+	ready := discoveryv1.EndpointConditions{Ready: pointer.Bool(true)}
+	notReady := discoveryv1.EndpointConditions{Ready: pointer.Bool(false)}
+
+	g.Expect(AreEndpointSlicesReady(nil)).To(BeFalse())
+	g.Expect(AreEndpointSlicesReady([]discoveryv1.EndpointSlice{newSlice()})).To(BeFalse())
+	g.Expect(AreEndpointSlicesReady([]discoveryv1.EndpointSlice{newSlice(notReady)})).To(BeFalse())
+	g.Expect(AreEndpointSlicesReady([]discoveryv1.EndpointSlice{newSlice(notReady), newSlice(notReady, ready)})).To(BeTrue())
+	g.Expect(AreEndpointSlicesReady([]discoveryv1.EndpointSlice{newSlice(discoveryv1.EndpointConditions{})})).To(BeTrue(), "an endpoint with unknown readiness should be considered ready")
+}
+
 func TestMatchingEndpointsPredicate(t *testing.T) {
 	g := NewWithT(t)
 
@@ -162,7 +183,7 @@ func TestMatchingEndpointsPredicate(t *testing.T) {
 			ep:                               epRelevant,
 			expectedCreateEventFilterOutput:  true,
 			expectedUpdateEventFilterOutput:  true,
-			expectedDeleteEventFilterOutput:  false,
+			expectedDeleteEventFilterOutput:  true,
 			expectedGenericEventFilterOutput: true,
 		},
 		{
@@ -179,7 +200,7 @@ func TestMatchingEndpointsPredicate(t *testing.T) {
 			oldEp:                            epRelevant,
 			expectedCreateEventFilterOutput:  true,
 			expectedUpdateEventFilterOutput:  true,
-			expectedDeleteEventFilterOutput:  false,
+			expectedDeleteEventFilterOutput:  true,
 			expectedGenericEventFilterOutput: true,
 		},
 		{
@@ -197,7 +218,7 @@ func TestMatchingEndpointsPredicate(t *testing.T) {
 			oldEp:                            epIrrelevant,
 			expectedCreateEventFilterOutput:  true,
 			expectedUpdateEventFilterOutput:  true,
-			expectedDeleteEventFilterOutput:  false,
+			expectedDeleteEventFilterOutput:  true,
 			expectedGenericEventFilterOutput: true,
 		},
 		{
@@ -250,3 +271,36 @@ func TestMatchingEndpointsPredicate(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchingServicesPredicate(t *testing.T) {
+	g := NewWithT(t)
+	predicate := MatchingServices(map[string]v12.DependantSelectors{"ep-relevant": {}})
+
+	g.Expect(predicate.Delete(event.DeleteEvent{Object: &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "ep-relevant"}}})).To(BeTrue())
+	g.Expect(predicate.Delete(event.DeleteEvent{Object: &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "ep-irrelevant"}}})).To(BeFalse())
+	g.Expect(predicate.Delete(event.DeleteEvent{Object: &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "ep-relevant"}}})).To(BeFalse(), "only services should be matched")
+}
+
+func TestDeletedServicesPredicate(t *testing.T) {
+	g := NewWithT(t)
+	predicate := DeletedServices()
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "ep-relevant"}}
+	terminatingSvc := svc.DeepCopy()
+	terminatingSvc.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	g.Expect(predicate.Create(event.CreateEvent{Object: svc})).To(BeFalse())
+	g.Expect(predicate.Update(event.UpdateEvent{ObjectOld: svc, ObjectNew: svc})).To(BeFalse())
+	g.Expect(predicate.Update(event.UpdateEvent{ObjectOld: svc, ObjectNew: terminatingSvc})).To(BeTrue(), "a service whose deletion has been requested should be allowed")
+	g.Expect(predicate.Update(event.UpdateEvent{ObjectOld: terminatingSvc, ObjectNew: terminatingSvc})).To(BeFalse())
+	g.Expect(predicate.Delete(event.DeleteEvent{Object: svc})).To(BeTrue())
+	g.Expect(predicate.Generic(event.GenericEvent{Object: svc})).To(BeFalse())
+}
+
+func TestInNamespacePredicate(t *testing.T) {
+	g := NewWithT(t)
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "ep-relevant", Namespace: "shoot--foo--bar"}}
+
+	g.Expect(InNamespace("").Create(event.CreateEvent{Object: svc})).To(BeTrue(), "all namespaces should be allowed if no namespace is given")
+	g.Expect(InNamespace("shoot--foo--bar").Create(event.CreateEvent{Object: svc})).To(BeTrue())
+	g.Expect(InNamespace("shoot--foo--baz").Create(event.CreateEvent{Object: svc})).To(BeFalse())
+}
@@ -9,20 +9,35 @@ import (
 	"time"
 
 	wapi "github.com/gardener/dependency-watchdog/api/weeder"
+	"github.com/gardener/dependency-watchdog/internal/claim"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
 	"github.com/gardener/dependency-watchdog/internal/weeder"
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
-const controllerName = "endpoint"
+const (
+	controllerName = "endpoint"
+	// weederRestartDeduplicationWindow is the duration after the creation of a weeder during which it is not replaced by a new one. When both
+	// Endpoints and EndpointSlices are watched, the recovery of a service is observed once per resource and must only start a single weeder.
+	weederRestartDeduplicationWindow = 10 * time.Second
+)
 
 // Reconciler EndpointReconciler reconciles an Endpoints object
 type Reconciler struct {
@@ -31,31 +46,158 @@ type Reconciler struct {
 	WeederConfig            *wapi.Config
 	WeederMgr               weeder.Manager
 	MaxConcurrentReconciles int
+	// EndpointsSource defines from which resources the readiness of the services is determined. Defaults to weeder.EndpointsSourceEndpoints.
+	EndpointsSource weeder.EndpointsSource
+	// Namespace restricts the reconciler to the services in the given namespace. If it is empty then services in all namespaces are reconciled.
+	Namespace string
+	// RuntimeOverrides are passed to the weeders. They are optional and can be nil.
+	RuntimeOverrides *overrides.Overrides
+	// EventRecorder is used by the weeders to record a summary event for each run. It is optional and can be nil.
+	EventRecorder record.EventRecorder
+	// NamespaceClaimer is used to claim the namespace of a service before a weeder is started for it. It is optional and can be nil, in which
+	// case the namespaces are not claimed.
+	NamespaceClaimer *claim.Claimer
+	// Clock is passed to the weeders and used to deduplicate their starts. It is optional and defaults to the real clock.
+	Clock clock.Clock
 }
 
 // +kubebuilder:rbac:resources=endpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:resources=services,verbs=get;list;watch
 // +kubebuilder:rbac:resources=pods,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=apps,resources=replicasets;deployments;statefulsets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=patch
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
+// +kubebuilder:rbac:resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 
-// Reconcile listens to create/update events for `Endpoints` resources and manages weeder which shoot the dependent pods of the configured services, if necessary
+// Reconcile listens to create/update/delete events for `Endpoints` resources and manages weeder which shoot the dependent pods of the configured services, if necessary.
+// If the endpoints resource or the service backing it has been deleted then any weeder which is still running for it is cancelled.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
-	//Get the endpoint object
-	var ep v1.Endpoints
-	err := r.Client.Get(ctx, req.NamespacedName, &ep)
+	ep, ready, err := r.getEndpoints(ctx, req.NamespacedName)
+	if err != nil {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
+	}
+	if ep == nil {
+		r.cancelWeeder(log, req.Namespace, req.Name, metrics.ReasonEndpointDeleted)
+		return ctrl.Result{}, nil
+	}
+	// An endpoints resource whose service has been deleted is awaiting garbage collection and must not be acted upon.
+	serviceDeleted, err := r.isServiceDeleted(ctx, req.NamespacedName)
 	if err != nil {
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
 	}
+	if serviceDeleted {
+		r.cancelWeeder(log, req.Namespace, req.Name, metrics.ReasonServiceDeleted)
+		return ctrl.Result{}, nil
+	}
+	if !ready {
+		r.cancelWeeder(log, req.Namespace, req.Name, metrics.ReasonEndpointNotReady)
+		log.Info("Endpoint does not have any ready address, not starting a weeder", "namespace", req.Namespace, "endpoint", ep.Name)
+		return ctrl.Result{}, nil
+	}
+	if r.hasRecentlyStartedWeeder(req.Namespace, req.Name) {
+		log.Info("Weeder for endpoint has been started recently, not replacing it", "namespace", req.Namespace, "endpoint", ep.Name)
+		return ctrl.Result{}, nil
+	}
+	claimed, holder, err := r.NamespaceClaimer.Claim(ctx, req.Namespace)
+	if err != nil {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
+	}
+	if !claimed {
+		log.Info("Namespace has been claimed by another instance of dependency-watchdog, not starting a weeder", "namespace", req.Namespace, "endpoint", ep.Name, "holder", holder)
+		return ctrl.Result{}, nil
+	}
 	log.Info("Starting a new weeder for endpoint, replacing old weeder, if any exists", "namespace", req.Namespace, "endpoint", ep.Name)
-	r.startWeeder(ctx, log, req.Namespace, &ep)
+	r.startWeeder(ctx, log, req.Namespace, ep)
 	return ctrl.Result{}, nil
 }
 
+// getEndpoints returns the endpoints of the service with the given key together with their readiness as determined from the EndpointsSource.
+// If EndpointSlices are watched and exist for the service they take precedence over its Endpoints, in which case the returned Endpoints only
+// identify the service. If neither exist then nil is returned.
+func (r *Reconciler) getEndpoints(ctx context.Context, key types.NamespacedName) (*v1.Endpoints, bool, error) {
+	if r.getEndpointsSource().WatchesEndpointSlices() {
+		var slices discoveryv1.EndpointSliceList
+		if err := r.Client.List(ctx, &slices, client.InNamespace(key.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: key.Name}); err != nil {
+			return nil, false, err
+		}
+		if len(slices.Items) > 0 {
+			ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+			return ep, AreEndpointSlicesReady(slices.Items), nil
+		}
+	}
+	if !r.getEndpointsSource().WatchesEndpoints() {
+		return nil, false, nil
+	}
+	var ep v1.Endpoints
+	if err := r.Client.Get(ctx, key, &ep); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return &ep, IsEndpointsReady(&ep), nil
+}
+
+// hasRecentlyStartedWeeder checks if a weeder which is still running has been started within the weederRestartDeduplicationWindow. This is only
+// checked if both Endpoints and EndpointSlices are watched, as only then a single recovery of a service results in multiple reconciliations.
+func (r *Reconciler) hasRecentlyStartedWeeder(namespace, name string) bool {
+	if r.getEndpointsSource() != weeder.EndpointsSourceBoth {
+		return false
+	}
+	wr, ok := r.WeederMgr.GetWeederRegistration(weeder.CreateKey(namespace, name))
+	return ok && !wr.IsClosed() && r.getClock().Since(wr.CreatedAt()) < weederRestartDeduplicationWindow
+}
+
+func (r *Reconciler) getEndpointsSource() weeder.EndpointsSource {
+	if r.EndpointsSource == "" {
+		return weeder.EndpointsSourceEndpoints
+	}
+	return r.EndpointsSource
+}
+
+func (r *Reconciler) getClock() clock.Clock {
+	if r.Clock == nil {
+		return clock.RealClock{}
+	}
+	return r.Clock
+}
+
 // startWeeder starts a new weeder for the endpoint
 func (r *Reconciler) startWeeder(ctx context.Context, logger logr.Logger, namespace string, ep *v1.Endpoints) {
-	w := weeder.NewWeeder(ctx, namespace, r.WeederConfig, r.Client, r.SeedClient, ep, logger)
+	w := weeder.NewWeeder(ctx, namespace, r.WeederConfig, r.Client, r.SeedClient, ep, r.RuntimeOverrides, r.EventRecorder, logger, weeder.WithClock(r.getClock()))
 	// Register the weeder
 	r.WeederMgr.Register(*w)
-	go w.Run()
+	w.Start()
+}
+
+// isServiceDeleted checks if the service backing the endpoints resource with the given name has been deleted or if its deletion has been requested.
+func (r *Reconciler) isServiceDeleted(ctx context.Context, key types.NamespacedName) (bool, error) {
+	var svc v1.Service
+	if err := r.Client.Get(ctx, key, &svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return svc.DeletionTimestamp != nil, nil
+}
+
+// cancelWeeder cancels the weeder, if any, for an endpoints resource which has been deleted, whose service has been deleted or which does not
+// have any ready address anymore, as given by the reason.
+func (r *Reconciler) cancelWeeder(logger logr.Logger, namespace, name, reason string) {
+	key := weeder.CreateKey(namespace, name)
+	wr, ok := r.WeederMgr.GetWeederRegistration(key)
+	if !ok {
+		return
+	}
+	if !wr.IsClosed() {
+		logger.Info("Cancelling running weeder", "namespace", namespace, "endpoint", name, "reason", reason, "correlationID", wr.CorrelationID())
+	}
+	r.WeederMgr.Unregister(key, reason)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -70,14 +212,54 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err != nil {
 		return err
 	}
+	if r.getEndpointsSource().WatchesEndpoints() {
+		if err = c.Watch(
+			source.Kind[client.Object](mgr.GetCache(), &v1.Endpoints{},
+				&handler.EnqueueRequestForObject{},
+				predicate.And[client.Object](
+					predicate.ResourceVersionChangedPredicate{},
+					InNamespace(r.Namespace),
+					MatchingEndpoints(r.WeederConfig.ServicesAndDependantSelectors),
+					ReadyEndpoints(c.GetLogger()),
+				),
+			),
+		); err != nil {
+			return err
+		}
+	}
+	if r.getEndpointsSource().WatchesEndpointSlices() {
+		// EndpointSlices are mapped to the service they belong to, so that a request for them is reconciled like one for the endpoints.
+		if err = c.Watch(
+			source.Kind[client.Object](mgr.GetCache(), &discoveryv1.EndpointSlice{},
+				handler.EnqueueRequestsFromMapFunc(mapEndpointSliceToService),
+				predicate.And[client.Object](
+					predicate.ResourceVersionChangedPredicate{},
+					InNamespace(r.Namespace),
+					MatchingEndpointSlices(r.WeederConfig.ServicesAndDependantSelectors),
+					ReadyEndpointSlices(c.GetLogger()),
+				),
+			),
+		); err != nil {
+			return err
+		}
+	}
+	// A service has the same name as the endpoints resource backing it, so a request for a deleted service is reconciled like one for the endpoints.
 	return c.Watch(
-		source.Kind[client.Object](mgr.GetCache(), &v1.Endpoints{},
+		source.Kind[client.Object](mgr.GetCache(), &v1.Service{},
 			&handler.EnqueueRequestForObject{},
 			predicate.And[client.Object](
-				predicate.ResourceVersionChangedPredicate{},
-				MatchingEndpoints(r.WeederConfig.ServicesAndDependantSelectors),
-				ReadyEndpoints(c.GetLogger()),
+				InNamespace(r.Namespace),
+				MatchingServices(r.WeederConfig.ServicesAndDependantSelectors),
+				DeletedServices(),
 			),
 		),
 	)
 }
+
+func mapEndpointSliceToService(_ context.Context, obj client.Object) []reconcile.Request {
+	serviceName, ok := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: serviceName}}}
+}
@@ -16,21 +16,28 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/rand"
 
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 	testutil "github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/go-logr/logr"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	internalutils "github.com/gardener/dependency-watchdog/internal/util"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	testclock "k8s.io/utils/clock/testing"
 	"k8s.io/utils/pointer"
 
 	weederpackage "github.com/gardener/dependency-watchdog/internal/weeder"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 )
 
@@ -69,7 +76,7 @@ func setupWeederEnv(ctx context.Context, t *testing.T, kubeApiServerFlags map[st
 
 	weederConfigPath := filepath.Join(testdataPath, "weeder-config.yaml")
 	testutil.ValidateIfFileExists(weederConfigPath, t)
-	weederConfig, err := weederpackage.LoadConfig(weederConfigPath)
+	weederConfig, err := weederpackage.LoadConfig(weederConfigPath, true)
 	g.Expect(err).ToNot(HaveOccurred())
 
 	epReconciler := &Reconciler{
@@ -78,6 +85,8 @@ func setupWeederEnv(ctx context.Context, t *testing.T, kubeApiServerFlags map[st
 		SeedClient:              clientSet,
 		WeederMgr:               weederpackage.NewManager(),
 		MaxConcurrentReconciles: maxConcurrentReconcilesWeeder,
+		// the watch durations of the weeders only expire once the clock is stepped
+		Clock: testclock.NewFakeClock(time.Now()),
 	}
 
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
@@ -166,6 +175,76 @@ func testWeederDedicatedEnvTest(t *testing.T) {
 	}
 }
 
+func TestReconcileShouldCancelWeederWhenEndpointIsDeleted(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	weederConfig, err := weederpackage.LoadConfig(filepath.Join(testdataPath, "weeder-config.yaml"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+	reconciler := &Reconciler{
+		Client:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		WeederConfig: weederConfig,
+		WeederMgr:    weederpackage.NewManager(),
+	}
+	w := weederpackage.NewWeeder(ctx, "test", weederConfig, nil, nil, newEndpoint(epName, "test"), nil, nil, logr.Discard())
+	reconciler.WeederMgr.Register(*w)
+	wr, ok := reconciler.WeederMgr.GetWeederRegistration(weederpackage.CreateKey("test", epName))
+	g.Expect(ok).To(BeTrue())
+	cancelledBefore := promtestutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonEndpointDeleted))
+
+	result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: epName}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+	_, ok = reconciler.WeederMgr.GetWeederRegistration(weederpackage.CreateKey("test", epName))
+	g.Expect(ok).To(BeFalse(), "weeder should have been unregistered")
+	g.Expect(wr.IsClosed()).To(BeTrue(), "weeder should have been cancelled")
+	g.Expect(promtestutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonEndpointDeleted)) - cancelledBefore).To(Equal(1.0))
+}
+
+func TestReconcileShouldCancelWeederAndNotStartANewOneWhenServiceIsDeleted(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	weederConfig, err := weederpackage.LoadConfig(filepath.Join(testdataPath, "weeder-config.yaml"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+	terminatingSvc := newService(epName, "test")
+	terminatingSvc.Finalizers = []string{"test.gardener.cloud/finalizer"}
+	terminatingSvc.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	testCases := []struct {
+		name string
+		svc  *v1.Service
+	}{
+		{name: "weeder should be cancelled if the service has been deleted"},
+		{name: "weeder should be cancelled if the deletion of the service has been requested", svc: terminatingSvc},
+	}
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clientBuilder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(newEndpoint(epName, "test"))
+			if entry.svc != nil {
+				clientBuilder.WithObjects(entry.svc.DeepCopy())
+			}
+			reconciler := &Reconciler{
+				Client:       clientBuilder.Build(),
+				WeederConfig: weederConfig,
+				WeederMgr:    weederpackage.NewManager(),
+			}
+			w := weederpackage.NewWeeder(ctx, "test", weederConfig, nil, nil, newEndpoint(epName, "test"), nil, nil, logr.Discard())
+			reconciler.WeederMgr.Register(*w)
+			wr, ok := reconciler.WeederMgr.GetWeederRegistration(weederpackage.CreateKey("test", epName))
+			g.Expect(ok).To(BeTrue())
+			cancelledBefore := promtestutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonServiceDeleted))
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: epName}})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result).To(Equal(ctrl.Result{}))
+			_, ok = reconciler.WeederMgr.GetWeederRegistration(weederpackage.CreateKey("test", epName))
+			g.Expect(ok).To(BeFalse(), "no weeder should be started for an endpoints resource whose service has been deleted")
+			g.Expect(wr.IsClosed()).To(BeTrue(), "weeder should have been cancelled")
+			g.Expect(promtestutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonServiceDeleted)) - cancelledBefore).To(Equal(1.0))
+		})
+	}
+}
+
 // tests
 // case 1: single pod in CLBF deleted, single healthy pod , other healthy pod remained
 // case 2: single pod healthy first, turned to CLBF gets deleted
@@ -248,8 +327,15 @@ func testPodTurningCLBFAfterWatchDuration(ctx context.Context, _ context.CancelF
 	g.Expect(err).ToNot(HaveOccurred())
 	turnPodToHealthy(ctx, g, reconciler.Client, pod)
 
-	// introducing wait
-	time.Sleep(reconciler.WeederConfig.WatchDuration.Duration + 2*time.Second)
+	// let the watch duration of the weeder expire
+	key := weederpackage.CreateKey(namespace, epName)
+	g.Eventually(func() bool {
+		_, ok := reconciler.WeederMgr.GetWeederRegistration(key)
+		return ok
+	}).Should(BeTrue())
+	wr, _ := reconciler.WeederMgr.GetWeederRegistration(key)
+	reconciler.Clock.(*testclock.FakeClock).Step(reconciler.WeederConfig.WatchDuration.Duration)
+	g.Eventually(wr.IsClosed).Should(BeTrue())
 
 	turnPodToCrashLoop(ctx, g, reconciler.Client, pod)
 	time.Sleep(5 * time.Second)
@@ -348,6 +434,8 @@ func deleteAllEp(ctx context.Context, g *WithT, cli client.Client) {
 }
 
 func createEp(ctx context.Context, g *WithT, reconciler *Reconciler, namespace string, ready bool) {
+	// the service is created first as a weeder is only started for an endpoints resource whose service exists
+	g.Expect(reconciler.Client.Create(ctx, newService(epName, namespace))).To(Succeed())
 	ep := newEndpoint(epName, namespace)
 	if !ready {
 		ep.Subsets[0].Addresses = nil
@@ -410,6 +498,18 @@ func turnPodToHealthy(ctx context.Context, g *WithT, crClient client.Client, p *
 	g.Expect(crClient.Status().Patch(ctx, pClone, client.MergeFrom(p))).To(Succeed())
 }
 
+func newService(name, namespace string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 2379}},
+		},
+	}
+}
+
 func newEndpoint(name, namespace string) *v1.Endpoints {
 	e := v1.Endpoints{
 		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
@@ -448,3 +548,98 @@ func turnEndpointToNotReady(ctx context.Context, g *WithT, client client.Client,
 	}
 	g.Expect(client.Update(ctx, epClone)).To(Succeed())
 }
+
+func TestReconcileShouldPreferEndpointSlicesOverEndpoints(t *testing.T) {
+	g := NewWithT(t)
+	weederConfig, err := weederpackage.LoadConfig(filepath.Join(testdataPath, "weeder-config.yaml"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+	notReadyEp := newEndpoint(epName, "test")
+	notReadyEp.Subsets[0].Addresses = nil
+
+	testCases := []struct {
+		name                    string
+		source                  weederpackage.EndpointsSource
+		ep                      *v1.Endpoints
+		slice                   *discoveryv1.EndpointSlice
+		expectWeederToBeStarted bool
+	}{
+		{name: "weeder should be started for ready endpoints if only endpoints are watched", source: weederpackage.EndpointsSourceEndpoints, ep: newEndpoint(epName, "test"), slice: newEndpointSlice(epName, "test", false), expectWeederToBeStarted: true},
+		{name: "weeder should be started for ready endpoint slices if only endpoint slices are watched", source: weederpackage.EndpointsSourceEndpointSlices, slice: newEndpointSlice(epName, "test", true), expectWeederToBeStarted: true},
+		{name: "weeder should be started for ready endpoint slices even if the endpoints are not ready", source: weederpackage.EndpointsSourceBoth, ep: notReadyEp, slice: newEndpointSlice(epName, "test", true), expectWeederToBeStarted: true},
+		{name: "weeder should not be started for ready endpoints if the endpoint slices are not ready", source: weederpackage.EndpointsSourceBoth, ep: newEndpoint(epName, "test"), slice: newEndpointSlice(epName, "test", false)},
+		{name: "weeder should be started for ready endpoints if no endpoint slices exist", source: weederpackage.EndpointsSourceBoth, ep: newEndpoint(epName, "test"), expectWeederToBeStarted: true},
+	}
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx, cancelFn := context.WithCancel(context.Background())
+			defer cancelFn()
+			clientBuilder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(newService(epName, "test"))
+			if entry.ep != nil {
+				clientBuilder.WithObjects(entry.ep.DeepCopy())
+			}
+			if entry.slice != nil {
+				clientBuilder.WithObjects(entry.slice.DeepCopy())
+			}
+			reconciler := &Reconciler{
+				Client:          clientBuilder.Build(),
+				SeedClient:      k8sfake.NewSimpleClientset(),
+				WeederConfig:    weederConfig,
+				WeederMgr:       weederpackage.NewManager(),
+				EndpointsSource: entry.source,
+			}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: epName}})
+			g.Expect(err).ToNot(HaveOccurred())
+			_, ok := reconciler.WeederMgr.GetWeederRegistration(weederpackage.CreateKey("test", epName))
+			g.Expect(ok).To(Equal(entry.expectWeederToBeStarted))
+		})
+	}
+}
+
+func TestReconcileShouldNotReplaceRecentlyStartedWeederIfEndpointsAndEndpointSlicesAreWatched(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	weederConfig, err := weederpackage.LoadConfig(filepath.Join(testdataPath, "weeder-config.yaml"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+	reconciler := &Reconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(newService(epName, "test"), newEndpoint(epName, "test"), newEndpointSlice(epName, "test", true)).Build(),
+		SeedClient:      k8sfake.NewSimpleClientset(),
+		WeederConfig:    weederConfig,
+		WeederMgr:       weederpackage.NewManager(),
+		EndpointsSource: weederpackage.EndpointsSourceBoth,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: epName}}
+
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	first, ok := reconciler.WeederMgr.GetWeederRegistration(weederpackage.CreateKey("test", epName))
+	g.Expect(ok).To(BeTrue())
+
+	// the recovery of the service is observed a second time via its endpoints
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	second, ok := reconciler.WeederMgr.GetWeederRegistration(weederpackage.CreateKey("test", epName))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(second.CreatedAt()).To(Equal(first.CreatedAt()), "the recently started weeder should not have been replaced")
+	g.Expect(first.IsClosed()).To(BeFalse())
+}
+
+func newEndpointSlice(serviceName, namespace string, ready bool) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName + "-" + rand.String(5),
+			Namespace: namespace,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: serviceName},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses:  []string{"10.1.0.52"},
+				Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(ready)},
+			},
+		},
+	}
+}
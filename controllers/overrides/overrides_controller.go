@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package overrides
+
+import (
+	"context"
+
+	"github.com/gardener/dependency-watchdog/internal/overrides"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const controllerName = "runtime-overrides"
+
+// Reconciler applies the runtime overrides which have been set via annotations on an object of dependency-watchdog itself, typically its own
+// Deployment or ConfigMap.
+type Reconciler struct {
+	client.Client
+	// RuntimeOverrides are updated from the annotations of the Object.
+	RuntimeOverrides *overrides.Overrides
+	// Object identifies the object whose annotations are watched by its type, namespace and name.
+	Object client.Object
+}
+
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+// +kubebuilder:rbac:resources=configmaps,verbs=get;list;watch
+
+// Reconcile applies the runtime overrides set via the annotations of the object. If the object does not exist then all runtime overrides are reset.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	obj := r.Object.DeepCopyObject().(client.Object)
+	if err := r.Client.Get(ctx, req.NamespacedName, obj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		log.Info("Object holding the runtime overrides does not exist, resetting all runtime overrides")
+		r.RuntimeOverrides.Apply(nil, log)
+		return ctrl.Result{}, nil
+	}
+	r.RuntimeOverrides.Apply(obj.GetAnnotations(), log)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	c, err := controller.New(
+		controllerName,
+		mgr,
+		controller.Options{
+			MaxConcurrentReconciles: 1,
+			Reconciler:              r},
+	)
+	if err != nil {
+		return err
+	}
+	return c.Watch(
+		source.Kind[client.Object](mgr.GetCache(), r.Object,
+			&handler.EnqueueRequestForObject{},
+			predicate.And[client.Object](
+				isObject(client.ObjectKeyFromObject(r.Object)),
+				predicate.AnnotationChangedPredicate{},
+			),
+		),
+	)
+}
+
+// isObject is a predicate to allow events for only the object with the given key.
+func isObject(key client.ObjectKey) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return client.ObjectKeyFromObject(obj) == key
+	})
+}
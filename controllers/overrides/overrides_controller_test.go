@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package overrides
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gardener/dependency-watchdog/internal/overrides"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestReconcileShouldApplyAnnotationsOfObject(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	dwd := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "garden", Name: "dependency-watchdog-prober",
+		Annotations: map[string]string{overrides.DryRunAnnotationKey: "true"}}}
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(dwd).Build()
+	runtimeOverrides := overrides.New(zap.NewAtomicLevel())
+	reconciler := &Reconciler{
+		Client:           cl,
+		RuntimeOverrides: runtimeOverrides,
+		Object:           &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: dwd.Namespace, Name: dwd.Name}},
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(dwd)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(runtimeOverrides.IsDryRun()).To(BeTrue())
+
+	g.Expect(cl.Delete(ctx, dwd)).To(Succeed())
+	_, err = reconciler.Reconcile(ctx, req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(runtimeOverrides.IsDryRun()).To(BeFalse(), "runtime overrides should be reset once the object has been deleted")
+}
+
+func TestIsObjectPredicate(t *testing.T) {
+	g := NewWithT(t)
+	p := isObject(client.ObjectKey{Namespace: "garden", Name: "dependency-watchdog-prober"})
+	g.Expect(p.Generic(event.GenericEvent{Object: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "garden", Name: "dependency-watchdog-prober"}}})).To(BeTrue())
+	g.Expect(p.Generic(event.GenericEvent{Object: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "garden", Name: "dependency-watchdog-weeder"}}})).To(BeFalse())
+}
@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// dashboardgen generates the Grafana dashboards for the metrics exposed by dependency-watchdog.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics/dashboards"
+)
+
+func main() {
+	outputDir := flag.String("output-dir", filepath.Join("internal", "metrics", "dashboards"), "Path to the directory under which the dashboards will be written")
+	flag.Parse()
+
+	generated, err := dashboards.Generate()
+	if err != nil {
+		log.Fatalf("failed to generate dashboards: %v", err)
+	}
+	for name, dashboardBytes := range generated {
+		path := filepath.Join(*outputDir, dashboards.FileName(name))
+		if err = os.WriteFile(path, dashboardBytes, 0644); err != nil {
+			log.Fatalf("failed to write dashboard %s: %v", path, err)
+		}
+		log.Printf("generated dashboard %s", path)
+	}
+}
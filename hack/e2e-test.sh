@@ -0,0 +1,16 @@
+#!/usr/bin/env bash
+#
+# SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+#
+# SPDX-License-Identifier: Apache-2.0
+
+set -e
+
+echo "> e2e-test"
+
+if [[ -z "${SEED_KUBECONFIG}" || -z "${SHOOT_KUBECONFIG}" || -z "${SHOOT_NAMESPACE}" ]]; then
+  echo "SEED_KUBECONFIG, SHOOT_KUBECONFIG and SHOOT_NAMESPACE must be set to run the e2e tests"
+  exit 1
+fi
+
+go test -v -count=1 -timeout 30m --tags=e2e_tests ./test/e2e/...
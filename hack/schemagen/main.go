@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// schemagen generates JSON schemas for the prober and weeder configuration types.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	wapi "github.com/gardener/dependency-watchdog/api/weeder"
+	"github.com/gardener/dependency-watchdog/internal/util"
+)
+
+func main() {
+	apiDir := flag.String("api-dir", "api", "Path to the api directory under which the schemas will be written")
+	flag.Parse()
+
+	schemas := []struct {
+		path   string
+		title  string
+		config interface{}
+	}{
+		{filepath.Join(*apiDir, "prober", "config.schema.json"), "dependency-watchdog prober configuration", papi.Config{}},
+		{filepath.Join(*apiDir, "weeder", "config.schema.json"), "dependency-watchdog weeder configuration", wapi.Config{}},
+	}
+	for _, s := range schemas {
+		schemaBytes, err := util.GenerateJSONSchema(s.title, s.config)
+		if err != nil {
+			log.Fatalf("failed to generate schema %s: %v", s.path, err)
+		}
+		if err = os.WriteFile(s.path, append(schemaBytes, '\n'), 0644); err != nil {
+			log.Fatalf("failed to write schema %s: %v", s.path, err)
+		}
+		log.Printf("generated schema %s", s.path)
+	}
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package claim provides per-namespace claims which prevent two instances of dependency-watchdog from acting upon the same shoot control
+// namespace, e.g. if dependency-watchdog has accidentally been deployed twice.
+package claim
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ProberLeaseName is the name of the Lease in a shoot control namespace via which the namespace is claimed by a prober.
+	ProberLeaseName = "dependency-watchdog-prober"
+	// WeederLeaseName is the name of the Lease in a shoot control namespace via which the namespace is claimed by a weeder.
+	WeederLeaseName = "dependency-watchdog-weeder"
+)
+
+// Claimer claims shoot control namespaces on behalf of an identity of dependency-watchdog. A namespace is claimed via a Lease in it whose
+// holder identity is the identity of the Claimer. A claim is held as long as it is renewed by its holder within the lease duration, after
+// which it can be taken over by another identity. The methods of a nil *Claimer report every namespace as claimed, so that it can be used
+// if claims are not enabled.
+type Claimer struct {
+	client        client.Client
+	reader        client.Reader
+	leaseName     string
+	identity      string
+	leaseDuration time.Duration
+	clock         clock.PassiveClock
+	// renewTimesMu guards renewTimes.
+	renewTimesMu sync.Mutex
+	// renewTimes records the times at which the claims of namespaces have last been acquired or renewed by this Claimer.
+	renewTimes map[string]time.Time
+}
+
+// New creates a Claimer which claims namespaces via the Lease with the given name on behalf of the given identity. The Leases are read via the
+// given reader, typically an uncached reader as Leases are not watched, and written via the given client.
+func New(c client.Client, reader client.Reader, leaseName, identity string, leaseDuration time.Duration, clock clock.PassiveClock) *Claimer {
+	return &Claimer{
+		client:        c,
+		reader:        reader,
+		leaseName:     leaseName,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		clock:         clock,
+		renewTimes:    make(map[string]time.Time),
+	}
+}
+
+// Claim acquires or renews the claim of the namespace. It returns false together with the identity of the holder if the namespace is claimed
+// by another identity whose claim has not expired yet, or if another identity has claimed the namespace concurrently. A claim held by this
+// Claimer is only renewed once half of its lease duration has elapsed, so that the Lease is not read and written on every call.
+func (c *Claimer) Claim(ctx context.Context, namespace string) (bool, string, error) {
+	if c == nil {
+		return true, "", nil
+	}
+	if !c.needsRenewal(namespace) {
+		return true, c.identity, nil
+	}
+	now := metav1.NewMicroTime(c.clock.Now())
+	lease := &coordinationv1.Lease{}
+	if err := c.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: c.leaseName}, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, "", err
+		}
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: c.leaseName},
+			Spec:       c.leaseSpec(now, now, 0),
+		}
+		if err = c.client.Create(ctx, lease); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return c.lostClaim(ctx, namespace)
+			}
+			return false, "", err
+		}
+		c.setRenewTime(namespace, now.Time)
+		return true, c.identity, nil
+	}
+	holder := pointer.StringDeref(lease.Spec.HolderIdentity, "")
+	if holder != c.identity && holder != "" && !c.isExpired(lease) {
+		c.forgetRenewTime(namespace)
+		return false, holder, nil
+	}
+	acquireTime, transitions := now, pointer.Int32Deref(lease.Spec.LeaseTransitions, 0)
+	if holder == c.identity && lease.Spec.AcquireTime != nil {
+		acquireTime = *lease.Spec.AcquireTime
+	} else {
+		transitions++
+	}
+	lease.Spec = c.leaseSpec(acquireTime, now, transitions)
+	if err := c.client.Update(ctx, lease); err != nil {
+		if apierrors.IsConflict(err) {
+			return c.lostClaim(ctx, namespace)
+		}
+		return false, holder, err
+	}
+	c.setRenewTime(namespace, now.Time)
+	return true, c.identity, nil
+}
+
+// lostClaim re-reads the Lease of the namespace after the claim has been lost to a concurrent write to it, and returns its actual holder.
+func (c *Claimer) lostClaim(ctx context.Context, namespace string) (bool, string, error) {
+	c.forgetRenewTime(namespace)
+	lease := &coordinationv1.Lease{}
+	if err := c.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: c.leaseName}, lease); err != nil {
+		return false, "", err
+	}
+	return false, pointer.StringDeref(lease.Spec.HolderIdentity, ""), nil
+}
+
+// needsRenewal checks if the claim of the namespace has to be acquired or renewed, which is the case if it has not been acquired by this
+// Claimer yet, or if half of its lease duration has elapsed since it has last been renewed.
+func (c *Claimer) needsRenewal(namespace string) bool {
+	c.renewTimesMu.Lock()
+	defer c.renewTimesMu.Unlock()
+	renewTime, ok := c.renewTimes[namespace]
+	return !ok || !c.clock.Now().Before(renewTime.Add(c.leaseDuration/2))
+}
+
+func (c *Claimer) setRenewTime(namespace string, renewTime time.Time) {
+	c.renewTimesMu.Lock()
+	defer c.renewTimesMu.Unlock()
+	c.renewTimes[namespace] = renewTime
+}
+
+func (c *Claimer) forgetRenewTime(namespace string) {
+	c.renewTimesMu.Lock()
+	defer c.renewTimesMu.Unlock()
+	delete(c.renewTimes, namespace)
+}
+
+// isExpired checks if the claim of the Lease has not been renewed within its lease duration.
+func (c *Claimer) isExpired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil {
+		return true
+	}
+	leaseDuration := c.leaseDuration
+	if lease.Spec.LeaseDurationSeconds != nil {
+		leaseDuration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return !c.clock.Now().Before(lease.Spec.RenewTime.Add(leaseDuration))
+}
+
+func (c *Claimer) leaseSpec(acquireTime, renewTime metav1.MicroTime, transitions int32) coordinationv1.LeaseSpec {
+	return coordinationv1.LeaseSpec{
+		HolderIdentity:       pointer.String(c.identity),
+		LeaseDurationSeconds: pointer.Int32(int32(c.leaseDuration.Seconds())),
+		AcquireTime:          &acquireTime,
+		RenewTime:            &renewTime,
+		LeaseTransitions:     pointer.Int32(transitions),
+	}
+}
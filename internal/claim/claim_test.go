@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package claim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const (
+	testNamespace     = "shoot--test"
+	testLeaseDuration = time.Minute
+)
+
+func TestClaimShouldBeHeldUntilItExpires(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	g.Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	clock := testclock.NewFakePassiveClock(time.Now())
+	first := New(c, c, ProberLeaseName, "garden/dwd-prober", testLeaseDuration, clock)
+	second := New(c, c, ProberLeaseName, "garden-dup/dwd-prober", testLeaseDuration, clock)
+
+	claimed, holder, err := first.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue())
+	g.Expect(holder).To(Equal("garden/dwd-prober"))
+
+	claimed, holder, err = second.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeFalse(), "a claim which has not expired should not be taken over")
+	g.Expect(holder).To(Equal("garden/dwd-prober"))
+
+	clock.SetTime(clock.Now().Add(testLeaseDuration / 2))
+	claimed, _, err = first.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue(), "a claim should be renewed by its holder")
+
+	clock.SetTime(clock.Now().Add(testLeaseDuration / 2))
+	claimed, _, err = second.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeFalse(), "a renewed claim should not be taken over")
+
+	clock.SetTime(clock.Now().Add(testLeaseDuration))
+	claimed, holder, err = second.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue(), "an expired claim should be taken over")
+	g.Expect(holder).To(Equal("garden-dup/dwd-prober"))
+
+	lease := &coordinationv1.Lease{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: ProberLeaseName}, lease)).To(Succeed())
+	g.Expect(*lease.Spec.HolderIdentity).To(Equal("garden-dup/dwd-prober"))
+	g.Expect(*lease.Spec.LeaseDurationSeconds).To(Equal(int32(testLeaseDuration.Seconds())))
+	g.Expect(*lease.Spec.LeaseTransitions).To(Equal(int32(1)))
+	g.Expect(lease.Spec.AcquireTime.Time).To(BeTemporally("~", clock.Now(), time.Second))
+
+	claimed, _, err = first.Claim(ctx, "other-namespace")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue(), "claims should be per namespace")
+}
+
+func TestClaimShouldTakeOverLeaseWithoutHolder(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	g.Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+	now := time.Now()
+	lease := &coordinationv1.Lease{}
+	lease.Namespace, lease.Name = testNamespace, WeederLeaseName
+	lease.Spec.LeaseDurationSeconds = pointer.Int32(60)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(lease).Build()
+
+	claimed, holder, err := New(c, c, WeederLeaseName, "garden/dwd-weeder", testLeaseDuration, testclock.NewFakePassiveClock(now)).Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue())
+	g.Expect(holder).To(Equal("garden/dwd-weeder"))
+}
+
+func TestClaimShouldOnlyBeRenewedIfItIsCloseToExpiring(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	g.Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+	var gets, updates int
+	c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme).Build(), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			gets++
+			return c.Get(ctx, key, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			updates++
+			return c.Update(ctx, obj, opts...)
+		},
+	})
+	clock := testclock.NewFakePassiveClock(time.Now())
+	claimer := New(c, c, ProberLeaseName, "garden/dwd-prober", testLeaseDuration, clock)
+
+	claimed, _, err := claimer.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue())
+	g.Expect(gets).To(Equal(1))
+
+	clock.SetTime(clock.Now().Add(testLeaseDuration / 4))
+	claimed, _, err = claimer.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue())
+	g.Expect(gets).To(Equal(1), "a claim which is not close to expiring should not be read")
+	g.Expect(updates).To(BeZero(), "a claim which is not close to expiring should not be renewed")
+
+	clock.SetTime(clock.Now().Add(testLeaseDuration / 4))
+	claimed, _, err = claimer.Claim(ctx, testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue())
+	g.Expect(gets).To(Equal(2))
+	g.Expect(updates).To(Equal(1), "a claim should be renewed once half of its lease duration has elapsed")
+}
+
+func TestClaimShouldReturnActualHolderIfClaimIsLostConcurrently(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	g.Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+	now := time.Now()
+	expired := metav1.NewMicroTime(now.Add(-2 * testLeaseDuration))
+	claimConcurrently := func(ctx context.Context, c client.WithWatch) {
+		// another identity claims the namespace after its Lease has been read
+		lease := &coordinationv1.Lease{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: ProberLeaseName}, lease); err != nil {
+			lease.Namespace, lease.Name = testNamespace, ProberLeaseName
+			lease.Spec.HolderIdentity = pointer.String("garden-dup/dwd-prober")
+			g.Expect(c.Create(ctx, lease)).To(Succeed())
+			return
+		}
+		lease.Spec.HolderIdentity = pointer.String("garden-dup/dwd-prober")
+		g.Expect(c.Update(ctx, lease)).To(Succeed())
+	}
+
+	tests := []struct {
+		title  string
+		leases []client.Object
+	}{
+		{"lease is created concurrently", nil},
+		{"expired lease is taken over concurrently", []client.Object{&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: ProberLeaseName},
+			Spec:       coordinationv1.LeaseSpec{HolderIdentity: pointer.String("garden-old/dwd-prober"), RenewTime: &expired},
+		}}},
+	}
+	for _, entry := range tests {
+		t.Run(entry.title, func(t *testing.T) {
+			g := NewWithT(t)
+			c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(entry.leases...).Build(), interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					claimConcurrently(ctx, c)
+					return c.Create(ctx, obj, opts...)
+				},
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					claimConcurrently(ctx, c)
+					return c.Update(ctx, obj, opts...)
+				},
+			})
+
+			claimed, holder, err := New(c, c, ProberLeaseName, "garden/dwd-prober", testLeaseDuration, testclock.NewFakePassiveClock(now)).Claim(ctx, testNamespace)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(claimed).To(BeFalse())
+			g.Expect(holder).To(Equal("garden-dup/dwd-prober"), "the identity which has claimed the namespace concurrently should be returned")
+		})
+	}
+}
+
+func TestNilClaimerShouldClaimEveryNamespace(t *testing.T) {
+	g := NewWithT(t)
+	var c *Claimer
+	claimed, _, err := c.Claim(context.Background(), testNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue())
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package features provides the feature gates of dependency-watchdog. New behaviors which are risky should be guarded by a feature gate which is
+// disabled by default (alpha), so that they can be enabled per landscape via the feature-gates flag before they are enabled by default (beta)
+// and the gate is eventually removed.
+package features
+
+import (
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+)
+
+const (
+	// AsyncScaling runs the scale flows of a prober asynchronously to its probes, so that a long-running scale flow does not delay subsequent
+	// probes. If it is disabled, a probe waits for the scale flow it has triggered to complete.
+	AsyncScaling featuregate.Feature = "AsyncScaling"
+	// KubeletHealthProbe enables the probe of the health endpoint of sampled kubelets before dependent resources are scaled down, if it is
+	// configured via kubeletHealthProbeSampleSize.
+	KubeletHealthProbe featuregate.Feature = "KubeletHealthProbe"
+	// SeedMeltdownCircuitBreaker enables the circuit breaker which suppresses the scale-downs of all shoots of the seed if the node lease probes of
+	// too many shoots fail at once, if it is configured via seedMeltdownFailureFraction.
+	SeedMeltdownCircuitBreaker featuregate.Feature = "SeedMeltdownCircuitBreaker"
+)
+
+// DefaultFeatureGate is the feature gate of dependency-watchdog. It is set via the feature-gates flag.
+var DefaultFeatureGate = featuregate.NewFeatureGate()
+
+// defaultFeatures are all known features along with their defaults.
+var defaultFeatures = map[featuregate.Feature]featuregate.FeatureSpec{
+	AsyncScaling:               {Default: false, PreRelease: featuregate.Alpha},
+	KubeletHealthProbe:         {Default: false, PreRelease: featuregate.Alpha},
+	SeedMeltdownCircuitBreaker: {Default: false, PreRelease: featuregate.Alpha},
+}
+
+func init() {
+	utilruntime.Must(DefaultFeatureGate.Add(defaultFeatures))
+}
+
+// Enabled returns true if the given feature is enabled.
+func Enabled(feature featuregate.Feature) bool {
+	return DefaultFeatureGate.Enabled(feature)
+}
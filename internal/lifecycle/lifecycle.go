@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lifecycle provides a common lifecycle for the long-running goroutines of dependency-watchdog, e.g. probers and the pod watchers of weeders.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/pkg/retry"
+	"github.com/go-logr/logr"
+)
+
+// State is the state of a Subsystem.
+type State string
+
+const (
+	// StatePending is the state of a Subsystem which has not been started yet or which is waiting for its initial delay to expire.
+	StatePending State = "Pending"
+	// StateRunning is the state of a Subsystem whose run function is running.
+	StateRunning State = "Running"
+	// StateRestarting is the state of a Subsystem whose run function has panicked and which is waiting for the restart backoff to expire.
+	StateRestarting State = "Restarting"
+	// StateStopped is the state of a Subsystem whose run function has returned or which has been stopped.
+	StateStopped State = "Stopped"
+)
+
+var defaultRestartBackOff = retry.ExponentialBackoff{Initial: time.Second, Factor: 2, Max: time.Minute, JitterFactor: 0.2}
+
+// Health captures the health of a Subsystem.
+type Health struct {
+	// State is the current state of the Subsystem.
+	State State
+	// Restarts is the number of times the run function of the Subsystem has been restarted after a panic.
+	Restarts int
+	// LastPanic is the value of the most recent panic of the run function. It is empty if the run function has never panicked.
+	LastPanic string
+}
+
+// Subsystem runs a function in a goroutine until it returns or the context of the Subsystem is cancelled. A panic of the run function is recovered
+// from and the run function is restarted after a backoff, so that e.g. a single malformed object cannot silently terminate the goroutine.
+type Subsystem struct {
+	name           string
+	ctx            context.Context
+	cancelFn       context.CancelFunc
+	runFn          func(ctx context.Context)
+	initialDelay   time.Duration
+	restartBackOff retry.BackoffStrategy
+	logger         logr.Logger
+	startOnce      sync.Once
+	done           chan struct{}
+	mu             sync.RWMutex
+	health         Health
+}
+
+// Option configures a Subsystem.
+type Option func(s *Subsystem)
+
+// WithInitialDelay delays the first invocation of the run function. The initial delay does not apply to restarts.
+func WithInitialDelay(initialDelay time.Duration) Option {
+	return func(s *Subsystem) {
+		s.initialDelay = initialDelay
+	}
+}
+
+// WithRestartBackOff sets the backoff after which the run function is restarted after it has panicked. Restarts are counted starting from 1.
+func WithRestartBackOff(restartBackOff retry.BackoffStrategy) Option {
+	return func(s *Subsystem) {
+		s.restartBackOff = restartBackOff
+	}
+}
+
+// New creates a new Subsystem with the given name which runs runFn once started. The context passed to runFn is cancelled when either the
+// given parent context is cancelled or the Subsystem is stopped.
+func New(parentCtx context.Context, name string, runFn func(ctx context.Context), logger logr.Logger, opts ...Option) *Subsystem {
+	ctx, cancelFn := context.WithCancel(parentCtx)
+	s := &Subsystem{
+		name:           name,
+		ctx:            ctx,
+		cancelFn:       cancelFn,
+		runFn:          runFn,
+		restartBackOff: defaultRestartBackOff,
+		logger:         logger.WithValues("subsystem", name),
+		done:           make(chan struct{}),
+		health:         Health{State: StatePending},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start starts the Subsystem in a new goroutine. Calling Start more than once has no effect.
+func (s *Subsystem) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+// Stop cancels the context of the Subsystem. It does not wait for the run function to return, use Done for that.
+func (s *Subsystem) Stop() {
+	s.cancelFn()
+}
+
+// Done returns a channel which is closed once the Subsystem has stopped.
+func (s *Subsystem) Done() <-chan struct{} {
+	return s.done
+}
+
+// Health returns the current health of the Subsystem.
+func (s *Subsystem) Health() Health {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.health
+}
+
+func (s *Subsystem) run() {
+	defer func() {
+		s.setState(StateStopped)
+		close(s.done)
+	}()
+	if !sleep(s.ctx, s.initialDelay) {
+		return
+	}
+	for {
+		s.setState(StateRunning)
+		panicValue, stack, panicked := s.runRecovering()
+		if !panicked {
+			return
+		}
+		metrics.PanicsTotal.WithLabelValues(s.name).Inc()
+		if s.ctx.Err() != nil {
+			s.logger.Error(fmt.Errorf("%v", panicValue), "Recovered from panic, not restarting as the subsystem has been stopped", "stack", string(stack))
+			return
+		}
+		restarts := s.recordPanic(panicValue)
+		backOff := s.restartBackOff.Backoff(restarts)
+		s.logger.Error(fmt.Errorf("%v", panicValue), "Recovered from panic, restarting after backoff", "restarts", restarts, "backOff", backOff, "stack", string(stack))
+		if !sleep(s.ctx, backOff) {
+			return
+		}
+	}
+}
+
+// runRecovering invokes the run function and returns the value of the panic, if any, the stack trace of the panicking goroutine and whether
+// the run function has panicked.
+func (s *Subsystem) runRecovering() (panicValue any, stack []byte, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicValue, stack, panicked = r, debug.Stack(), true
+		}
+	}()
+	s.runFn(s.ctx)
+	return nil, nil, false
+}
+
+func (s *Subsystem) recordPanic(panicValue any) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.State = StateRestarting
+	s.health.Restarts++
+	s.health.LastPanic = fmt.Sprintf("%v", panicValue)
+	return s.health.Restarts
+}
+
+func (s *Subsystem) setState(state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.State = state
+}
+
+// CombinedHealth combines the health of the given subsystems. The combined state is the state of the least healthy subsystem, in the order
+// Restarting, Pending, Running and Stopped. The restarts are summed up and the last panic is the one of any restarted subsystem.
+func CombinedHealth(subsystems ...*Subsystem) Health {
+	combined := Health{State: StateStopped}
+	for _, s := range subsystems {
+		health := s.Health()
+		if statePriority(health.State) > statePriority(combined.State) {
+			combined.State = health.State
+		}
+		combined.Restarts += health.Restarts
+		if health.LastPanic != "" {
+			combined.LastPanic = health.LastPanic
+		}
+	}
+	return combined
+}
+
+func statePriority(state State) int {
+	switch state {
+	case StateRestarting:
+		return 3
+	case StatePending:
+		return 2
+	case StateRunning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sleep waits for the given duration and returns false if the context has been cancelled before.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
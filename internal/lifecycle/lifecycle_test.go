@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package lifecycle
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/pkg/retry"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testRestartBackOff = 5 * time.Millisecond
+
+func TestSubsystemShouldRestartRunFunctionAfterPanic(t *testing.T) {
+	g := NewWithT(t)
+	var invocations atomic.Int32
+	s := New(context.Background(), "test", func(context.Context) {
+		if invocations.Add(1) < 3 {
+			panic("malformed object")
+		}
+	}, logr.Discard(), WithRestartBackOff(retry.ConstantBackoff(testRestartBackOff)))
+	g.Expect(s.Health().State).To(Equal(StatePending))
+
+	s.Start()
+	g.Eventually(s.Done()).Should(BeClosed())
+	g.Expect(invocations.Load()).To(Equal(int32(3)))
+	g.Expect(s.Health()).To(Equal(Health{State: StateStopped, Restarts: 2, LastPanic: "malformed object"}))
+	g.Expect(testutil.ToFloat64(metrics.PanicsTotal.WithLabelValues("test"))).To(BeNumerically(">=", 2), "recovered panics should be counted")
+}
+
+func TestSubsystemShouldNotRestartAfterStop(t *testing.T) {
+	g := NewWithT(t)
+	var invocations atomic.Int32
+	s := New(context.Background(), "test", func(ctx context.Context) {
+		invocations.Add(1)
+		<-ctx.Done()
+		panic("panic after stop")
+	}, logr.Discard(), WithRestartBackOff(retry.ConstantBackoff(testRestartBackOff)))
+
+	s.Start()
+	s.Start()
+	g.Eventually(func() State { return s.Health().State }).Should(Equal(StateRunning))
+	s.Stop()
+	g.Eventually(s.Done()).Should(BeClosed())
+	g.Expect(invocations.Load()).To(Equal(int32(1)), "the run function should neither be started twice nor restarted once the subsystem is stopped")
+	g.Expect(s.Health().State).To(Equal(StateStopped))
+}
+
+func TestSubsystemShouldNotRunIfStoppedDuringInitialDelay(t *testing.T) {
+	g := NewWithT(t)
+	var invocations atomic.Int32
+	s := New(context.Background(), "test", func(context.Context) {
+		invocations.Add(1)
+	}, logr.Discard(), WithInitialDelay(time.Minute))
+
+	s.Start()
+	s.Stop()
+	g.Eventually(s.Done()).Should(BeClosed())
+	g.Expect(invocations.Load()).To(BeZero())
+}
+
+func TestCombinedHealth(t *testing.T) {
+	g := NewWithT(t)
+	running := New(context.Background(), "running", nil, logr.Discard())
+	running.setState(StateRunning)
+	restarting := New(context.Background(), "restarting", nil, logr.Discard())
+	restarting.recordPanic("bingo")
+
+	g.Expect(CombinedHealth()).To(Equal(Health{State: StateStopped}))
+	g.Expect(CombinedHealth(running)).To(Equal(Health{State: StateRunning}))
+	g.Expect(CombinedHealth(running, restarting)).To(Equal(Health{State: StateRestarting, Restarts: 1, LastPanic: "bingo"}))
+}
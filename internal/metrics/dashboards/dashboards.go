@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dashboards provides Grafana dashboards for the metrics exposed by dependency-watchdog. The dashboards are defined in Go, so that they
+// refer to the metrics via the same namespace and subsystems as the metrics package, and are generated as JSON files via `make generate-dashboards`.
+// The generated files are embedded into the binary and can be exported via `dwd dashboards`.
+package dashboards
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// fileSuffix is the suffix of the files of the generated dashboards.
+	fileSuffix = ".json"
+	// schemaVersion is the version of the Grafana dashboard JSON model of the generated dashboards.
+	schemaVersion = 39
+	// panelWidth and panelHeight are the size of a panel in units of the Grafana grid, which is 24 units wide.
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+//go:embed *.json
+var files embed.FS
+
+// datasourceRef refers to the prometheus datasource which is selected via the datasource variable of a dashboard.
+var datasourceRef = &datasource{Type: "prometheus", UID: "${datasource}"}
+
+// Names returns the names of all embedded dashboards in alphabetical order.
+func Names() []string {
+	entries, _ := files.ReadDir(".") // the embedded files are always readable
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), fileSuffix))
+	}
+	return names
+}
+
+// Get returns the JSON of the embedded dashboard with the given name.
+func Get(name string) ([]byte, error) {
+	if !slices.Contains(Names(), name) {
+		return nil, fmt.Errorf("unknown dashboard %q, known dashboards are: %s", name, strings.Join(Names(), ", "))
+	}
+	return files.ReadFile(name + fileSuffix)
+}
+
+// FileName returns the name of the file of the dashboard with the given name.
+func FileName(name string) string {
+	return name + fileSuffix
+}
+
+// Generate generates the JSON of all dashboards keyed by their names. It is used to generate the embedded files.
+func Generate() (map[string][]byte, error) {
+	generated := make(map[string][]byte, len(definitions))
+	for name, definition := range definitions {
+		dashboardBytes, err := json.MarshalIndent(definition.build(), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate dashboard %s: %w", name, err)
+		}
+		generated[name] = append(dashboardBytes, '\n')
+	}
+	return generated, nil
+}
+
+// dashboardDefinition is the definition of a dashboard from which its JSON is generated.
+type dashboardDefinition struct {
+	title       string
+	description string
+	// shootNamespaceQuery is the query of the values of the shoot_namespace variable. The variable is omitted if it is empty.
+	shootNamespaceQuery string
+	panels              []panelDefinition
+}
+
+// panelDefinition is the definition of a time series panel of a dashboard.
+type panelDefinition struct {
+	title       string
+	description string
+	unit        string
+	targets     []target
+}
+
+func (d dashboardDefinition) build() dashboard {
+	variables := []variable{{Name: "datasource", Label: "Datasource", Type: "datasource", Query: "prometheus"}}
+	if d.shootNamespaceQuery != "" {
+		variables = append(variables, variable{
+			Name:       "shoot_namespace",
+			Label:      "Shoot namespace",
+			Type:       "query",
+			Datasource: datasourceRef,
+			Query:      d.shootNamespaceQuery,
+			Refresh:    2,
+			IncludeAll: true,
+			Multi:      true,
+		})
+	}
+	panels := make([]panel, 0, len(d.panels))
+	for i, p := range d.panels {
+		unit := p.unit
+		if unit == "" {
+			unit = "short"
+		}
+		targets := make([]target, 0, len(p.targets))
+		for j, t := range p.targets {
+			t.RefID = string(rune('A' + j))
+			targets = append(targets, t)
+		}
+		panels = append(panels, panel{
+			ID:          i + 1,
+			Type:        "timeseries",
+			Title:       p.title,
+			Description: p.description,
+			Datasource:  datasourceRef,
+			GridPos:     gridPos{H: panelHeight, W: panelWidth, X: (i % 2) * panelWidth, Y: (i / 2) * panelHeight},
+			FieldConfig: fieldConfig{Defaults: fieldDefaults{Unit: unit}, Overrides: []any{}},
+			Targets:     targets,
+		})
+	}
+	return dashboard{
+		Title:         d.title,
+		Description:   d.description,
+		UID:           strings.ReplaceAll(strings.ToLower(d.title), " ", "-"),
+		Tags:          []string{"dependency-watchdog"},
+		SchemaVersion: schemaVersion,
+		Editable:      true,
+		Refresh:       "1m",
+		Time:          timeRange{From: "now-6h", To: "now"},
+		Templating:    templating{List: variables},
+		Panels:        panels,
+	}
+}
+
+// The following types are the subset of the Grafana dashboard JSON model which is used by the generated dashboards.
+
+type dashboard struct {
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	UID           string     `json:"uid"`
+	Tags          []string   `json:"tags"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Editable      bool       `json:"editable"`
+	Refresh       string     `json:"refresh"`
+	Time          timeRange  `json:"time"`
+	Templating    templating `json:"templating"`
+	Panels        []panel    `json:"panels"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type templating struct {
+	List []variable `json:"list"`
+}
+
+type variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label"`
+	Type       string      `json:"type"`
+	Datasource *datasource `json:"datasource,omitempty"`
+	Query      string      `json:"query"`
+	Refresh    int         `json:"refresh,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+}
+
+type datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type panel struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Datasource  *datasource `json:"datasource"`
+	GridPos     gridPos     `json:"gridPos"`
+	FieldConfig fieldConfig `json:"fieldConfig"`
+	Targets     []target    `json:"targets"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type fieldConfig struct {
+	Defaults  fieldDefaults `json:"defaults"`
+	Overrides []any         `json:"overrides"`
+}
+
+type fieldDefaults struct {
+	Unit string `json:"unit"`
+}
+
+type target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
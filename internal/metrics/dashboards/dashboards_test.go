@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package dashboards
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestEmbeddedDashboardsShouldBeUpToDate(t *testing.T) {
+	g := NewWithT(t)
+	generated, err := Generate()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(Names()).To(ConsistOf(ProberDashboard, WeederDashboard))
+	for name, dashboardBytes := range generated {
+		embedded, err := Get(name)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(embedded)).To(Equal(string(dashboardBytes)), "the dashboards should be regenerated via make generate-dashboards")
+	}
+}
+
+func TestDashboardsShouldReferToMetricsOfDependencyWatchdog(t *testing.T) {
+	g := NewWithT(t)
+	dashboardBytes, err := Get(ProberDashboard)
+	g.Expect(err).ToNot(HaveOccurred())
+	var d dashboard
+	g.Expect(json.Unmarshal(dashboardBytes, &d)).To(Succeed())
+	g.Expect(d.UID).To(Equal(ProberDashboard))
+	g.Expect(d.Templating.List).To(ContainElement(HaveField("Name", "shoot_namespace")))
+	g.Expect(d.Panels).ToNot(BeEmpty())
+	for _, p := range d.Panels {
+		g.Expect(p.Targets).ToNot(BeEmpty())
+		g.Expect(p.Targets).To(HaveEach(HaveField("Expr", ContainSubstring("dependency_watchdog_"))))
+	}
+
+	_, err = Get("unknown")
+	g.Expect(err).To(MatchError(ContainSubstring(`unknown dashboard "unknown"`)))
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dashboards
+
+import (
+	"fmt"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// ProberDashboard is the name of the dashboard of the prober.
+	ProberDashboard = "dependency-watchdog-prober"
+	// WeederDashboard is the name of the dashboard of the weeder.
+	WeederDashboard = "dependency-watchdog-weeder"
+
+	// rateInterval is the interval over which the rates of counters are computed.
+	rateInterval = "5m"
+	// shootSelector selects the series of the shoots chosen via the shoot_namespace variable.
+	shootSelector = `{` + metrics.LabelShootNamespace + `=~"$shoot_namespace"}`
+)
+
+// metricName returns the fully-qualified name of a metric of dependency-watchdog.
+func metricName(subsystem, name string) string {
+	return prometheus.BuildFQName(metrics.Namespace, subsystem, name)
+}
+
+// rateBy returns the expression of the rate of a counter summed up by the given label.
+func rateBy(counter, label string) string {
+	return fmt.Sprintf("sum by (%s) (rate(%s[%s]))", label, counter, rateInterval)
+}
+
+// definitions are the definitions of all dashboards keyed by their names.
+var definitions = map[string]dashboardDefinition{
+	ProberDashboard: {
+		title:               "Dependency Watchdog Prober",
+		description:         "Probes of the shoots of the seed and the scaling of their dependent resources by the dependency-watchdog prober.",
+		shootNamespaceQuery: fmt.Sprintf("label_values(%s, %s)", metricName("shoot", "api_probe_healthy"), metrics.LabelShootNamespace),
+		panels: []panelDefinition{
+			{
+				title:       "Probed shoots",
+				description: "Number of active probers and of the shoots whose most recent API server or node lease probe has failed.",
+				targets: []target{
+					{Expr: metricName("", "probers_active"), LegendFormat: "active probers"},
+					{Expr: metricName("prober", "shoots_api_server_probe_failed"), LegendFormat: "API server probe failed"},
+					{Expr: metricName("prober", "shoots_lease_probe_failed"), LegendFormat: "node lease probe failed"},
+				},
+			},
+			{
+				title:       "Shoots with scaled down dependents",
+				description: "Number of shoots whose dependent resources are scaled down and whether the seed meltdown circuit breaker is open.",
+				targets: []target{
+					{Expr: metricName("prober", "shoots_dependents_scaled_down"), LegendFormat: "scaled down"},
+					{Expr: metricName("prober", "seed_meltdown_circuit_breaker_open"), LegendFormat: "seed meltdown circuit breaker open"},
+				},
+			},
+			{
+				title:       "Suppressed scale-downs",
+				description: "Rate of the scale-downs which have been suppressed, by reason.",
+				unit:        "ops",
+				targets:     []target{{Expr: rateBy(metricName("prober", "scale_downs_suppressed_total"), metrics.LabelReason), LegendFormat: "{{" + metrics.LabelReason + "}}"}},
+			},
+			{
+				title:       "Failed scale attempts",
+				description: "Rate of the failed attempts to scale a dependent resource and of the conflicts among them, by operation.",
+				unit:        "ops",
+				targets: []target{
+					{Expr: rateBy(metricName("prober", "scale_attempt_failures_total"), metrics.LabelOperation), LegendFormat: "{{" + metrics.LabelOperation + "}} failures"},
+					{Expr: rateBy(metricName("prober", "scale_conflicts_total"), metrics.LabelOperation), LegendFormat: "{{" + metrics.LabelOperation + "}} conflicts"},
+				},
+			},
+			{
+				title:       "Prober restarts and closures",
+				description: "Rate of the probers which have been restarted or closed, by reason.",
+				unit:        "ops",
+				targets: []target{
+					{Expr: rateBy(metricName("", "probers_restarted_total"), metrics.LabelReason), LegendFormat: "restarted: {{" + metrics.LabelReason + "}}"},
+					{Expr: rateBy(metricName("", "probers_closed_total"), metrics.LabelReason), LegendFormat: "closed: {{" + metrics.LabelReason + "}}"},
+				},
+			},
+			{
+				title:       "Probe authentication failures",
+				description: "Rate of the probes which have failed as the credentials of the prober have been rejected or lack permissions, by reason.",
+				unit:        "ops",
+				targets:     []target{{Expr: rateBy(metricName("prober", "probe_auth_failures_total"), metrics.LabelReason), LegendFormat: "{{" + metrics.LabelReason + "}}"}},
+			},
+			{
+				title:       "Shoot client creation failures",
+				description: "Rate of the shoot clients which could not be created, by reason. All reasons but invalid_kubeconfig are failures to read the credentials from the seed.",
+				unit:        "ops",
+				targets:     []target{{Expr: rateBy(metricName("prober", "shoot_client_creation_failures_total"), metrics.LabelReason), LegendFormat: "{{" + metrics.LabelReason + "}}"}},
+			},
+			{
+				title:       "Shoot client creation duration",
+				description: "90th percentile of the duration of the creation of shoot clients including the read of their credentials from the seed, by kind of client.",
+				unit:        "s",
+				targets: []target{{
+					Expr:         fmt.Sprintf("histogram_quantile(0.9, sum by (le, %s) (rate(%s_bucket[%s])))", metrics.LabelClient, metricName("prober", "shoot_client_creation_duration_seconds"), rateInterval),
+					LegendFormat: "{{" + metrics.LabelClient + "}}",
+				}},
+			},
+			{
+				title:       "API server probe health",
+				description: "1 if the most recent API server probe of the shoot has succeeded, else 0.",
+				targets:     []target{{Expr: metricName("shoot", "api_probe_healthy") + shootSelector, LegendFormat: "{{" + metrics.LabelShootNamespace + "}}"}},
+			},
+			{
+				title:       "Expired node lease fraction",
+				description: "Fraction of expired node leases of the shoot determined by the most recent node lease probe.",
+				unit:        "percentunit",
+				targets:     []target{{Expr: metricName("shoot", "lease_expired_fraction") + shootSelector, LegendFormat: "{{" + metrics.LabelShootNamespace + "}}"}},
+			},
+			{
+				title:       "Dependents scaled down",
+				description: "1 if the dependent resources of the shoot are scaled down, else 0.",
+				targets:     []target{{Expr: metricName("shoot", "dependents_scaled_down") + shootSelector, LegendFormat: "{{" + metrics.LabelShootNamespace + "}}"}},
+			},
+			{
+				title:       "Scale flows in flight",
+				description: "1 while a scale flow of the shoot is in flight, else 0. A scale flow which is in flight for long indicates a stuck scale operation.",
+				targets:     []target{{Expr: metricName("shoot", "scale_flow_in_flight") + shootSelector, LegendFormat: "{{" + metrics.LabelShootNamespace + "}}"}},
+			},
+			{
+				title:       "Scaled down duration",
+				description: "90th percentile of the duration for which dependent resources have been scaled down before they have been scaled up again.",
+				unit:        "s",
+				targets: []target{{
+					Expr:         fmt.Sprintf("histogram_quantile(0.9, sum by (le) (rate(%s_bucket%s[1h])))", metricName("shoot", "dependents_scaled_down_duration_seconds"), shootSelector),
+					LegendFormat: "p90",
+				}},
+			},
+			{
+				title:       "Recovered panics",
+				description: "Rate of the panics which have been recovered from, by subsystem.",
+				unit:        "ops",
+				targets:     []target{{Expr: rateBy(metricName("", "panics_total"), metrics.LabelSubsystem), LegendFormat: "{{" + metrics.LabelSubsystem + "}}"}},
+			},
+		},
+	},
+	WeederDashboard: {
+		title:       "Dependency Watchdog Weeder",
+		description: "Weeders which are started by the dependency-watchdog weeder to delete pods in CrashLoopBackOff once a service has recovered.",
+		panels: []panelDefinition{
+			{
+				title:       "Active weeders",
+				description: "Number of weeders which are currently running.",
+				targets:     []target{{Expr: metricName("", "weeders_active"), LegendFormat: "active"}},
+			},
+			{
+				title:       "Started weeders",
+				description: "Rate of the weeders which have been started.",
+				unit:        "ops",
+				targets:     []target{{Expr: fmt.Sprintf("sum(rate(%s[%s]))", metricName("", "weeders_started_total"), rateInterval), LegendFormat: "started"}},
+			},
+			{
+				title:       "Cancelled weeders",
+				description: "Rate of the weeders which have been cancelled before their watch duration expired, by reason.",
+				unit:        "ops",
+				targets:     []target{{Expr: rateBy(metricName("", "weeders_cancelled_total"), metrics.LabelReason), LegendFormat: "{{" + metrics.LabelReason + "}}"}},
+			},
+			{
+				title:       "Watch recreations",
+				description: "Rate of the watches of weeders which have been recreated, by reason.",
+				unit:        "ops",
+				targets:     []target{{Expr: rateBy(metricName("", "weeder_watch_recreations_total"), metrics.LabelReason), LegendFormat: "{{" + metrics.LabelReason + "}}"}},
+			},
+			{
+				title:       "Pod deletions",
+				description: "Rate of the weeders whose watch duration has expired and of the pod deletions which have been avoided.",
+				unit:        "ops",
+				targets: []target{
+					{Expr: fmt.Sprintf("sum(rate(%s[%s]))", metricName("", "weeder_watch_duration_expiries_total"), rateInterval), LegendFormat: "watch duration expired"},
+					{Expr: fmt.Sprintf("sum(rate(%s[%s]))", metricName("", "weeder_pod_deletions_avoided_total"), rateInterval), LegendFormat: "pod deletions avoided"},
+				},
+			},
+			{
+				title:       "Recovered panics",
+				description: "Rate of the panics which have been recovered from, by subsystem.",
+				unit:        "ops",
+				targets:     []target{{Expr: rateBy(metricName("", "panics_total"), metrics.LabelSubsystem), LegendFormat: "{{" + metrics.LabelSubsystem + "}}"}},
+			},
+		},
+	},
+}
@@ -0,0 +1,7 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics contains the prometheus metrics exposed by dependency-watchdog. All metrics are registered with the
+// controller-runtime metrics registry and are therefore served on the metrics endpoint of the prober and weeder.
+package metrics
@@ -0,0 +1,334 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Namespace is the namespace of all metrics exposed by dependency-watchdog. It used to be dwd, see the migration note in docs/deployment/monitor.md.
+const Namespace = "dependency_watchdog"
+
+const (
+	// LabelReason is the label used to capture the reason for an event that is counted by a metric.
+	LabelReason = "reason"
+	// LabelOperation is the label used to capture the scaling operation, i.e. scale-up or scale-down, that is counted by a metric.
+	LabelOperation = "operation"
+	// LabelSubsystem is the label used to capture the kind of a long-running goroutine, e.g. prober or pod-watcher, that is counted by a metric.
+	LabelSubsystem = "subsystem"
+	// LabelShootNamespace is the label used to capture the shoot control plane namespace of a per-shoot metric. It is deliberately not called
+	// namespace to not clash with the namespace label of the scrape target.
+	LabelShootNamespace = "shoot_namespace"
+	// LabelOverride is the label used to capture the annotation key of a runtime override.
+	LabelOverride = "override"
+	// LabelConfigHash is the label used to capture the hash of the effective config a prober or a weeder is running with.
+	LabelConfigHash = "config_hash"
+	// LabelWorkerPool is the label used to capture the worker pool of a shoot of a per-worker-pool metric.
+	LabelWorkerPool = "worker_pool"
+	// LabelClient is the label used to capture the kind of a shoot client, i.e. client or discovery, that is created.
+	LabelClient = "client"
+	// ReasonEndpointDeleted is the reason used when a weeder is cancelled as the endpoint it was started for has been deleted.
+	ReasonEndpointDeleted = "endpoint_deleted"
+	// ReasonServiceDeleted is the reason used when a weeder is cancelled as the service backing the endpoint it was started for has been deleted.
+	ReasonServiceDeleted = "service_deleted"
+	// ReasonDuplicate is the reason used when a weeder is cancelled as a new weeder has been started for the same endpoint.
+	ReasonDuplicate = "duplicate"
+	// ReasonEndpointNotReady is the reason used when a weeder is cancelled as the endpoint it was started for does not have any ready address anymore.
+	ReasonEndpointNotReady = "endpoint_not_ready"
+	// ReasonContextCancelled is the reason used when a weeder is cancelled as the context it has been started with has been cancelled, e.g. on shutdown.
+	ReasonContextCancelled = "context_cancelled"
+	// ReasonWatchClosed is the reason used when a watch of a weeder is recreated as it has been closed, e.g. by the API server once its timeout has expired.
+	ReasonWatchClosed = "watch_closed"
+	// ReasonWatchError is the reason used when a watch of a weeder is recreated as it has received an error, e.g. as the resource version is too old.
+	ReasonWatchError = "watch_error"
+	// ReasonSeedMeltdown is the reason used when a scale-down is suppressed as the node lease probes of many shoots of the seed have failed.
+	ReasonSeedMeltdown = "seed_meltdown"
+	// ReasonKubeletsUnhealthy is the reason used when a scale-down is suppressed as none of the kubelets sampled by the kubelet health probe is healthy.
+	ReasonKubeletsUnhealthy = "kubelets_unhealthy"
+	// ReasonScaleDownDisabled is the reason used when a scale-down is suppressed as scale-downs have been disabled via the configuration.
+	ReasonScaleDownDisabled = "scale_down_disabled"
+	// ReasonWarmUp is the reason used when a scale-down is suppressed as the prober is still warming up after it has been created.
+	ReasonWarmUp = "warm_up"
+	// ReasonRuntimeOverride is the reason used when a scale-down is suppressed as scale-downs have been disabled via a runtime override.
+	ReasonRuntimeOverride = "runtime_override"
+	// ReasonUnauthorized is the reason used when a probe has failed as the credentials of the prober have been rejected.
+	ReasonUnauthorized = "unauthorized"
+	// ReasonForbidden is the reason used when a probe has failed as the prober lacks the required RBAC permissions.
+	ReasonForbidden = "forbidden"
+	// ReasonSecretNotFound is the reason used when a shoot client could not be created as the secret holding its kubeconfig or token has not
+	// been found in the seed.
+	ReasonSecretNotFound = "secret_not_found"
+	// ReasonSecretGetFailed is the reason used when a shoot client could not be created as the kubeconfig or token could not be read from its
+	// secret in the seed for another reason, e.g. as the request to the seed API server has been throttled or the secret lacks the expected key.
+	ReasonSecretGetFailed = "secret_get_failed"
+	// ReasonInvalidKubeConfig is the reason used when a shoot client could not be created from the kubeconfig read from the seed.
+	ReasonInvalidKubeConfig = "invalid_kubeconfig"
+	// ReasonClusterNotFound is the reason used when a prober is closed as the Cluster resource of the shoot has been deleted.
+	ReasonClusterNotFound = "cluster_not_found"
+	// ReasonShootNotFound is the reason used when a prober is closed as the Shoot resource watched in the garden cluster has been deleted.
+	ReasonShootNotFound = "shoot_not_found"
+	// ReasonSeedChange is the reason used when a prober is closed as the Shoot resource watched in the garden cluster is no longer scheduled to
+	// the seed of dependency-watchdog.
+	ReasonSeedChange = "seed_change"
+	// ReasonShootDeletion is the reason used when a prober is closed as the shoot has been marked for deletion.
+	ReasonShootDeletion = "deletion"
+	// ReasonHibernation is the reason used when a prober is closed as the shoot is hibernated.
+	ReasonHibernation = "hibernation"
+	// ReasonMigration is the reason used when a prober is closed as the control plane of the shoot is migrated.
+	ReasonMigration = "migration"
+	// ReasonNoWorkers is the reason used when a prober is closed as the shoot does not have any workers.
+	ReasonNoWorkers = "no_workers"
+	// ReasonProberDisabled is the reason used when a prober is closed as it has been disabled for the shoot via an annotation.
+	ReasonProberDisabled = "prober_disabled"
+	// ReasonConfigChange is the reason used when a prober is restarted as its probe config has changed and cannot be swapped in place.
+	ReasonConfigChange = "config_change"
+	// ReasonNodeConditionsChange is the reason used when a prober is restarted as the node conditions of the workers of the shoot have changed.
+	ReasonNodeConditionsChange = "node_conditions_change"
+)
+
+var (
+	// RESTMapperResetsTotal counts the number of times a cached RESTMapper has been reset because a mapping could not be found.
+	RESTMapperResetsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "restmapper_resets_total",
+		Help:      "Total number of times a cached RESTMapper has been reset due to a missing resource mapping.",
+	})
+	// PanicsTotal counts the number of panics which have been recovered from in long-running goroutines, partitioned by subsystem.
+	PanicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "panics_total",
+		Help:      "Total number of panics which have been recovered from in long-running goroutines.",
+	}, []string{LabelSubsystem})
+	// WeedersCancelledTotal counts the number of running weeders which have been cancelled before their watch duration expired, partitioned by reason.
+	WeedersCancelledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "weeders_cancelled_total",
+		Help:      "Total number of running weeders which have been cancelled before their watch duration expired.",
+	}, []string{LabelReason})
+	// WeedersStartedTotal counts the number of weeders which have been registered with the weeder manager.
+	WeedersStartedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "weeders_started_total",
+		Help:      "Total number of weeders which have been registered with the weeder manager.",
+	})
+	// WeedersActive is the number of weeders which are currently running, i.e. which have neither been cancelled nor has their watch duration expired.
+	WeedersActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "weeders_active",
+		Help:      "Number of weeders which are currently running.",
+	})
+	// WeederWatchDurationExpiriesTotal counts the number of weeders which have run until their watch duration expired.
+	WeederWatchDurationExpiriesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "weeder_watch_duration_expiries_total",
+		Help:      "Total number of weeders which have run until their watch duration expired.",
+	})
+	// WeederWatchRecreationsTotal counts the number of times a watch of a running weeder has been recreated, partitioned by reason.
+	WeederWatchRecreationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "weeder_watch_recreations_total",
+		Help:      "Total number of times a watch of a running weeder has been recreated.",
+	}, []string{LabelReason})
+	// WeederPodDeletionsAvoidedTotal counts the number of dependant pods in CrashLoopBackOff which have recovered on their own within the grace period
+	// of a weeder or right before their deletion and have therefore not been weeded.
+	WeederPodDeletionsAvoidedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "weeder_pod_deletions_avoided_total",
+		Help:      "Total number of dependant pods in CrashLoopBackOff which have recovered within the grace period of a weeder or right before their deletion and have not been weeded.",
+	})
+	// ProbersCreatedTotal counts the number of probers which have been registered with the prober manager.
+	ProbersCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "probers_created_total",
+		Help:      "Total number of probers which have been registered with the prober manager.",
+	})
+	// ProbersClosedTotal counts the number of probers which have been closed and removed from the prober manager, partitioned by reason.
+	ProbersClosedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "probers_closed_total",
+		Help:      "Total number of probers which have been closed and removed from the prober manager.",
+	}, []string{LabelReason})
+	// ProbersRestartedTotal counts the number of probers which have been replaced by a new prober for the same shoot, partitioned by reason.
+	ProbersRestartedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "probers_restarted_total",
+		Help:      "Total number of probers which have been replaced by a new prober for the same shoot.",
+	}, []string{LabelReason})
+	// ProbersActive is the number of probers which are currently registered with the prober manager.
+	ProbersActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "probers_active",
+		Help:      "Number of probers which are currently registered with the prober manager.",
+	})
+	// ShootDecodeFailuresTotal counts the number of Shoots which could not be decoded from their Clusters, once per resource version of a Cluster.
+	ShootDecodeFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "shoot_decode_failures_total",
+		Help:      "Total number of Shoots which could not be decoded from their Clusters, counted once per resource version of a Cluster.",
+	})
+	// ShootClientCreationDurationSeconds observes the duration of the creation of shoot clients including the read of their kubeconfig from the
+	// seed, partitioned by the kind of client. Clients which are reused from the cache are observed as well.
+	ShootClientCreationDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "shoot_client_creation_duration_seconds",
+		Help:      "Duration of the creation of shoot clients including the read of their kubeconfig from the seed.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{LabelClient})
+	// ShootClientCreationFailuresTotal counts the number of shoot clients which could not be created, partitioned by the kind of client and by
+	// reason. The reasons tell failures to read the credentials from the seed apart from failures to create a client out of them.
+	ShootClientCreationFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "shoot_client_creation_failures_total",
+		Help:      "Total number of shoot clients which could not be created.",
+	}, []string{LabelClient, LabelReason})
+	// ScaleDownsSuppressedTotal counts the number of scale-downs of dependent resources which have been suppressed, partitioned by reason.
+	ScaleDownsSuppressedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "scale_downs_suppressed_total",
+		Help:      "Total number of scale-downs of dependent resources which have been suppressed.",
+	}, []string{LabelReason})
+	// ScaleDownReassertionsTotal counts the number of times the scale-down flow of a shoot has been run again while its dependent resources are
+	// scaled down, e.g. as a dependent resource has been scaled up by someone else.
+	ScaleDownReassertionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "scale_down_reassertions_total",
+		Help:      "Total number of times the scale-down flow of a shoot has been run again while its dependent resources are scaled down.",
+	})
+	// ProbeAuthFailuresTotal counts the number of probe runs which have failed due to Unauthorized or Forbidden errors, partitioned by reason.
+	ProbeAuthFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "probe_auth_failures_total",
+		Help:      "Total number of probe runs which have failed due to Unauthorized or Forbidden errors.",
+	}, []string{LabelReason})
+	// ScaleAttemptFailuresTotal counts the number of failed attempts to scale a dependent resource, partitioned by operation.
+	ScaleAttemptFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "scale_attempt_failures_total",
+		Help:      "Total number of failed attempts to scale a dependent resource.",
+	}, []string{LabelOperation})
+	// ScaleConflictsTotal counts the number of attempts to scale a dependent resource which have failed with a conflict as the resource has been changed
+	// concurrently, e.g. by an overlapping scale flow, partitioned by operation. Every conflict is retried.
+	ScaleConflictsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "scale_conflicts_total",
+		Help:      "Total number of attempts to scale a dependent resource which have failed with a conflict.",
+	}, []string{LabelOperation})
+	// SeedMeltdownCircuitBreakerOpen is 1 if the seed meltdown circuit breaker is open and scale-downs are suppressed for all shoots, else 0.
+	SeedMeltdownCircuitBreakerOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "prober",
+		Name:      "seed_meltdown_circuit_breaker_open",
+		Help:      "Whether the seed meltdown circuit breaker is open (1) and scale-downs are suppressed for all shoots or not (0).",
+	})
+	// ShootAPIProbeHealthy is 1 if the most recent probe of the shoot control plane API server has succeeded, else 0, partitioned by shoot namespace.
+	ShootAPIProbeHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "shoot",
+		Name:      "api_probe_healthy",
+		Help:      "Whether the most recent probe of the shoot control plane API server has succeeded (1) or not (0).",
+	}, []string{LabelShootNamespace})
+	// ShootLeaseExpiredFraction is the fraction of expired node leases determined by the most recent node lease probe, partitioned by shoot namespace.
+	ShootLeaseExpiredFraction = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "shoot",
+		Name:      "lease_expired_fraction",
+		Help:      "Fraction of expired node leases determined by the most recent node lease probe.",
+	}, []string{LabelShootNamespace})
+	// ShootWorkerPoolLeaseExpiredFraction is the fraction of expired node leases of a worker pool determined by the most recent node lease probe,
+	// partitioned by shoot namespace and worker pool. It is only informational, the scale decisions are based on ShootLeaseExpiredFraction.
+	ShootWorkerPoolLeaseExpiredFraction = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "shoot",
+		Name:      "worker_pool_lease_expired_fraction",
+		Help:      "Fraction of expired node leases of a worker pool determined by the most recent node lease probe.",
+	}, []string{LabelShootNamespace, LabelWorkerPool})
+	// ShootDependentsScaledDown is 1 if the dependent resources of a shoot have been scaled down by the prober and have not been scaled up since, else 0,
+	// partitioned by shoot namespace.
+	ShootDependentsScaledDown = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "shoot",
+		Name:      "dependents_scaled_down",
+		Help:      "Whether the dependent resources have been scaled down by the prober (1) or not (0).",
+	}, []string{LabelShootNamespace})
+	// ShootDependentsScaledDownDurationSeconds observes for how long the dependent resources of a shoot have been scaled down once they have been
+	// scaled up again, partitioned by shoot namespace. The duration is computed from the time of the scale-down captured on the dependent resources.
+	ShootDependentsScaledDownDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "shoot",
+		Name:      "dependents_scaled_down_duration_seconds",
+		Help:      "Duration for which the dependent resources have been scaled down by the prober before they have been scaled up again.",
+		Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+	}, []string{LabelShootNamespace})
+	// ShootScaleFlowInFlight is 1 while the prober of a shoot runs a scale flow for its dependent resources, else 0, partitioned by shoot namespace.
+	ShootScaleFlowInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "shoot",
+		Name:      "scale_flow_in_flight",
+		Help:      "Whether the prober runs a scale flow for the dependent resources (1) or not (0).",
+	}, []string{LabelShootNamespace})
+	// ShootProberConfigInfo is 1 for the hash of the effective probe config the prober of a shoot is running with, partitioned by shoot namespace.
+	ShootProberConfigInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "shoot",
+		Name:      "prober_config_info",
+		Help:      "Hash of the effective probe config the prober of a shoot is running with, captured by the config_hash label. The value is always 1.",
+	}, []string{LabelShootNamespace, LabelConfigHash})
+	// RuntimeOverrideActive is 1 if a runtime override is active, else 0, partitioned by the annotation key of the override.
+	RuntimeOverrideActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "runtime_override_active",
+		Help:      "Whether a runtime override which has been set via an annotation is active (1) or not (0).",
+	}, []string{LabelOverride})
+	// WeederConfigInfo is 1 for the hash of the config the most recently registered weeder is running with.
+	WeederConfigInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "weeder_config_info",
+		Help:      "Hash of the config the most recently registered weeder is running with, captured by the config_hash label. The value is always 1.",
+	}, []string{LabelConfigHash})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		RESTMapperResetsTotal,
+		PanicsTotal,
+		WeedersStartedTotal,
+		WeedersActive,
+		WeedersCancelledTotal,
+		WeederWatchDurationExpiriesTotal,
+		WeederWatchRecreationsTotal,
+		WeederPodDeletionsAvoidedTotal,
+		ProbersCreatedTotal,
+		ProbersClosedTotal,
+		ProbersRestartedTotal,
+		ProbersActive,
+		ShootDecodeFailuresTotal,
+		ShootClientCreationDurationSeconds,
+		ShootClientCreationFailuresTotal,
+		ScaleDownsSuppressedTotal,
+		ScaleDownReassertionsTotal,
+		SeedMeltdownCircuitBreakerOpen,
+		ProbeAuthFailuresTotal,
+		ScaleAttemptFailuresTotal,
+		ScaleConflictsTotal,
+		ShootAPIProbeHealthy,
+		ShootLeaseExpiredFraction,
+		ShootWorkerPoolLeaseExpiredFraction,
+		ShootDependentsScaledDown,
+		ShootDependentsScaledDownDurationSeconds,
+		ShootScaleFlowInFlight,
+		ShootProberConfigInfo,
+		WeederConfigInfo,
+		RuntimeOverrideActive,
+	)
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package overrides
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// LogVerbosityAnnotationKey is the key of the annotation which overrides the log verbosity. Its value is a non-negative integer, log messages
+	// up to this verbosity, i.e. logger.V(n), are logged.
+	LogVerbosityAnnotationKey = "dependency-watchdog.gardener.cloud/log-verbosity"
+	// DryRunAnnotationKey is the key of the annotation which enables the dry-run mode if set to true. In dry-run mode, the probers decide upon
+	// scale operations and the weeders decide upon pods to weed, but neither dependent resources are scaled nor pods are weeded.
+	DryRunAnnotationKey = "dependency-watchdog.gardener.cloud/dry-run"
+	// DisableScaleDownAnnotationKey is the key of the annotation which suppresses all scale-downs of dependent resources if set to true. Scale-ups
+	// are still run.
+	DisableScaleDownAnnotationKey = "dependency-watchdog.gardener.cloud/disable-scale-down"
+)
+
+// Overrides are switches which operators can flip at runtime, without restarting dependency-watchdog, by annotating its own Deployment or
+// ConfigMap. The methods of a nil *Overrides report that no override is set, so that it can be used if runtime overrides are not configured.
+type Overrides struct {
+	mu                sync.RWMutex
+	logLevel          zap.AtomicLevel
+	defaultLogLevel   zapcore.Level
+	dryRun            bool
+	scaleDownDisabled bool
+}
+
+// New creates Overrides which change the given log level. The current level of the given log level is restored once the log verbosity
+// is no longer overridden.
+func New(logLevel zap.AtomicLevel) *Overrides {
+	return &Overrides{
+		logLevel:        logLevel,
+		defaultLogLevel: logLevel.Level(),
+	}
+}
+
+// IsDryRun returns true if the dry-run mode has been enabled.
+func (o *Overrides) IsDryRun() bool {
+	if o == nil {
+		return false
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.dryRun
+}
+
+// IsScaleDownDisabled returns true if scale-downs of dependent resources have been disabled.
+func (o *Overrides) IsScaleDownDisabled() bool {
+	if o == nil {
+		return false
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.scaleDownDisabled
+}
+
+// Apply applies the overrides set via the given annotations. Overrides which are not set via an annotation are reset. An annotation with an
+// invalid value is logged and the override is reset, so that an emergency switch is never flipped accidentally.
+func (o *Overrides) Apply(annotations map[string]string, logger logr.Logger) {
+	logLevel := o.defaultLogLevel
+	if verbosity, ok, err := parseLogVerbosity(annotations); err != nil {
+		logger.Error(err, "Ignoring invalid runtime override", "annotation", LogVerbosityAnnotationKey)
+	} else if ok {
+		logLevel = zapcore.Level(-verbosity)
+	}
+	dryRun := parseSwitch(annotations, DryRunAnnotationKey, logger)
+	scaleDownDisabled := parseSwitch(annotations, DisableScaleDownAnnotationKey, logger)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.logLevel.Level() != logLevel {
+		logger.Info("Changing log level", "from", o.logLevel.Level(), "to", logLevel)
+		o.logLevel.SetLevel(logLevel)
+	}
+	if o.dryRun != dryRun {
+		logger.Info("Changing runtime override", "annotation", DryRunAnnotationKey, "value", dryRun)
+		o.dryRun = dryRun
+	}
+	if o.scaleDownDisabled != scaleDownDisabled {
+		logger.Info("Changing runtime override", "annotation", DisableScaleDownAnnotationKey, "value", scaleDownDisabled)
+		o.scaleDownDisabled = scaleDownDisabled
+	}
+	setOverrideActiveMetric(DryRunAnnotationKey, dryRun)
+	setOverrideActiveMetric(DisableScaleDownAnnotationKey, scaleDownDisabled)
+	setOverrideActiveMetric(LogVerbosityAnnotationKey, logLevel != o.defaultLogLevel)
+}
+
+func parseLogVerbosity(annotations map[string]string) (int, bool, error) {
+	value, ok := annotations[LogVerbosityAnnotationKey]
+	if !ok {
+		return 0, false, nil
+	}
+	verbosity, err := strconv.Atoi(value)
+	if err != nil || verbosity < 0 {
+		return 0, false, fmt.Errorf("log verbosity %q is not a non-negative integer", value)
+	}
+	return verbosity, true, nil
+}
+
+func parseSwitch(annotations map[string]string, key string, logger logr.Logger) bool {
+	value, ok := annotations[key]
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid runtime override", "annotation", key, "value", value)
+		return false
+	}
+	return enabled
+}
+
+func setOverrideActiveMetric(key string, active bool) {
+	if active {
+		metrics.RuntimeOverrideActive.WithLabelValues(key).Set(1)
+	} else {
+		metrics.RuntimeOverrideActive.WithLabelValues(key).Set(0)
+	}
+}
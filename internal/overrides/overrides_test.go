@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package overrides
+
+import (
+	"testing"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name                      string
+		annotations               map[string]string
+		expectedLogLevel          zapcore.Level
+		expectedDryRun            bool
+		expectedScaleDownDisabled bool
+	}{
+		{name: "no annotations should not override anything", expectedLogLevel: zapcore.DebugLevel},
+		{name: "log verbosity should be overridden", annotations: map[string]string{LogVerbosityAnnotationKey: "4"}, expectedLogLevel: zapcore.Level(-4)},
+		{name: "invalid log verbosity should be ignored", annotations: map[string]string{LogVerbosityAnnotationKey: "-1"}, expectedLogLevel: zapcore.DebugLevel},
+		{name: "dry-run should be enabled", annotations: map[string]string{DryRunAnnotationKey: "true"}, expectedLogLevel: zapcore.DebugLevel, expectedDryRun: true},
+		{name: "scale-down should be disabled", annotations: map[string]string{DisableScaleDownAnnotationKey: "true"}, expectedLogLevel: zapcore.DebugLevel, expectedScaleDownDisabled: true},
+		{name: "invalid switch should be ignored", annotations: map[string]string{DisableScaleDownAnnotationKey: "yes please"}, expectedLogLevel: zapcore.DebugLevel},
+	}
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			logLevel := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+			o := New(logLevel)
+			o.Apply(entry.annotations, logr.Discard())
+			g.Expect(logLevel.Level()).To(Equal(entry.expectedLogLevel))
+			g.Expect(o.IsDryRun()).To(Equal(entry.expectedDryRun))
+			g.Expect(o.IsScaleDownDisabled()).To(Equal(entry.expectedScaleDownDisabled))
+		})
+	}
+}
+
+func TestApplyShouldResetOverridesWhichAreNoLongerSet(t *testing.T) {
+	g := NewWithT(t)
+	logLevel := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	o := New(logLevel)
+	o.Apply(map[string]string{LogVerbosityAnnotationKey: "6", DryRunAnnotationKey: "true", DisableScaleDownAnnotationKey: "true"}, logr.Discard())
+	g.Expect(testutil.ToFloat64(metrics.RuntimeOverrideActive.WithLabelValues(DryRunAnnotationKey))).To(Equal(1.0))
+
+	o.Apply(nil, logr.Discard())
+	g.Expect(logLevel.Level()).To(Equal(zapcore.DebugLevel), "the log level at creation should be restored")
+	g.Expect(o.IsDryRun()).To(BeFalse())
+	g.Expect(o.IsScaleDownDisabled()).To(BeFalse())
+	g.Expect(testutil.ToFloat64(metrics.RuntimeOverrideActive.WithLabelValues(DryRunAnnotationKey))).To(Equal(0.0))
+}
+
+func TestNilOverridesShouldNotOverrideAnything(t *testing.T) {
+	g := NewWithT(t)
+	var o *Overrides
+	g.Expect(o.IsDryRun()).To(BeFalse())
+	g.Expect(o.IsScaleDownDisabled()).To(BeFalse())
+}
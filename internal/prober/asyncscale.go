@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gardener/dependency-watchdog/internal/features"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/prober/errors"
+	dwdScaler "github.com/gardener/dependency-watchdog/internal/prober/scaler"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// scaleFlowSubsystemName is the subsystem with which panics of scale flows are counted.
+const scaleFlowSubsystemName = "scale-flow"
+
+// inFlightScale tracks the scale flow which is run asynchronously by a prober. It is referenced via a pointer from the Prober so that it is shared
+// between copies of a Prober, e.g. the one which is registered with the Manager and the one which is run.
+type inFlightScale struct {
+	sync.Mutex
+	// operation is the operation of the scale flow which is in flight. It is empty if no scale flow is in flight.
+	operation string
+	// done is closed once the scale flow which has been started most recently has completed.
+	done chan struct{}
+	// err is the error of the most recently completed scale flow which has not been collected yet.
+	err error
+}
+
+// start marks a scale flow of the given operation as in flight unless another scale flow is already in flight, in which case the operation of the
+// scale flow in flight and false are returned.
+func (s *inFlightScale) start(operation string) (string, bool) {
+	s.Lock()
+	defer s.Unlock()
+	if s.operation != "" {
+		return s.operation, false
+	}
+	s.operation = operation
+	s.done = make(chan struct{})
+	return operation, true
+}
+
+func (s *inFlightScale) finish(err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.operation = ""
+	if err != nil {
+		s.err = err
+	}
+	close(s.done)
+}
+
+func (s *inFlightScale) getOperation() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.operation
+}
+
+// collectErr returns the error of the most recently completed scale flow, if any. An error is only returned once.
+func (s *inFlightScale) collectErr() error {
+	s.Lock()
+	defer s.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// wait blocks until the scale flow which is in flight, if any, has completed.
+func (s *inFlightScale) wait() {
+	s.Lock()
+	done := s.done
+	s.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// triggerScale runs the scale flow of the given operation in a separate goroutine so that a long-running scale flow does not delay subsequent
+// probes, unless the AsyncScaling feature is disabled in which case it waits for the scale flow to complete. Only a single scale flow is in flight at any time. A scale flow of the same operation which is already in flight is not started again,
+// a scale flow of the opposite operation is decided upon again by the first probe after the scale flow in flight has completed.
+func (p *Prober) triggerScale(ctx context.Context, operation string, result nodeLeaseProbeResult, expiredNodeLeaseCount int) {
+	if inFlightOperation, started := p.inFlightScale.start(operation); !started {
+		p.l.Info("Skipping scale operation as a scale flow is already in flight", "operation", operation, "inFlightOperation", inFlightOperation)
+		return
+	}
+	p.setShootMetric(metrics.ShootScaleFlowInFlight, 1)
+	p.setLastDecision(operation, time.Now())
+	// the scale flow runs on a copy of the prober as the probe loop replaces the config of the prober once it has been swapped
+	sp := *p
+	runScaleFlow := func() {
+		sp.runRecovering(operation, func() error {
+			scaledDownBefore := sp.AreDependentsScaledDown()
+			code, message, err := sp.runScaleFlow(ctx, operation)
+			decision := newScaleDecision(operation, result, expiredNodeLeaseCount, *sp.config.NodeLeaseFailureFraction, err)
+			if operation == scaleDecisionOperationScaleDown {
+				decision.ExpiredNodeNames = sp.sampleExpiredNodeNames(result.candidateNodeLeases, maxExpiredNodeNamesInScaleDecision)
+			}
+			sp.recordScaleDecision(ctx, decision)
+			if !scaledDownBefore && sp.AreDependentsScaledDown() {
+				sp.recordScaledDownEvent(decision)
+			}
+			return errors.WrapError(err, code, message)
+		})
+	}
+	if !features.Enabled(features.AsyncScaling) {
+		runScaleFlow()
+		return
+	}
+	go runScaleFlow()
+}
+
+// runRecovering runs the given scale flow of the given operation and finishes the scale flow in flight with its error. The scale flow does not
+// run within the lifecycle.Subsystem of the probe loop, so a panic of the scale flow is recovered from here and recorded as its error. It
+// neither crashes the process nor leaves the scale flow in flight forever, the scale flow is decided upon again by the next probe.
+func (p *Prober) runRecovering(operation string, scaleFlowFn func() error) {
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.PanicsTotal.WithLabelValues(scaleFlowSubsystemName).Inc()
+			var code errors.ErrorCode = errors.ErrScaleUp
+			if operation == scaleDecisionOperationScaleDown {
+				code = errors.ErrScaleDown
+			}
+			err = errors.WrapError(fmt.Errorf("%v", r), code, "Scale flow has panicked")
+			p.l.Error(err, "Recovered from panic of scale flow", "operation", operation, "stack", string(debug.Stack()))
+		}
+		p.setShootMetric(metrics.ShootScaleFlowInFlight, 0)
+		p.inFlightScale.finish(err)
+	}()
+	err = scaleFlowFn()
+}
+
+// recordScaledDownEvent records an event which explains why the dependent resources have been scaled down, so that the reason of a scale-down can
+// be told from the event alone.
+func (p *Prober) recordScaledDownEvent(decision scaleDecision) {
+	explanation := decision.explain()
+	p.l.Info("Scaled down dependent resources", "explanation", explanation)
+	if p.recorder != nil {
+		p.recorder.Eventf(&corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: p.namespace, Namespace: p.namespace}, corev1.EventTypeWarning, eventReasonScaledDown,
+			"Scaled down dependent resources as %s", explanation)
+	}
+}
+
+// runScaleFlow runs the scale flow of the given operation. Next to the error of the scale flow, if any, it returns the error code and the message
+// an error of the scale flow is recorded with.
+func (p *Prober) runScaleFlow(ctx context.Context, operation string) (errors.ErrorCode, string, error) {
+	var code errors.ErrorCode = errors.ErrScaleDown
+	scaleFn, message, scaledDown := p.scaler.ScaleDown, "Failed to scale down resources", true
+	if operation == scaleDecisionOperationScaleUp {
+		scaleFn, code, message, scaledDown = p.scaler.ScaleUp, errors.ErrScaleUp, "Failed to scale up resources", false
+	}
+	if err := scaleFn(ctx); err != nil {
+		if stderrors.Is(err, dwdScaler.ErrDryRun) {
+			p.l.Info("Scale operation has not been run as the dry-run mode is enabled", "operation", operation)
+			return code, message, nil
+		}
+		if stderrors.Is(err, dwdScaler.ErrScaleRejected) {
+			code = errors.ErrScaleRejected
+		}
+		p.l.Error(err, message)
+		return code, message, err
+	}
+	p.setDependentsScaledDown(scaledDown)
+	return code, message, nil
+}
+
+// ReassertScaleDown runs the scale-down flow again if the dependent resources of the shoot are currently scaled down, e.g. to scale down an
+// optional dependent resource which has been created after the scale-down. Dependent resources which are already scaled down are skipped by the
+// flow. It returns false if the dependent resources are not scaled down, if a scale-down is not allowed, see scaleDownAllowed, or if a scale flow
+// is already in flight, see ScaleOperationInFlight.
+func (p *Prober) ReassertScaleDown(ctx context.Context) bool {
+	if !p.AreDependentsScaledDown() {
+		return false
+	}
+	allowed, err := p.scaleDownAllowed(ctx)
+	if err != nil {
+		p.l.Error(err, "Failed to check if the scale-down can be re-asserted")
+		return false
+	}
+	if !allowed {
+		return false
+	}
+	if _, started := p.inFlightScale.start(scaleDecisionOperationScaleDown); !started {
+		return false
+	}
+	p.setShootMetric(metrics.ShootScaleFlowInFlight, 1)
+	sp := *p
+	go sp.runRecovering(scaleDecisionOperationScaleDown, func() error {
+		code, message, err := sp.runScaleFlow(sp.ctx, scaleDecisionOperationScaleDown)
+		return errors.WrapError(err, code, message)
+	})
+	return true
+}
+
+// collectScaleFlowErr records the error of the most recently completed scale flow, if any.
+func (p *Prober) collectScaleFlowErr() {
+	if err := p.inFlightScale.collectErr(); err != nil {
+		p.lastErr = err
+	}
+}
+
+// ScaleOperationInFlight returns the operation of the scale flow which is currently run by the prober. It is empty if no scale flow is in flight.
+func (p *Prober) ScaleOperationInFlight() string {
+	return p.inFlightScale.getOperation()
+}
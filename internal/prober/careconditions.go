@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"context"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1helper "github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	reasonNodeLeasesRenewed    = "DependencyWatchdogNodeLeasesRenewed"
+	reasonNodeLeasesExpired    = "DependencyWatchdogNodeLeasesExpired"
+	reasonNodeLeaseProbeFailed = "DependencyWatchdogNodeLeaseProbeFailed"
+)
+
+// careConditionUpdate is the status, reason and message with which a care condition is updated. The reason and message only depend on the
+// status, so that a care condition is only updated if its status changes.
+type careConditionUpdate struct {
+	status  gardencorev1beta1.ConditionStatus
+	reason  string
+	message string
+}
+
+var (
+	nodeLeasesRenewedUpdate = careConditionUpdate{status: gardencorev1beta1.ConditionTrue, reason: reasonNodeLeasesRenewed,
+		message: "The node leases of all candidate nodes have been renewed."}
+	nodeLeasesExpiredUpdate = careConditionUpdate{status: gardencorev1beta1.ConditionFalse, reason: reasonNodeLeasesExpired,
+		message: "The node leases of some candidate nodes have expired."}
+	nodeLeasesNotProbedUpdate = careConditionUpdate{status: gardencorev1beta1.ConditionUnknown, reason: reasonNodeLeaseProbeFailed,
+		message: "The node leases could not be probed."}
+)
+
+// nodeLeasesUpdate reflects the node leases of the candidate nodes. Every node is only considered to be ready if none of the leases has expired,
+// regardless of the NodeLeaseFailureFraction, which only decides whether the dependent resources are scaled down.
+func (p *Prober) nodeLeasesUpdate(result nodeLeaseProbeResult) careConditionUpdate {
+	if p.countExpiredNodeLeases(result.candidateNodeLeases) > 0 {
+		return nodeLeasesExpiredUpdate
+	}
+	return nodeLeasesRenewedUpdate
+}
+
+// reportCareCondition reports the outcome of a probe run as the EveryNodeReady condition in the status of the Workers of the shoot, if
+// ReportCareConditions is enabled. gardenlet merges the EveryNodeReady conditions reported by the extension resources of a shoot into its
+// EveryNodeReady care condition. A Worker is only patched if the status of its condition has changed. Reporting the condition is best-effort,
+// a failure is only logged and does not affect the probe.
+func (p *Prober) reportCareCondition(ctx context.Context, update careConditionUpdate) {
+	if !pointer.BoolDeref(p.config.ReportCareConditions, false) {
+		return
+	}
+	workers := &extensionsv1alpha1.WorkerList{}
+	if err := p.seedClient.List(ctx, workers, client.InNamespace(p.namespace)); err != nil {
+		p.setBackOffIfSeedThrottlingError(err)
+		p.l.Error(err, "Failed to list the workers to report the care condition")
+		return
+	}
+	for i := range workers.Items {
+		worker := &workers.Items[i]
+		if current := v1beta1helper.GetCondition(worker.Status.Conditions, gardencorev1beta1.ShootEveryNodeReady); current != nil && current.Status == update.status {
+			continue
+		}
+		patch := client.MergeFromWithOptions(worker.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		condition := v1beta1helper.GetOrInitConditionWithClock(clock.RealClock{}, worker.Status.Conditions, gardencorev1beta1.ShootEveryNodeReady)
+		condition = v1beta1helper.UpdatedConditionWithClock(clock.RealClock{}, condition, update.status, update.reason, update.message)
+		worker.Status.Conditions = v1beta1helper.MergeConditions(worker.Status.Conditions, condition)
+		if err := p.seedClient.Status().Patch(ctx, worker, patch); err != nil {
+			p.setBackOffIfSeedThrottlingError(err)
+			p.l.Error(err, "Failed to report the care condition on the worker", "worker", worker.Name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	k8sfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/k8s"
+	shootfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/shoot"
+	"github.com/gardener/dependency-watchdog/internal/test"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestProbeShouldReportCareConditionOnWorker(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name}})
+	worker := &extensionsv1alpha1.Worker{ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: test.DefaultNamespace}}
+	seedClient := initializeSeedClientBuilder(machines, nil, worker).WithStatusSubresource(worker).Build()
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.6)
+	config.ReportCareConditions = pointer.Bool(true)
+
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+	p.probe(ctx)
+	condition := getCareCondition(ctx, g, seedClient, worker)
+	g.Expect(condition).To(beCareCondition(gardencorev1beta1.ConditionFalse, reasonNodeLeasesExpired), "an expired node lease should be reported even if the node lease failure fraction is not reached")
+	resourceVersion := worker.ResourceVersion
+
+	p.probe(ctx)
+	g.Expect(getCareCondition(ctx, g, seedClient, worker)).To(Equal(condition))
+	g.Expect(worker.ResourceVersion).To(Equal(resourceVersion), "the worker should only be patched if the status of the condition has changed")
+
+	scc = shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(errors.New("connection refused")), shootClient).Build()
+	p = NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+	p.probe(ctx)
+	updatedCondition := getCareCondition(ctx, g, seedClient, worker)
+	g.Expect(updatedCondition).To(beCareCondition(gardencorev1beta1.ConditionUnknown, reasonNodeLeaseProbeFailed))
+	g.Expect(updatedCondition.Message).ToNot(ContainSubstring("connection refused"), "the message should not depend on the error of the probe")
+	g.Expect(updatedCondition.LastTransitionTime.Time).ToNot(BeTemporally("<", condition.LastTransitionTime.Time), "the transition time should be updated along with the status")
+}
+
+func TestProbeShouldNotReportCareConditionUnlessEnabled(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	worker := &extensionsv1alpha1.Worker{ObjectMeta: metav1.ObjectMeta{Name: "test-shoot", Namespace: test.DefaultNamespace}}
+	seedClient := initializeSeedClientBuilder(nil, nil, worker).WithStatusSubresource(worker).Build()
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(errors.New("connection refused")), k8sfakes.NewFakeClientBuilder().Build()).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+	p.probe(ctx)
+	g.Expect(seedClient.Get(ctx, client.ObjectKeyFromObject(worker), worker)).To(Succeed())
+	g.Expect(worker.Status.Conditions).To(BeEmpty())
+}
+
+func getCareCondition(ctx context.Context, g *WithT, seedClient client.Client, worker *extensionsv1alpha1.Worker) gardencorev1beta1.Condition {
+	g.Expect(seedClient.Get(ctx, client.ObjectKeyFromObject(worker), worker)).To(Succeed())
+	g.Expect(worker.Status.Conditions).To(HaveLen(1))
+	g.Expect(worker.Status.Conditions[0].Type).To(Equal(gardencorev1beta1.ShootEveryNodeReady))
+	return worker.Status.Conditions[0]
+}
+
+func beCareCondition(status gardencorev1beta1.ConditionStatus, reason string) types.GomegaMatcher {
+	return And(HaveField("Status", status), HaveField("Reason", reason))
+}
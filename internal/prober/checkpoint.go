@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// proberCheckpointConfigMapName is the name of the ConfigMap in the shoot control plane namespace in which the checkpoint of the prober is persisted.
+	proberCheckpointConfigMapName = "dependency-watchdog-prober-checkpoint"
+	// proberCheckpointDataKey is the key in the ConfigMap data under which the checkpoint is stored as JSON.
+	proberCheckpointDataKey = "checkpoint"
+	// minCheckpointRefreshInterval is the minimum interval with which an unchanged checkpoint is persisted again to refresh its LastProbeTime.
+	minCheckpointRefreshInterval = 20 * time.Second
+)
+
+// proberCheckpoint captures the state of a prober which is retained across restarts of dependency-watchdog. All times are persisted with a
+// resolution of seconds.
+type proberCheckpoint struct {
+	// LastProbeTime is the time at which the checkpoint has most recently been persisted by a probe run.
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
+	// LastHealthyTime is the time at which the API server probe has most recently succeeded without the lease probe having failed.
+	LastHealthyTime *metav1.Time `json:"lastHealthyTime,omitempty"`
+	// LastDecision is the most recent scale operation which has been triggered by the prober.
+	LastDecision string `json:"lastDecision,omitempty"`
+	// LastDecisionTime is the time at which LastDecision has been triggered.
+	LastDecisionTime *metav1.Time `json:"lastDecisionTime,omitempty"`
+	// DependentsScaledDown is true if the dependent resources have been scaled down by the prober and have not been scaled up since.
+	DependentsScaledDown bool `json:"dependentsScaledDown"`
+	// BackOffUntil is the time at which the backoff of the prober ends, if it is backing off.
+	BackOffUntil *metav1.Time `json:"backOffUntil,omitempty"`
+	// WarmUpStartedAt is the time at which the WarmUpDuration of the prober has started.
+	WarmUpStartedAt metav1.Time `json:"warmUpStartedAt"`
+}
+
+// isCheckpointEnabled checks if the state of the prober should be persisted in a checkpoint.
+func (p *Prober) isCheckpointEnabled() bool {
+	return pointer.BoolDeref(p.config.PersistCheckpoint, false)
+}
+
+// restoreCheckpoint restores the state of the prober from the checkpoint which has been persisted by a previous prober for the same shoot, if
+// PersistCheckpoint is enabled. It is only tried once per prober. The warm-up of the previous prober is only continued if the checkpoint has been
+// persisted less than WarmUpDuration ago, else a new warm-up is started. Restoring is best-effort, any error is logged and otherwise ignored.
+func (p *Prober) restoreCheckpoint(ctx context.Context) {
+	if p.checkpointRestored || !p.isCheckpointEnabled() {
+		return
+	}
+	p.checkpointRestored = true
+	checkpoint, err := p.getCheckpoint(ctx)
+	if err != nil {
+		p.setBackOffIfSeedThrottlingError(err)
+		p.l.Error(err, "Failed to restore the checkpoint of the prober, ignoring error", "configMap", proberCheckpointConfigMapName)
+		return
+	}
+	if checkpoint == nil {
+		return
+	}
+	p.checkpoint = checkpoint
+	p.setDependentsScaledDown(checkpoint.DependentsScaledDown)
+	if checkpoint.LastDecisionTime != nil {
+		p.setLastDecision(checkpoint.LastDecision, checkpoint.LastDecisionTime.Time)
+	}
+	if checkpoint.BackOffUntil != nil {
+		if remaining := time.Until(checkpoint.BackOffUntil.Time); remaining > 0 {
+			p.resetBackoff(remaining)
+		}
+	}
+	if time.Since(checkpoint.LastProbeTime.Time) < getDurationOrZero(p.config.WarmUpDuration) {
+		p.warmUpStartedAt = checkpoint.WarmUpStartedAt.Time
+	}
+	p.l.Info("Restored the checkpoint of the prober", "lastProbeTime", checkpoint.LastProbeTime, "dependentsScaledDown", checkpoint.DependentsScaledDown,
+		"lastDecision", checkpoint.LastDecision, "warmUpStartedAt", p.warmUpStartedAt)
+}
+
+// persistCheckpoint persists the current state of the prober, if PersistCheckpoint is enabled. An unchanged state is only persisted again once the
+// LastProbeTime of the persisted checkpoint is older than half of the WarmUpDuration, but at least minCheckpointRefreshInterval, to limit the
+// number of writes. Persisting is best-effort, any error is logged and otherwise ignored.
+func (p *Prober) persistCheckpoint(ctx context.Context) {
+	if !p.isCheckpointEnabled() {
+		return
+	}
+	now := time.Now()
+	checkpoint := p.newCheckpoint(now)
+	if !p.isCheckpointOutdated(checkpoint, now) {
+		return
+	}
+	if err := p.writeCheckpoint(ctx, checkpoint); err != nil {
+		p.setBackOffIfSeedThrottlingError(err)
+		p.l.Error(err, "Failed to persist the checkpoint of the prober, ignoring error", "configMap", proberCheckpointConfigMapName)
+		return
+	}
+	p.checkpoint = checkpoint
+}
+
+func (p *Prober) newCheckpoint(now time.Time) *proberCheckpoint {
+	lastDecision, lastDecisionTime := p.getLastDecision()
+	checkpoint := &proberCheckpoint{
+		LastProbeTime:        *checkpointTime(now),
+		LastDecision:         lastDecision,
+		LastDecisionTime:     checkpointTime(lastDecisionTime),
+		DependentsScaledDown: p.AreDependentsScaledDown(),
+		BackOffUntil:         checkpointTime(p.backOffUntil),
+		WarmUpStartedAt:      *checkpointTime(p.warmUpStartedAt),
+	}
+	if !p.HasAPIServerProbeFailed() && !p.HasLeaseProbeFailed() {
+		checkpoint.LastHealthyTime = checkpointTime(now)
+	} else if p.checkpoint != nil {
+		checkpoint.LastHealthyTime = p.checkpoint.LastHealthyTime
+	}
+	return checkpoint
+}
+
+// isCheckpointOutdated checks if the given checkpoint differs from the one which has been persisted most recently in anything but the probe
+// times, or if the persisted checkpoint is due to be refreshed.
+func (p *Prober) isCheckpointOutdated(checkpoint *proberCheckpoint, now time.Time) bool {
+	if p.checkpoint == nil {
+		return true
+	}
+	refreshInterval := max(getDurationOrZero(p.config.WarmUpDuration)/2, minCheckpointRefreshInterval)
+	if now.Sub(p.checkpoint.LastProbeTime.Time) >= refreshInterval {
+		return true
+	}
+	persisted, current := *p.checkpoint, *checkpoint
+	persisted.LastProbeTime, current.LastProbeTime = metav1.Time{}, metav1.Time{}
+	persisted.LastHealthyTime, current.LastHealthyTime = nil, nil
+	return !reflect.DeepEqual(persisted, current)
+}
+
+// getCheckpoint returns the persisted checkpoint of the prober. If none has been persisted or it cannot be parsed then nil is returned. It is read
+// directly from the API server, as the checkpoint is restored before the cache of the seed client might have been synced.
+func (p *Prober) getCheckpoint(ctx context.Context) (*proberCheckpoint, error) {
+	cm := &corev1.ConfigMap{}
+	if err := p.seedReader.Get(ctx, client.ObjectKey{Namespace: p.namespace, Name: proberCheckpointConfigMapName}, cm); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	data, ok := cm.Data[proberCheckpointDataKey]
+	if !ok {
+		return nil, nil
+	}
+	checkpoint := &proberCheckpoint{}
+	if err := json.Unmarshal([]byte(data), checkpoint); err != nil {
+		p.l.Info("Discarding unparsable checkpoint of the prober", "configMap", proberCheckpointConfigMapName, "err", err.Error())
+		return nil, nil
+	}
+	return checkpoint, nil
+}
+
+func (p *Prober) writeCheckpoint(ctx context.Context, checkpoint *proberCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	if err = p.seedReader.Get(ctx, client.ObjectKey{Namespace: p.namespace, Name: proberCheckpointConfigMapName}, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: proberCheckpointConfigMapName, Namespace: p.namespace},
+			Data:       map[string]string{proberCheckpointDataKey: string(data)},
+		}
+		return p.seedClient.Create(ctx, cm)
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, 1)
+	}
+	cm.Data[proberCheckpointDataKey] = string(data)
+	return p.seedClient.Update(ctx, cm)
+}
+
+// checkpointTime returns the given time with the resolution of seconds with which it is persisted, or nil if it is the zero time.
+func checkpointTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &metav1.Time{Time: t.UTC().Truncate(time.Second)}
+}
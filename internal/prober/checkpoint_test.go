@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	k8sfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/k8s"
+	scalefakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/scale"
+	shootfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/shoot"
+	"github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCheckpointShouldBePersistedAndRestoredByNextProber(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, generateScaleTargetDeployments(1)).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.PersistCheckpoint = pointer.Bool(true)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	p.restoreCheckpoint(ctx)
+	g.Expect(p.checkpoint).To(BeNil(), "there should be no checkpoint to restore for the first prober")
+	p.probe(ctx)
+	p.inFlightScale.wait()
+	g.Expect(p.AreDependentsScaledDown()).To(BeTrue())
+	p.persistCheckpoint(ctx)
+	checkpoint := getCheckpoint(ctx, g, seedClient)
+	g.Expect(checkpoint.LastDecision).To(Equal(scaleDecisionOperationScaleDown))
+	g.Expect(checkpoint.LastDecisionTime).ToNot(BeNil())
+	g.Expect(checkpoint.DependentsScaledDown).To(BeTrue())
+	g.Expect(checkpoint.LastHealthyTime).To(BeNil(), "the shoot should not have been healthy as the lease probe has failed")
+	g.Expect(checkpoint.WarmUpStartedAt.Time).To(BeTemporally("==", p.warmUpStartedAt.Truncate(time.Second)))
+	p.Close()
+
+	restartedConfig := *config
+	restartedConfig.WarmUpDuration = &metav1.Duration{Duration: time.Hour}
+	restarted := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, &restartedConfig, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	defer restarted.Close()
+	restarted.restoreCheckpoint(ctx)
+	g.Expect(restarted.AreDependentsScaledDown()).To(BeTrue())
+	lastDecision, _ := restarted.getLastDecision()
+	g.Expect(lastDecision).To(Equal(scaleDecisionOperationScaleDown))
+	g.Expect(restarted.warmUpStartedAt).To(BeTemporally("==", checkpoint.WarmUpStartedAt.Time), "the warm-up of the previous prober should be continued")
+}
+
+func TestCheckpointShouldOnlyContinueRecentWarmUp(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	now := time.Now()
+	backOffUntil := metav1.NewTime(now.Add(time.Hour))
+	checkpoint := proberCheckpoint{
+		LastProbeTime:   metav1.NewTime(now.Add(-2 * time.Hour)),
+		BackOffUntil:    &backOffUntil,
+		WarmUpStartedAt: metav1.NewTime(now.Add(-3 * time.Hour)),
+	}
+	data, err := json.Marshal(checkpoint)
+	g.Expect(err).ToNot(HaveOccurred())
+	seedClient := initializeSeedClientBuilder(nil, nil, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: proberCheckpointConfigMapName, Namespace: test.DefaultNamespace},
+		Data:       map[string]string{proberCheckpointDataKey: string(data)},
+	}).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.PersistCheckpoint = pointer.Bool(true)
+	config.WarmUpDuration = &metav1.Duration{Duration: time.Hour}
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	p.restoreCheckpoint(ctx)
+	g.Expect(p.warmUpStartedAt).To(BeTemporally(">=", now), "a new warm-up should be started as the checkpoint is older than the warm-up duration")
+	g.Expect(p.IsInBackOff()).To(BeTrue(), "the backoff of the previous prober should be continued")
+	g.Expect(p.isWarmingUp()).To(BeTrue())
+}
+
+func TestCheckpointShouldOnlyBePersistedIfOutdated(t *testing.T) {
+	g := NewWithT(t)
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.WarmUpDuration = &metav1.Duration{Duration: time.Minute}
+	p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	now := time.Now()
+	g.Expect(p.isCheckpointOutdated(p.newCheckpoint(now), now)).To(BeTrue(), "a checkpoint should be persisted if none has been persisted yet")
+
+	p.checkpoint = p.newCheckpoint(now)
+	later := now.Add(10 * time.Second)
+	g.Expect(p.isCheckpointOutdated(p.newCheckpoint(later), later)).To(BeFalse(), "an unchanged checkpoint should not be persisted again before it is due to be refreshed")
+	later = now.Add(30 * time.Second)
+	g.Expect(p.isCheckpointOutdated(p.newCheckpoint(later), later)).To(BeTrue(), "an unchanged checkpoint should be refreshed after half of the warm-up duration")
+
+	p.setDependentsScaledDown(true)
+	later = now.Add(10 * time.Second)
+	g.Expect(p.isCheckpointOutdated(p.newCheckpoint(later), later)).To(BeTrue(), "a changed checkpoint should be persisted")
+}
+
+func getCheckpoint(ctx context.Context, g *WithT, seedClient client.Client) proberCheckpoint {
+	cm := &corev1.ConfigMap{}
+	g.Expect(seedClient.Get(ctx, client.ObjectKey{Namespace: test.DefaultNamespace, Name: proberCheckpointConfigMapName}, cm)).To(Succeed())
+	var checkpoint proberCheckpoint
+	g.Expect(json.Unmarshal([]byte(cm.Data[proberCheckpointDataKey]), &checkpoint)).To(Succeed())
+	return checkpoint
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// eventReasonScaleDownSuppressed is the reason of the event which is recorded when a scale-down has been suppressed by a ScaleDownCircuitBreaker.
+const eventReasonScaleDownSuppressed = "ScaleDownSuppressed"
+
+// ScaleDownCircuitBreaker guards scale-downs of the dependent resources of all shoots on a seed.
+type ScaleDownCircuitBreaker interface {
+	// ShouldSuppressScaleDown returns true if the scale-down of the dependent resources of the shoot with the given control plane namespace should be
+	// suppressed. A suppressed scale-down is recorded as an event for the shoot control plane namespace.
+	ShouldSuppressScaleDown(namespace string) bool
+}
+
+// seedMeltdownCircuitBreaker opens if the node lease probes of at least failureFraction of all the shoots probed by a Manager have failed. Such
+// simultaneous failures indicate a network or infrastructure problem of the seed rather than a problem of the kubelets of the individual shoots.
+type seedMeltdownCircuitBreaker struct {
+	mu              sync.Mutex
+	mgr             Manager
+	failureFraction float64
+	minShoots       int
+	recorder        record.EventRecorder
+	open            bool
+	l               logr.Logger
+}
+
+// NewSeedMeltdownCircuitBreaker creates a ScaleDownCircuitBreaker which suppresses scale-downs for all shoots probed by the given Manager while the
+// fraction of shoots with a failed node lease probe is at or above failureFraction. The circuit breaker is never open if less than minShoots are probed.
+func NewSeedMeltdownCircuitBreaker(mgr Manager, failureFraction float64, minShoots int, recorder record.EventRecorder, logger logr.Logger) ScaleDownCircuitBreaker {
+	return &seedMeltdownCircuitBreaker{
+		mgr:             mgr,
+		failureFraction: failureFraction,
+		minShoots:       minShoots,
+		recorder:        recorder,
+		l:               logger.WithName("seed-meltdown-circuit-breaker"),
+	}
+}
+
+func (cb *seedMeltdownCircuitBreaker) ShouldSuppressScaleDown(namespace string) bool {
+	summary := cb.mgr.GetSeedProbeSummary()
+	open := summary.Shoots > 0 && summary.Shoots >= cb.minShoots &&
+		float64(summary.ShootsWithFailedLeaseProbe)/float64(summary.Shoots) >= cb.failureFraction
+	cb.setOpen(open, summary)
+	if !open {
+		return false
+	}
+	metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonSeedMeltdown).Inc()
+	cb.recorder.Eventf(&corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: namespace, Namespace: namespace}, corev1.EventTypeWarning, eventReasonScaleDownSuppressed,
+		"Scale-down of dependent resources has been suppressed as the node lease probes of %d out of %d shoots on the seed have failed, which indicates a problem of the seed", summary.ShootsWithFailedLeaseProbe, summary.Shoots)
+	return true
+}
+
+func (cb *seedMeltdownCircuitBreaker) setOpen(open bool, summary SeedProbeSummary) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.open != open {
+		cb.l.Info(fmt.Sprintf("Seed meltdown circuit breaker is now %s", circuitBreakerStateName(open)), "shoots", summary.Shoots, "shootsWithFailedLeaseProbe", summary.ShootsWithFailedLeaseProbe, "failureFraction", cb.failureFraction)
+		cb.open = open
+	}
+	if open {
+		metrics.SeedMeltdownCircuitBreakerOpen.Set(1)
+	} else {
+		metrics.SeedMeltdownCircuitBreakerOpen.Set(0)
+	}
+}
+
+func circuitBreakerStateName(open bool) string {
+	if open {
+		return "open, scale-downs are suppressed"
+	}
+	return "closed, scale-downs are allowed"
+}
+
+// runtimeOverridesCircuitBreaker suppresses the scale-downs of all shoots while scale-downs have been disabled via the runtime overrides.
+type runtimeOverridesCircuitBreaker struct {
+	overrides *overrides.Overrides
+	recorder  record.EventRecorder
+}
+
+// NewRuntimeOverridesCircuitBreaker creates a ScaleDownCircuitBreaker which suppresses scale-downs for all shoots while they have been disabled
+// via the given runtime overrides.
+func NewRuntimeOverridesCircuitBreaker(overrides *overrides.Overrides, recorder record.EventRecorder) ScaleDownCircuitBreaker {
+	return &runtimeOverridesCircuitBreaker{overrides: overrides, recorder: recorder}
+}
+
+func (cb *runtimeOverridesCircuitBreaker) ShouldSuppressScaleDown(namespace string) bool {
+	if !cb.overrides.IsScaleDownDisabled() {
+		return false
+	}
+	metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonRuntimeOverride).Inc()
+	cb.recorder.Eventf(&corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: namespace, Namespace: namespace}, corev1.EventTypeWarning, eventReasonScaleDownSuppressed,
+		"Scale-down of dependent resources has been suppressed as scale-downs have been disabled via the runtime override %s", overrides.DisableScaleDownAnnotationKey)
+	return true
+}
+
+// anyScaleDownCircuitBreaker suppresses a scale-down if any of its circuit breakers suppresses it.
+type anyScaleDownCircuitBreaker []ScaleDownCircuitBreaker
+
+// CombineScaleDownCircuitBreakers creates a ScaleDownCircuitBreaker which suppresses a scale-down if any of the given circuit breakers suppresses
+// it. The circuit breakers are asked in the given order until the first one suppresses the scale-down. Circuit breakers which are nil are skipped,
+// nil is returned if all circuit breakers are nil.
+func CombineScaleDownCircuitBreakers(circuitBreakers ...ScaleDownCircuitBreaker) ScaleDownCircuitBreaker {
+	var combined anyScaleDownCircuitBreaker
+	for _, cb := range circuitBreakers {
+		if cb != nil {
+			combined = append(combined, cb)
+		}
+	}
+	switch len(combined) {
+	case 0:
+		return nil
+	case 1:
+		return combined[0]
+	default:
+		return combined
+	}
+}
+
+func (cbs anyScaleDownCircuitBreaker) ShouldSuppressScaleDown(namespace string) bool {
+	for _, cb := range cbs {
+		if cb.ShouldSuppressScaleDown(namespace) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSeedMeltdownCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name                 string
+		shoots               int
+		failedLeaseProbes    int
+		minShoots            int
+		expectedSuppressed   bool
+		expectedEventsLength int
+	}{
+		{"scale-down should not be suppressed if no lease probe has failed", 4, 0, 3, false, 0},
+		{"scale-down should not be suppressed if the fraction of failed lease probes is below the failure fraction", 4, 1, 3, false, 0},
+		{"scale-down should be suppressed if the fraction of failed lease probes reaches the failure fraction", 4, 2, 3, true, 1},
+		{"scale-down should not be suppressed if fewer shoots than minShoots are probed", 2, 2, 3, false, 0},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mgr := NewManager()
+			for i := 0; i < entry.shoots; i++ {
+				p := NewProber(context.Background(), nil, nil, fmt.Sprintf("shoot--p--s%d", i), &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+				p.setLeaseProbeFailed(i < entry.failedLeaseProbes)
+				g.Expect(mgr.Register(*p)).To(BeTrue())
+			}
+			recorder := record.NewFakeRecorder(10)
+			cb := NewSeedMeltdownCircuitBreaker(mgr, 0.5, entry.minShoots, recorder, pmLogger)
+			suppressedBefore := testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonSeedMeltdown))
+
+			g.Expect(cb.ShouldSuppressScaleDown("shoot--p--s0")).To(Equal(entry.expectedSuppressed))
+			g.Expect(recorder.Events).To(HaveLen(entry.expectedEventsLength))
+			if entry.expectedSuppressed {
+				g.Expect(<-recorder.Events).To(ContainSubstring(eventReasonScaleDownSuppressed))
+				g.Expect(testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonSeedMeltdown))).To(Equal(suppressedBefore + 1))
+				g.Expect(testutil.ToFloat64(metrics.SeedMeltdownCircuitBreakerOpen)).To(Equal(1.0))
+			} else {
+				g.Expect(testutil.ToFloat64(metrics.SeedMeltdownCircuitBreakerOpen)).To(Equal(0.0))
+			}
+		})
+	}
+}
+
+func TestRuntimeOverridesCircuitBreaker(t *testing.T) {
+	g := NewWithT(t)
+	runtimeOverrides := overrides.New(zap.NewAtomicLevel())
+	recorder := record.NewFakeRecorder(10)
+	cb := NewRuntimeOverridesCircuitBreaker(runtimeOverrides, recorder)
+	g.Expect(cb.ShouldSuppressScaleDown("shoot--p--s0")).To(BeFalse())
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	runtimeOverrides.Apply(map[string]string{overrides.DisableScaleDownAnnotationKey: "true"}, pmLogger)
+	suppressedBefore := testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonRuntimeOverride))
+	g.Expect(cb.ShouldSuppressScaleDown("shoot--p--s0")).To(BeTrue())
+	g.Expect(<-recorder.Events).To(ContainSubstring(eventReasonScaleDownSuppressed))
+	g.Expect(testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonRuntimeOverride))).To(Equal(suppressedBefore + 1))
+}
+
+func TestCombineScaleDownCircuitBreakers(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(CombineScaleDownCircuitBreakers(nil, nil)).To(BeNil())
+	g.Expect(CombineScaleDownCircuitBreakers(nil, openCircuitBreaker{})).To(Equal(openCircuitBreaker{}))
+	g.Expect(CombineScaleDownCircuitBreakers(closedCircuitBreaker{}, closedCircuitBreaker{}).ShouldSuppressScaleDown("shoot--p--s0")).To(BeFalse())
+	g.Expect(CombineScaleDownCircuitBreakers(closedCircuitBreaker{}, openCircuitBreaker{}).ShouldSuppressScaleDown("shoot--p--s0")).To(BeTrue())
+}
+
+type closedCircuitBreaker struct{}
+
+func (closedCircuitBreaker) ShouldSuppressScaleDown(_ string) bool {
+	return false
+}
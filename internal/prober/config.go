@@ -5,12 +5,18 @@
 package prober
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/prober/scaler"
 	"github.com/gardener/dependency-watchdog/internal/util"
+	multierr "github.com/hashicorp/go-multierror"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
@@ -36,12 +42,38 @@ const (
 	// See https://kubernetes.io/docs/reference/command-line-tools-reference/kube-controller-manager/#:~:text=%2D%2Dnode%2Dmonitor%2Dgrace%2Dperiod%20duration
 	// Note: Make sure to keep this value in sync with default value of nodeMonitorGracePeriod in KCM.
 	DefaultKCMNodeMonitorGraceDuration = 40 * time.Second
+	// DefaultMinNodeAge is the default minimum age of a node for its lease to be considered by the lease probe.
+	DefaultMinNodeAge = 2 * time.Minute
+	// DefaultMinNodeCountForScaling is the default minimum number of candidate nodes below which no dependent resources are scaled.
+	DefaultMinNodeCountForScaling = 2
+	// DefaultScaleDecisionLogSize is the default number of scale decisions that are recorded per shoot control plane namespace. A value of 0 disables recording.
+	DefaultScaleDecisionLogSize = 0
+	// DefaultThrottlingBackOff is the default duration for which the prober backs off after a request to the shoot or seed API server has been throttled.
+	DefaultThrottlingBackOff = 10 * time.Second
+	// DefaultSeedMeltdownMinShoots is the default minimum number of probed shoots on a seed for the seed meltdown circuit breaker to be considered.
+	DefaultSeedMeltdownMinShoots = 3
+)
+
+var (
+	// DefaultExcludedNodeTaintKeys are the default keys of taints which exclude a node from the lease probe.
+	DefaultExcludedNodeTaintKeys = []string{corev1.TaintNodeUnschedulable, util.ToBeDeletedByClusterAutoscalerTaintKey}
+	// DefaultExcludedNodeAnnotationKeys are the default keys of annotations which exclude a node from the lease probe.
+	DefaultExcludedNodeAnnotationKeys = []string{util.TriggerDeletionByMCMAnnotationKey}
 )
 
 // LoadConfig reads the prober configuration from a file, unmarshalls it, fills in the default values and
 // validates the unmarshalled configuration If all validations pass it will return papi.Config else it will return an error.
-func LoadConfig(file string, scheme *runtime.Scheme) (*papi.Config, error) {
-	config, err := util.ReadAndUnmarshall[papi.Config](file)
+// If strict is true then any field in the file which is not known to papi.Config will result in an error.
+func LoadConfig(file string, scheme *runtime.Scheme, strict bool) (*papi.Config, error) {
+	var (
+		config *papi.Config
+		err    error
+	)
+	if strict {
+		config, err = util.ReadAndUnmarshallStrict[papi.Config](file)
+	} else {
+		config, err = util.ReadAndUnmarshall[papi.Config](file)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -57,28 +89,174 @@ func validate(c *papi.Config, scheme *runtime.Scheme) error {
 	v := new(util.Validator)
 	// Check the mandatory config parameters for which a default will not be set
 	v.MustNotBeEmpty("KubeConfigSecretName", c.KubeConfigSecretName)
+	if c.KubeConfigTokenSecretName != nil {
+		v.MustNotBeEmpty("KubeConfigTokenSecretName", *c.KubeConfigTokenSecretName)
+	}
 	if c.KCMNodeMonitorGraceDuration != nil {
 		v.MustNotBeZeroDuration("KCMNodeMonitorGraceDuration", *c.KCMNodeMonitorGraceDuration)
 	}
+	if c.APIServerProbeTimeout != nil {
+		v.MustBePositiveDuration("APIServerProbeTimeout", *c.APIServerProbeTimeout)
+	}
+	for _, endpoint := range c.APIServerProbeEndpoints {
+		v.MustNotBeEmpty("APIServerProbeEndpoints.ServiceName", endpoint.ServiceName)
+		v.MustBeInRange("APIServerProbeEndpoints.Port", int(endpoint.Port), 1, 65535)
+	}
+	if c.APIServerProbeFailureQuorum != nil {
+		v.MustBeInRange("APIServerProbeFailureQuorum", *c.APIServerProbeFailureQuorum, 1, len(c.APIServerProbeEndpoints)+1)
+	}
+	if target := c.APIServerProbeTarget; target != nil {
+		if target.Host != "" {
+			v.MustBeHTTPSURL("APIServerProbeTarget.Host", target.Host)
+		}
+		if target.CABundle != "" {
+			v.MustBePEMEncodedCertificates("APIServerProbeTarget.CABundle", target.CABundle)
+		}
+		if target.Path != "" {
+			v.MustBeAbsolutePath("APIServerProbeTarget.Path", target.Path)
+		}
+	}
+	if c.LeaseProbeTimeout != nil {
+		v.MustBePositiveDuration("LeaseProbeTimeout", *c.LeaseProbeTimeout)
+	}
+	if c.ShootClientQPS != nil {
+		v.MustNotBeNegativeFloat("ShootClientQPS", *c.ShootClientQPS)
+	}
+	if c.ShootClientBurst != nil {
+		v.MustNotBeNegative("ShootClientBurst", *c.ShootClientBurst)
+	}
+	if c.ShootClientDialTimeout != nil {
+		v.MustBePositiveDuration("ShootClientDialTimeout", *c.ShootClientDialTimeout)
+	}
+	if c.ShootClientTLSHandshakeTimeout != nil {
+		v.MustBePositiveDuration("ShootClientTLSHandshakeTimeout", *c.ShootClientTLSHandshakeTimeout)
+	}
+	if c.ShootThrottlingBackOff != nil {
+		v.MustBePositiveDuration("ShootThrottlingBackOff", *c.ShootThrottlingBackOff)
+	}
+	if c.SeedThrottlingBackOff != nil {
+		v.MustBePositiveDuration("SeedThrottlingBackOff", *c.SeedThrottlingBackOff)
+	}
+	if c.WarmUpDuration != nil {
+		v.MustNotBeNegative("WarmUpDuration", int(c.WarmUpDuration.Duration))
+	}
+	if c.MinNodeAge != nil {
+		v.MustNotBeNegative("MinNodeAge", int(c.MinNodeAge.Duration))
+	}
+	if c.MinNodeCountForScaling != nil {
+		v.MustNotBeNegative("MinNodeCountForScaling", *c.MinNodeCountForScaling)
+	}
+	if c.KubeletHealthProbeSampleSize != nil {
+		v.MustNotBeNegative("KubeletHealthProbeSampleSize", *c.KubeletHealthProbeSampleSize)
+	}
+	if c.ScaleDecisionLogSize != nil {
+		v.MustNotBeNegative("ScaleDecisionLogSize", *c.ScaleDecisionLogSize)
+	}
+	if c.SeedMeltdownFailureFraction != nil {
+		v.MustBeFraction("SeedMeltdownFailureFraction", *c.SeedMeltdownFailureFraction)
+	}
+	if c.SeedMeltdownMinShoots != nil {
+		v.MustNotBeNegative("SeedMeltdownMinShoots", *c.SeedMeltdownMinShoots)
+	}
+	if c.ScaleFlowTimeout != nil {
+		v.MustBePositiveDuration("ScaleFlowTimeout", *c.ScaleFlowTimeout)
+	}
+	if c.ReassertScaleDownMinInterval != nil {
+		v.MustBePositiveDuration("ReassertScaleDownMinInterval", *c.ReassertScaleDownMinInterval)
+	}
+	if c.MaxConcurrentScalesPerLevel != nil {
+		v.MustNotBeNegative("MaxConcurrentScalesPerLevel", *c.MaxConcurrentScalesPerLevel)
+	}
+	if c.ReplicasAnnotationKey != nil {
+		v.MustBeQualifiedName("ReplicasAnnotationKey", *c.ReplicasAnnotationKey)
+	}
 	v.MustNotBeEmpty("ScaleResourceInfos", c.DependentResourceInfos)
 	for _, resInfo := range c.DependentResourceInfos {
 		v.ResourceRefMustBeValid(resInfo.Ref, scheme)
 		v.MustNotBeNil("scaleUp", resInfo.ScaleUpInfo)
 		v.MustNotBeNil("scaleDown", resInfo.ScaleDownInfo)
+		if resInfo.ScaleDownInfo != nil && resInfo.ScaleDownInfo.Replicas != nil {
+			v.MustNotBeNegative("scaleDown.replicas", int(*resInfo.ScaleDownInfo.Replicas))
+		}
 	}
+	validateDependentResourceOrdering(v, c.DependentResourceInfos)
 	if v.Error != nil {
 		return v.Error
 	}
 	return nil
 }
 
+// validateDependentResourceOrdering validates that every dependent resource is configured only once, as a resource which is configured more than
+// once would be ordered relative to itself, and that the levels of the scale-up and the scale-down each start at 0 and have no gaps, so that a
+// typo in a level, e.g. 10 instead of 1, does not silently change the order in which the dependent resources are scaled.
+func validateDependentResourceOrdering(v *util.Validator, resourceInfos []papi.DependentResourceInfo) {
+	refs := sets.New[string]()
+	scaleUpLevels, scaleDownLevels := sets.New[int](), sets.New[int]()
+	for _, resInfo := range resourceInfos {
+		if resInfo.Ref != nil {
+			ref := fmt.Sprintf("%s/%s/%s", resInfo.Ref.APIVersion, resInfo.Ref.Kind, resInfo.Ref.Name)
+			if refs.Has(ref) {
+				v.Error = multierr.Append(v.Error, fmt.Errorf("dependentResourceInfos: resource %s is configured more than once", ref))
+			}
+			refs.Insert(ref)
+		}
+		if resInfo.ScaleUpInfo != nil {
+			scaleUpLevels.Insert(resInfo.ScaleUpInfo.Level)
+		}
+		if resInfo.ScaleDownInfo != nil {
+			scaleDownLevels.Insert(resInfo.ScaleDownInfo.Level)
+		}
+	}
+	validateLevelsAreContiguous(v, "scaleUp", scaleUpLevels)
+	validateLevelsAreContiguous(v, "scaleDown", scaleDownLevels)
+}
+
+// validateLevelsAreContiguous validates that the given levels are 0 to n-1 for n distinct levels.
+func validateLevelsAreContiguous(v *util.Validator, direction string, levels sets.Set[int]) {
+	for _, level := range sets.List(levels) {
+		if level < 0 {
+			v.Error = multierr.Append(v.Error, fmt.Errorf("%s.level: level %d must not be negative", direction, level))
+			return
+		}
+	}
+	for level := 0; level < levels.Len(); level++ {
+		if !levels.Has(level) {
+			v.Error = multierr.Append(v.Error, fmt.Errorf("%s.level: levels must start at 0 and must not have gaps, level %d is missing but level %d is configured", direction, level, slices.Max(sets.List(levels))))
+			return
+		}
+	}
+}
+
 func fillDefaultValues(c *papi.Config) {
 	c.ProbeInterval = util.GetValOrDefault(c.ProbeInterval, metav1.Duration{Duration: DefaultProbeInterval})
 	c.InitialDelay = util.GetValOrDefault(c.InitialDelay, metav1.Duration{Duration: DefaultProbeInitialDelay})
 	c.ProbeTimeout = util.GetValOrDefault(c.ProbeTimeout, metav1.Duration{Duration: DefaultProbeTimeout})
+	c.APIServerProbeTimeout = util.GetValOrDefault(c.APIServerProbeTimeout, *c.ProbeTimeout)
+	c.LeaseProbeTimeout = util.GetValOrDefault(c.LeaseProbeTimeout, *c.ProbeTimeout)
+	if len(c.APIServerProbeEndpoints) > 0 {
+		// the server of the kubeconfig is always probed in addition to the configured endpoints, a majority of all probes has to fail by default
+		c.APIServerProbeFailureQuorum = util.GetValOrDefault(c.APIServerProbeFailureQuorum, (len(c.APIServerProbeEndpoints)+1)/2+1)
+	}
+	c.ShootThrottlingBackOff = util.GetValOrDefault(c.ShootThrottlingBackOff, metav1.Duration{Duration: DefaultThrottlingBackOff})
+	c.SeedThrottlingBackOff = util.GetValOrDefault(c.SeedThrottlingBackOff, metav1.Duration{Duration: DefaultThrottlingBackOff})
+	c.HonorRetryAfter = util.GetValOrDefault(c.HonorRetryAfter, true)
 	c.BackoffJitterFactor = util.GetValOrDefault(c.BackoffJitterFactor, DefaultBackoffJitterFactor)
 	c.NodeLeaseFailureFraction = util.GetValOrDefault(c.NodeLeaseFailureFraction, DefaultNodeLeaseFailureFraction)
+	c.MinNodeAge = util.GetValOrDefault(c.MinNodeAge, metav1.Duration{Duration: DefaultMinNodeAge})
+	c.MinNodeCountForScaling = util.GetValOrDefault(c.MinNodeCountForScaling, DefaultMinNodeCountForScaling)
+	// an explicitly configured empty list disables the exclusion and is therefore not defaulted
+	if c.ExcludedNodeTaintKeys == nil {
+		c.ExcludedNodeTaintKeys = DefaultExcludedNodeTaintKeys
+	}
+	if c.ExcludedNodeAnnotationKeys == nil {
+		c.ExcludedNodeAnnotationKeys = DefaultExcludedNodeAnnotationKeys
+	}
 	c.KCMNodeMonitorGraceDuration = util.GetValOrDefault(c.KCMNodeMonitorGraceDuration, metav1.Duration{Duration: DefaultKCMNodeMonitorGraceDuration})
+	c.WarmUpDuration = util.GetValOrDefault(c.WarmUpDuration, *c.KCMNodeMonitorGraceDuration)
+	c.ScaleDecisionLogSize = util.GetValOrDefault(c.ScaleDecisionLogSize, DefaultScaleDecisionLogSize)
+	c.SeedMeltdownMinShoots = util.GetValOrDefault(c.SeedMeltdownMinShoots, DefaultSeedMeltdownMinShoots)
+	c.ReplicasAnnotationKey = util.GetValOrDefault(c.ReplicasAnnotationKey, scaler.DefaultReplicasAnnotationKey)
+	c.DualWriteReplicasAnnotation = util.GetValOrDefault(c.DualWriteReplicasAnnotation, false)
 	fillDefaultValuesForResourceInfos(c.DependentResourceInfos)
 }
 
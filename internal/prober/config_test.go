@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/gardener/dependency-watchdog/internal/prober/scaler"
 	testutil "github.com/gardener/dependency-watchdog/internal/test"
 	multierr "github.com/hashicorp/go-multierror"
 	. "github.com/onsi/gomega"
@@ -33,6 +35,8 @@ func TestProberConfigSuite(t *testing.T) {
 		{"config file not found", testConfigFileNotFound},
 		{"invalid configuration yaml", testErrorInUnMarshallingYaml},
 		{"valid configuration yaml", testValidConfigShouldPassAllValidations},
+		{"unknown fields should error out in strict mode", testUnknownFieldsShouldReturnErrorInStrictMode},
+		{"unknown fields should be ignored in relaxed mode", testUnknownFieldsShouldBeIgnoredInRelaxedMode},
 	}
 
 	scheme := runtime.NewScheme()
@@ -52,7 +56,7 @@ func testCheckIfDefaultValuesAreSetForAllOptionalMissingValues(t *testing.T, s *
 
 	configPath := filepath.Join(testdataPath, "config_missing_voluntary_values.yaml")
 	testutil.ValidateIfFileExists(configPath, t)
-	config, err := LoadConfig(configPath, s)
+	config, err := LoadConfig(configPath, s, true)
 
 	g.Expect(err).ToNot(HaveOccurred(), "LoadConfig should not give any error for a valid config file")
 	g.Expect(config).ToNot(BeNil(), "LoadConfig should not return nil for a valid config file")
@@ -61,6 +65,22 @@ func testCheckIfDefaultValuesAreSetForAllOptionalMissingValues(t *testing.T, s *
 	g.Expect(*config.BackoffJitterFactor).To(Equal(DefaultBackoffJitterFactor), "LoadConfig should set jitter factor to DefaultJitterFactor if not set in the config file")
 	g.Expect(*config.NodeLeaseFailureFraction).To(Equal(DefaultNodeLeaseFailureFraction), "LoadConfig should set lease failure threshold fraction to DefaultNodeLeaseFailureFraction if not set in the config file")
 	g.Expect(config.KCMNodeMonitorGraceDuration.Milliseconds()).To(Equal(DefaultKCMNodeMonitorGraceDuration.Milliseconds()), "LoadConfig should set kcmNodeMonitorGraceDuration to DefaultKCMNodeMonitorGraceDuration if not set in the config file")
+	g.Expect(config.WarmUpDuration.Milliseconds()).To(Equal(DefaultKCMNodeMonitorGraceDuration.Milliseconds()), "LoadConfig should set warmUpDuration to kcmNodeMonitorGraceDuration if not set in the config file")
+	g.Expect(config.APIServerProbeTimeout.Milliseconds()).To(Equal(DefaultProbeTimeout.Milliseconds()), "LoadConfig should set apiServerProbeTimeout to probeTimeout if not set in the config file")
+	g.Expect(config.LeaseProbeTimeout.Milliseconds()).To(Equal(DefaultProbeTimeout.Milliseconds()), "LoadConfig should set leaseProbeTimeout to probeTimeout if not set in the config file")
+	g.Expect(*config.ScaleDecisionLogSize).To(Equal(DefaultScaleDecisionLogSize), "LoadConfig should set scaleDecisionLogSize to DefaultScaleDecisionLogSize if not set in the config file")
+	g.Expect(config.SeedMeltdownFailureFraction).To(BeNil(), "LoadConfig should not set seedMeltdownFailureFraction if not set in the config file")
+	g.Expect(*config.SeedMeltdownMinShoots).To(Equal(DefaultSeedMeltdownMinShoots), "LoadConfig should set seedMeltdownMinShoots to DefaultSeedMeltdownMinShoots if not set in the config file")
+	g.Expect(*config.ReplicasAnnotationKey).To(Equal(scaler.DefaultReplicasAnnotationKey), "LoadConfig should set replicasAnnotationKey to DefaultReplicasAnnotationKey if not set in the config file")
+	g.Expect(*config.DualWriteReplicasAnnotation).To(BeFalse(), "LoadConfig should disable dualWriteReplicasAnnotation if not set in the config file")
+	g.Expect(config.MinNodeAge.Milliseconds()).To(Equal(DefaultMinNodeAge.Milliseconds()), "LoadConfig should set minNodeAge to DefaultMinNodeAge if not set in the config file")
+	g.Expect(*config.MinNodeCountForScaling).To(Equal(DefaultMinNodeCountForScaling), "LoadConfig should set minNodeCountForScaling to DefaultMinNodeCountForScaling if not set in the config file")
+	g.Expect(config.ExcludedNodeTaintKeys).To(Equal(DefaultExcludedNodeTaintKeys), "LoadConfig should set excludedNodeTaintKeys to DefaultExcludedNodeTaintKeys if not set in the config file")
+	g.Expect(config.ExcludedNodeAnnotationKeys).To(Equal(DefaultExcludedNodeAnnotationKeys), "LoadConfig should set excludedNodeAnnotationKeys to DefaultExcludedNodeAnnotationKeys if not set in the config file")
+	g.Expect(config.ShootThrottlingBackOff.Milliseconds()).To(Equal(DefaultThrottlingBackOff.Milliseconds()), "LoadConfig should set shootThrottlingBackOff to DefaultThrottlingBackOff if not set in the config file")
+	g.Expect(config.SeedThrottlingBackOff.Milliseconds()).To(Equal(DefaultThrottlingBackOff.Milliseconds()), "LoadConfig should set seedThrottlingBackOff to DefaultThrottlingBackOff if not set in the config file")
+	g.Expect(*config.HonorRetryAfter).To(BeTrue(), "LoadConfig should enable honorRetryAfter if not set in the config file")
+	g.Expect(config.APIServerProbeFailureQuorum).To(BeNil(), "LoadConfig should not set apiServerProbeFailureQuorum if no apiServerProbeEndpoints are set in the config file")
 	for _, resInfo := range config.DependentResourceInfos {
 		g.Expect(resInfo.ScaleUpInfo.InitialDelay.Milliseconds()).To(Equal(DefaultScaleInitialDelay.Milliseconds()), fmt.Sprintf("LoadConfig should set scale up initial delay for %v to DefaultInitialDelay if not set in the config file", resInfo.Ref.Name))
 		g.Expect(resInfo.ScaleUpInfo.Timeout.Milliseconds()).To(Equal(DefaultScaleUpdateTimeout.Milliseconds()), fmt.Sprintf("LoadConfig should set scale up timeout for %v to DefaultScaleUpTimeout if not set in the config file", resInfo.Ref.Name))
@@ -77,6 +97,7 @@ func testMissingConfigValuesShouldReturnErrorAndNilConfig(t *testing.T, s *runti
 	}{
 		{"config_missing_mandatory_values.yaml", 5},
 		{"config_missing_dependent_resource_infos.yaml", 2},
+		{"config_invalid_dependent_resource_levels.yaml", 3},
 	}
 
 	for _, entry := range table {
@@ -84,7 +105,7 @@ func testMissingConfigValuesShouldReturnErrorAndNilConfig(t *testing.T, s *runti
 		testutil.ValidateIfFileExists(testdataPath, t)
 		configPath := filepath.Join(testdataPath, entry.fileName)
 		testutil.ValidateIfFileExists(configPath, t)
-		config, err := LoadConfig(configPath, s)
+		config, err := LoadConfig(configPath, s, true)
 
 		g.Expect(err).To(HaveOccurred(), "LoadConfig should return error for a config with missing mandatory values")
 		g.Expect(config).To(BeNil(), "LoadConfig should return a nil config for a file with missing mandatory values")
@@ -97,7 +118,7 @@ func testMissingConfigValuesShouldReturnErrorAndNilConfig(t *testing.T, s *runti
 
 func testConfigFileNotFound(t *testing.T, s *runtime.Scheme) {
 	g := NewWithT(t)
-	config, err := LoadConfig(filepath.Join(testdataPath, "notfound.yaml"), s)
+	config, err := LoadConfig(filepath.Join(testdataPath, "notfound.yaml"), s, true)
 	g.Expect(err).To(HaveOccurred(), "LoadConfig should give error if config file is not found")
 	g.Expect(config).To(BeNil(), "LoadConfig should return a nil config if config file is not found")
 	g.Expect(err.Error()).To(ContainSubstring("no such file or directory"), "LoadConfig did not load all the dependent resources")
@@ -109,7 +130,7 @@ func testErrorInUnMarshallingYaml(t *testing.T, s *runtime.Scheme) {
 
 	configPath := filepath.Join(testdataPath, "invalidsyntax.yaml")
 	testutil.ValidateIfFileExists(configPath, t)
-	config, err := LoadConfig(configPath, s)
+	config, err := LoadConfig(configPath, s, true)
 	g.Expect(err).To(HaveOccurred(), "LoadConfig should not give error for a valid config")
 	g.Expect(config).To(BeNil(), "LoadConfig should got nil config for a valid file")
 	g.Expect(err.Error()).To(ContainSubstring("cannot unmarshal string into Go struct field DependentResourceInfo.dependentResourceInfos.scaleUp"), "Wrong error recieved")
@@ -121,10 +142,32 @@ func testValidConfigShouldPassAllValidations(t *testing.T, s *runtime.Scheme) {
 
 	configPath := filepath.Join(testdataPath, "valid_config.yaml")
 	testutil.ValidateIfFileExists(configPath, t)
-	config, err := LoadConfig(configPath, s)
+	config, err := LoadConfig(configPath, s, true)
 	g.Expect(err).ToNot(HaveOccurred(), "LoadConfig should not give error for a valid config")
 	g.Expect(config).ToNot(BeNil(), "LoadConfig should got nil config for a valid file")
 	g.Expect(config.DependentResourceInfos).To(HaveLen(3), "LoadConfig did not load all the dependent resources")
+	g.Expect(config.LeaseProbeTimeout.Duration).To(Equal(time.Minute), "LoadConfig did not load the leaseProbeTimeout")
+	g.Expect(config.APIServerProbeTimeout.Duration).To(Equal(DefaultProbeTimeout), "LoadConfig should default apiServerProbeTimeout to probeTimeout")
 
 	t.Log("Valid config is loaded correctly")
 }
+
+func testUnknownFieldsShouldReturnErrorInStrictMode(t *testing.T, s *runtime.Scheme) {
+	g := NewWithT(t)
+	configPath := filepath.Join(testdataPath, "config_with_unknown_fields.yaml")
+	testutil.ValidateIfFileExists(configPath, t)
+	config, err := LoadConfig(configPath, s, true)
+	g.Expect(err).To(HaveOccurred(), "LoadConfig should return error for a config with unknown fields in strict mode")
+	g.Expect(config).To(BeNil(), "LoadConfig should return a nil config for a config with unknown fields in strict mode")
+	g.Expect(err.Error()).To(ContainSubstring("unknown field \"probeIntervall\""), "LoadConfig should report the unknown field")
+}
+
+func testUnknownFieldsShouldBeIgnoredInRelaxedMode(t *testing.T, s *runtime.Scheme) {
+	g := NewWithT(t)
+	configPath := filepath.Join(testdataPath, "config_with_unknown_fields.yaml")
+	testutil.ValidateIfFileExists(configPath, t)
+	config, err := LoadConfig(configPath, s, false)
+	g.Expect(err).ToNot(HaveOccurred(), "LoadConfig should ignore unknown fields in relaxed mode")
+	g.Expect(config).ToNot(BeNil(), "LoadConfig should return a config when unknown fields are ignored")
+	g.Expect(config.ProbeInterval.Duration).To(Equal(DefaultProbeInterval), "LoadConfig should use the default for a field which has been mis-typed")
+}
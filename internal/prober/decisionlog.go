@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// scaleDecisionLogConfigMapName is the name of the ConfigMap in the shoot control plane namespace in which the most recent scale decisions are recorded.
+	scaleDecisionLogConfigMapName = "dependency-watchdog-scale-decisions"
+	// scaleDecisionLogDataKey is the key in the ConfigMap data under which the scale decisions are stored as a JSON array.
+	scaleDecisionLogDataKey = "decisions"
+
+	scaleDecisionOperationScaleUp   = "ScaleUp"
+	scaleDecisionOperationScaleDown = "ScaleDown"
+	// maxExpiredNodeNamesInScaleDecision bounds the sample of the names of the nodes with expired leases which is recorded with a scale-down
+	// decision, so that events and the scale decision log stay small for large shoots.
+	maxExpiredNodeNamesInScaleDecision = 10
+)
+
+// scaleDecision captures a scale decision taken by the prober along with the inputs that led to it.
+type scaleDecision struct {
+	// Time is the time at which the decision was taken.
+	Time metav1.Time `json:"time"`
+	// Operation is the scale operation that has been triggered.
+	Operation string `json:"operation"`
+	// TotalNodeCount is the total number of nodes in the shoot.
+	TotalNodeCount int `json:"totalNodeCount"`
+	// CandidateNodeCount is the number of nodes that have been considered for the lease probe after filtering.
+	CandidateNodeCount int `json:"candidateNodeCount"`
+	// CandidateNodeLeaseCount is the number of node leases that have been considered for the lease probe.
+	CandidateNodeLeaseCount int `json:"candidateNodeLeaseCount"`
+	// ExpiredNodeLeaseCount is the number of candidate node leases which have expired.
+	ExpiredNodeLeaseCount int `json:"expiredNodeLeaseCount"`
+	// ExpiredNodeLeaseFraction is the fraction of the candidate node leases which have expired.
+	ExpiredNodeLeaseFraction float64 `json:"expiredNodeLeaseFraction"`
+	// NodeLeaseFailureFraction is the configured fraction of expired node leases at or above which the lease probe fails.
+	NodeLeaseFailureFraction float64 `json:"nodeLeaseFailureFraction"`
+	// ExpiredNodeNames is a sample of at most maxExpiredNodeNamesInScaleDecision names of the nodes whose leases have expired. It is only recorded
+	// for scale-down decisions.
+	ExpiredNodeNames []string `json:"expiredNodeNames,omitempty"`
+	// Error is the error, if any, that was returned by the scale operation.
+	Error string `json:"error,omitempty"`
+}
+
+// recordScaleDecision appends the decision to the scale decision log ConfigMap of the shoot control plane namespace, retaining
+// only the last ScaleDecisionLogSize decisions. A decision is only recorded if it differs from the one which has been recorded most recently,
+// so that the scale-up which is triggered by every successful probe does not push the evidence of a scale-down out of the log. Recording is
+// best-effort, any error is logged and otherwise ignored.
+func (p *Prober) recordScaleDecision(ctx context.Context, decision scaleDecision) {
+	if p.config.ScaleDecisionLogSize == nil || *p.config.ScaleDecisionLogSize <= 0 {
+		return
+	}
+	if decision.key() == p.getLastRecordedScaleDecision() {
+		return
+	}
+	if err := p.appendScaleDecision(ctx, decision); err != nil {
+		p.setBackOffIfSeedThrottlingError(err)
+		p.l.Error(err, "Failed to record scale decision, ignoring error", "configMap", scaleDecisionLogConfigMapName)
+		return
+	}
+	p.setLastRecordedScaleDecision(decision.key())
+}
+
+// getLastRecordedScaleDecision returns the key of the scale decision which has been recorded most recently. Until a decision has been recorded,
+// the one of a successful scale-up is returned as the dependent resources are initially not scaled down.
+func (p *Prober) getLastRecordedScaleDecision() string {
+	p.status.RLock()
+	defer p.status.RUnlock()
+	if p.status.lastRecordedScaleDecision == "" {
+		return scaleDecision{Operation: scaleDecisionOperationScaleUp}.key()
+	}
+	return p.status.lastRecordedScaleDecision
+}
+
+func (p *Prober) setLastRecordedScaleDecision(key string) {
+	p.status.Lock()
+	defer p.status.Unlock()
+	p.status.lastRecordedScaleDecision = key
+}
+
+func (p *Prober) appendScaleDecision(ctx context.Context, decision scaleDecision) error {
+	cm := &corev1.ConfigMap{}
+	err := p.seedReader.Get(ctx, client.ObjectKey{Namespace: p.namespace, Name: scaleDecisionLogConfigMapName}, cm)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	notFound := err != nil
+
+	var decisions []scaleDecision
+	if data, ok := cm.Data[scaleDecisionLogDataKey]; ok {
+		if err = json.Unmarshal([]byte(data), &decisions); err != nil {
+			p.l.Info("Discarding unparsable scale decision log", "configMap", scaleDecisionLogConfigMapName, "err", err.Error())
+			decisions = nil
+		}
+	}
+	decisions = append(decisions, decision)
+	if maxSize := *p.config.ScaleDecisionLogSize; len(decisions) > maxSize {
+		decisions = decisions[len(decisions)-maxSize:]
+	}
+	data, err := json.Marshal(decisions)
+	if err != nil {
+		return err
+	}
+
+	if notFound {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: scaleDecisionLogConfigMapName, Namespace: p.namespace},
+			Data:       map[string]string{scaleDecisionLogDataKey: string(data)},
+		}
+		return p.seedClient.Create(ctx, cm)
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, 1)
+	}
+	cm.Data[scaleDecisionLogDataKey] = string(data)
+	return p.seedClient.Update(ctx, cm)
+}
+
+func newScaleDecision(operation string, result nodeLeaseProbeResult, expiredNodeLeaseCount int, nodeLeaseFailureFraction float64, err error) scaleDecision {
+	decision := scaleDecision{
+		Time:                     metav1.NewTime(time.Now().UTC()),
+		Operation:                operation,
+		TotalNodeCount:           result.totalNodeCount,
+		CandidateNodeCount:       result.candidateNodeCount,
+		CandidateNodeLeaseCount:  len(result.candidateNodeLeases),
+		ExpiredNodeLeaseCount:    expiredNodeLeaseCount,
+		ExpiredNodeLeaseFraction: expiredFraction(len(result.candidateNodeLeases), expiredNodeLeaseCount),
+		NodeLeaseFailureFraction: nodeLeaseFailureFraction,
+	}
+	if err != nil {
+		decision.Error = err.Error()
+	}
+	return decision
+}
+
+// key identifies a scale decision by its operation and error, consecutive decisions with the same key are only recorded once.
+func (d scaleDecision) key() string {
+	return d.Operation + "/" + d.Error
+}
+
+// explain returns a human-readable explanation of a scale-down decision, which names the fraction of expired node leases that has led to it as well
+// as the sample of the nodes with expired leases.
+func (d scaleDecision) explain() string {
+	explanation := fmt.Sprintf("%d of %d candidate node leases (%.0f%%) have expired, which is at or above the node lease failure fraction of %.0f%%",
+		d.ExpiredNodeLeaseCount, d.CandidateNodeLeaseCount, d.ExpiredNodeLeaseFraction*100, d.NodeLeaseFailureFraction*100)
+	if len(d.ExpiredNodeNames) == 0 {
+		return explanation
+	}
+	explanation += ". Nodes with expired leases: " + strings.Join(d.ExpiredNodeNames, ", ")
+	if more := d.ExpiredNodeLeaseCount - len(d.ExpiredNodeNames); more > 0 {
+		explanation += fmt.Sprintf(" and %d more", more)
+	}
+	return explanation
+}
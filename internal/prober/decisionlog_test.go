@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	k8sfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/k8s"
+	scalefakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/scale"
+	shootfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/shoot"
+	"github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestScaleDecisionShouldBeRecordedOnLeaseProbeFailure(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{
+		{Name: test.Node1Name, IsExpired: true},
+		{Name: test.Node2Name, IsExpired: true},
+	})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, generateScaleTargetDeployments(1)).Build()
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.ScaleDecisionLogSize = pointer.Int(2)
+
+	recorder := record.NewFakeRecorder(10)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, recorder, logr.Discard())
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+
+	decisions := getScaleDecisions(ctx, g, seedClient)
+	g.Expect(decisions).ToNot(BeEmpty())
+	g.Expect(len(decisions)).To(BeNumerically("<=", 2))
+	lastDecision := decisions[len(decisions)-1]
+	g.Expect(lastDecision.Operation).To(Equal(scaleDecisionOperationScaleDown))
+	g.Expect(lastDecision.TotalNodeCount).To(Equal(2))
+	g.Expect(lastDecision.CandidateNodeCount).To(Equal(2))
+	g.Expect(lastDecision.CandidateNodeLeaseCount).To(Equal(2))
+	g.Expect(lastDecision.ExpiredNodeLeaseCount).To(Equal(2))
+	g.Expect(lastDecision.ExpiredNodeLeaseFraction).To(Equal(1.0))
+	g.Expect(lastDecision.NodeLeaseFailureFraction).To(Equal(DefaultNodeLeaseFailureFraction))
+	g.Expect(lastDecision.ExpiredNodeNames).To(ConsistOf(test.Node1Name, test.Node2Name))
+	g.Expect(lastDecision.Error).To(BeEmpty())
+	g.Expect(recorder.Events).To(Receive(And(
+		ContainSubstring(eventReasonScaledDown),
+		ContainSubstring("2 of 2 candidate node leases (100%) have expired"),
+		ContainSubstring(test.Node1Name),
+	)))
+}
+
+func TestScaleDecisionExplanationShouldBoundTheExpiredNodeNames(t *testing.T) {
+	g := NewWithT(t)
+	decision := scaleDecision{
+		CandidateNodeLeaseCount:  20,
+		ExpiredNodeLeaseCount:    12,
+		ExpiredNodeLeaseFraction: 0.6,
+		NodeLeaseFailureFraction: 0.5,
+		ExpiredNodeNames:         []string{"node-1", "node-2"},
+	}
+	g.Expect(decision.explain()).To(Equal("12 of 20 candidate node leases (60%) have expired, which is at or above the node lease failure fraction of 50%. " +
+		"Nodes with expired leases: node-1, node-2 and 10 more"))
+
+	decision.ExpiredNodeNames = nil
+	g.Expect(decision.explain()).To(Equal("12 of 20 candidate node leases (60%) have expired, which is at or above the node lease failure fraction of 50%"))
+}
+
+func TestScaleDecisionLogShouldRetainOnlyConfiguredNumberOfDecisions(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	seedClient := initializeSeedClientBuilder(nil, nil).Build()
+	config := createConfig(testProbeInterval, testProbeInterval, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.ScaleDecisionLogSize = pointer.Int(2)
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+
+	result := nodeLeaseProbeResult{totalNodeCount: 3, candidateNodeCount: 3}
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleDown, result, 3, 0.6, nil))
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleDown, result, 3, 0.6, errors.New("scale down failed")))
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleUp, result, 0, 0.6, nil))
+
+	decisions := getScaleDecisions(ctx, g, seedClient)
+	g.Expect(decisions).To(HaveLen(2))
+	g.Expect(decisions[0].Operation).To(Equal(scaleDecisionOperationScaleDown))
+	g.Expect(decisions[0].Error).To(Equal("scale down failed"))
+	g.Expect(decisions[1].Operation).To(Equal(scaleDecisionOperationScaleUp))
+}
+
+func TestScaleDecisionShouldOnlyBeRecordedIfItHasChanged(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	seedClient := initializeSeedClientBuilder(nil, nil).Build()
+	config := createConfig(testProbeInterval, testProbeInterval, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.ScaleDecisionLogSize = pointer.Int(5)
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+
+	result := nodeLeaseProbeResult{totalNodeCount: 3, candidateNodeCount: 3}
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleUp, result, 0, 0.6, nil))
+	err := seedClient.Get(ctx, client.ObjectKey{Namespace: test.DefaultNamespace, Name: scaleDecisionLogConfigMapName}, &corev1.ConfigMap{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "a scale-up of dependent resources which have not been scaled down should not be recorded")
+
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleDown, result, 3, 0.6, nil))
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleDown, result, 3, 0.6, nil))
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleUp, result, 0, 0.6, nil))
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleUp, result, 0, 0.6, nil))
+
+	decisions := getScaleDecisions(ctx, g, seedClient)
+	g.Expect(decisions).To(HaveLen(2))
+	g.Expect(decisions[0].Operation).To(Equal(scaleDecisionOperationScaleDown))
+	g.Expect(decisions[1].Operation).To(Equal(scaleDecisionOperationScaleUp))
+}
+
+func TestScaleDecisionShouldNotBeRecordedIfDisabled(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	seedClient := initializeSeedClientBuilder(nil, nil).Build()
+	config := createConfig(testProbeInterval, testProbeInterval, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.ScaleDecisionLogSize = pointer.Int(0)
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+
+	p.recordScaleDecision(ctx, newScaleDecision(scaleDecisionOperationScaleUp, nodeLeaseProbeResult{}, 0, 0.6, nil))
+
+	err := seedClient.Get(ctx, client.ObjectKey{Namespace: test.DefaultNamespace, Name: scaleDecisionLogConfigMapName}, &corev1.ConfigMap{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
+
+func getScaleDecisions(ctx context.Context, g *WithT, seedClient client.Client) []scaleDecision {
+	cm := &corev1.ConfigMap{}
+	g.Expect(seedClient.Get(ctx, client.ObjectKey{Namespace: test.DefaultNamespace, Name: scaleDecisionLogConfigMapName}, cm)).To(Succeed())
+	var decisions []scaleDecision
+	g.Expect(json.Unmarshal([]byte(cm.Data[scaleDecisionLogDataKey]), &decisions)).To(Succeed())
+	return decisions
+}
@@ -10,12 +10,23 @@ const (
 	ErrProbeAPIServer = "ERR_PROBE_API_SERVER"
 	// ErrSetupProbeClient is the error code for errors in setting up the probe client.
 	ErrSetupProbeClient = "ERR_SETUP_PROBE_CLIENT"
+	// ErrProbeUnauthorized is the error code for probe errors caused by the shoot rejecting the credentials of the prober.
+	ErrProbeUnauthorized = "ERR_PROBE_UNAUTHORIZED"
+	// ErrProbeForbidden is the error code for probe errors caused by missing RBAC permissions of the prober.
+	ErrProbeForbidden = "ERR_PROBE_FORBIDDEN"
 	// ErrProbeNodeLease is the error code for errors in the node lease probe.
 	ErrProbeNodeLease = "ERR_PROBE_NODE_LEASE"
+	// ErrGetNamespace is the error code for errors in getting the shoot control namespace from the seed.
+	ErrGetNamespace = "ERR_GET_NAMESPACE"
+	// ErrClaimNamespace is the error code for errors in claiming the shoot control namespace for the prober.
+	ErrClaimNamespace = "ERR_CLAIM_NAMESPACE"
 	// ErrScaleUp is the error code for errors in scaling up the dependent resources
 	ErrScaleUp = "ERR_SCALE_UP"
 	// ErrScaleDown is the error code for errors in scaling down the dependent resources
 	ErrScaleDown = "ERR_SCALE_DOWN"
+	// ErrScaleRejected is the error code for scale-ups or scale-downs which have failed as the update of a scale subresource has been rejected by
+	// its server-side dry-run, e.g. by an admission webhook.
+	ErrScaleRejected = "ERR_SCALE_REJECTED"
 )
 
 // ProbeError is the error type for probe errors. It contains the error code, the cause of the error, and the error message.
@@ -6,6 +6,9 @@ package k8s
 
 import (
 	"context"
+	"strings"
+	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -22,8 +25,18 @@ import (
 type ClientMethod string
 
 const (
+	// ClientMethodGet is the name of the Get method on client.Client.
+	ClientMethodGet ClientMethod = "Get"
 	// ClientMethodList is the name of the List method on client.Client.
 	ClientMethodList ClientMethod = "List"
+	// ClientMethodCreate is the name of the Create method on client.Client.
+	ClientMethodCreate ClientMethod = "Create"
+	// ClientMethodUpdate is the name of the Update method on client.Client.
+	ClientMethodUpdate ClientMethod = "Update"
+	// ClientMethodPatch is the name of the Patch method on client.Client.
+	ClientMethodPatch ClientMethod = "Patch"
+	// ClientMethodDelete is the name of the Delete method on client.Client.
+	ClientMethodDelete ClientMethod = "Delete"
 )
 
 // errorRecord contains the recorded error for a specific client.Client method and identifiers such as name, namespace and matching labels.
@@ -43,10 +56,18 @@ type ErrorsForGVK struct {
 	ListErr      *apierrors.StatusError
 }
 
-// FakeClientBuilder builds a client.Client which will also react to the configured errors.
+// callKey identifies the calls of a client.Client method for objects of a GroupVersionKind.
+type callKey struct {
+	method ClientMethod
+	gvk    schema.GroupVersionKind
+}
+
+// FakeClientBuilder builds a client.Client which will also react to the configured errors and latencies.
 type FakeClientBuilder struct {
 	errorRecords    []errorRecord
+	latencies       map[schema.GroupVersionKind]time.Duration
 	existingObjects []client.Object
+	statusObjects   []client.Object
 	scheme          *runtime.Scheme
 }
 
@@ -72,32 +93,72 @@ func (b *FakeClientBuilder) RecordErrorForObjectsWithGVK(method ClientMethod, na
 	return b
 }
 
+// WithLatencyForGVK delays every call of the client for objects of the given GroupVersionKind by the given latency, which simulates a slow API
+// server. For a List call the GroupVersionKind of the items of the list is considered. A call returns the error of its context if the context is
+// done before the latency has passed.
+func (b *FakeClientBuilder) WithLatencyForGVK(gvk schema.GroupVersionKind, latency time.Duration) *FakeClientBuilder {
+	if b.latencies == nil {
+		b.latencies = make(map[schema.GroupVersionKind]time.Duration)
+	}
+	b.latencies[gvk] = latency
+	return b
+}
+
 // WithScheme sets the scheme for the client.
 func (b *FakeClientBuilder) WithScheme(scheme *runtime.Scheme) *FakeClientBuilder {
 	b.scheme = scheme
 	return b
 }
 
-// Build creates a new instance of client.Client which will react to the configured errors.
-func (b *FakeClientBuilder) Build() client.Client {
+// WithStatusSubresource configures the kinds of the given objects to have a status subresource, which is then only changed via the status writer.
+func (b *FakeClientBuilder) WithStatusSubresource(objs ...client.Object) *FakeClientBuilder {
+	b.statusObjects = append(b.statusObjects, objs...)
+	return b
+}
+
+// Build creates a new instance of FakeClient which will react to the configured errors and latencies.
+func (b *FakeClientBuilder) Build() *FakeClient {
 	if b.scheme == nil {
 		b.scheme = scheme.Scheme
 	}
-	return &fakeClient{
-		Client:       fake.NewClientBuilder().WithObjects(b.existingObjects...).WithScheme(b.scheme).Build(),
+	return &FakeClient{
+		Client:       fake.NewClientBuilder().WithObjects(b.existingObjects...).WithStatusSubresource(b.statusObjects...).WithScheme(b.scheme).Build(),
 		errorRecords: b.errorRecords,
+		latencies:    b.latencies,
+		callCounts:   make(map[callKey]int),
 	}
 }
 
-// fakeClient is a client.Client implementation which reacts to the configured errors.
-type fakeClient struct {
+// FakeClient is a client.Client implementation which reacts to the configured errors and latencies. It additionally tracks the number of calls
+// of its methods per GroupVersionKind, see CallCount.
+type FakeClient struct {
 	client.Client
 	errorRecords []errorRecord
+	latencies    map[schema.GroupVersionKind]time.Duration
+	mu           sync.Mutex
+	callCounts   map[callKey]int
+}
+
+// CallCount returns the number of calls of the given method for objects of the given GroupVersionKind. For List calls the GroupVersionKind of
+// the items of the list is considered.
+func (c *FakeClient) CallCount(method ClientMethod, gvk schema.GroupVersionKind) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.callCounts[callKey{method: method, gvk: gvk}]
 }
 
 // ---------------------------------- Implementation of client.Client ----------------------------------
 
-func (c *fakeClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+// Get gets the object from the underlying fake client after the configured latency.
+func (c *FakeClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.trackCall(ctx, ClientMethodGet, obj); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+// List lists the objects from the underlying fake client after the configured latency, unless an error has been recorded for them.
+func (c *FakeClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
 	listOpts := client.ListOptions{}
 	listOpts.ApplyOptions(opts)
 
@@ -105,6 +166,9 @@ func (c *fakeClient) List(ctx context.Context, list client.ObjectList, opts ...c
 	if err != nil {
 		return err
 	}
+	if err := c.trackCall(ctx, ClientMethodList, list); err != nil {
+		return err
+	}
 
 	if err := c.getRecordedObjectCollectionError(ClientMethodList, listOpts.Namespace, listOpts.LabelSelector, gvk); err != nil {
 		return err
@@ -112,9 +176,69 @@ func (c *fakeClient) List(ctx context.Context, list client.ObjectList, opts ...c
 	return c.Client.List(ctx, list, opts...)
 }
 
+// Create creates the object with the underlying fake client after the configured latency.
+func (c *FakeClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.trackCall(ctx, ClientMethodCreate, obj); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Update updates the object with the underlying fake client after the configured latency.
+func (c *FakeClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.trackCall(ctx, ClientMethodUpdate, obj); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Patch patches the object with the underlying fake client after the configured latency.
+func (c *FakeClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.trackCall(ctx, ClientMethodPatch, obj); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete deletes the object with the underlying fake client after the configured latency.
+func (c *FakeClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.trackCall(ctx, ClientMethodDelete, obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
 // ---------------------------------- Helper methods ----------------------------------
 
-func (c *fakeClient) getRecordedObjectCollectionError(method ClientMethod, namespace string, labelSelector labels.Selector, objGVK schema.GroupVersionKind) error {
+// trackCall counts the call of the given method for the given object and delays it by the latency configured for the GroupVersionKind of the
+// object. It returns the error of the context if the context is done before the latency has passed.
+func (c *FakeClient) trackCall(ctx context.Context, method ClientMethod, obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	if _, isList := obj.(client.ObjectList); isList {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	c.mu.Lock()
+	c.callCounts[callKey{method: method, gvk: gvk}]++
+	c.mu.Unlock()
+
+	latency, ok := c.latencies[gvk]
+	if !ok {
+		return nil
+	}
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *FakeClient) getRecordedObjectCollectionError(method ClientMethod, namespace string, labelSelector labels.Selector, objGVK schema.GroupVersionKind) error {
 	for _, errRecord := range c.errorRecords {
 		if errRecord.method == method && errRecord.resourceNamespace == namespace &&
 			(errRecord.resourceGVK == objGVK || // if the GVK is set, we need to match it
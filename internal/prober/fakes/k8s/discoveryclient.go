@@ -4,12 +4,14 @@ import (
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 )
 
 // discoveryClient is a test implementation of DiscoveryInterface.
 type discoveryClient struct {
 	discovery.DiscoveryInterface
-	err error
+	err        error
+	restClient rest.Interface
 }
 
 // ServerVersion is the implementation of the DiscoveryInterface method for discoveryClient
@@ -20,6 +22,11 @@ func (t *discoveryClient) ServerVersion() (*version.Info, error) {
 	return t.DiscoveryInterface.ServerVersion()
 }
 
+// RESTClient is the implementation of the DiscoveryInterface method for discoveryClient
+func (t *discoveryClient) RESTClient() rest.Interface {
+	return t.restClient
+}
+
 // NewFakeDiscoveryClient creates a new DiscoveryClient.
 func NewFakeDiscoveryClient(err error) discovery.DiscoveryInterface {
 	return &discoveryClient{
@@ -27,3 +34,11 @@ func NewFakeDiscoveryClient(err error) discovery.DiscoveryInterface {
 		err:                err,
 	}
 }
+
+// NewFakeDiscoveryClientWithRESTClient creates a new DiscoveryClient which returns the given rest.Interface for raw requests.
+func NewFakeDiscoveryClientWithRESTClient(restClient rest.Interface) discovery.DiscoveryInterface {
+	return &discoveryClient{
+		DiscoveryInterface: fake.NewSimpleClientset().Discovery(),
+		restClient:         restClient,
+	}
+}
@@ -3,6 +3,7 @@ package scale
 import (
 	"context"
 
+	papi "github.com/gardener/dependency-watchdog/api/prober"
 	"github.com/gardener/dependency-watchdog/internal/prober/scaler"
 	"github.com/gardener/dependency-watchdog/internal/test"
 	appsv1 "k8s.io/api/apps/v1"
@@ -66,6 +67,8 @@ func (f *fakeScaler) ScaleDown(ctx context.Context) error {
 	return nil
 }
 
+func (f *fakeScaler) Rebuild(_ []papi.DependentResourceInfo) {}
+
 func (f *fakeScaler) doScale(ctx context.Context, ref client.ObjectKey, replicas int32) error {
 	deploy := &appsv1.Deployment{}
 	if err := f.client.Get(ctx, ref, deploy); err != nil {
@@ -2,9 +2,11 @@ package shoot
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/gardener/dependency-watchdog/internal/prober/shoot"
+	"github.com/gardener/dependency-watchdog/internal/util"
 	"github.com/go-logr/logr"
 	"k8s.io/client-go/discovery"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -15,6 +17,10 @@ type shootClientCreator struct {
 	client                       client.Client
 	discoveryClientCreationError error
 	clientCreationError          error
+	discoveryClientsForHosts     map[string]discovery.DiscoveryInterface
+	cacheInvalidations           int
+	mu                           sync.Mutex
+	discoveryClientOverrides     []util.ConnectionOverrides
 }
 
 type shootClientBuilder struct {
@@ -37,6 +43,15 @@ func (s *shootClientBuilder) WithDiscoveryClientCreationError(err error) *shootC
 	return s
 }
 
+// WithDiscoveryClientForHost sets the discovery client to be returned when creating a discovery client whose overrides have the given host.
+func (s *shootClientBuilder) WithDiscoveryClientForHost(host string, discoveryClient discovery.DiscoveryInterface) *shootClientBuilder {
+	if s.shootClientCreator.discoveryClientsForHosts == nil {
+		s.shootClientCreator.discoveryClientsForHosts = make(map[string]discovery.DiscoveryInterface)
+	}
+	s.shootClientCreator.discoveryClientsForHosts[host] = discoveryClient
+	return s
+}
+
 // WithClientCreationError sets the error to be returned when creating a client.
 func (s *shootClientBuilder) WithClientCreationError(err error) *shootClientBuilder {
 	s.shootClientCreator.clientCreationError = err
@@ -63,3 +78,33 @@ func (s *shootClientCreator) CreateDiscoveryClient(_ context.Context, _ logr.Log
 	}
 	return s.discoveryClient, nil
 }
+
+func (s *shootClientCreator) CreateDiscoveryClientWithOverrides(_ context.Context, _ logr.Logger, _ time.Duration, overrides util.ConnectionOverrides) (discovery.DiscoveryInterface, error) {
+	if s.discoveryClientCreationError != nil {
+		return nil, s.discoveryClientCreationError
+	}
+	s.mu.Lock()
+	s.discoveryClientOverrides = append(s.discoveryClientOverrides, overrides)
+	s.mu.Unlock()
+	if discoveryClient, ok := s.discoveryClientsForHosts[overrides.Host]; ok {
+		return discoveryClient, nil
+	}
+	return s.discoveryClient, nil
+}
+
+// InvalidateCache counts the number of times the cache has been invalidated.
+func (s *shootClientCreator) InvalidateCache() {
+	s.cacheInvalidations++
+}
+
+// CacheInvalidations returns the number of times InvalidateCache has been called.
+func (s *shootClientCreator) CacheInvalidations() int {
+	return s.cacheInvalidations
+}
+
+// DiscoveryClientOverrides returns the overrides of all discovery clients which have been created via CreateDiscoveryClientWithOverrides.
+func (s *shootClientCreator) DiscoveryClientOverrides() []util.ConnectionOverrides {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]util.ConnectionOverrides(nil), s.discoveryClientOverrides...)
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"context"
+	"math/rand"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/features"
+	coordinationv1 "k8s.io/api/coordination/v1"
+)
+
+// kubeletHealthzPath is the path of the kubelet health endpoint relative to the node proxy subresource.
+const kubeletHealthzPath = "healthz"
+
+func isKubeletHealthProbeEnabled(config *papi.Config) bool {
+	return features.Enabled(features.KubeletHealthProbe) && config.KubeletHealthProbeSampleSize != nil && *config.KubeletHealthProbeSampleSize > 0
+}
+
+// areSampledKubeletsUnhealthy probes the health endpoint of the kubelets of up to KubeletHealthProbeSampleSize nodes with expired node leases,
+// which are sampled at random, via the API server proxy. It returns true only if none of the sampled kubelets is healthy. If the kubelet health
+// probe is disabled, if there are no nodes with expired node leases or if the kubelets cannot be probed at all then false is returned so that the
+// scale-down is not prevented.
+func (p *Prober) areSampledKubeletsUnhealthy(ctx context.Context, candidateNodeLeases []coordinationv1.Lease) bool {
+	if !isKubeletHealthProbeEnabled(p.config) {
+		return false
+	}
+	nodeNames := p.sampleNodeNamesWithExpiredLeases(candidateNodeLeases, *p.config.KubeletHealthProbeSampleSize)
+	if len(nodeNames) == 0 {
+		return false
+	}
+	discoveryClient, err := p.shootClientCreator.CreateDiscoveryClient(ctx, p.l, getTimeoutOrDefault(p.config.APIServerProbeTimeout, p.config.ProbeTimeout))
+	if err != nil {
+		p.l.Error(err, "Failed to create client to probe kubelets, skipping kubelet health probe")
+		return false
+	}
+	restClient := discoveryClient.RESTClient()
+	if restClient == nil {
+		p.l.Info("Client to probe kubelets does not support raw requests, skipping kubelet health probe")
+		return false
+	}
+	for _, nodeName := range nodeNames {
+		err = restClient.Get().AbsPath("/api/v1/nodes", nodeName, "proxy", kubeletHealthzPath).Do(ctx).Error()
+		if err == nil {
+			p.l.Info("Kubelet of node with expired lease is healthy", "node", nodeName)
+			return false
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		p.l.Info("Kubelet of node with expired lease is not healthy", "node", nodeName, "err", err.Error())
+	}
+	return true
+}
+
+// sampleNodeNamesWithExpiredLeases returns the names of up to sampleSize nodes, picked at random, whose node leases have expired.
+func (p *Prober) sampleNodeNamesWithExpiredLeases(candidateNodeLeases []coordinationv1.Lease, sampleSize int) []string {
+	var nodeNames []string
+	for _, lease := range candidateNodeLeases {
+		if p.isLeaseExpired(lease) {
+			// node leases have the same names as nodes
+			nodeNames = append(nodeNames, lease.Name)
+		}
+	}
+	rand.Shuffle(len(nodeNames), func(i, j int) {
+		nodeNames[i], nodeNames[j] = nodeNames[j], nodeNames[i]
+	})
+	if len(nodeNames) > sampleSize {
+		nodeNames = nodeNames[:sampleSize]
+	}
+	return nodeNames
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gardener/dependency-watchdog/internal/features"
+	k8sfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/k8s"
+	scalefakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/scale"
+	shootfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/shoot"
+	"github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	restfake "k8s.io/client-go/rest/fake"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+	"k8s.io/utils/pointer"
+)
+
+func TestScaleDownShouldDependOnKubeletHealthIfKubeletHealthProbeIsEnabled(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.KubeletHealthProbe, true)
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+
+	testCases := []struct {
+		name                       string
+		kubeletStatusCode          int
+		expectedDeploymentReplicas int32
+	}{
+		{name: "scale down should happen if the sampled kubelets are healthy", kubeletStatusCode: http.StatusOK, expectedDeploymentReplicas: 0},
+		{name: "no scale down should happen if none of the sampled kubelets is healthy", kubeletStatusCode: http.StatusServiceUnavailable, expectedDeploymentReplicas: 1},
+	}
+
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			var mu sync.Mutex
+			var requestedPaths []string
+			restClient := &restfake.RESTClient{
+				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+				Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					requestedPaths = append(requestedPaths, req.URL.Path)
+					return &http.Response{StatusCode: entry.kubeletStatusCode, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+				}),
+			}
+			scaleTargetDeployments := generateScaleTargetDeployments(1)
+			shootClient := initializeShootClientBuilder(nodes, leases).Build()
+			seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+			scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClientWithRESTClient(restClient), shootClient).Build()
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+			config.KubeletHealthProbeSampleSize = pointer.Int(1)
+
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+			g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+			g.Expect(p.HasLeaseProbeFailed()).To(BeTrue())
+			assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), entry.expectedDeploymentReplicas)
+
+			mu.Lock()
+			defer mu.Unlock()
+			g.Expect(requestedPaths).ToNot(BeEmpty(), "the kubelets should have been probed")
+			g.Expect(requestedPaths).To(HaveEach(Or(
+				Equal("/api/v1/nodes/"+test.Node1Name+"/proxy/healthz"),
+				Equal("/api/v1/nodes/"+test.Node2Name+"/proxy/healthz"),
+			)), "only the kubelets of nodes with expired leases should be probed via the API server proxy")
+		})
+	}
+}
+
+func TestSampleNodeNamesWithExpiredLeasesShouldNotExceedSampleSize(t *testing.T) {
+	g := NewWithT(t)
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{
+		{Name: test.Node1Name, IsExpired: true},
+		{Name: test.Node2Name, IsExpired: true},
+		{Name: test.Node3Name, IsExpired: false},
+	})
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+
+	g.Expect(p.sampleNodeNamesWithExpiredLeases(derefLeases(leases), 1)).To(ConsistOf(BeElementOf(test.Node1Name, test.Node2Name)))
+	g.Expect(p.sampleNodeNamesWithExpiredLeases(derefLeases(leases), 5)).To(ConsistOf(test.Node1Name, test.Node2Name))
+}
+
+func TestKubeletHealthProbeShouldBeSkippedIfNotEnabled(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.KubeletHealthProbe, true)
+	g := NewWithT(t)
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}})
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+
+	g.Expect(p.areSampledKubeletsUnhealthy(context.Background(), derefLeases(leases))).To(BeFalse())
+	g.Expect(RequiredShootPermissions(config)).To(HaveLen(2))
+	config.KubeletHealthProbeSampleSize = pointer.Int(1)
+	g.Expect(RequiredShootPermissions(config)).To(HaveLen(3))
+
+	featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.KubeletHealthProbe, false)
+	g.Expect(p.areSampledKubeletsUnhealthy(context.Background(), derefLeases(leases))).To(BeFalse())
+	g.Expect(RequiredShootPermissions(config)).To(HaveLen(2), "the kubelets should not be probed if the feature is disabled")
+}
+
+func derefLeases(leases []*coordinationv1.Lease) []coordinationv1.Lease {
+	derefed := make([]coordinationv1.Lease, 0, len(leases))
+	for _, lease := range leases {
+		derefed = append(derefed, *lease)
+	}
+	return derefed
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"slices"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+)
+
+// RequiredSeedPermissions returns the permissions which the prober requires in the seed for the given config. Reads of kinds which are served
+// from an informer cache require list and watch in addition to the verbs which are used, reads of kinds for which isUncached returns true only
+// require the verbs which are used. The resources of the dependent resources are determined via the given mapper, dependent resources which are
+// optional and whose kind is not known are skipped.
+func RequiredSeedPermissions(config *papi.Config, mapper meta.RESTMapper, isUncached func(schema.GroupKind) bool) ([]util.ResourcePermission, error) {
+	var permissions []util.ResourcePermission
+	permissions = append(permissions, util.NewResourcePermissions(extensionsv1alpha1.SchemeGroupVersion.Group, "clusters", "get", "list", "watch")...)
+	if pointer.BoolDeref(config.ReportCareConditions, false) {
+		permissions = append(permissions, readPermissions(extensionsv1alpha1.SchemeGroupVersion.WithKind(extensionsv1alpha1.WorkerResource).GroupKind(), "workers", isUncached, "list")...)
+		permissions = append(permissions, util.ResourcePermission{Verb: "patch", Group: extensionsv1alpha1.SchemeGroupVersion.Group, Resource: "workers", Subresource: "status"})
+	}
+	permissions = append(permissions, readPermissions(corev1.SchemeGroupVersion.WithKind("Namespace").GroupKind(), "namespaces", isUncached, "get")...)
+	permissions = append(permissions, readPermissions(corev1.SchemeGroupVersion.WithKind("Secret").GroupKind(), "secrets", isUncached, "get")...)
+	if !pointer.BoolDeref(config.UnmanagedNodes, false) {
+		permissions = append(permissions, readPermissions(v1alpha1.SchemeGroupVersion.WithKind("Machine").GroupKind(), "machines", isUncached, "list")...)
+	}
+	if (config.ScaleDecisionLogSize != nil && *config.ScaleDecisionLogSize > 0) || pointer.BoolDeref(config.PersistCheckpoint, false) {
+		permissions = append(permissions, readPermissions(corev1.SchemeGroupVersion.WithKind("ConfigMap").GroupKind(), "configmaps", isUncached, "get")...)
+		permissions = append(permissions, util.NewResourcePermissions("", "configmaps", "create", "update")...)
+	}
+	permissions = append(permissions, util.NewResourcePermissions("", "events", "create", "patch")...)
+	for _, resInfo := range config.DependentResourceInfos {
+		gv, err := schema.ParseGroupVersion(resInfo.Ref.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		gk := schema.GroupKind{Group: gv.Group, Kind: resInfo.Ref.Kind}
+		mapping, err := mapper.RESTMapping(gk, gv.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) && resInfo.Optional {
+				continue
+			}
+			return nil, err
+		}
+		gr := mapping.Resource.GroupResource()
+		permissions = append(permissions, readPermissions(gk, gr.Resource, isUncached, "get")...)
+		permissions = append(permissions,
+			util.ResourcePermission{Verb: "patch", Group: gr.Group, Resource: gr.Resource},
+			util.ResourcePermission{Verb: "get", Group: gr.Group, Resource: gr.Resource, Subresource: "scale"},
+			util.ResourcePermission{Verb: "update", Group: gr.Group, Resource: gr.Resource, Subresource: "scale"})
+	}
+	return permissions, nil
+}
+
+// readPermissions returns the permissions to read the given resource of the given kind with the given verbs. Reads of kinds which are served
+// from an informer cache additionally require list and watch.
+func readPermissions(gk schema.GroupKind, resource string, isUncached func(schema.GroupKind) bool, verbs ...string) []util.ResourcePermission {
+	if !isUncached(gk) {
+		for _, verb := range []string{"list", "watch"} {
+			if !slices.Contains(verbs, verb) {
+				verbs = append(verbs, verb)
+			}
+		}
+	}
+	return util.NewResourcePermissions(gk.Group, resource, verbs...)
+}
+
+// RequiredShootPermissions returns the permissions which the prober requires in a shoot for the given config.
+func RequiredShootPermissions(config *papi.Config) []util.ResourcePermission {
+	permissions := []util.ResourcePermission{
+		{Verb: "list", Resource: "nodes"},
+		{Verb: "list", Group: coordinationv1.GroupName, Resource: "leases", Namespace: nodeLeaseNamespace},
+	}
+	if isKubeletHealthProbeEnabled(config) {
+		permissions = append(permissions, util.ResourcePermission{Verb: "get", Resource: "nodes", Subresource: "proxy"})
+	}
+	return permissions
+}
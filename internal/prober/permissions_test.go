@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"testing"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	. "github.com/onsi/gomega"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+)
+
+func TestRequiredSeedPermissionsShouldIncludeDependentResources(t *testing.T) {
+	g := NewWithT(t)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	config := &papi.Config{
+		ScaleDecisionLogSize: pointer.Int(10),
+		DependentResourceInfos: []papi.DependentResourceInfo{
+			{Ref: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "kube-controller-manager"}},
+			{Ref: &autoscalingv1.CrossVersionObjectReference{APIVersion: "example.io/v1", Kind: "Unknown", Name: "optional"}, Optional: true},
+		},
+	}
+
+	permissions, err := RequiredSeedPermissions(config, mapper, noneUncached)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(permissions).To(ContainElements(
+		util.ResourcePermission{Verb: "list", Group: "machine.sapcloud.io", Resource: "machines"},
+		util.ResourcePermission{Verb: "create", Resource: "configmaps"},
+		util.ResourcePermission{Verb: "patch", Group: "apps", Resource: "deployments"},
+		util.ResourcePermission{Verb: "update", Group: "apps", Resource: "deployments", Subresource: "scale"},
+	))
+	g.Expect(permissions).ToNot(ContainElement(HaveField("Group", "example.io")), "optional dependent resources whose kind is not known should be skipped")
+	g.Expect(permissions).To(ContainElement(util.ResourcePermission{Verb: "watch", Group: "extensions.gardener.cloud", Resource: "clusters"}))
+	g.Expect(permissions).ToNot(ContainElement(HaveField("Resource", "workers")))
+
+	config.ReportCareConditions = pointer.Bool(true)
+	permissions, err = RequiredSeedPermissions(config, mapper, noneUncached)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(permissions).To(ContainElements(
+		util.ResourcePermission{Verb: "list", Group: "extensions.gardener.cloud", Resource: "workers"},
+		util.ResourcePermission{Verb: "patch", Group: "extensions.gardener.cloud", Resource: "workers", Subresource: "status"},
+	), "reporting care conditions requires to patch the status of workers")
+
+	config.ScaleDecisionLogSize = nil
+	permissions, err = RequiredSeedPermissions(config, mapper, noneUncached)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(permissions).ToNot(ContainElement(HaveField("Resource", "configmaps")))
+	config.PersistCheckpoint = pointer.Bool(true)
+	permissions, err = RequiredSeedPermissions(config, mapper, noneUncached)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(permissions).To(ContainElement(util.ResourcePermission{Verb: "update", Resource: "configmaps"}), "persisting the checkpoint requires to update configmaps")
+
+	config.UnmanagedNodes = pointer.Bool(true)
+	permissions, err = RequiredSeedPermissions(config, mapper, noneUncached)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(permissions).ToNot(ContainElement(HaveField("Resource", "machines")), "machines should not be read if nodes are not managed by MCM")
+}
+
+func TestRequiredSeedPermissionsShouldFailForUnknownMandatoryDependentResource(t *testing.T) {
+	g := NewWithT(t)
+	config := &papi.Config{
+		DependentResourceInfos: []papi.DependentResourceInfo{
+			{Ref: &autoscalingv1.CrossVersionObjectReference{APIVersion: "example.io/v1", Kind: "Unknown", Name: "mandatory"}},
+		},
+	}
+	_, err := RequiredSeedPermissions(config, meta.NewDefaultRESTMapper(nil), noneUncached)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestRequiredSeedPermissionsShouldOnlyRequireUsedVerbsForUncachedKinds(t *testing.T) {
+	g := NewWithT(t)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	config := &papi.Config{
+		DependentResourceInfos: []papi.DependentResourceInfo{
+			{Ref: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "kube-controller-manager"}},
+		},
+	}
+	isUncached := func(gk schema.GroupKind) bool {
+		return gk == schema.GroupKind{Kind: "Secret"} || gk == schema.GroupKind{Kind: "Namespace"} || gk == schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	}
+
+	permissions, err := RequiredSeedPermissions(config, mapper, isUncached)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(permissions).To(ContainElements(
+		util.ResourcePermission{Verb: "get", Resource: "secrets"},
+		util.ResourcePermission{Verb: "get", Resource: "namespaces"},
+		util.ResourcePermission{Verb: "get", Group: "apps", Resource: "deployments"},
+		util.ResourcePermission{Verb: "list", Group: "machine.sapcloud.io", Resource: "machines"},
+		util.ResourcePermission{Verb: "watch", Group: "machine.sapcloud.io", Resource: "machines"},
+	))
+	for _, resource := range []string{"secrets", "namespaces", "deployments"} {
+		g.Expect(permissions).ToNot(ContainElement(And(HaveField("Resource", resource), HaveField("Verb", BeElementOf("list", "watch")))), "uncached %s should not require list and watch", resource)
+	}
+}
+
+func noneUncached(schema.GroupKind) bool {
+	return false
+}
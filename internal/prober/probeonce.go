@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"context"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"k8s.io/utils/pointer"
+)
+
+// ProbeOutcome captures the outcome of a single probe cycle run via Prober.ProbeOnce.
+type ProbeOutcome struct {
+	// APIServerProbeFailed is true if the probe of the shoot control plane API server has failed.
+	APIServerProbeFailed bool
+	// LeaseProbeFailed is true if the fraction of expired node leases has reached the NodeLeaseFailureFraction.
+	LeaseProbeFailed bool
+	// ScaleOperation is the scale operation which would have been performed for the dependent resources. It is empty if no scale decision has been taken.
+	ScaleOperation string
+	// Err is the error, if any, which has occurred during the probe cycle.
+	Err error
+}
+
+// ProbeOnce runs a single probe cycle consisting of the API server probe, the node lease probe and the scale decision. No dependent resource is scaled,
+// instead the scale operation which would have been performed is reported in the ProbeOutcome. Scale decisions are not recorded either.
+// It is meant to validate the connectivity and the permissions of the prober, e.g. when onboarding a new seed.
+func (p *Prober) ProbeOnce(ctx context.Context) ProbeOutcome {
+	dryRun := &dryRunScaler{}
+	config := *p.config
+	config.ScaleDecisionLogSize = pointer.Int(0)
+	p.config = &config
+	p.scaler = dryRun
+	p.circuitBreaker = nil
+	p.lastErr = nil
+
+	p.probe(ctx)
+	p.inFlightScale.wait()
+	p.collectScaleFlowErr()
+	return ProbeOutcome{
+		APIServerProbeFailed: p.HasAPIServerProbeFailed(),
+		LeaseProbeFailed:     p.HasLeaseProbeFailed(),
+		ScaleOperation:       dryRun.operation,
+		Err:                  p.lastErr,
+	}
+}
+
+// dryRunScaler is a dwdScaler.Scaler which only records the scale operation that has been requested.
+type dryRunScaler struct {
+	operation string
+}
+
+func (d *dryRunScaler) ScaleUp(_ context.Context) error {
+	d.operation = scaleDecisionOperationScaleUp
+	return nil
+}
+
+func (d *dryRunScaler) ScaleDown(_ context.Context) error {
+	d.operation = scaleDecisionOperationScaleDown
+	return nil
+}
+
+func (d *dryRunScaler) Rebuild(_ []papi.DependentResourceInfo) {}
@@ -6,10 +6,15 @@ package prober
 
 import (
 	"context"
+	"fmt"
 	"reflect"
-	"slices"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/gardener/dependency-watchdog/internal/claim"
+	"github.com/gardener/dependency-watchdog/internal/lifecycle"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 	"github.com/gardener/dependency-watchdog/internal/prober/errors"
 	"github.com/gardener/dependency-watchdog/internal/prober/shoot"
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
@@ -19,16 +24,23 @@ import (
 	papi "github.com/gardener/dependency-watchdog/api/prober"
 	dwdScaler "github.com/gardener/dependency-watchdog/internal/prober/scaler"
 	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/gardener/dependency-watchdog/pkg/retry"
 	"github.com/go-logr/logr"
+	multierr "github.com/hashicorp/go-multierror"
+	"github.com/prometheus/client_golang/prometheus"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 )
 
 const (
-	backOffDurationForThrottledRequests = 10 * time.Second
 	// expiryBufferFraction is used to compute a revised expiry time used by the prober to determine expired leases
 	// Using a fraction allows the prober to intervene before KCM marks a node as unknown, but at the same time allowing
 	// kubelet sufficient retries to renew the node lease.
@@ -37,8 +49,60 @@ const (
 	// 		to renew the node lease.
 	expiryBufferFraction = 0.75
 	nodeLeaseNamespace   = "kube-node-lease"
+	// skipScalingAnnotationKey is the key for an annotation which if set to true on the shoot control namespace in the seed will suspend
+	// all scaling actions of the prober for that namespace. Unlike the ignore-scaling annotation which is set on individual dependent resources,
+	// this is meant to be used by operators who are manually operating a control plane and do not want DWD to interfere.
+	skipScalingAnnotationKey = "dependency-watchdog.gardener.cloud/skip-scaling"
+	// unauthorizedThresholdForClientInvalidation is the number of consecutive probe runs failing with an Unauthorized error after which the
+	// credentials are considered to have been rotated and the cached shoot clients are dropped.
+	unauthorizedThresholdForClientInvalidation = 3
+	// maxRestartBackOff caps the backoff after which a probe loop which has panicked is restarted. The backoff starts at the probe interval.
+	maxRestartBackOff = 5 * time.Minute
+	// eventReasonProbeForbidden is the reason of the event which is recorded when a probe has failed with a Forbidden error.
+	eventReasonProbeForbidden = "ProbeForbidden"
+	// eventReasonScaledDown is the reason of the event which is recorded when the dependent resources have been scaled down.
+	eventReasonScaledDown = "ScaledDown"
 )
 
+// nodeLeaseProbeResult captures the outcome of a node lease probe which serves as an input for a scale decision.
+type nodeLeaseProbeResult struct {
+	totalNodeCount      int
+	candidateNodeCount  int
+	candidateNodeLeases []coordinationv1.Lease
+	machineCount        int
+	// candidateNodeWorkerPools maps the names of the candidate nodes to the worker pools they belong to. Nodes without a worker pool are mapped
+	// to an empty string.
+	candidateNodeWorkerPools map[string]string
+}
+
+// hasNoWorkers returns true if the shoot has neither candidate nodes nor machines, e.g. because its last worker pool has been scaled to zero.
+func (r nodeLeaseProbeResult) hasNoWorkers() bool {
+	return r.candidateNodeCount == 0 && r.machineCount == 0
+}
+
+// status captures the outcome of the most recent probe run. It is referenced via a pointer from the Prober so that it is shared between copies of
+// a Prober, e.g. the one which is registered with the Manager and the one which is run.
+type status struct {
+	sync.RWMutex
+	apiServerProbeFailed bool
+	leaseProbeFailed     bool
+	dependentsScaledDown bool
+	scalingPaused        bool
+	// lastDecision is the most recent scale operation which has been triggered by the prober and lastDecisionTime the time it has been triggered at.
+	lastDecision     string
+	lastDecisionTime time.Time
+	// lastRecordedScaleDecision identifies the scale decision which has most recently been recorded in the scale decision log, see scaleDecision.key.
+	lastRecordedScaleDecision string
+}
+
+// latestConfig holds the most recent probe config. It is referenced via a pointer from the Prober so that a config which has been swapped via the
+// copy of a Prober which is registered with the Manager is picked up by the copy which is run.
+type latestConfig struct {
+	sync.RWMutex
+	config     *papi.Config
+	configHash string
+}
+
 // Prober represents a probe to the Kube ApiServer of a shoot
 type Prober struct {
 	namespace            string
@@ -46,34 +110,71 @@ type Prober struct {
 	workerNodeConditions map[string][]string
 	scaler               dwdScaler.Scaler
 	seedClient           client.Client
-	shootClientCreator   shoot.ClientCreator
-	backOff              *time.Timer
-	ctx                  context.Context
-	cancelFn             context.CancelFunc
-	l                    logr.Logger
-	lastErr              error // this is currently used only for unit tests
+	// seedReader reads directly from the API server of the seed. It is used for the objects which are not cached by seedClient.
+	seedReader         client.Reader
+	shootClientCreator shoot.ClientCreator
+	circuitBreaker     ScaleDownCircuitBreaker
+	claimer            *claim.Claimer
+	recorder           record.EventRecorder
+	backOff            *time.Timer
+	// consecutiveUnauthorizedCount is the number of consecutive probe runs which have failed with an Unauthorized error.
+	consecutiveUnauthorizedCount int
+	ctx                          context.Context
+	cancelFn                     context.CancelFunc
+	// l is the logger of the current probe cycle, it is derived from logger and carries the ID of the probe cycle.
+	l             logr.Logger
+	logger        logr.Logger
+	lastErr       error // this is currently used only for unit tests
+	status        *status
+	latestConfig  *latestConfig
+	inFlightScale *inFlightScale
+	subsystem     *lifecycle.Subsystem
+	// warmUpStartedAt is the time at which the WarmUpDuration of the prober has started. It is the time at which the prober has been created,
+	// unless it has been restored from a checkpoint.
+	warmUpStartedAt time.Time
+	// backOffUntil is the time at which the current backoff ends, it is persisted with the checkpoint of the prober.
+	backOffUntil time.Time
+	// checkpointRestored is set once the prober has tried to restore its state from a checkpoint, see PersistCheckpoint.
+	checkpointRestored bool
+	// checkpoint is the checkpoint which has most recently been persisted by the prober.
+	checkpoint *proberCheckpoint
 }
 
 // NewProber creates a new Prober
-func NewProber(parentCtx context.Context, seedClient client.Client, namespace string, config *papi.Config, workerNodeConditions map[string][]string, scaler dwdScaler.Scaler, shootClientCreator shoot.ClientCreator, logger logr.Logger) *Prober {
+func NewProber(parentCtx context.Context, seedClient client.Client, seedReader client.Reader, namespace string, config *papi.Config, workerNodeConditions map[string][]string, scaler dwdScaler.Scaler, shootClientCreator shoot.ClientCreator, circuitBreaker ScaleDownCircuitBreaker, claimer *claim.Claimer, recorder record.EventRecorder, logger logr.Logger) *Prober {
 	pLogger := logger.WithValues("shootNamespace", namespace)
 	ctx, cancelFn := context.WithCancel(parentCtx)
-	return &Prober{
+	p := &Prober{
 		namespace:            namespace,
 		config:               config,
 		workerNodeConditions: workerNodeConditions,
 		scaler:               scaler,
 		seedClient:           seedClient,
+		seedReader:           seedReader,
 		shootClientCreator:   shootClientCreator,
+		circuitBreaker:       circuitBreaker,
+		claimer:              claimer,
+		recorder:             recorder,
 		ctx:                  ctx,
 		cancelFn:             cancelFn,
 		l:                    pLogger,
+		logger:               pLogger,
+		status:               &status{},
+		latestConfig:         &latestConfig{config: config, configHash: util.ComputeConfigHash(config)},
+		inFlightScale:        &inFlightScale{},
+		warmUpStartedAt:      time.Now(),
 	}
+	p.subsystem = lifecycle.New(ctx, "prober", p.runProbeLoop, pLogger,
+		lifecycle.WithInitialDelay(getDurationOrZero(config.InitialDelay)),
+		lifecycle.WithRestartBackOff(retry.ExponentialBackoff{Initial: getDurationOrZero(config.ProbeInterval), Factor: 2, Max: maxRestartBackOff, JitterFactor: 0.2}))
+	pLogger.Info("Created prober", "configHash", p.latestConfig.configHash)
+	return p
 }
 
 // Close closes a probe
 func (p *Prober) Close() {
 	p.cancelFn()
+	p.deleteShootMetrics()
 }
 
 // IsClosed checks if the context of the prober is cancelled or not.
@@ -86,162 +187,547 @@ func (p *Prober) IsClosed() bool {
 	}
 }
 
-// Run starts a probe which will run with a configured interval and jitter.
+// Start starts the prober in a new goroutine. The probe loop is run as a lifecycle.Subsystem, which restarts it after a backoff should it panic.
+func (p *Prober) Start() {
+	p.setConfigInfoMetric(p.ConfigHash())
+	p.subsystem.Start()
+}
+
+// Run starts the prober and blocks until it has been closed.
 func (p *Prober) Run() {
-	_ = util.SleepWithContext(p.ctx, p.config.InitialDelay.Duration)
-	wait.JitterUntilWithContext(p.ctx, p.probe, p.config.ProbeInterval.Duration, *p.config.BackoffJitterFactor, true)
+	p.Start()
+	<-p.subsystem.Done()
+}
+
+// Health returns the health of the probe loop.
+func (p *Prober) Health() lifecycle.Health {
+	return p.subsystem.Health()
+}
+
+// runProbeLoop runs a probe with a configured interval and jitter until the context is cancelled. A probe config which has been swapped via
+// UpdateConfig is picked up at the beginning of the next probe run.
+func (p *Prober) runProbeLoop(ctx context.Context) {
+	p.config = p.GetConfig()
+	p.restoreCheckpoint(ctx)
+	for ctx.Err() == nil {
+		p.config = p.GetConfig()
+		p.probe(ctx)
+		_ = util.SleepWithContext(ctx, p.getJitteredProbeInterval())
+	}
+}
+
+// getJitteredProbeInterval returns the probe interval with a random jitter of up to BackoffJitterFactor added to it.
+func (p *Prober) getJitteredProbeInterval() time.Duration {
+	if *p.config.BackoffJitterFactor > 0 {
+		return wait.Jitter(p.config.ProbeInterval.Duration, *p.config.BackoffJitterFactor)
+	}
+	return p.config.ProbeInterval.Duration
+}
+
+// UpdateConfig swaps the probe config of a running prober without losing its state, e.g. its backoff. This is only possible if the config
+// differs in fields which are read on every probe run, like the probe interval or the node lease failure fraction, or in the dependent resources,
+// for which the scale flows of the scaler are rebuilt. If any of the fields with which the scaler or the shoot client creator of the prober have
+// been created differs, then the config is not swapped and false is returned, in which case the prober has to be recreated.
+func (p *Prober) UpdateConfig(config *papi.Config) bool {
+	p.latestConfig.Lock()
+	defer p.latestConfig.Unlock()
+	if !canSwapConfig(p.latestConfig.config, config) {
+		return false
+	}
+	if !reflect.DeepEqual(p.latestConfig.config.DependentResourceInfos, config.DependentResourceInfos) {
+		p.scaler.Rebuild(config.DependentResourceInfos)
+		p.logger.Info("Rebuilt scale flows for updated dependent resources")
+	}
+	p.latestConfig.config = config
+	p.latestConfig.configHash = util.ComputeConfigHash(config)
+	p.logger.Info("Swapped probe config", "configHash", p.latestConfig.configHash)
+	p.setConfigInfoMetric(p.latestConfig.configHash)
+	return true
 }
 
+// canSwapConfig checks if the current probe config can be swapped with the updated one without recreating the prober.
+func canSwapConfig(current, updated *papi.Config) bool {
+	return current.KubeConfigSecretName == updated.KubeConfigSecretName &&
+		reflect.DeepEqual(current.KubeConfigTokenSecretName, updated.KubeConfigTokenSecretName) &&
+		reflect.DeepEqual(current.ReplicasAnnotationKey, updated.ReplicasAnnotationKey) &&
+		reflect.DeepEqual(current.DualWriteReplicasAnnotation, updated.DualWriteReplicasAnnotation) &&
+		reflect.DeepEqual(current.MaxConcurrentScalesPerLevel, updated.MaxConcurrentScalesPerLevel) &&
+		reflect.DeepEqual(current.ScaleFlowTimeout, updated.ScaleFlowTimeout) &&
+		reflect.DeepEqual(current.DryRunScaleUpdates, updated.DryRunScaleUpdates) &&
+		reflect.DeepEqual(current.HonorRetryAfter, updated.HonorRetryAfter) &&
+		reflect.DeepEqual(current.ShootClientQPS, updated.ShootClientQPS) &&
+		reflect.DeepEqual(current.ShootClientBurst, updated.ShootClientBurst) &&
+		reflect.DeepEqual(current.ShootClientDialTimeout, updated.ShootClientDialTimeout) &&
+		reflect.DeepEqual(current.ShootClientTLSHandshakeTimeout, updated.ShootClientTLSHandshakeTimeout)
+}
+
+// probe runs a single probe cycle. Every probe cycle is assigned an ID which is part of all log lines written on its behalf, including the ones of
+// the scaler, so that the decision trail of a single probe cycle can be followed in the logs.
 func (p *Prober) probe(ctx context.Context) {
+	probeCycleID := string(uuid.NewUUID())
+	ctx = util.ContextWithProbeCycleID(ctx, probeCycleID)
+	p.l = p.logger.WithValues(util.ProbeCycleIDLogKey, probeCycleID)
+	p.collectScaleFlowErr()
 	p.backOffIfNeeded()
+	defer p.persistCheckpoint(ctx)
 	err := p.probeAPIServer(ctx)
+	p.setAPIServerProbeFailed(err != nil)
 	if err != nil {
-		p.recordError(err, errors.ErrProbeAPIServer, "Failed to probe API server")
+		p.setLeaseProbeFailed(false)
+		p.recordProbeError(err, errors.ErrProbeAPIServer, "Failed to probe API server")
 		p.l.Info("API server probe failed, Skipping lease probe and scaling operation", "err", err.Error())
+		p.reportCareCondition(ctx, nodeLeasesNotProbedUpdate)
 		return
 	}
 	p.l.Info("API server probe is successful, will conduct node lease probe")
 
 	shootClient, err := p.setupProbeClient(ctx)
 	if err != nil {
-		p.recordError(err, errors.ErrSetupProbeClient, "Failed to setup probe client")
+		p.setLeaseProbeFailed(false)
+		p.recordProbeError(err, errors.ErrSetupProbeClient, "Failed to setup probe client")
 		p.l.Error(err, "Failed to create shoot client using the KubeConfig secret, ignoring error, probe will be re-attempted")
+		p.reportCareCondition(ctx, nodeLeasesNotProbedUpdate)
 		return
 	}
-	candidateNodeLeases, err := p.probeNodeLeases(ctx, shootClient)
+	result, err := p.probeNodeLeases(ctx, shootClient)
+	// the outcome of the lease probe is updated before scaling is possibly skipped, so that the seed meltdown circuit breaker never considers the
+	// outcome of an earlier probe cycle. A shoot whose node leases could not be probed does not count as a shoot with a failed lease probe.
+	p.setLeaseProbeFailed(err == nil && p.isLeaseProbeFailed(result))
 	if err != nil {
-		p.recordError(err, errors.ErrProbeNodeLease, "Failed to probe node leases")
+		p.recordProbeError(err, errors.ErrProbeNodeLease, "Failed to probe node leases")
 		p.l.Error(err, "Failed to probe node leases, ignoring error, probe will be re-attempted")
+		p.reportCareCondition(ctx, nodeLeasesNotProbedUpdate)
 		return
 	}
-	if len(candidateNodeLeases) != 1 {
-		p.checkAndTriggerScale(ctx, candidateNodeLeases)
-	} else {
-		p.l.Info("Skipping scaling operation as number of candidate node leases == 1")
+	p.reportCareCondition(ctx, p.nodeLeasesUpdate(result))
+	p.consecutiveUnauthorizedCount = 0
+	p.setShootMetric(metrics.ShootLeaseExpiredFraction, expiredFraction(len(result.candidateNodeLeases), p.countExpiredNodeLeases(result.candidateNodeLeases)))
+	p.setWorkerPoolLeaseExpiredFractions(result)
+	p.setScalingPaused(result.hasNoWorkers())
+	if result.hasNoWorkers() {
+		p.l.Info("Pausing scale decisions as the shoot has neither candidate nodes nor machines", "totalNodeCount", result.totalNodeCount)
+		return
+	}
+	if p.isBelowMinNodeCountForScaling(len(result.candidateNodeLeases)) {
+		p.l.Info("Skipping scaling operation as number of candidate node leases is below the minimum node count for scaling", "candidateNodeLeases", len(result.candidateNodeLeases), "minNodeCountForScaling", p.getMinNodeCountForScaling())
+		return
 	}
+	p.checkAndTriggerScale(ctx, result)
+}
+
+// isBelowMinNodeCountForScaling checks if the given number of candidate node leases is below MinNodeCountForScaling. A shoot without any candidate
+// node leases is never considered to be below it so that dependent resources can still be scaled up.
+func (p *Prober) isBelowMinNodeCountForScaling(candidateNodeLeaseCount int) bool {
+	return candidateNodeLeaseCount > 0 && candidateNodeLeaseCount < p.getMinNodeCountForScaling()
+}
+
+func (p *Prober) getMinNodeCountForScaling() int {
+	return *util.GetValOrDefault(p.config.MinNodeCountForScaling, DefaultMinNodeCountForScaling)
 }
 
 func (p *Prober) recordError(err error, code errors.ErrorCode, message string) {
 	p.lastErr = errors.WrapError(err, code, message)
 }
 
-func (p *Prober) checkAndTriggerScale(ctx context.Context, candidateNodeLeases []coordinationv1.Lease) {
-	// revive:disable:early-return
-	if p.shouldPerformScaleUp(candidateNodeLeases) {
-		if err := p.scaler.ScaleUp(ctx); err != nil {
-			p.recordError(err, errors.ErrScaleUp, "Failed to scale up resources")
-			p.l.Error(err, "Failed to scale up resources")
+// recordProbeError records an error of the API server probe or the node lease probe. Unlike other errors, Unauthorized and Forbidden errors
+// will not go away by re-attempting the probe and are therefore recorded with dedicated error codes. Unauthorized errors which persist across
+// probe runs indicate rotated credentials, in which case the cached shoot clients are dropped. Forbidden errors indicate missing RBAC
+// permissions, which is surfaced as an event for the shoot control namespace.
+func (p *Prober) recordProbeError(err error, code errors.ErrorCode, message string) {
+	switch {
+	case apierrors.IsUnauthorized(err):
+		metrics.ProbeAuthFailuresTotal.WithLabelValues(metrics.ReasonUnauthorized).Inc()
+		p.consecutiveUnauthorizedCount++
+		if p.consecutiveUnauthorizedCount >= unauthorizedThresholdForClientInvalidation {
+			p.invalidateShootClientCache()
 		}
-	} else {
-		p.l.Info("Lease probe failed, performing scale down operation if required")
-		if err := p.scaler.ScaleDown(ctx); err != nil {
-			p.recordError(err, errors.ErrScaleDown, "Failed to scale down resources")
-			p.l.Error(err, "Failed to scale down resources")
+		code = errors.ErrProbeUnauthorized
+	case apierrors.IsForbidden(err):
+		metrics.ProbeAuthFailuresTotal.WithLabelValues(metrics.ReasonForbidden).Inc()
+		p.consecutiveUnauthorizedCount = 0
+		if p.recorder != nil {
+			p.recorder.Eventf(&corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: p.namespace, Namespace: p.namespace}, corev1.EventTypeWarning, eventReasonProbeForbidden,
+				"%s as the request was forbidden, check the RBAC permissions of dependency-watchdog: %v", message, err)
 		}
+		code = errors.ErrProbeForbidden
+	default:
+		p.consecutiveUnauthorizedCount = 0
+	}
+	p.recordError(err, code, message)
+}
+
+// invalidateShootClientCache drops the shoot clients cached by the shoot.ClientCreator, if it caches clients at all, so that the next probe
+// run creates them afresh using the current kubeconfig.
+func (p *Prober) invalidateShootClientCache() {
+	if invalidator, ok := p.shootClientCreator.(shoot.CacheInvalidator); ok {
+		p.l.Info("Probe has persistently failed with Unauthorized errors, invalidating cached shoot clients", "consecutiveUnauthorizedCount", p.consecutiveUnauthorizedCount)
+		invalidator.InvalidateCache()
+	}
+	p.consecutiveUnauthorizedCount = 0
+}
+
+func (p *Prober) checkAndTriggerScale(ctx context.Context, result nodeLeaseProbeResult) {
+	expiredNodeLeaseCount := p.countExpiredNodeLeases(result.candidateNodeLeases)
+	if p.shouldPerformScaleUp(result.candidateNodeLeases, expiredNodeLeaseCount) {
+		allowed, err := p.scalingAllowed(ctx)
+		if err != nil {
+			p.lastErr = err
+			p.l.Error(err, "Failed to check if scaling is allowed, ignoring error, probe will be re-attempted")
+			return
+		}
+		if allowed {
+			p.triggerScale(ctx, scaleDecisionOperationScaleUp, result, expiredNodeLeaseCount)
+		}
+		return
+	}
+	allowed, err := p.scaleDownAllowed(ctx)
+	if err != nil {
+		p.lastErr = err
+		p.l.Error(err, "Failed to check if scaling is allowed, ignoring error, probe will be re-attempted")
 		return
 	}
-	// revive:enable:early-return
+	if !allowed {
+		return
+	}
+	if p.areSampledKubeletsUnhealthy(ctx, result.candidateNodeLeases) {
+		metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonKubeletsUnhealthy).Inc()
+		p.l.Info("Lease probe failed, skipping scale down operation as none of the sampled kubelets of nodes with expired leases is healthy")
+		return
+	}
+	p.l.Info("Lease probe failed, performing scale down operation if required")
+	p.triggerScale(ctx, scaleDecisionOperationScaleDown, result, expiredNodeLeaseCount)
+}
+
+// scalingAllowed checks if the prober may scale the dependent resources of the shoot at all, which is not the case if the shoot control namespace
+// has been annotated to skip scaling or if it has been claimed by another instance of dependency-watchdog. The returned error carries the error
+// code with which it is recorded.
+func (p *Prober) scalingAllowed(ctx context.Context) (bool, error) {
+	skipScaling, err := p.isScalingSkippedForNamespace(ctx)
+	if err != nil {
+		return false, errors.WrapError(err, errors.ErrGetNamespace, "Failed to get shoot control namespace")
+	}
+	if skipScaling {
+		p.l.Info("Skipping scaling operation as the namespace has been annotated to skip scaling", "annotation", skipScalingAnnotationKey)
+		return false, nil
+	}
+	claimed, holder, err := p.claimer.Claim(ctx, p.namespace)
+	if err != nil {
+		p.setBackOffIfSeedThrottlingError(err)
+		return false, errors.WrapError(err, errors.ErrClaimNamespace, "Failed to claim shoot control namespace")
+	}
+	if !claimed {
+		p.l.Info("Skipping scaling operation as the namespace has been claimed by another instance of dependency-watchdog", "holder", holder)
+		return false, nil
+	}
+	return true, nil
+}
+
+// scaleDownAllowed checks if the prober may scale down the dependent resources of the shoot. Next to the checks of scalingAllowed, a scale-down is
+// suppressed if scale-downs have been disabled, while the prober is warming up or if the scale-down circuit breaker suppresses it. Every
+// suppressed scale-down is counted by the ScaleDownsSuppressedTotal metric. It is used both by the probe and by the re-assertion of a scale-down,
+// so that neither of them bypasses a guard of the other.
+func (p *Prober) scaleDownAllowed(ctx context.Context) (bool, error) {
+	if allowed, err := p.scalingAllowed(ctx); !allowed || err != nil {
+		return false, err
+	}
+	if pointer.BoolDeref(p.config.DisableScaleDown, false) {
+		metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonScaleDownDisabled).Inc()
+		p.l.Info("Skipping scale down operation as scale-downs have been disabled")
+		return false, nil
+	}
+	if p.isWarmingUp() {
+		metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonWarmUp).Inc()
+		p.l.Info("Skipping scale down operation as the prober is still warming up", "warmUpDuration", getDurationOrZero(p.config.WarmUpDuration))
+		return false, nil
+	}
+	if p.circuitBreaker != nil && p.circuitBreaker.ShouldSuppressScaleDown(p.namespace) {
+		p.l.Info("Skipping scale down operation as it has been suppressed by the scale-down circuit breaker")
+		return false, nil
+	}
+	return true, nil
+}
+
+// isWarmingUp checks if the prober has been created less than WarmUpDuration ago. Node leases which appear to be expired during this time may be
+// caused by the downtime of the control plane itself, e.g. after a restart of dependency-watchdog, so that only scale-ups are allowed.
+func (p *Prober) isWarmingUp() bool {
+	return time.Since(p.warmUpStartedAt) < getDurationOrZero(p.config.WarmUpDuration)
+}
+
+// isScalingSkippedForNamespace checks if the shoot control namespace in the seed has skipScalingAnnotationKey set to true.
+// If the namespace is not found then scaling is not skipped.
+func (p *Prober) isScalingSkippedForNamespace(ctx context.Context) (bool, error) {
+	ns := &corev1.Namespace{}
+	if err := p.seedClient.Get(ctx, client.ObjectKey{Name: p.namespace}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		p.setBackOffIfSeedThrottlingError(err)
+		return false, err
+	}
+	if val, ok := ns.Annotations[skipScalingAnnotationKey]; ok {
+		skip, err := strconv.ParseBool(val)
+		if err != nil {
+			p.l.Info("Ignoring invalid value for annotation on namespace", "annotation", skipScalingAnnotationKey, "value", val)
+			return false, nil
+		}
+		return skip, nil
+	}
+	return false, nil
 }
 
 // shouldPerformScaleUp returns true if the ratio of expired node leases to valid node leases is less than
 // the NodeLeaseFailureFraction set in the prober config
-func (p *Prober) shouldPerformScaleUp(candidateNodeLeases []coordinationv1.Lease) bool {
+func (p *Prober) shouldPerformScaleUp(candidateNodeLeases []coordinationv1.Lease, expiredNodeLeaseCount int) bool {
 	if len(candidateNodeLeases) == 0 {
 		p.l.Info("No owned node leases are present in the cluster, performing scale up operation if required")
 		return true
 	}
-	var expiredNodeLeaseCount float64
-	for _, lease := range candidateNodeLeases {
+	shouldScaleUp := float64(expiredNodeLeaseCount)/float64(len(candidateNodeLeases)) < *p.config.NodeLeaseFailureFraction
+	if shouldScaleUp {
+		p.l.Info("Lease probe succeeded, performing scale up operation if required")
+	}
+	return shouldScaleUp
+}
+
+// isLeaseProbeFailed checks if the fraction of expired candidate node leases has reached the NodeLeaseFailureFraction. The lease probe of a shoot
+// without candidate node leases never fails.
+func (p *Prober) isLeaseProbeFailed(result nodeLeaseProbeResult) bool {
+	candidateNodeLeaseCount := len(result.candidateNodeLeases)
+	return candidateNodeLeaseCount > 0 && expiredFraction(candidateNodeLeaseCount, p.countExpiredNodeLeases(result.candidateNodeLeases)) >= *p.config.NodeLeaseFailureFraction
+}
+
+func (p *Prober) countExpiredNodeLeases(nodeLeases []coordinationv1.Lease) int {
+	var expiredNodeLeaseCount int
+	for _, lease := range nodeLeases {
 		if p.isLeaseExpired(lease) {
 			expiredNodeLeaseCount++
 		}
 	}
-	shouldScaleUp := expiredNodeLeaseCount/float64(len(candidateNodeLeases)) < *p.config.NodeLeaseFailureFraction
-	if shouldScaleUp {
-		p.l.Info("Lease probe succeeded, performing scale up operation if required")
+	return expiredNodeLeaseCount
+}
+
+// sampleExpiredNodeNames returns the names of at most maxCount nodes whose leases have expired. The name of a node lease is the name of its node.
+func (p *Prober) sampleExpiredNodeNames(nodeLeases []coordinationv1.Lease, maxCount int) []string {
+	var nodeNames []string
+	for _, lease := range nodeLeases {
+		if len(nodeNames) == maxCount {
+			break
+		}
+		if p.isLeaseExpired(lease) {
+			nodeNames = append(nodeNames, lease.Name)
+		}
 	}
-	return shouldScaleUp
+	return nodeNames
 }
 
 func (p *Prober) setupProbeClient(ctx context.Context) (client.Client, error) {
-	shootClient, err := p.shootClientCreator.CreateClient(ctx, p.l, p.config.ProbeTimeout.Duration)
+	shootClient, err := p.shootClientCreator.CreateClient(ctx, p.l, getTimeoutOrDefault(p.config.LeaseProbeTimeout, p.config.ProbeTimeout))
 	if err != nil {
-		p.setBackOffIfThrottlingError(err)
+		p.setBackOffIfSeedThrottlingError(err)
 		return nil, err
 	}
 	return shootClient, nil
 }
 
 func (p *Prober) probeAPIServer(ctx context.Context) error {
-	discoveryClient, err := p.shootClientCreator.CreateDiscoveryClient(ctx, p.l, p.config.ProbeTimeout.Duration)
+	if len(p.config.APIServerProbeEndpoints) == 0 {
+		err := p.probeAPIServerViaHost(ctx, p.apiServerProbeTargetHost())
+		p.setBackOffIfShootThrottlingError(err)
+		return err
+	}
+	return p.probeAPIServerViaEndpoints(ctx)
+}
+
+// probeAPIServerViaEndpoints probes the API server via the server of the kubeconfig as well as via each of the APIServerProbeEndpoints concurrently.
+// An error is only returned if at least APIServerProbeFailureQuorum of these probes have failed, so that a single bad backend of a highly available
+// API server does not fail the probe.
+func (p *Prober) probeAPIServerViaEndpoints(ctx context.Context) error {
+	hosts := make([]string, 0, len(p.config.APIServerProbeEndpoints)+1)
+	hosts = append(hosts, p.apiServerProbeTargetHost())
+	for _, endpoint := range p.config.APIServerProbeEndpoints {
+		hosts = append(hosts, fmt.Sprintf("https://%s.%s.svc:%d", endpoint.ServiceName, p.namespace, endpoint.Port))
+	}
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = p.probeAPIServerViaHost(ctx, host)
+		}()
+	}
+	wg.Wait()
+
+	var combinedErr error
+	for i, err := range errs {
+		p.setBackOffIfShootThrottlingError(err)
+		if err != nil {
+			combinedErr = multierr.Append(combinedErr, fmt.Errorf("probe via %s failed: %w", hostOrKubeConfigServer(hosts[i]), err))
+		}
+	}
+	if combinedErr == nil {
+		return nil
+	}
+	failedProbes := len(combinedErr.(*multierr.Error).Errors)
+	quorum := *util.GetValOrDefault(p.config.APIServerProbeFailureQuorum, len(hosts)/2+1)
+	if failedProbes >= quorum {
+		return combinedErr
+	}
+	p.l.Info("API server probe failed via some of the endpoints, ignoring as the failure quorum has not been reached", "failedProbes", failedProbes, "probes", len(hosts), "quorum", quorum, "err", combinedErr.Error())
+	return nil
+}
+
+// probeAPIServerViaHost probes the API server via the given host. If host is empty then the server of the kubeconfig is used. As it can be called
+// concurrently it is left to the caller to back off in case of throttling errors.
+func (p *Prober) probeAPIServerViaHost(ctx context.Context, host string) error {
+	var (
+		discoveryClient discovery.DiscoveryInterface
+		err             error
+	)
+	timeout := getTimeoutOrDefault(p.config.APIServerProbeTimeout, p.config.ProbeTimeout)
+	overrides := p.apiServerConnectionOverrides(host)
+	if overrides.IsEmpty() {
+		discoveryClient, err = p.shootClientCreator.CreateDiscoveryClient(ctx, p.l, timeout)
+	} else {
+		discoveryClient, err = p.shootClientCreator.CreateDiscoveryClientWithOverrides(ctx, p.l, timeout, overrides)
+	}
 	if err != nil {
-		p.l.Error(err, "Failed to create discovery client, probe will be re-attempted")
-		p.setBackOffIfThrottlingError(err)
+		p.l.Error(err, "Failed to create discovery client, probe will be re-attempted", "host", hostOrKubeConfigServer(host))
 		return err
 	}
+	if p.config.APIServerProbeTarget != nil && p.config.APIServerProbeTarget.Path != "" {
+		return discoveryClient.RESTClient().Get().AbsPath(p.config.APIServerProbeTarget.Path).Do(ctx).Error()
+	}
 	_, err = discoveryClient.ServerVersion()
-	p.setBackOffIfThrottlingError(err)
 	return err
 }
 
-func (p *Prober) probeNodeLeases(ctx context.Context, shootClient client.Client) ([]coordinationv1.Lease, error) {
-	nodeNames, err := p.getFilteredNodeNames(ctx, shootClient)
+// apiServerProbeTargetHost returns the host which replaces the server of the kubeconfig for the API server probe. It is empty if the server of the
+// kubeconfig is used.
+func (p *Prober) apiServerProbeTargetHost() string {
+	if p.config.APIServerProbeTarget == nil {
+		return ""
+	}
+	return p.config.APIServerProbeTarget.Host
+}
+
+// apiServerConnectionOverrides returns the overrides for the connection to the API server via the given host. The server name and the CA bundle of
+// the APIServerProbeTarget apply to all hosts.
+func (p *Prober) apiServerConnectionOverrides(host string) util.ConnectionOverrides {
+	overrides := util.ConnectionOverrides{Host: host}
+	if target := p.config.APIServerProbeTarget; target != nil {
+		overrides.ServerName = target.ServerName
+		overrides.CAData = []byte(target.CABundle)
+	}
+	return overrides
+}
+
+func hostOrKubeConfigServer(host string) string {
+	if host == "" {
+		return "server of the kubeconfig"
+	}
+	return host
+}
+
+// getTimeoutOrDefault returns timeout if it is set, else it falls back to defaultTimeout.
+func getTimeoutOrDefault(timeout, defaultTimeout *metav1.Duration) time.Duration {
+	if timeout != nil {
+		return timeout.Duration
+	}
+	return defaultTimeout.Duration
+}
+
+// getDurationOrZero returns the duration if it is set, else zero.
+func getDurationOrZero(duration *metav1.Duration) time.Duration {
+	if duration != nil {
+		return duration.Duration
+	}
+	return 0
+}
+
+func (p *Prober) probeNodeLeases(ctx context.Context, shootClient client.Client) (nodeLeaseProbeResult, error) {
+	candidateNodes, totalNodeCount, machineCount, err := p.getFilteredNodeNames(ctx, shootClient)
 	if err != nil {
-		return nil, err
+		return nodeLeaseProbeResult{}, err
 	}
-	return p.getFilteredNodeLeases(ctx, shootClient, nodeNames)
+	nodeLeases, err := p.getFilteredNodeLeases(ctx, shootClient, candidateNodes)
+	if err != nil {
+		return nodeLeaseProbeResult{}, err
+	}
+	return nodeLeaseProbeResult{
+		totalNodeCount:           totalNodeCount,
+		candidateNodeCount:       len(candidateNodes),
+		candidateNodeLeases:      nodeLeases,
+		machineCount:             machineCount,
+		candidateNodeWorkerPools: candidateNodes,
+	}, nil
 }
 
 // getFilteredNodeNames filters nodes for which node leases should be eventually checked in the caller. This function filters out the nodes which are:
 // 1. Not managed by MCM - these nodes will not be considered for lease probe.
 // 2. Unhealthy (checked via node conditions) - these will not be considered for lease probe allowing MCM to replace these nodes.
 // 3. If the corresponding Machine object for a node has its state set to Terminating or Failed, the node will not be considered for lease probe.
-func (p *Prober) getFilteredNodeNames(ctx context.Context, shootClient client.Client) ([]string, error) {
+// 4. Younger than MinNodeAge - these nodes may not have renewed their first lease yet.
+// 5. Tainted or annotated with any of the ExcludedNodeTaintKeys or ExcludedNodeAnnotationKeys - these nodes are typically cordoned or about to be
+// deleted and may legitimately stop renewing their leases.
+// 6. Part of any of the ExcludedWorkerPools - the lease behavior of these pools should not influence the scaling of the control plane.
+// If UnmanagedNodes is enabled, the nodes are not filtered via 1. and 3. and no machines are read.
+// The names of the remaining nodes are returned mapped to the worker pools they belong to. It additionally returns the total number of nodes in
+// the shoot and the number of machines in the shoot control namespace.
+func (p *Prober) getFilteredNodeNames(ctx context.Context, shootClient client.Client) (map[string]string, int, int, error) {
 	nodes := &corev1.NodeList{}
 	if err := shootClient.List(ctx, nodes); err != nil {
-		p.setBackOffIfThrottlingError(err)
+		p.setBackOffIfShootThrottlingError(err)
 		p.l.Error(err, "Failed to list nodes, will retry probe")
-		return nil, err
+		return nil, 0, 0, err
 	}
-	machines, err := p.getMachines(ctx)
-	if err != nil {
-		return nil, err
+	unmanagedNodes := pointer.BoolDeref(p.config.UnmanagedNodes, false)
+	var machines []v1alpha1.Machine
+	if !unmanagedNodes {
+		var err error
+		if machines, err = p.getMachines(ctx); err != nil {
+			return nil, 0, 0, err
+		}
 	}
-	nodeNames := make([]string, 0, len(nodes.Items))
+	candidateNodes := make(map[string]string, len(nodes.Items))
 	for _, node := range nodes.Items {
-		if util.IsNodeManagedByMCM(&node) &&
+		if (unmanagedNodes || util.IsNodeManagedByMCM(&node)) &&
+			!util.IsNodeYoungerThan(&node, getDurationOrZero(p.config.MinNodeAge)) &&
+			!util.HasAnyTaint(&node, p.config.ExcludedNodeTaintKeys) &&
+			!util.HasAnyAnnotation(&node, p.config.ExcludedNodeAnnotationKeys) &&
+			!util.IsNodeInAnyWorkerPool(&node, p.config.ExcludedWorkerPools) &&
 			util.IsNodeHealthyByConditions(&node, util.GetWorkerUnhealthyNodeConditions(&node, p.workerNodeConditions)) &&
-			util.GetMachineNotInFailedOrTerminatingState(node.Name, machines) != nil {
-			nodeNames = append(nodeNames, node.Name)
+			(unmanagedNodes || util.GetMachineNotInFailedOrTerminatingState(node.Name, machines) != nil) {
+			candidateNodes[node.Name] = node.Labels[util.WorkerPoolLabel]
 		}
 	}
-	return nodeNames, nil
+	return candidateNodes, len(nodes.Items), len(machines), nil
 }
 
 // getMachines will retrieve all machines in the shoot namespace for which this probe is running.
 func (p *Prober) getMachines(ctx context.Context) ([]v1alpha1.Machine, error) {
 	machines := &v1alpha1.MachineList{}
 	if err := p.seedClient.List(ctx, machines, client.InNamespace(p.namespace)); err != nil {
-		p.setBackOffIfThrottlingError(err)
+		p.setBackOffIfSeedThrottlingError(err)
 		p.l.Error(err, "Failed to list machines, will retry probe")
 		return nil, err
 	}
 	return machines.Items, nil
 }
 
-// getFilteredNodeLeases filters out node leases which are not created for given nodeNames. The nodes are filtered via getFilteredNodeNames.
-// It is assumed that the node leases have the same name as the corresponding node name for which they are created.
-func (p *Prober) getFilteredNodeLeases(ctx context.Context, shootClient client.Client, nodeNames []string) ([]coordinationv1.Lease, error) {
+// getFilteredNodeLeases filters out node leases which are not created for any of the given candidate nodes. The nodes are filtered via
+// getFilteredNodeNames. It is assumed that the node leases have the same name as the corresponding node name for which they are created.
+func (p *Prober) getFilteredNodeLeases(ctx context.Context, shootClient client.Client, candidateNodes map[string]string) ([]coordinationv1.Lease, error) {
 	leases := &coordinationv1.LeaseList{}
 	if err := shootClient.List(ctx, leases, client.InNamespace(nodeLeaseNamespace)); err != nil {
-		p.setBackOffIfThrottlingError(err)
+		p.setBackOffIfShootThrottlingError(err)
 		p.l.Error(err, "Failed to list leases, will retry probe")
 		return nil, err
 	}
 
 	var filteredLeases []coordinationv1.Lease
 	for _, lease := range leases.Items {
-		if slices.Contains(nodeNames, lease.Name) {
+		if _, ok := candidateNodes[lease.Name]; ok {
 			// node leases have the same names as nodes
 			filteredLeases = append(filteredLeases, lease)
 		}
@@ -260,6 +746,7 @@ func (p *Prober) backOffIfNeeded() {
 		<-p.backOff.C
 		p.backOff.Stop()
 		p.backOff = nil
+		p.backOffUntil = time.Time{}
 	}
 }
 
@@ -271,18 +758,41 @@ func (p *Prober) doProbe(client kubernetes.Interface) error {
 	return nil
 }
 
-func (p *Prober) setBackOffIfThrottlingError(err error) {
+// setBackOffIfShootThrottlingError backs off if the error is caused by the throttling of a request to the shoot control plane API server.
+func (p *Prober) setBackOffIfShootThrottlingError(err error) {
+	p.setBackOffIfThrottlingError(err, p.config.ShootThrottlingBackOff)
+}
+
+// setBackOffIfSeedThrottlingError backs off if the error is caused by the throttling of a request to the seed API server.
+func (p *Prober) setBackOffIfSeedThrottlingError(err error) {
+	p.setBackOffIfThrottlingError(err, p.config.SeedThrottlingBackOff)
+}
+
+func (p *Prober) setBackOffIfThrottlingError(err error, throttlingBackOff *metav1.Duration) {
 	if err != nil && apierrors.IsTooManyRequests(err) {
-		p.l.V(4).Info("API server is throttled, backing off", "backOffDuration", backOffDurationForThrottledRequests.Seconds())
-		p.resetBackoff(backOffDurationForThrottledRequests)
+		backOffDuration := p.getThrottlingBackOffDuration(err, throttlingBackOff)
+		p.l.V(4).Info("API server is throttled, backing off", "backOffDuration", backOffDuration.Seconds())
+		p.resetBackoff(backOffDuration)
 	}
 }
 
+// getThrottlingBackOffDuration returns the duration suggested by the Retry-After information of the throttling error if HonorRetryAfter is
+// enabled, else the configured throttlingBackOff.
+func (p *Prober) getThrottlingBackOffDuration(err error, throttlingBackOff *metav1.Duration) time.Duration {
+	if pointer.BoolDeref(p.config.HonorRetryAfter, true) {
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return getTimeoutOrDefault(throttlingBackOff, &metav1.Duration{Duration: DefaultThrottlingBackOff})
+}
+
 func (p *Prober) resetBackoff(d time.Duration) {
 	if p.backOff != nil {
 		p.backOff.Stop()
 	}
 	p.backOff = time.NewTimer(d)
+	p.backOffUntil = time.Now().Add(d)
 }
 
 // AreWorkerNodeConditionsStale checks if the worker node conditions are up-to-date
@@ -290,12 +800,161 @@ func (p *Prober) AreWorkerNodeConditionsStale(newWorkerNodeConditions map[string
 	return !reflect.DeepEqual(p.workerNodeConditions, newWorkerNodeConditions)
 }
 
+// HasAPIServerProbeFailed returns true if the most recent probe of the shoot control plane API server has failed.
+func (p *Prober) HasAPIServerProbeFailed() bool {
+	p.status.RLock()
+	defer p.status.RUnlock()
+	return p.status.apiServerProbeFailed
+}
+
+// HasLeaseProbeFailed returns true if the most recent node lease probe has failed, i.e. the fraction of expired node leases has reached the NodeLeaseFailureFraction.
+func (p *Prober) HasLeaseProbeFailed() bool {
+	p.status.RLock()
+	defer p.status.RUnlock()
+	return p.status.leaseProbeFailed
+}
+
+// AreDependentsScaledDown returns true if the dependent resources have been scaled down by the prober and have not been scaled up since.
+func (p *Prober) AreDependentsScaledDown() bool {
+	p.status.RLock()
+	defer p.status.RUnlock()
+	return p.status.dependentsScaledDown
+}
+
+// IsScalingPaused returns true if scale decisions have been paused by the most recent probe because the shoot has neither candidate nodes nor
+// machines, e.g. because its last worker pool has been scaled to zero.
+func (p *Prober) IsScalingPaused() bool {
+	p.status.RLock()
+	defer p.status.RUnlock()
+	return p.status.scalingPaused
+}
+
+func (p *Prober) setAPIServerProbeFailed(failed bool) {
+	p.status.Lock()
+	defer p.status.Unlock()
+	p.status.apiServerProbeFailed = failed
+	p.setShootMetric(metrics.ShootAPIProbeHealthy, boolToFloat64(!failed))
+}
+
+func (p *Prober) setLeaseProbeFailed(failed bool) {
+	p.status.Lock()
+	defer p.status.Unlock()
+	p.status.leaseProbeFailed = failed
+}
+
+func (p *Prober) setScalingPaused(paused bool) {
+	p.status.Lock()
+	defer p.status.Unlock()
+	p.status.scalingPaused = paused
+}
+
+func (p *Prober) setLastDecision(operation string, decidedAt time.Time) {
+	p.status.Lock()
+	defer p.status.Unlock()
+	p.status.lastDecision = operation
+	p.status.lastDecisionTime = decidedAt
+}
+
+func (p *Prober) getLastDecision() (string, time.Time) {
+	p.status.RLock()
+	defer p.status.RUnlock()
+	return p.status.lastDecision, p.status.lastDecisionTime
+}
+
+func (p *Prober) setDependentsScaledDown(scaledDown bool) {
+	p.status.Lock()
+	defer p.status.Unlock()
+	p.status.dependentsScaledDown = scaledDown
+	p.setShootMetric(metrics.ShootDependentsScaledDown, boolToFloat64(scaledDown))
+}
+
+// setShootMetric sets the value of a per-shoot gauge for the shoot control namespace of the prober. Once the prober has been closed its
+// series have been deleted and are not set again by a probe run which is still in progress.
+func (p *Prober) setShootMetric(gauge *prometheus.GaugeVec, value float64) {
+	if p.IsClosed() {
+		return
+	}
+	gauge.WithLabelValues(p.namespace).Set(value)
+}
+
+// deleteShootMetrics deletes the series of all per-shoot gauges for the shoot control namespace of the prober.
+func (p *Prober) deleteShootMetrics() {
+	for _, gauge := range []*prometheus.GaugeVec{metrics.ShootAPIProbeHealthy, metrics.ShootLeaseExpiredFraction, metrics.ShootDependentsScaledDown, metrics.ShootScaleFlowInFlight} {
+		gauge.DeleteLabelValues(p.namespace)
+	}
+	metrics.ShootDependentsScaledDownDurationSeconds.DeleteLabelValues(p.namespace)
+	metrics.ShootProberConfigInfo.DeletePartialMatch(prometheus.Labels{metrics.LabelShootNamespace: p.namespace})
+	metrics.ShootWorkerPoolLeaseExpiredFraction.DeletePartialMatch(prometheus.Labels{metrics.LabelShootNamespace: p.namespace})
+}
+
+// setWorkerPoolLeaseExpiredFractions replaces the series of the per-worker-pool expired fraction gauge for the shoot control namespace of the prober
+// with the fractions of the given node lease probe result and logs them. The fractions are only informational, the scale decision is still based on
+// the expired fraction of all candidate node leases. Node leases of nodes without a worker pool are not considered.
+func (p *Prober) setWorkerPoolLeaseExpiredFractions(result nodeLeaseProbeResult) {
+	leaseCounts := make(map[string]int)
+	expiredLeaseCounts := make(map[string]int)
+	for _, lease := range result.candidateNodeLeases {
+		workerPool := result.candidateNodeWorkerPools[lease.Name]
+		if workerPool == "" {
+			continue
+		}
+		leaseCounts[workerPool]++
+		if p.isLeaseExpired(lease) {
+			expiredLeaseCounts[workerPool]++
+		}
+	}
+	fractions := make(map[string]float64, len(leaseCounts))
+	for workerPool, leaseCount := range leaseCounts {
+		fractions[workerPool] = expiredFraction(leaseCount, expiredLeaseCounts[workerPool])
+	}
+	if len(fractions) > 0 {
+		p.l.Info("Computed the expired node lease fractions per worker pool", "workerPoolLeaseExpiredFractions", fractions)
+	}
+	if p.IsClosed() {
+		return
+	}
+	metrics.ShootWorkerPoolLeaseExpiredFraction.DeletePartialMatch(prometheus.Labels{metrics.LabelShootNamespace: p.namespace})
+	for workerPool, fraction := range fractions {
+		metrics.ShootWorkerPoolLeaseExpiredFraction.WithLabelValues(p.namespace, workerPool).Set(fraction)
+	}
+}
+
+// setConfigInfoMetric replaces the series of the config info gauge for the shoot control namespace of the prober with one for the given config hash.
+func (p *Prober) setConfigInfoMetric(configHash string) {
+	metrics.ShootProberConfigInfo.DeletePartialMatch(prometheus.Labels{metrics.LabelShootNamespace: p.namespace})
+	metrics.ShootProberConfigInfo.WithLabelValues(p.namespace, configHash).Set(1)
+}
+
+// expiredFraction returns the fraction of expired node leases. If there are no node leases then the fraction is 0.
+func expiredFraction(nodeLeaseCount, expiredNodeLeaseCount int) float64 {
+	if nodeLeaseCount == 0 {
+		return 0
+	}
+	return float64(expiredNodeLeaseCount) / float64(nodeLeaseCount)
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // IsInBackOff checks if the prober is in backoff. Currently, this is only used for testing purposes.
 func (p *Prober) IsInBackOff() bool {
 	return p.backOff != nil
 }
 
-// GetConfig returns the probe config for the prober.  Currently, this is only used for testing purposes.
+// GetConfig returns the most recent probe config of the prober.
 func (p *Prober) GetConfig() *papi.Config {
-	return p.config
+	p.latestConfig.RLock()
+	defer p.latestConfig.RUnlock()
+	return p.latestConfig.config
+}
+
+// ConfigHash returns the hash of the most recent probe config of the prober.
+func (p *Prober) ConfigHash() string {
+	p.latestConfig.RLock()
+	defer p.latestConfig.RUnlock()
+	return p.latestConfig.configHash
 }
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// benchmarkNodeCounts are the cluster sizes the probe decision logic is benchmarked with.
+var benchmarkNodeCounts = []int{1000, 10000, 50000}
+
+// benchmarkExpiredLeaseInterval expires every 10th node lease of the benchmarked clusters.
+const benchmarkExpiredLeaseInterval = 10
+
+func BenchmarkGetFilteredNodeNames(b *testing.B) {
+	for _, nodeCount := range benchmarkNodeCounts {
+		b.Run(fmt.Sprintf("nodes=%d", nodeCount), func(b *testing.B) {
+			p, shootClient, _ := createBenchmarkProber(b, nodeCount)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, _, err := p.getFilteredNodeNames(ctx, shootClient); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetFilteredNodeLeases(b *testing.B) {
+	for _, nodeCount := range benchmarkNodeCounts {
+		b.Run(fmt.Sprintf("nodes=%d", nodeCount), func(b *testing.B) {
+			p, shootClient, cluster := createBenchmarkProber(b, nodeCount)
+			ctx := context.Background()
+			candidateNodes := make(map[string]string, len(cluster.Nodes))
+			for _, node := range cluster.Nodes {
+				candidateNodes[node.Name] = node.Labels[util.WorkerPoolLabel]
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.getFilteredNodeLeases(ctx, shootClient, candidateNodes); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkShouldPerformScaleUp(b *testing.B) {
+	for _, nodeCount := range benchmarkNodeCounts {
+		b.Run(fmt.Sprintf("nodes=%d", nodeCount), func(b *testing.B) {
+			p, shootClient, _ := createBenchmarkProber(b, nodeCount)
+			result, err := p.probeNodeLeases(context.Background(), shootClient)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p.shouldPerformScaleUp(result.candidateNodeLeases, p.countExpiredNodeLeases(result.candidateNodeLeases))
+			}
+		})
+	}
+}
+
+// createBenchmarkProber creates a prober for a synthetic cluster of the given number of nodes. It returns the prober, the client of the shoot
+// and the synthetic cluster.
+func createBenchmarkProber(b *testing.B, nodeCount int) (*Prober, client.Client, test.SyntheticCluster) {
+	b.Helper()
+	cluster := test.GenerateSyntheticCluster(nodeCount, benchmarkExpiredLeaseInterval, test.DefaultNamespace)
+	shootClient := initializeShootClientBuilder(cluster.Nodes, cluster.NodeLeases).Build()
+	seedClient := initializeSeedClientBuilder(cluster.Machines, nil).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	p := NewProber(context.Background(), seedClient, seedClient, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+	return p, shootClient, cluster
+}
@@ -9,29 +9,50 @@ package prober
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gardener/dependency-watchdog/internal/claim"
+	"github.com/gardener/dependency-watchdog/internal/features"
+	"github.com/gardener/dependency-watchdog/internal/lifecycle"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
 	perrors "github.com/gardener/dependency-watchdog/internal/prober/errors"
 	k8sfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/k8s"
 	scalefakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/scale"
 	shootfakes "github.com/gardener/dependency-watchdog/internal/prober/fakes/shoot"
+	dwdScaler "github.com/gardener/dependency-watchdog/internal/prober/scaler"
 	"github.com/gardener/dependency-watchdog/internal/test"
 	"github.com/gardener/dependency-watchdog/internal/util"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
 	"github.com/gardener/machine-controller-manager/pkg/util/provider/machineutils"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	restfake "k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/pointer"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
@@ -46,19 +67,21 @@ var (
 func initializeTestScheme() *runtime.Scheme {
 	seedClientScheme := *scheme.Scheme
 	_ = v1alpha1.AddToScheme(&seedClientScheme)
+	_ = extensionsv1alpha1.AddToScheme(&seedClientScheme)
 	return &seedClientScheme
 }
 
 func TestAPIServerProbeFailure(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
-		name          string
-		discoveryErr  error
-		shouldBackOff bool
+		name            string
+		discoveryErr    error
+		shouldBackOff   bool
+		expectedErrCode perrors.ErrorCode
 	}{
-		{name: "Forbidden request error is returned by api server", discoveryErr: apierrors.NewForbidden(schema.GroupResource{}, "test", errors.New("forbidden")), shouldBackOff: false},
-		{name: "Unauthorized request error is returned by api server", discoveryErr: apierrors.NewUnauthorized("unauthorized"), shouldBackOff: false},
-		{name: "Throttling error is returned by api server", discoveryErr: apierrors.NewTooManyRequests("Too many requests", 10), shouldBackOff: true},
+		{name: "Forbidden request error is returned by api server", discoveryErr: apierrors.NewForbidden(schema.GroupResource{}, "test", errors.New("forbidden")), shouldBackOff: false, expectedErrCode: perrors.ErrProbeForbidden},
+		{name: "Unauthorized request error is returned by api server", discoveryErr: apierrors.NewUnauthorized("unauthorized"), shouldBackOff: false, expectedErrCode: perrors.ErrProbeUnauthorized},
+		{name: "Throttling error is returned by api server", discoveryErr: apierrors.NewTooManyRequests("Too many requests", 10), shouldBackOff: true, expectedErrCode: perrors.ErrProbeAPIServer},
 	}
 
 	g := NewWithT(t)
@@ -69,13 +92,112 @@ func TestAPIServerProbeFailure(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(entry.discoveryErr), k8sfakes.NewFakeClientBuilder().Build()).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(context.Background(), nil, test.DefaultNamespace, config, nil, nil, scc, logr.Discard())
+			p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
 			g.Expect(p.IsClosed()).To(BeTrue())
 			g.Expect(p.IsInBackOff()).To(Equal(entry.shouldBackOff))
-			assertError(g, err, entry.discoveryErr, perrors.ErrProbeAPIServer)
+			assertError(g, err, entry.discoveryErr, entry.expectedErrCode)
+		})
+	}
+}
+
+func TestLeaseProbeShouldNotBeConsideredFailedIfAPIServerProbeFails(t *testing.T) {
+	g := NewWithT(t)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(errors.New("connection refused")), k8sfakes.NewFakeClientBuilder().Build()).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+	p.setLeaseProbeFailed(true)
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(HaveOccurred())
+	g.Expect(p.HasLeaseProbeFailed()).To(BeFalse(), "the outcome of a lease probe of an earlier probe cycle should not be retained")
+}
+
+func TestAPIServerProbeViaMultipleEndpointsShouldRequireFailureQuorum(t *testing.T) {
+	t.Parallel()
+	throttlingErr := apierrors.NewTooManyRequests("Too many requests", 10)
+	unavailableErr := apierrors.NewServiceUnavailable("service unavailable")
+	testCases := []struct {
+		name                   string
+		kubeConfigServerErr    error
+		endpointErrs           []error
+		failureQuorum          *int
+		expectAPIServerFailure bool
+	}{
+		{name: "all probes succeed", endpointErrs: []error{nil, nil}, expectAPIServerFailure: false},
+		{name: "probe via one endpoint fails", endpointErrs: []error{unavailableErr, nil}, expectAPIServerFailure: false},
+		{name: "probe via kubeconfig server fails", kubeConfigServerErr: unavailableErr, endpointErrs: []error{nil, nil}, expectAPIServerFailure: false},
+		{name: "probes via majority fail", kubeConfigServerErr: unavailableErr, endpointErrs: []error{throttlingErr, nil}, expectAPIServerFailure: true},
+		{name: "probe via one endpoint fails with failure quorum of 1", endpointErrs: []error{nil, unavailableErr}, failureQuorum: pointer.Int(1), expectAPIServerFailure: true},
+	}
+	g := NewWithT(t)
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			entry := entry
+			t.Parallel()
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+			config.APIServerProbeFailureQuorum = entry.failureQuorum
+			sccBuilder := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(entry.kubeConfigServerErr), nil)
+			for i, err := range entry.endpointErrs {
+				serviceName := fmt.Sprintf("kube-apiserver-%d", i)
+				config.APIServerProbeEndpoints = append(config.APIServerProbeEndpoints, papi.APIServerProbeEndpoint{ServiceName: serviceName, Port: 443})
+				sccBuilder.WithDiscoveryClientForHost(fmt.Sprintf("https://%s.%s.svc:443", serviceName, test.DefaultNamespace), k8sfakes.NewFakeDiscoveryClient(err))
+			}
+
+			p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, sccBuilder.Build(), nil, nil, nil, logr.Discard())
+			err := p.probeAPIServer(context.Background())
+			if entry.expectAPIServerFailure {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(p.IsInBackOff()).To(Equal(errors.Is(entry.endpointErrs[0], throttlingErr)), "prober should back off if any of the probes has been throttled")
+		})
+	}
+}
+
+func TestAPIServerProbeShouldApplyProbeTarget(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		statusCode    int
+		expectFailure bool
+	}{
+		{name: "probe succeeds if the endpoint responds with 2xx", statusCode: http.StatusOK, expectFailure: false},
+		{name: "probe fails if the endpoint responds with 5xx", statusCode: http.StatusServiceUnavailable, expectFailure: true},
+	}
+	g := NewWithT(t)
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			entry := entry
+			t.Parallel()
+			var requestedPath atomic.Value
+			restClient := &restfake.RESTClient{
+				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+				Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+					requestedPath.Store(req.URL.Path)
+					return &http.Response{StatusCode: entry.statusCode, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+				}),
+			}
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+			config.APIServerProbeTarget = &papi.APIServerProbeTarget{Host: "https://api.shoot.example.com:443", ServerName: "api.shoot.example.com", CABundle: "ca-bundle", Path: "/readyz"}
+			scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(errors.New("server of the kubeconfig should not be probed")), nil).
+				WithDiscoveryClientForHost("https://api.shoot.example.com:443", k8sfakes.NewFakeDiscoveryClientWithRESTClient(restClient)).
+				Build()
+
+			p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+			err := p.probeAPIServer(context.Background())
+			if entry.expectFailure {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(requestedPath.Load()).To(Equal("/readyz"))
+			recordedOverrides := scc.(interface {
+				DiscoveryClientOverrides() []util.ConnectionOverrides
+			}).DiscoveryClientOverrides()
+			g.Expect(recordedOverrides).To(ConsistOf(util.ConnectionOverrides{Host: "https://api.shoot.example.com:443", ServerName: "api.shoot.example.com", CAData: []byte("ca-bundle")}))
 		})
 	}
 }
@@ -86,10 +208,11 @@ func TestDiscoveryClientCreationFailed(t *testing.T) {
 		name                       string
 		discoveryClientCreationErr error
 		shouldBackOff              bool
+		expectedErrCode            perrors.ErrorCode
 	}{
-		{name: "Forbidden request error is returned while creating discovery client", discoveryClientCreationErr: apierrors.NewForbidden(schema.GroupResource{}, "test", errors.New("forbidden")), shouldBackOff: false},
-		{name: "Unauthorized request error is returned while creating discovery client", discoveryClientCreationErr: apierrors.NewUnauthorized("unauthorized"), shouldBackOff: false},
-		{name: "Throttling error is returned while creating discovery client", discoveryClientCreationErr: apierrors.NewTooManyRequests("Too many requests", 10), shouldBackOff: true},
+		{name: "Forbidden request error is returned while creating discovery client", discoveryClientCreationErr: apierrors.NewForbidden(schema.GroupResource{}, "test", errors.New("forbidden")), shouldBackOff: false, expectedErrCode: perrors.ErrProbeForbidden},
+		{name: "Unauthorized request error is returned while creating discovery client", discoveryClientCreationErr: apierrors.NewUnauthorized("unauthorized"), shouldBackOff: false, expectedErrCode: perrors.ErrProbeUnauthorized},
+		{name: "Throttling error is returned while creating discovery client", discoveryClientCreationErr: apierrors.NewTooManyRequests("Too many requests", 10), shouldBackOff: true, expectedErrCode: perrors.ErrProbeAPIServer},
 	}
 	g := NewWithT(t)
 	for _, entry := range testCases {
@@ -99,13 +222,13 @@ func TestDiscoveryClientCreationFailed(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(nil, nil).WithDiscoveryClientCreationError(entry.discoveryClientCreationErr).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(context.Background(), nil, test.DefaultNamespace, config, nil, nil, scc, logr.Discard())
+			p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
 			g.Expect(p.IsClosed()).To(BeTrue())
 			g.Expect(p.IsInBackOff()).To(Equal(entry.shouldBackOff))
-			assertError(g, err, entry.discoveryClientCreationErr, perrors.ErrProbeAPIServer)
+			assertError(g, err, entry.discoveryClientCreationErr, entry.expectedErrCode)
 		})
 	}
 }
@@ -116,10 +239,11 @@ func TestClientCreationFailed(t *testing.T) {
 		name              string
 		clientCreationErr error
 		shouldBackOff     bool
+		expectedErrCode   perrors.ErrorCode
 	}{
-		{name: "Forbidden request error is returned while creating client", clientCreationErr: apierrors.NewForbidden(schema.GroupResource{}, "test", errors.New("forbidden")), shouldBackOff: false},
-		{name: "Unauthorized request error is returned while creating client", clientCreationErr: apierrors.NewUnauthorized("unauthorized"), shouldBackOff: false},
-		{name: "Throttling error is returned while creating client", clientCreationErr: apierrors.NewTooManyRequests("Too many requests", 10), shouldBackOff: true},
+		{name: "Forbidden request error is returned while creating client", clientCreationErr: apierrors.NewForbidden(schema.GroupResource{}, "test", errors.New("forbidden")), shouldBackOff: false, expectedErrCode: perrors.ErrProbeForbidden},
+		{name: "Unauthorized request error is returned while creating client", clientCreationErr: apierrors.NewUnauthorized("unauthorized"), shouldBackOff: false, expectedErrCode: perrors.ErrProbeUnauthorized},
+		{name: "Throttling error is returned while creating client", clientCreationErr: apierrors.NewTooManyRequests("Too many requests", 10), shouldBackOff: true, expectedErrCode: perrors.ErrSetupProbeClient},
 	}
 
 	shootDiscoveryClient := k8sfakes.NewFakeDiscoveryClient(nil)
@@ -131,13 +255,40 @@ func TestClientCreationFailed(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, nil).WithClientCreationError(entry.clientCreationErr).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(context.Background(), nil, test.DefaultNamespace, config, nil, nil, scc, logr.Discard())
+			p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
 			g.Expect(p.IsClosed()).To(BeTrue())
 			g.Expect(p.IsInBackOff()).To(Equal(entry.shouldBackOff))
-			assertError(g, err, entry.clientCreationErr, perrors.ErrSetupProbeClient)
+			assertError(g, err, entry.clientCreationErr, entry.expectedErrCode)
+		})
+	}
+}
+
+func TestThrottlingBackOffDuration(t *testing.T) {
+	throttlingErr := apierrors.NewTooManyRequests("Too many requests", 3)
+	throttlingErrWithoutRetryAfter := apierrors.NewTooManyRequests("Too many requests", 0)
+	testCases := []struct {
+		name                    string
+		err                     error
+		throttlingBackOff       *metav1.Duration
+		honorRetryAfter         *bool
+		expectedBackOffDuration time.Duration
+	}{
+		{name: "default should be used if no back off is configured", err: throttlingErrWithoutRetryAfter, expectedBackOffDuration: DefaultThrottlingBackOff},
+		{name: "configured back off should be used if retry after is not honored", err: throttlingErr, throttlingBackOff: &metav1.Duration{Duration: time.Minute}, honorRetryAfter: pointer.Bool(false), expectedBackOffDuration: time.Minute},
+		{name: "retry after should be honored by default", err: throttlingErr, throttlingBackOff: &metav1.Duration{Duration: time.Minute}, expectedBackOffDuration: 3 * time.Second},
+		{name: "retry after should be used if honored", err: throttlingErr, throttlingBackOff: &metav1.Duration{Duration: time.Minute}, honorRetryAfter: pointer.Bool(true), expectedBackOffDuration: 3 * time.Second},
+		{name: "configured back off should be used if retry after is not set", err: throttlingErrWithoutRetryAfter, throttlingBackOff: &metav1.Duration{Duration: time.Minute}, honorRetryAfter: pointer.Bool(true), expectedBackOffDuration: time.Minute},
+	}
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+			config.HonorRetryAfter = entry.honorRetryAfter
+			p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+			g.Expect(p.getThrottlingBackOffDuration(entry.err, entry.throttlingBackOff)).To(Equal(entry.expectedBackOffDuration))
 		})
 	}
 }
@@ -176,7 +327,7 @@ func TestNoScalingIfErrorInListingNodes(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, scc, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
@@ -222,7 +373,7 @@ func TestNoScalingIfErrorInListingMachines(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, scc, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
@@ -255,7 +406,7 @@ func TestLeaseProbeShouldNotConsiderNodesNotManagedByMCM(t *testing.T) {
 	scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-	p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, scc, logr.Discard())
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
 	g.Expect(p.IsClosed()).To(BeFalse())
 
 	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
@@ -263,6 +414,107 @@ func TestLeaseProbeShouldNotConsiderNodesNotManagedByMCM(t *testing.T) {
 	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
 }
 
+func TestLeaseProbeShouldConsiderNodesNotManagedByMCMIfUnmanagedNodesIsEnabled(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name, Annotations: map[string]string{machineutils.NotManagedByMCM: "true"}}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+
+	ctx := context.Background()
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	// listing machines fails as if MCM is not deployed at all, machines must not be read
+	seedClient := initializeSeedClientBuilder(nil, scaleTargetDeployments).RecordErrorForObjectsWithGVK("List", test.DefaultNamespace, corev1.SchemeGroupVersion.WithKind("Machines"),
+		apierrors.NewNotFound(v1alpha1.Resource("machines"), "")).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.UnmanagedNodes = pointer.Bool(true)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+	g.Expect(p.HasLeaseProbeFailed()).To(BeTrue())
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 0)
+}
+
+func TestLeaseProbeShouldNotConsiderNodesYoungerThanMinNodeAge(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	now := metav1.Now()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}, {Name: test.Node3Name, CreationTimestamp: now}, {Name: test.Node4Name, CreationTimestamp: now}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine3Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node3Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine4Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node4Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name}, {Name: test.Node2Name}, {Name: test.Node3Name, IsExpired: true}, {Name: test.Node4Name, IsExpired: true}})
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+
+	ctx := context.Background()
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.NodeLeaseFailureFraction = pointer.Float64(0.5)
+	config.MinNodeAge = &metav1.Duration{Duration: time.Minute}
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+	g.Expect(p.HasLeaseProbeFailed()).To(BeFalse(), "expired leases of nodes younger than MinNodeAge should not fail the lease probe")
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+}
+
+func TestLeaseProbeShouldNotConsiderCordonedOrToBeDeletedNodes(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name                   string
+		nodeSpec               test.NodeSpec
+		excludedNodeTaintKeys  []string
+		excludedWorkerPools    []string
+		expectLeaseProbeFailed bool
+	}{
+		{name: "node marked unschedulable", nodeSpec: test.NodeSpec{Unschedulable: true}, excludedNodeTaintKeys: DefaultExcludedNodeTaintKeys},
+		{name: "node tainted by cluster-autoscaler", nodeSpec: test.NodeSpec{Taints: []corev1.Taint{{Key: util.ToBeDeletedByClusterAutoscalerTaintKey, Effect: corev1.TaintEffectNoSchedule}}}, excludedNodeTaintKeys: DefaultExcludedNodeTaintKeys},
+		{name: "node annotated for deletion by MCM", nodeSpec: test.NodeSpec{Annotations: map[string]string{util.TriggerDeletionByMCMAnnotationKey: "true"}}, excludedNodeTaintKeys: DefaultExcludedNodeTaintKeys},
+		{name: "node marked unschedulable with exclusion by taints disabled", nodeSpec: test.NodeSpec{Unschedulable: true}, excludedNodeTaintKeys: []string{}, expectLeaseProbeFailed: true},
+		{name: "node of an excluded worker pool", nodeSpec: test.NodeSpec{Labels: map[string]string{util.WorkerPoolLabel: "gpu-batch"}}, excludedWorkerPools: []string{"gpu-batch"}},
+		{name: "node of a worker pool which is not excluded", nodeSpec: test.NodeSpec{Labels: map[string]string{util.WorkerPoolLabel: "worker-1"}}, excludedWorkerPools: []string{"gpu-batch"}, expectLeaseProbeFailed: true},
+	}
+	g := NewWithT(t)
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			entry := entry
+			t.Parallel()
+			drainedNodeSpec := entry.nodeSpec
+			drainedNodeSpec.Name = test.Node3Name
+			nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}, drainedNodeSpec})
+			machines := test.GenerateMachines([]test.MachineSpec{
+				{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+				{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+				{Name: test.Machine3Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node3Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+			}, test.DefaultNamespace)
+			leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name}, {Name: test.Node2Name, IsExpired: true}, {Name: test.Node3Name, IsExpired: true}})
+			scaleTargetDeployments := generateScaleTargetDeployments(1)
+
+			ctx := context.Background()
+			shootClient := initializeShootClientBuilder(nodes, leases).Build()
+			seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+			scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+			config.ExcludedNodeTaintKeys = entry.excludedNodeTaintKeys
+			config.ExcludedNodeAnnotationKeys = DefaultExcludedNodeAnnotationKeys
+			config.ExcludedWorkerPools = entry.excludedWorkerPools
+
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+			g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+			g.Expect(p.HasLeaseProbeFailed()).To(Equal(entry.expectLeaseProbeFailed))
+		})
+	}
+}
+
 func TestLeaseProbeShouldNotConsiderFailedOrTerminatingMachines(t *testing.T) {
 	t.Parallel()
 	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}, {Name: test.Node3Name}, {Name: test.Node4Name}})
@@ -303,7 +555,7 @@ func TestLeaseProbeShouldNotConsiderFailedOrTerminatingMachines(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, scc, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
@@ -358,7 +610,7 @@ func TestLeaseProbeShouldNotConsiderUnhealthyNodes(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, map[string][]string{test.Worker1Name: {test.NodeConditionDiskPressure, test.NodeConditionMemoryPressure}}, scaler, scc, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, map[string][]string{test.Worker1Name: {test.NodeConditionDiskPressure, test.NodeConditionMemoryPressure}}, scaler, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
@@ -401,7 +653,7 @@ func TestNoScalingIfErrorInListingLeases(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, scc, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
@@ -443,7 +695,7 @@ func TestNoScalingInSingleNodeClusters(t *testing.T) {
 			shootClientCreator := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, shootClientCreator, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, shootClientCreator, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
@@ -454,6 +706,82 @@ func TestNoScalingInSingleNodeClusters(t *testing.T) {
 	}
 }
 
+func TestNoScalingBelowConfiguredMinNodeCountForScaling(t *testing.T) {
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+
+	testCases := []struct {
+		name                       string
+		minNodeCountForScaling     int
+		expectedDeploymentReplicas int32
+	}{
+		{name: "no scale down should happen if the number of candidate nodes is below the minimum", minNodeCountForScaling: 3, expectedDeploymentReplicas: 1},
+		{name: "scale down should happen if the number of candidate nodes is not below the minimum", minNodeCountForScaling: 2, expectedDeploymentReplicas: 0},
+	}
+	g := NewWithT(t)
+	t.Parallel()
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			entry := entry
+			t.Parallel()
+			ctx := context.Background()
+			scaleTargetDeployments := generateScaleTargetDeployments(1)
+			shootClient := initializeShootClientBuilder(nodes, leases).Build()
+			seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+			shootClientCreator := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+			config.MinNodeCountForScaling = &entry.minNodeCountForScaling
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, shootClientCreator, nil, nil, nil, logr.Discard())
+			g.Expect(p.IsClosed()).To(BeFalse())
+
+			err := runProber(p, testProbeTimeout.Duration)
+			g.Expect(p.IsClosed()).To(BeTrue())
+			g.Expect(err).To(BeNil())
+			assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), entry.expectedDeploymentReplicas)
+		})
+	}
+}
+
+func TestScaleDecisionsShouldBePausedForShootsWithoutWorkers(t *testing.T) {
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachinePending}},
+	}, test.DefaultNamespace)
+
+	testCases := []struct {
+		name                       string
+		machines                   []*v1alpha1.Machine
+		expectScalingPaused        bool
+		expectedDeploymentReplicas int32
+	}{
+		{name: "scale decisions should be paused if there are neither nodes nor machines", expectScalingPaused: true, expectedDeploymentReplicas: 0},
+		{name: "scale up should happen if there are machines but no nodes yet", machines: machines, expectScalingPaused: false, expectedDeploymentReplicas: 1},
+	}
+
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			scaleTargetDeployments := generateScaleTargetDeployments(0)
+			shootClient := initializeShootClientBuilder(nil, nil).Build()
+			seedClient := initializeSeedClientBuilder(entry.machines, scaleTargetDeployments).Build()
+			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+			scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+			g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+			g.Expect(p.IsScalingPaused()).To(Equal(entry.expectScalingPaused))
+			assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), entry.expectedDeploymentReplicas)
+		})
+	}
+}
+
 func TestLeaseProbeShouldNotConsiderOrphanedLeases(t *testing.T) {
 	t.Parallel()
 	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
@@ -492,7 +820,7 @@ func TestLeaseProbeShouldNotConsiderOrphanedLeases(t *testing.T) {
 			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, scc, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
@@ -537,7 +865,7 @@ func TestSuccessfulProbesShouldRunScaleUp(t *testing.T) {
 			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, entry.scaleUpErr, nil)
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, shootClientCreator, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, shootClientCreator, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
@@ -573,11 +901,13 @@ func TestLeaseProbeFailureShouldRunScaleDown(t *testing.T) {
 	shootClientCreator := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
 
 	testCases := []struct {
-		name         string
-		scaleDownErr error
+		name              string
+		scaleDownErr      error
+		expectedErrorCode perrors.ErrorCode
 	}{
 		{name: "Scale Down Succeeds"},
-		{name: "Scale Down Fails", scaleDownErr: errors.New("scale down failed")},
+		{name: "Scale Down Fails", scaleDownErr: errors.New("scale down failed"), expectedErrorCode: perrors.ErrScaleDown},
+		{name: "Scale Down Is Rejected", scaleDownErr: fmt.Errorf("%w: admission webhook denied the request", dwdScaler.ErrScaleRejected), expectedErrorCode: perrors.ErrScaleRejected},
 	}
 
 	for _, entry := range testCases {
@@ -588,13 +918,13 @@ func TestLeaseProbeFailureShouldRunScaleDown(t *testing.T) {
 			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, entry.scaleDownErr)
 			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
 
-			p := NewProber(ctx, seedClient, test.DefaultNamespace, config, nil, scaler, shootClientCreator, logr.Discard())
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, shootClientCreator, nil, nil, nil, logr.Discard())
 			g.Expect(p.IsClosed()).To(BeFalse())
 
 			err := runProber(p, testProbeTimeout.Duration)
 			g.Expect(p.IsClosed()).To(BeTrue())
 			if entry.scaleDownErr != nil {
-				assertError(g, err, entry.scaleDownErr, perrors.ErrScaleDown)
+				assertError(g, err, entry.scaleDownErr, entry.expectedErrorCode)
 			} else {
 				g.Expect(err).To(BeNil())
 				targetDeploymentRefs := getDeploymentRefs(scaleTargetDeployments)
@@ -604,6 +934,89 @@ func TestLeaseProbeFailureShouldRunScaleDown(t *testing.T) {
 	}
 }
 
+func TestScalingShouldBeSkippedIfNamespaceIsAnnotated(t *testing.T) {
+	t.Parallel()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+
+	testCases := []struct {
+		name                       string
+		skipScalingAnnotationValue string
+		isLeaseExpired             bool
+		initialDeploymentReplicas  int32
+		expectedDeploymentReplicas int32
+	}{
+		{name: "no scale down should happen if namespace is annotated to skip scaling", skipScalingAnnotationValue: "true", isLeaseExpired: true, initialDeploymentReplicas: 1, expectedDeploymentReplicas: 1},
+		{name: "no scale up should happen if namespace is annotated to skip scaling", skipScalingAnnotationValue: "true", isLeaseExpired: false, initialDeploymentReplicas: 0, expectedDeploymentReplicas: 0},
+		{name: "scale down should happen if skip scaling annotation is set to false", skipScalingAnnotationValue: "false", isLeaseExpired: true, initialDeploymentReplicas: 1, expectedDeploymentReplicas: 0},
+		{name: "scale down should happen if skip scaling annotation has an invalid value", skipScalingAnnotationValue: "bingo", isLeaseExpired: true, initialDeploymentReplicas: 1, expectedDeploymentReplicas: 0},
+	}
+
+	shootDiscoveryClient := k8sfakes.NewFakeDiscoveryClient(nil)
+	g := NewWithT(t)
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			entry := entry
+			t.Parallel()
+			ctx := context.Background()
+			leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: entry.isLeaseExpired}, {Name: test.Node2Name, IsExpired: entry.isLeaseExpired}})
+			scaleTargetDeployments := generateScaleTargetDeployments(entry.initialDeploymentReplicas)
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: test.DefaultNamespace, Annotations: map[string]string{skipScalingAnnotationKey: entry.skipScalingAnnotationValue}}}
+			shootClient := initializeShootClientBuilder(nodes, leases).Build()
+			seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments, ns).Build()
+			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+			scc := shootfakes.NewFakeShootClientBuilder(shootDiscoveryClient, shootClient).Build()
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+			g.Expect(p.IsClosed()).To(BeFalse())
+
+			g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+			g.Expect(p.IsClosed()).To(BeTrue())
+			g.Expect(p.HasLeaseProbeFailed()).To(Equal(entry.isLeaseExpired))
+			assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), entry.expectedDeploymentReplicas)
+		})
+	}
+}
+
+func TestScalingShouldBeSkippedIfNamespaceIsClaimedByAnotherInstance(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	otherClaimer := claim.New(seedClient, seedClient, claim.ProberLeaseName, "other", time.Minute, clock.RealClock{})
+	claimed, _, err := otherClaimer.Claim(ctx, test.DefaultNamespace)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(claimed).To(BeTrue())
+
+	claimer := claim.New(seedClient, seedClient, claim.ProberLeaseName, "dwd", time.Minute, clock.RealClock{})
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, claimer, nil, logr.Discard())
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+	g.Expect(p.HasLeaseProbeFailed()).To(BeTrue(), "the outcome of the lease probe should be recorded although scaling has been skipped")
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+}
+
+func TestGetTimeoutOrDefault(t *testing.T) {
+	g := NewWithT(t)
+	defaultTimeout := &metav1.Duration{Duration: 30 * time.Second}
+	g.Expect(getTimeoutOrDefault(nil, defaultTimeout)).To(Equal(30 * time.Second))
+	g.Expect(getTimeoutOrDefault(&metav1.Duration{Duration: time.Minute}, defaultTimeout)).To(Equal(time.Minute))
+}
+
 //---------------------------------- Helper functions ----------------------------------
 
 func getDeploymentRefs(deployments []*appsv1.Deployment) []client.ObjectKey {
@@ -634,8 +1047,9 @@ func initializeShootClientBuilder(nodes []*corev1.Node, nodeLeases []*coordinati
 	return k8sfakes.NewFakeClientBuilder(shootObjects...)
 }
 
-func initializeSeedClientBuilder(machines []*v1alpha1.Machine, deployments []*appsv1.Deployment) *k8sfakes.FakeClientBuilder {
-	seedObjects := make([]client.Object, 0, len(machines)+len(deployments))
+func initializeSeedClientBuilder(machines []*v1alpha1.Machine, deployments []*appsv1.Deployment, additionalObjects ...client.Object) *k8sfakes.FakeClientBuilder {
+	seedObjects := make([]client.Object, 0, len(machines)+len(deployments)+len(additionalObjects))
+	seedObjects = append(seedObjects, additionalObjects...)
 	for _, machine := range machines {
 		seedObjects = append(seedObjects, machine)
 	}
@@ -659,6 +1073,8 @@ func runProber(p *Prober, d time.Duration) (err error) {
 	for {
 		select {
 		case <-exitAfter.C:
+			p.inFlightScale.wait()
+			p.collectScaleFlowErr()
 			err = p.lastErr
 			p.Close()
 			return
@@ -677,6 +1093,139 @@ func assertError(g *WithT, err error, expectedError error, expectedErrorCode per
 	}
 }
 
+// TestShootMetricsShouldReflectProbeResults is deliberately not run in parallel as all probers of the tests share the same namespace.
+func TestShootMetricsShouldReflectProbeResults(t *testing.T) {
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}, {Name: test.Node3Name}, {Name: test.Node4Name}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine3Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node3Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine4Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node4Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+
+	testCases := []struct {
+		name                         string
+		expiredNodeCount             int
+		initialDeploymentReplicas    int32
+		expectedLeaseExpiredFraction float64
+		expectedDependentsScaledDown float64
+	}{
+		{name: "dependents should not be reported as scaled down if the lease probe succeeds", expiredNodeCount: 1, initialDeploymentReplicas: 0, expectedLeaseExpiredFraction: 0.25, expectedDependentsScaledDown: 0},
+		{name: "dependents should be reported as scaled down if the lease probe fails", expiredNodeCount: 3, initialDeploymentReplicas: 1, expectedLeaseExpiredFraction: 0.75, expectedDependentsScaledDown: 1},
+	}
+	for _, entry := range testCases {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			var leaseSpecs []test.NodeLeaseSpec
+			for i, nodeName := range []string{test.Node1Name, test.Node2Name, test.Node3Name, test.Node4Name} {
+				leaseSpecs = append(leaseSpecs, test.NodeLeaseSpec{Name: nodeName, IsExpired: i < entry.expiredNodeCount})
+			}
+			scaleTargetDeployments := generateScaleTargetDeployments(entry.initialDeploymentReplicas)
+			shootClient := initializeShootClientBuilder(nodes, test.GenerateNodeLeases(leaseSpecs)).Build()
+			seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+			scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+			p.probe(ctx)
+			p.inFlightScale.wait()
+			g.Expect(testutil.ToFloat64(metrics.ShootAPIProbeHealthy.WithLabelValues(test.DefaultNamespace))).To(Equal(1.0))
+			g.Expect(testutil.ToFloat64(metrics.ShootLeaseExpiredFraction.WithLabelValues(test.DefaultNamespace))).To(Equal(entry.expectedLeaseExpiredFraction))
+			g.Expect(testutil.ToFloat64(metrics.ShootDependentsScaledDown.WithLabelValues(test.DefaultNamespace))).To(Equal(entry.expectedDependentsScaledDown))
+
+			p.Close()
+			g.Expect(metrics.ShootAPIProbeHealthy.DeleteLabelValues(test.DefaultNamespace)).To(BeFalse(), "series should be deleted when the prober is closed")
+			g.Expect(metrics.ShootLeaseExpiredFraction.DeleteLabelValues(test.DefaultNamespace)).To(BeFalse(), "series should be deleted when the prober is closed")
+			g.Expect(metrics.ShootDependentsScaledDown.DeleteLabelValues(test.DefaultNamespace)).To(BeFalse(), "series should be deleted when the prober is closed")
+			g.Expect(metrics.ShootScaleFlowInFlight.DeleteLabelValues(test.DefaultNamespace)).To(BeFalse(), "series should be deleted when the prober is closed")
+		})
+	}
+}
+
+func TestShootMetricsShouldReflectLeaseExpiredFractionsPerWorkerPool(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{
+		{Name: test.Node1Name, Labels: map[string]string{util.WorkerPoolLabel: test.Worker1Name}},
+		{Name: test.Node2Name, Labels: map[string]string{util.WorkerPoolLabel: test.Worker1Name}},
+		{Name: test.Node3Name, Labels: map[string]string{util.WorkerPoolLabel: test.Worker2Name}},
+		{Name: test.Node4Name},
+	})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine3Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node3Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine4Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node4Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{
+		{Name: test.Node1Name, IsExpired: true},
+		{Name: test.Node2Name, IsExpired: false},
+		{Name: test.Node3Name, IsExpired: false},
+		{Name: test.Node4Name, IsExpired: true},
+	})
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, generateScaleTargetDeployments(1)).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.6)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	p.probe(ctx)
+	p.inFlightScale.wait()
+	g.Expect(testutil.ToFloat64(metrics.ShootLeaseExpiredFraction.WithLabelValues(test.DefaultNamespace))).To(Equal(0.5))
+	g.Expect(testutil.ToFloat64(metrics.ShootWorkerPoolLeaseExpiredFraction.WithLabelValues(test.DefaultNamespace, test.Worker1Name))).To(Equal(0.5))
+	g.Expect(testutil.ToFloat64(metrics.ShootWorkerPoolLeaseExpiredFraction.WithLabelValues(test.DefaultNamespace, test.Worker2Name))).To(Equal(0.0))
+	g.Expect(metrics.ShootWorkerPoolLeaseExpiredFraction.DeleteLabelValues(test.DefaultNamespace, "")).To(BeFalse(), "nodes without a worker pool should not have a series")
+
+	p.Close()
+	g.Expect(metrics.ShootWorkerPoolLeaseExpiredFraction.DeletePartialMatch(prometheus.Labels{metrics.LabelShootNamespace: test.DefaultNamespace})).To(BeZero(), "series should be deleted when the prober is closed")
+}
+
+func TestRunningProberShouldPickUpUpdatedConfig(t *testing.T) {
+	g := NewWithT(t)
+	t.Parallel()
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}, {Name: test.Node3Name}, {Name: test.Node4Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{
+		{Name: test.Node1Name, IsExpired: true},
+		{Name: test.Node2Name, IsExpired: true},
+		{Name: test.Node3Name, IsExpired: false},
+		{Name: test.Node4Name, IsExpired: false},
+	})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine3Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node3Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine4Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node4Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	scaleTargetDeployments := generateScaleTargetDeployments(0)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	go p.Run()
+	// half of the node leases have expired which is below the default node lease failure fraction
+	g.Eventually(func() int32 {
+		deploy := &appsv1.Deployment{}
+		g.Expect(seedClient.Get(ctx, getDeploymentRefs(scaleTargetDeployments)[0], deploy)).To(Succeed())
+		return *deploy.Spec.Replicas
+	}).WithTimeout(2 * time.Second).Should(Equal(int32(1)))
+
+	// swap the config via a copy of the prober, as it is done for the copy which is registered with the Manager
+	updatedConfig := *config
+	updatedConfig.NodeLeaseFailureFraction = pointer.Float64(0.4)
+	registeredProber := *p
+	g.Expect(registeredProber.UpdateConfig(&updatedConfig)).To(BeTrue())
+	g.Eventually(p.AreDependentsScaledDown).WithTimeout(2*time.Second).Should(BeTrue(), "running prober should scale down with the updated node lease failure fraction")
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 0)
+}
+
 func createConfig(probeInterval metav1.Duration, initialDelay metav1.Duration, kcmNodeMonitorGraceDuration metav1.Duration, backoffJitterFactor float64) *papi.Config {
 	return &papi.Config{
 		ProbeInterval:               &probeInterval,
@@ -687,3 +1236,421 @@ func createConfig(probeInterval metav1.Duration, initialDelay metav1.Duration, k
 		NodeLeaseFailureFraction:    pointer.Float64(DefaultNodeLeaseFailureFraction),
 	}
 }
+
+func TestScaleDownShouldBeSuppressedIfCircuitBreakerIsOpen(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, openCircuitBreaker{}, nil, nil, logr.Discard())
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+	g.Expect(p.HasLeaseProbeFailed()).To(BeTrue())
+	g.Expect(p.AreDependentsScaledDown()).To(BeFalse())
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+}
+
+func TestScaleDownShouldBeSkippedIfScaleDownsAreDisabled(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.DisableScaleDown = pointer.Bool(true)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+	g.Expect(p.HasLeaseProbeFailed()).To(BeTrue())
+	g.Expect(p.AreDependentsScaledDown()).To(BeFalse())
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+}
+
+func TestScaleDownShouldBeSkippedWhileProberIsWarmingUp(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.WarmUpDuration = &metav1.Duration{Duration: time.Hour}
+	suppressedBefore := testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonWarmUp))
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	g.Expect(runProber(p, testProbeTimeout.Duration)).To(BeNil())
+	g.Expect(p.HasLeaseProbeFailed()).To(BeTrue())
+	g.Expect(p.AreDependentsScaledDown()).To(BeFalse())
+	g.Expect(testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(metrics.ReasonWarmUp))).To(BeNumerically(">", suppressedBefore))
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+
+	p.warmUpStartedAt = time.Now().Add(-time.Hour)
+	p.probe(ctx)
+	p.inFlightScale.wait()
+	g.Expect(p.AreDependentsScaledDown()).To(BeTrue(), "dependents should be scaled down once the warm-up duration has passed")
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 0)
+}
+
+func TestReassertScaleDownShouldOnlyScaleDownIfDependentsAreScaledDown(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+	seedClient := initializeSeedClientBuilder(nil, scaleTargetDeployments).Build()
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, nil, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	g.Expect(p.ReassertScaleDown(ctx)).To(BeFalse(), "scale-down should not be re-asserted if the dependents are not scaled down")
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+
+	p.setDependentsScaledDown(true)
+	g.Expect(p.ReassertScaleDown(ctx)).To(BeTrue())
+	p.inFlightScale.wait()
+	g.Expect(p.inFlightScale.collectErr()).ToNot(HaveOccurred())
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 0)
+}
+
+func TestReassertScaleDownShouldNotScaleDownIfScaleDownIsNotAllowed(t *testing.T) {
+	disabledScaleDownOverrides := overrides.New(zap.NewAtomicLevel())
+	disabledScaleDownOverrides.Apply(map[string]string{overrides.DisableScaleDownAnnotationKey: "true"}, pmLogger)
+	tests := []struct {
+		title            string
+		objs             []client.Object
+		claimedByOther   bool
+		configure        func(config *papi.Config)
+		circuitBreaker   ScaleDownCircuitBreaker
+		suppressedReason string
+	}{
+		{title: "namespace is annotated to skip scaling",
+			objs: []client.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: test.DefaultNamespace, Annotations: map[string]string{skipScalingAnnotationKey: "true"}}}}},
+		{title: "namespace is claimed by another instance", claimedByOther: true},
+		{title: "scale-downs are disabled", configure: func(config *papi.Config) { config.DisableScaleDown = pointer.Bool(true) }, suppressedReason: metrics.ReasonScaleDownDisabled},
+		{title: "prober is warming up", configure: func(config *papi.Config) { config.WarmUpDuration = &metav1.Duration{Duration: time.Hour} }, suppressedReason: metrics.ReasonWarmUp},
+		{title: "scale-downs are disabled via the runtime override", circuitBreaker: NewRuntimeOverridesCircuitBreaker(disabledScaleDownOverrides, record.NewFakeRecorder(10)),
+			suppressedReason: metrics.ReasonRuntimeOverride},
+		{title: "scale-down circuit breaker is open", circuitBreaker: openCircuitBreaker{}},
+	}
+	for _, entry := range tests {
+		t.Run(entry.title, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			scaleTargetDeployments := generateScaleTargetDeployments(1)
+			seedClient := initializeSeedClientBuilder(nil, scaleTargetDeployments, entry.objs...).Build()
+			scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+			config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+			if entry.configure != nil {
+				entry.configure(config)
+			}
+			var claimer *claim.Claimer
+			if entry.claimedByOther {
+				claimed, _, err := claim.New(seedClient, seedClient, claim.ProberLeaseName, "other", time.Minute, clock.RealClock{}).Claim(ctx, test.DefaultNamespace)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(claimed).To(BeTrue())
+				claimer = claim.New(seedClient, seedClient, claim.ProberLeaseName, "dwd", time.Minute, clock.RealClock{})
+			}
+			var suppressedBefore float64
+			if entry.suppressedReason != "" {
+				suppressedBefore = testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(entry.suppressedReason))
+			}
+
+			p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, nil, entry.circuitBreaker, claimer, nil, logr.Discard())
+			defer p.Close()
+			p.setDependentsScaledDown(true)
+			g.Expect(p.ReassertScaleDown(ctx)).To(BeFalse())
+			g.Expect(p.ScaleOperationInFlight()).To(BeEmpty())
+			assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+			if entry.suppressedReason != "" {
+				g.Expect(testutil.ToFloat64(metrics.ScaleDownsSuppressedTotal.WithLabelValues(entry.suppressedReason))).To(Equal(suppressedBefore + 1))
+			}
+		})
+	}
+}
+
+func TestPersistentUnauthorizedErrorsShouldInvalidateShootClientCache(t *testing.T) {
+	g := NewWithT(t)
+	unauthorizedErr := apierrors.NewUnauthorized("unauthorized")
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(unauthorizedErr), nil).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+	for i := 0; i < unauthorizedThresholdForClientInvalidation-1; i++ {
+		p.probe(context.Background())
+	}
+	cacheInvalidations := scc.(interface{ CacheInvalidations() int }).CacheInvalidations
+	g.Expect(cacheInvalidations()).To(BeZero(), "cached shoot clients should not be invalidated before the threshold is reached")
+	p.probe(context.Background())
+	g.Expect(cacheInvalidations()).To(Equal(1), "cached shoot clients should be invalidated once the threshold is reached")
+	assertError(g, p.lastErr, unauthorizedErr, perrors.ErrProbeUnauthorized)
+}
+
+func TestForbiddenErrorShouldRecordEvent(t *testing.T) {
+	g := NewWithT(t)
+	forbiddenErr := apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", errors.New("forbidden"))
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), nil).WithClientCreationError(forbiddenErr).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	recorder := record.NewFakeRecorder(1)
+
+	p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, recorder, logr.Discard())
+	p.probe(context.Background())
+	assertError(g, p.lastErr, forbiddenErr, perrors.ErrProbeForbidden)
+	g.Expect(recorder.Events).To(Receive(ContainSubstring(eventReasonProbeForbidden)))
+}
+
+func TestForbiddenErrorOfLeaseProbeShouldRecordEvent(t *testing.T) {
+	g := NewWithT(t)
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	forbiddenErr := apierrors.NewForbidden(schema.GroupResource{Group: coordinationv1.GroupName, Resource: "leases"}, "", errors.New("forbidden"))
+	shootClient := initializeShootClientBuilder(nodes, leases).RecordErrorForObjectsWithGVK("List", nodeLeaseNamespace, corev1.SchemeGroupVersion.WithKind("Leases"), forbiddenErr).Build()
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	recorder := record.NewFakeRecorder(1)
+	forbiddenBefore := testutil.ToFloat64(metrics.ProbeAuthFailuresTotal.WithLabelValues(metrics.ReasonForbidden))
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	seedClient := initializeSeedClientBuilder(machines, nil).Build()
+
+	p := NewProber(context.Background(), seedClient, seedClient, test.DefaultNamespace, config, nil, nil, scc, nil, nil, recorder, logr.Discard())
+	p.probe(context.Background())
+	assertError(g, p.lastErr, forbiddenErr, perrors.ErrProbeForbidden)
+	g.Expect(recorder.Events).To(Receive(ContainSubstring(eventReasonProbeForbidden)))
+	g.Expect(testutil.ToFloat64(metrics.ProbeAuthFailuresTotal.WithLabelValues(metrics.ReasonForbidden))).To(BeNumerically(">", forbiddenBefore))
+}
+
+// TestScaleFlowShouldNotBlockProbeLoop is deliberately not run in parallel as it checks a per-shoot metric of the default namespace.
+func TestScaleFlowShouldNotBlockProbeLoop(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.AsyncScaling, true)
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, generateScaleTargetDeployments(1)).Build()
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	scaler := &blockingScaler{release: make(chan struct{})}
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	p.probe(ctx)
+	p.probe(ctx)
+	g.Expect(p.ScaleOperationInFlight()).To(Equal(scaleDecisionOperationScaleDown), "the probe should return while the scale flow is in flight")
+	g.Expect(testutil.ToFloat64(metrics.ShootScaleFlowInFlight.WithLabelValues(test.DefaultNamespace))).To(Equal(1.0))
+
+	close(scaler.release)
+	p.inFlightScale.wait()
+	g.Expect(scaler.scaleDowns.Load()).To(Equal(int32(1)), "a scale flow which is already in flight should not be started again")
+	g.Expect(p.ScaleOperationInFlight()).To(BeEmpty())
+	g.Expect(p.AreDependentsScaledDown()).To(BeTrue())
+	g.Expect(testutil.ToFloat64(metrics.ShootScaleFlowInFlight.WithLabelValues(test.DefaultNamespace))).To(BeZero())
+}
+
+// TestPanicOfScaleFlowShouldBeRecovered is deliberately not run in parallel as it checks a per-shoot metric of the default namespace.
+func TestPanicOfScaleFlowShouldBeRecovered(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.AsyncScaling, true)
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, generateScaleTargetDeployments(1)).Build()
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	panicsBefore := testutil.ToFloat64(metrics.PanicsTotal.WithLabelValues(scaleFlowSubsystemName))
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, panickingScaler{}, scc, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	p.probe(ctx)
+	p.inFlightScale.wait()
+	g.Expect(p.ScaleOperationInFlight()).To(BeEmpty(), "a scale flow which has panicked should not remain in flight")
+	g.Expect(testutil.ToFloat64(metrics.ShootScaleFlowInFlight.WithLabelValues(test.DefaultNamespace))).To(BeZero())
+	g.Expect(testutil.ToFloat64(metrics.PanicsTotal.WithLabelValues(scaleFlowSubsystemName))).To(Equal(panicsBefore + 1))
+	p.collectScaleFlowErr()
+	g.Expect(p.lastErr).To(MatchError(ContainSubstring("scale down has panicked")))
+	g.Expect(p.AreDependentsScaledDown()).To(BeFalse())
+}
+
+func TestScaleFlowShouldBeRunSynchronouslyIfAsyncScalingIsDisabled(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.AsyncScaling, false)
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	scaler := scalefakes.NewFakeScaler(seedClient, test.DefaultNamespace, nil, nil)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, scaler, scc, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	p.probe(ctx)
+	g.Expect(p.ScaleOperationInFlight()).To(BeEmpty(), "the probe should only return once the scale flow has completed")
+	g.Expect(p.AreDependentsScaledDown()).To(BeTrue())
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 0)
+}
+
+// blockingScaler is a dwdScaler.Scaler whose scale-down blocks until it is released.
+type blockingScaler struct {
+	dwdScaler.Scaler
+	release    chan struct{}
+	scaleDowns atomic.Int32
+}
+
+func (s *blockingScaler) ScaleDown(ctx context.Context) error {
+	s.scaleDowns.Add(1)
+	select {
+	case <-s.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type panickingScaler struct {
+	dwdScaler.Scaler
+}
+
+func (panickingScaler) ScaleDown(context.Context) error {
+	panic("scale down has panicked")
+}
+
+type openCircuitBreaker struct{}
+
+func (openCircuitBreaker) ShouldSuppressScaleDown(_ string) bool {
+	return true
+}
+
+func TestProbeOnceShouldReportScaleDecisionWithoutScaling(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name, IsExpired: true}, {Name: test.Node2Name, IsExpired: true}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	scaleTargetDeployments := generateScaleTargetDeployments(1)
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, scaleTargetDeployments).Build()
+	scc := shootfakes.NewFakeShootClientBuilder(k8sfakes.NewFakeDiscoveryClient(nil), shootClient).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	config.ScaleDecisionLogSize = pointer.Int(10)
+
+	p := NewProber(ctx, seedClient, seedClient, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+	outcome := p.ProbeOnce(ctx)
+	g.Expect(outcome.Err).ToNot(HaveOccurred())
+	g.Expect(outcome.APIServerProbeFailed).To(BeFalse())
+	g.Expect(outcome.LeaseProbeFailed).To(BeTrue())
+	g.Expect(outcome.ScaleOperation).To(Equal(scaleDecisionOperationScaleDown))
+	assertScale(ctx, g, seedClient, getDeploymentRefs(scaleTargetDeployments), 1)
+	err := seedClient.Get(ctx, client.ObjectKey{Namespace: test.DefaultNamespace, Name: scaleDecisionLogConfigMapName}, &corev1.ConfigMap{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "ProbeOnce should not record scale decisions")
+}
+
+// panicOnceDiscoveryClient panics on the first request for the server version, e.g. as if a malformed object was encountered.
+type panicOnceDiscoveryClient struct {
+	discovery.DiscoveryInterface
+	calls atomic.Int32
+}
+
+func (d *panicOnceDiscoveryClient) ServerVersion() (*version.Info, error) {
+	if d.calls.Add(1) == 1 {
+		panic("malformed object")
+	}
+	return d.DiscoveryInterface.ServerVersion()
+}
+
+func TestProberShouldBeRestartedAfterPanic(t *testing.T) {
+	g := NewWithT(t)
+	discoveryClient := &panicOnceDiscoveryClient{DiscoveryInterface: k8sfakes.NewFakeDiscoveryClient(nil)}
+	scc := shootfakes.NewFakeShootClientBuilder(discoveryClient, nil).WithClientCreationError(errors.New("no shoot client")).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+
+	p := NewProber(context.Background(), nil, nil, test.DefaultNamespace, config, nil, nil, scc, nil, nil, nil, logr.Discard())
+	defer p.Close()
+	p.Start()
+	g.Eventually(p.Health, 5*time.Second).Should(HaveField("Restarts", 1), "the probe loop should be restarted after it has panicked")
+	g.Expect(p.Health().LastPanic).To(Equal("malformed object"))
+	g.Eventually(discoveryClient.calls.Load, 5*time.Second).Should(BeNumerically(">", 1), "the restarted probe loop should continue probing")
+	g.Expect(p.Health().State).To(Equal(lifecycle.StateRunning))
+}
+
+func TestLeaseProbeShouldListEachKindOncePerProbe(t *testing.T) {
+	g := NewWithT(t)
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	machines := test.GenerateMachines([]test.MachineSpec{
+		{Name: test.Machine1Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node1Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+		{Name: test.Machine2Name, Labels: map[string]string{v1alpha1.NodeLabelKey: test.Node2Name}, CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning}},
+	}, test.DefaultNamespace)
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name}, {Name: test.Node2Name}})
+	shootClient := initializeShootClientBuilder(nodes, leases).Build()
+	seedClient := initializeSeedClientBuilder(machines, nil).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	p := NewProber(context.Background(), seedClient, seedClient, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+
+	result, err := p.probeNodeLeases(context.Background(), shootClient)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.candidateNodeLeases).To(HaveLen(2))
+	g.Expect(shootClient.CallCount(k8sfakes.ClientMethodList, corev1.SchemeGroupVersion.WithKind("Node"))).To(Equal(1))
+	g.Expect(shootClient.CallCount(k8sfakes.ClientMethodList, coordinationv1.SchemeGroupVersion.WithKind("Lease"))).To(Equal(1))
+	g.Expect(seedClient.CallCount(k8sfakes.ClientMethodList, v1alpha1.SchemeGroupVersion.WithKind("Machine"))).To(Equal(1))
+	g.Expect(shootClient.CallCount(k8sfakes.ClientMethodGet, corev1.SchemeGroupVersion.WithKind("Node"))).To(BeZero(), "nodes should not be fetched individually")
+}
+
+func TestLeaseProbeShouldFailIfListingIsSlowerThanTheProbeContext(t *testing.T) {
+	g := NewWithT(t)
+	nodes := test.GenerateNodes([]test.NodeSpec{{Name: test.Node1Name}})
+	leases := test.GenerateNodeLeases([]test.NodeLeaseSpec{{Name: test.Node1Name}})
+	shootClient := initializeShootClientBuilder(nodes, leases).WithLatencyForGVK(coordinationv1.SchemeGroupVersion.WithKind("Lease"), time.Minute).Build()
+	seedClient := initializeSeedClientBuilder(nil, nil).Build()
+	config := createConfig(testProbeInterval, metav1.Duration{Duration: time.Microsecond}, metav1.Duration{Duration: 40 * time.Second}, 0.2)
+	p := NewProber(context.Background(), seedClient, seedClient, test.DefaultNamespace, config, nil, nil, nil, nil, nil, nil, logr.Discard())
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelFn()
+	_, err := p.probeNodeLeases(ctx, shootClient)
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+	g.Expect(shootClient.CallCount(k8sfakes.ClientMethodList, coordinationv1.SchemeGroupVersion.WithKind("Lease"))).To(Equal(1))
+}
@@ -6,18 +6,49 @@ package prober
 
 import (
 	"sync"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/lifecycle"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 )
 
 // Manager is the convenience interface to manage lifecycle of probers.
 type Manager interface {
 	// Register registers the given prober with the manager. It should return false if prober is already registered.
 	Register(prober Prober) bool
-	// Unregister closes the prober and removes it from the manager. It should return false if prober is not registered with the manager.
-	Unregister(key string) bool
+	// Unregister closes the prober and removes it from the manager. The reason is captured by the metrics of the manager. It should return false
+	// if prober is not registered with the manager.
+	Unregister(key string, reason string) bool
+	// Replace closes the prober which is registered against the key of the given prober, if any, and registers the given prober in its place.
+	// The reason is captured by the metrics of the manager. It returns false if no prober has been registered against the key before.
+	Replace(prober Prober, reason string) bool
 	// GetProber uses the given key to get a registered prober from the manager. It returns false if prober is not found.
 	GetProber(key string) (Prober, bool)
 	// GetAllProbers returns a slice of all the probers registered with the manager.
 	GetAllProbers() []Prober
+	// UpdateConfig swaps the probe config of the prober registered against the given key without recreating it. It returns false if the prober
+	// is not registered with the manager or if the config cannot be swapped in place, in which case the prober has to be recreated.
+	UpdateConfig(key string, config *papi.Config) bool
+	// GetSeedProbeSummary aggregates the outcome of the most recent probe runs of all the probers registered with the manager.
+	GetSeedProbeSummary() SeedProbeSummary
+}
+
+// SeedProbeSummary captures seed-wide statistics across all shoots probed by the probers registered with a Manager.
+type SeedProbeSummary struct {
+	// Shoots is the number of shoots that are probed.
+	Shoots int `json:"shoots"`
+	// ShootsWithFailedAPIServerProbe is the number of shoots for which the most recent API server probe has failed.
+	ShootsWithFailedAPIServerProbe int `json:"shootsWithFailedAPIServerProbe"`
+	// ShootsWithFailedLeaseProbe is the number of shoots for which the most recent node lease probe has failed.
+	ShootsWithFailedLeaseProbe int `json:"shootsWithFailedLeaseProbe"`
+	// ShootsWithScaledDownDependents is the number of shoots for which the dependent resources are currently scaled down.
+	ShootsWithScaledDownDependents int `json:"shootsWithScaledDownDependents"`
+	// ShootsWithPausedScaling is the number of shoots for which scale decisions are paused as they have neither candidate nodes nor machines.
+	ShootsWithPausedScaling int `json:"shootsWithPausedScaling"`
+	// ProbersRestarting is the number of probers whose probe loop has panicked and is waiting to be restarted.
+	ProbersRestarting int `json:"probersRestarting"`
+	// ConfigHashes is the number of probers per hash of the effective probe config they are running with.
+	ConfigHashes map[string]int `json:"configHashes,omitempty"`
 }
 
 // NewManager creates a new manager to manage probers.
@@ -32,12 +63,14 @@ type manager struct {
 	probers map[string]Prober
 }
 
-func (pm *manager) Unregister(key string) bool {
+func (pm *manager) Unregister(key string, reason string) bool {
 	pm.Lock()
 	defer pm.Unlock()
 	if probe, ok := pm.probers[key]; ok {
 		delete(pm.probers, key)
 		probe.Close()
+		metrics.ProbersClosedTotal.WithLabelValues(reason).Inc()
+		metrics.ProbersActive.Dec()
 		return true
 	}
 	return false
@@ -49,17 +82,47 @@ func (pm *manager) Register(prober Prober) bool {
 	key := createKey(prober)
 	if _, ok := pm.probers[key]; !ok {
 		pm.probers[key] = prober
+		metrics.ProbersCreatedTotal.Inc()
+		metrics.ProbersActive.Inc()
 		return true
 	}
 	return false
 }
 
+func (pm *manager) Replace(prober Prober, reason string) bool {
+	pm.Lock()
+	defer pm.Unlock()
+	key := createKey(prober)
+	existing, ok := pm.probers[key]
+	if ok {
+		existing.Close()
+		metrics.ProbersClosedTotal.WithLabelValues(reason).Inc()
+		metrics.ProbersRestartedTotal.WithLabelValues(reason).Inc()
+	} else {
+		metrics.ProbersActive.Inc()
+	}
+	pm.probers[key] = prober
+	metrics.ProbersCreatedTotal.Inc()
+	return ok
+}
+
+func (pm *manager) UpdateConfig(key string, config *papi.Config) bool {
+	pm.Lock()
+	defer pm.Unlock()
+	if prober, ok := pm.probers[key]; ok {
+		return prober.UpdateConfig(config)
+	}
+	return false
+}
+
 func (pm *manager) GetProber(key string) (Prober, bool) {
 	prober, ok := pm.probers[key]
 	return prober, ok
 }
 
 func (pm *manager) GetAllProbers() []Prober {
+	pm.Lock()
+	defer pm.Unlock()
 	probers := make([]Prober, 0, len(pm.probers))
 	for _, p := range pm.probers {
 		probers = append(probers, p)
@@ -67,6 +130,30 @@ func (pm *manager) GetAllProbers() []Prober {
 	return probers
 }
 
+func (pm *manager) GetSeedProbeSummary() SeedProbeSummary {
+	summary := SeedProbeSummary{ConfigHashes: make(map[string]int)}
+	for _, p := range pm.GetAllProbers() {
+		summary.Shoots++
+		summary.ConfigHashes[p.ConfigHash()]++
+		if p.HasAPIServerProbeFailed() {
+			summary.ShootsWithFailedAPIServerProbe++
+		}
+		if p.HasLeaseProbeFailed() {
+			summary.ShootsWithFailedLeaseProbe++
+		}
+		if p.AreDependentsScaledDown() {
+			summary.ShootsWithScaledDownDependents++
+		}
+		if p.IsScalingPaused() {
+			summary.ShootsWithPausedScaling++
+		}
+		if p.Health().State == lifecycle.StateRestarting {
+			summary.ProbersRestarting++
+		}
+	}
+	return summary
+}
+
 func createKey(prober Prober) string {
 	return prober.namespace // check if this would be sufficient
 }
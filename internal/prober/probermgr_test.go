@@ -9,11 +9,19 @@ package prober
 import (
 	"context"
 	"testing"
+	"time"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	dwdScaler "github.com/gardener/dependency-watchdog/internal/prober/scaler"
+	"github.com/gardener/dependency-watchdog/internal/util"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 )
 
 const proberMgrTestNamespace = "default"
@@ -27,7 +35,7 @@ func setupMgrTest(t *testing.T) (Manager, func(mgr Manager)) {
 
 	return mgr, func(mgr Manager) {
 		for _, p := range mgr.GetAllProbers() {
-			mgr.Unregister(p.namespace)
+			mgr.Unregister(p.namespace, metrics.ReasonShootDeletion)
 		}
 	}
 }
@@ -37,7 +45,7 @@ func TestRegisterNewProberAndCheckIfItExistsAndIsNotClosed(t *testing.T) {
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	p := NewProber(context.Background(), nil, proberMgrTestNamespace, &papi.Config{}, nil, nil, nil, pmLogger)
+	p := NewProber(context.Background(), nil, nil, proberMgrTestNamespace, &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
 	g.Expect(p).ShouldNot(BeNil(), "NewProber should have returned a non nil Prober")
 	g.Expect(p.namespace).Should(Equal(proberMgrTestNamespace), "The namespace of the created prober should match")
 	g.Expect(mgr.Register(*p)).To(BeTrue(), "mgr.Register should register a new prober")
@@ -56,10 +64,10 @@ func TestProberRegistrationWithSameKeyShouldNotOverwriteExistingProber(t *testin
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	p1 := NewProber(context.Background(), nil, proberMgrTestNamespace, &papi.Config{KubeConfigSecretName: "bingo"}, nil, nil, nil, pmLogger)
+	p1 := NewProber(context.Background(), nil, nil, proberMgrTestNamespace, &papi.Config{KubeConfigSecretName: "bingo"}, nil, nil, nil, nil, nil, nil, pmLogger)
 	g.Expect(mgr.Register(*p1)).To(BeTrue(), "mgr.Register should register a new prober")
 
-	p2 := NewProber(context.Background(), nil, proberMgrTestNamespace, &papi.Config{KubeConfigSecretName: "zingo"}, nil, nil, nil, pmLogger)
+	p2 := NewProber(context.Background(), nil, nil, proberMgrTestNamespace, &papi.Config{KubeConfigSecretName: "zingo"}, nil, nil, nil, nil, nil, nil, pmLogger)
 	g.Expect(mgr.Register(*p2)).To(BeFalse(), "mgr.Register should return false if a prober with the same key is already registered")
 
 	foundProber, ok := mgr.GetProber(proberMgrTestNamespace)
@@ -75,10 +83,10 @@ func TestUnregisterExistingProberShouldCloseItAndRemoveItFromManager(t *testing.
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	p := NewProber(context.Background(), nil, proberMgrTestNamespace, &papi.Config{}, nil, nil, nil, pmLogger)
+	p := NewProber(context.Background(), nil, nil, proberMgrTestNamespace, &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
 	g.Expect(mgr.Register(*p)).To(BeTrue(), "mgr.Register should register a new prober")
 
-	mgr.Unregister(proberMgrTestNamespace)
+	mgr.Unregister(proberMgrTestNamespace, metrics.ReasonShootDeletion)
 	_, ok := mgr.GetProber(proberMgrTestNamespace)
 	g.Expect(ok).Should(BeFalse(), "mgr.Unregister should delete the prober for the corresponding key")
 	g.Eventually(p.IsClosed).Should(BeTrue(), "mgr.Unregister should cancel the unregistered prober")
@@ -92,7 +100,128 @@ func TestUnregisterNonExistingProberShouldNotFail(t *testing.T) {
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	g.Expect(mgr.Unregister("bazingo")).To(BeFalse(), "mgr.Unregister should return false for non existing prober")
+	g.Expect(mgr.Unregister("bazingo", metrics.ReasonShootDeletion)).To(BeFalse(), "mgr.Unregister should return false for non existing prober")
 	t.Log("De-registering a non existing prober did not fail")
 
 }
+
+func TestGetSeedProbeSummaryShouldAggregateProberStatus(t *testing.T) {
+	g := NewWithT(t)
+	mgr, tearDownTest := setupMgrTest(t)
+	defer tearDownTest(mgr)
+
+	p1 := NewProber(context.Background(), nil, nil, "shoot--p--s1", &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+	p2 := NewProber(context.Background(), nil, nil, "shoot--p--s2", &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+	p3 := NewProber(context.Background(), nil, nil, "shoot--p--s3", &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+	for _, p := range []*Prober{p1, p2, p3} {
+		g.Expect(mgr.Register(*p)).To(BeTrue(), "mgr.Register should register a new prober")
+	}
+	p1.setAPIServerProbeFailed(true)
+	p2.setAPIServerProbeFailed(true)
+	p2.setDependentsScaledDown(true)
+	p3.setLeaseProbeFailed(true)
+	p3.setScalingPaused(true)
+
+	g.Expect(mgr.GetSeedProbeSummary()).To(Equal(SeedProbeSummary{Shoots: 3, ShootsWithFailedAPIServerProbe: 2, ShootsWithFailedLeaseProbe: 1, ShootsWithScaledDownDependents: 1, ShootsWithPausedScaling: 1,
+		ConfigHashes: map[string]int{p1.ConfigHash(): 3}}))
+
+	p2.setDependentsScaledDown(false)
+	g.Expect(mgr.GetSeedProbeSummary().ShootsWithScaledDownDependents).To(BeZero(), "status changes of a running prober should be reflected for the registered prober")
+}
+
+func TestUpdateConfigShouldSwapConfigOnlyIfProberNeedNotBeRecreated(t *testing.T) {
+	g := NewWithT(t)
+	mgr, tearDownTest := setupMgrTest(t)
+	defer tearDownTest(mgr)
+
+	config := &papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.6)}
+	rebuiltScaler := &rebuildRecordingScaler{}
+	p := NewProber(context.Background(), nil, nil, proberMgrTestNamespace, config, nil, rebuiltScaler, nil, nil, nil, nil, pmLogger)
+	g.Expect(mgr.Register(*p)).To(BeTrue(), "mgr.Register should register a new prober")
+
+	updatedConfig := &papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.8)}
+	g.Expect(mgr.UpdateConfig(proberMgrTestNamespace, updatedConfig)).To(BeTrue(), "mgr.UpdateConfig should swap a config which only differs in fields read on every probe run")
+	g.Expect(p.GetConfig()).To(BeIdenticalTo(updatedConfig), "the swapped config should be visible to the running prober")
+	g.Expect(p.ConfigHash()).To(Equal(util.ComputeConfigHash(updatedConfig)), "the config hash should be updated along with the swapped config")
+
+	dependentResourceInfos := []papi.DependentResourceInfo{{Ref: &autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: "kube-controller-manager", APIVersion: "apps/v1"}}}
+	rebuildConfig := &papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.8), DependentResourceInfos: dependentResourceInfos}
+	g.Expect(mgr.UpdateConfig(proberMgrTestNamespace, rebuildConfig)).To(BeTrue(), "mgr.UpdateConfig should swap a config with different dependent resources")
+	g.Expect(rebuiltScaler.dependentResourceInfos).To(Equal(dependentResourceInfos), "the scale flows should be rebuilt for the updated dependent resources")
+	g.Expect(mgr.UpdateConfig(proberMgrTestNamespace, updatedConfig)).To(BeTrue())
+
+	recreateConfig := &papi.Config{KubeConfigSecretName: "zingo", NodeLeaseFailureFraction: pointer.Float64(0.8)}
+	g.Expect(mgr.UpdateConfig(proberMgrTestNamespace, recreateConfig)).To(BeFalse(), "mgr.UpdateConfig should not swap a config which requires the prober to be recreated")
+	g.Expect(p.GetConfig()).To(BeIdenticalTo(updatedConfig))
+	recreateConfig = &papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.8), ShootClientQPS: pointer.Float64(50)}
+	g.Expect(mgr.UpdateConfig(proberMgrTestNamespace, recreateConfig)).To(BeFalse(), "mgr.UpdateConfig should not swap a config with different shoot client rate limits")
+	recreateConfig = &papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.8), MaxConcurrentScalesPerLevel: pointer.Int(2)}
+	g.Expect(mgr.UpdateConfig(proberMgrTestNamespace, recreateConfig)).To(BeFalse(), "mgr.UpdateConfig should not swap a config with a different concurrency limit of the scale flows")
+	recreateConfig = &papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.8), ScaleFlowTimeout: &metav1.Duration{Duration: time.Minute}}
+	g.Expect(mgr.UpdateConfig(proberMgrTestNamespace, recreateConfig)).To(BeFalse(), "mgr.UpdateConfig should not swap a config with a different scale flow timeout")
+
+	g.Expect(mgr.UpdateConfig("bazingo", updatedConfig)).To(BeFalse(), "mgr.UpdateConfig should return false for non existing prober")
+}
+
+// rebuildRecordingScaler is a dwdScaler.Scaler which records the dependent resources it has been rebuilt for.
+type rebuildRecordingScaler struct {
+	dwdScaler.Scaler
+	dependentResourceInfos []papi.DependentResourceInfo
+}
+
+func (s *rebuildRecordingScaler) Rebuild(dependentResourceInfos []papi.DependentResourceInfo) {
+	s.dependentResourceInfos = dependentResourceInfos
+}
+
+func TestConfigInfoMetricShouldReflectConfigHashOfProber(t *testing.T) {
+	g := NewWithT(t)
+	mgr, tearDownTest := setupMgrTest(t)
+	defer tearDownTest(mgr)
+
+	const namespace = "shoot--p--config-hash"
+	config := &papi.Config{KubeConfigSecretName: "bingo", InitialDelay: &metav1.Duration{Duration: time.Hour}, NodeLeaseFailureFraction: pointer.Float64(0.6)}
+	p := NewProber(context.Background(), nil, nil, namespace, config, nil, nil, nil, nil, nil, nil, pmLogger)
+	g.Expect(mgr.Register(*p)).To(BeTrue(), "mgr.Register should register a new prober")
+	p.Start()
+	g.Expect(testutil.ToFloat64(metrics.ShootProberConfigInfo.WithLabelValues(namespace, util.ComputeConfigHash(config)))).To(Equal(1.0))
+
+	updatedConfig := &papi.Config{KubeConfigSecretName: "bingo", InitialDelay: &metav1.Duration{Duration: time.Hour}, NodeLeaseFailureFraction: pointer.Float64(0.8)}
+	g.Expect(mgr.UpdateConfig(namespace, updatedConfig)).To(BeTrue())
+	g.Expect(metrics.ShootProberConfigInfo.DeleteLabelValues(namespace, util.ComputeConfigHash(config))).To(BeFalse(), "the series of the previous config hash should have been replaced")
+	g.Expect(testutil.ToFloat64(metrics.ShootProberConfigInfo.WithLabelValues(namespace, util.ComputeConfigHash(updatedConfig)))).To(Equal(1.0))
+
+	g.Expect(mgr.Unregister(namespace, metrics.ReasonShootDeletion)).To(BeTrue())
+	g.Expect(metrics.ShootProberConfigInfo.DeleteLabelValues(namespace, util.ComputeConfigHash(updatedConfig))).To(BeFalse(), "the series should be deleted when the prober is closed")
+}
+
+func TestLifecycleMetricsShouldCaptureRegistrationsRestartsAndCloses(t *testing.T) {
+	g := NewWithT(t)
+	mgr, tearDownTest := setupMgrTest(t)
+	defer tearDownTest(mgr)
+
+	createdBefore := testutil.ToFloat64(metrics.ProbersCreatedTotal)
+	activeBefore := testutil.ToFloat64(metrics.ProbersActive)
+	restartedBefore := testutil.ToFloat64(metrics.ProbersRestartedTotal.WithLabelValues(metrics.ReasonConfigChange))
+	closedBefore := testutil.ToFloat64(metrics.ProbersClosedTotal.WithLabelValues(metrics.ReasonHibernation))
+
+	p1 := NewProber(context.Background(), nil, nil, proberMgrTestNamespace, &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+	g.Expect(mgr.Register(*p1)).To(BeTrue())
+	g.Expect(mgr.Register(*p1)).To(BeFalse())
+	g.Expect(testutil.ToFloat64(metrics.ProbersCreatedTotal)).To(Equal(createdBefore+1), "only a new prober should be counted as created")
+	g.Expect(testutil.ToFloat64(metrics.ProbersActive)).To(Equal(activeBefore + 1))
+
+	p2 := NewProber(context.Background(), nil, nil, proberMgrTestNamespace, &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+	g.Expect(mgr.Replace(*p2, metrics.ReasonConfigChange)).To(BeTrue(), "mgr.Replace should return true if a prober has been replaced")
+	g.Eventually(p1.IsClosed).Should(BeTrue(), "mgr.Replace should close the replaced prober")
+	foundProber, ok := mgr.GetProber(proberMgrTestNamespace)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(foundProber.IsClosed()).To(BeFalse(), "mgr.Replace should register the new prober")
+	g.Expect(testutil.ToFloat64(metrics.ProbersCreatedTotal)).To(Equal(createdBefore + 2))
+	g.Expect(testutil.ToFloat64(metrics.ProbersActive)).To(Equal(activeBefore+1), "a restart should not change the number of active probers")
+	g.Expect(testutil.ToFloat64(metrics.ProbersRestartedTotal.WithLabelValues(metrics.ReasonConfigChange))).To(Equal(restartedBefore + 1))
+
+	g.Expect(mgr.Unregister(proberMgrTestNamespace, metrics.ReasonHibernation)).To(BeTrue())
+	g.Expect(mgr.Unregister(proberMgrTestNamespace, metrics.ReasonHibernation)).To(BeFalse())
+	g.Expect(testutil.ToFloat64(metrics.ProbersClosedTotal.WithLabelValues(metrics.ReasonHibernation))).To(Equal(closedBefore+1), "only a registered prober should be counted as closed")
+	g.Expect(testutil.ToFloat64(metrics.ProbersActive)).To(Equal(activeBefore))
+}
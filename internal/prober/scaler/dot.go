@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package scaler
+
+import (
+	"fmt"
+	"strings"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/go-logr/logr"
+)
+
+// RenderFlows renders the scale-up and scale-down flows which are created for the given dependent resources as a DOT graph. Every task of a flow
+// is a node labelled with its level and the resources it scales, every edge points from a task to a task which waits for it to complete. The
+// output can be rendered with graphviz, e.g. `dot -Tsvg`, to verify complex multi-level configurations.
+func RenderFlows(namespace string, dependentResourceInfos []papi.DependentResourceInfo) string {
+	fc := newFlowCreator(nil, nil, nil, &scaledDownSince{}, logr.Discard(), buildScalerOptions(), dependentResourceInfos)
+	var sb strings.Builder
+	sb.WriteString("digraph \"scale-flows\" {\n")
+	sb.WriteString("\trankdir=LR;\n")
+	sb.WriteString("\tnode [shape=box];\n")
+	for _, opType := range []operation{scaleUp, scaleDown} {
+		name := flowName(namespace, opType)
+		writeFlowAsDOTSubgraph(&sb, name, fc.createFlow(name, namespace, opType))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// writeFlowAsDOTSubgraph writes the tasks of the scale flow and their wait dependencies as a DOT subgraph. Node IDs are prefixed with the name of
+// the flow as the same task IDs are used by the scale-up and the scale-down flow.
+func writeFlowAsDOTSubgraph(sb *strings.Builder, name string, sf *scaleFlow) {
+	nodeID := func(taskID string) string { return fmt.Sprintf("%q", name+"/"+taskID) }
+	fmt.Fprintf(sb, "\tsubgraph %q {\n", "cluster_"+name)
+	fmt.Fprintf(sb, "\t\tlabel=%q;\n", name)
+	for _, step := range sf.flowStepInfos {
+		label := fmt.Sprintf("level %d\n%s", step.level, strings.Join(getTaskResourceNames(string(step.taskID)), "\n"))
+		fmt.Fprintf(sb, "\t\t%s [label=%q];\n", nodeID(string(step.taskID)), label)
+	}
+	for _, step := range sf.flowStepInfos {
+		for _, dependentTaskID := range step.dependentTaskIDs.StringList() {
+			fmt.Fprintf(sb, "\t\t%s -> %s;\n", nodeID(dependentTaskID), nodeID(string(step.taskID)))
+		}
+	}
+	sb.WriteString("\t}\n")
+}
+
+// getTaskResourceNames returns the names of the resources which are scaled by the task with the given ID, see createTaskName.
+func getTaskResourceNames(taskID string) []string {
+	return strings.Split(taskID[strings.LastIndex(taskID, ":")+1:], "#")
+}
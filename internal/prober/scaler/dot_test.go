@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package scaler
+
+import (
+	"testing"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	. "github.com/onsi/gomega"
+)
+
+func TestRenderFlowsShouldRenderLevelsAndWaitDependencies(t *testing.T) {
+	g := NewWithT(t)
+	depResInfos := []papi.DependentResourceInfo{
+		createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 1, nil, nil, false),
+		createTestDeploymentDependentResourceInfo(mcmObjectRef.Name, 1, 0, nil, nil, false),
+		createTestDeploymentDependentResourceInfo(caObjectRef.Name, 1, 0, nil, nil, true),
+	}
+
+	dot := RenderFlows("test-render", depResInfos)
+	g.Expect(dot).To(HavePrefix("digraph \"scale-flows\" {\n"))
+	g.Expect(dot).To(HaveSuffix("}\n"))
+	g.Expect(dot).To(ContainSubstring(`subgraph "cluster_scale-up-test-render" {`))
+	g.Expect(dot).To(ContainSubstring(`subgraph "cluster_scale-down-test-render" {`))
+
+	scaleUpKCM := `"scale-up-test-render/scale:level-0:` + kcmObjectRef.Name + `"`
+	scaleUpLevel1 := `"scale-up-test-render/scale:level-1:` + mcmObjectRef.Name + "#" + caObjectRef.Name + `"`
+	g.Expect(dot).To(ContainSubstring(scaleUpKCM + ` [label="level 0\n` + kcmObjectRef.Name + `"];`))
+	g.Expect(dot).To(ContainSubstring(scaleUpLevel1 + ` [label="level 1\n` + mcmObjectRef.Name + `\n` + caObjectRef.Name + `"];`))
+	g.Expect(dot).To(ContainSubstring(scaleUpKCM+" -> "+scaleUpLevel1+";"), "level 1 should wait for level 0 to be scaled up")
+
+	scaleDownLevel0 := `"scale-down-test-render/scale:level-0:` + mcmObjectRef.Name + "#" + caObjectRef.Name + `"`
+	scaleDownKCM := `"scale-down-test-render/scale:level-1:` + kcmObjectRef.Name + `"`
+	g.Expect(dot).To(ContainSubstring(scaleDownLevel0+" -> "+scaleDownKCM+";"), "level 1 should wait for level 0 to be scaled down")
+	g.Expect(dot).ToNot(ContainSubstring(scaleDownKCM+" -> "), "the last level should not be waited for")
+}
@@ -6,20 +6,28 @@ package scaler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/go-logr/logr"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/gardener/dependency-watchdog/pkg/retry"
 	"github.com/gardener/gardener/pkg/utils/flow"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	scalev1 "k8s.io/client-go/scale"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	defaultMaxResourceScalingAttempts = 3
+	// scaleResourceBackOffFactor is the factor by which the backoff between two attempts to scale a resource is increased.
+	scaleResourceBackOffFactor = 2.0
+	// scaleResourceBackOffJitterFactor spreads the retries of the scale flows of different shoots which have failed at the same time.
+	scaleResourceBackOffJitterFactor = 0.2
 )
 
 type flowCreator interface {
@@ -29,15 +37,19 @@ type flowCreator interface {
 type creator struct {
 	client                 client.Client
 	scaler                 scalev1.ScaleInterface
+	statuses               *statusCache
+	scaledDownSince        *scaledDownSince
 	logger                 logr.Logger
 	options                *scalerOptions
 	dependentResourceInfos []papi.DependentResourceInfo
 }
 
-func newFlowCreator(client client.Client, scaler scalev1.ScaleInterface, logger logr.Logger, options *scalerOptions, dependentResourceInfos []papi.DependentResourceInfo) flowCreator {
+func newFlowCreator(client client.Client, scaler scalev1.ScaleInterface, statuses *statusCache, scaledDownSince *scaledDownSince, logger logr.Logger, options *scalerOptions, dependentResourceInfos []papi.DependentResourceInfo) flowCreator {
 	return &creator{
 		client:                 client,
 		scaler:                 scaler,
+		statuses:               statuses,
+		scaledDownSince:        scaledDownSince,
 		logger:                 logger,
 		options:                options,
 		dependentResourceInfos: dependentResourceInfos,
@@ -60,7 +72,7 @@ func (c *creator) createFlow(name string, namespace string, opType operation) *s
 				Fn:           c.createScaleTaskFn(namespace, resInfos),
 				Dependencies: dependentTaskIDs,
 			})
-			sf.addScaleStepInfo(taskID, dependentTaskIDs, previousLevelResourceInfos)
+			sf.addScaleStepInfo(taskID, level, dependentTaskIDs, previousLevelResourceInfos)
 			previousLevelResourceInfos = append(previousLevelResourceInfos, resInfos...)
 			if previousTaskIDs == nil {
 				previousTaskIDs = flow.NewTaskIDs(taskID)
@@ -86,9 +98,33 @@ func (c *creator) createScaleTaskFn(namespace string, resourceInfos []scalableRe
 	if len(taskFns) == 1 {
 		return taskFns[0]
 	}
+	if maxConcurrentScales := c.options.maxConcurrentScalesPerLevel; maxConcurrentScales > 0 && len(taskFns) > maxConcurrentScales {
+		return parallelWithLimit(maxConcurrentScales, taskFns...)
+	}
 	return flow.Parallel(taskFns...)
 }
 
+// parallelWithLimit runs the given task functions in parallel like flow.Parallel does, but at most maxConcurrency of them at the same time. The
+// semaphore is created per invocation, so that concurrent runs of the same flow do not limit each other.
+func parallelWithLimit(maxConcurrency int, taskFns ...flow.TaskFn) flow.TaskFn {
+	return func(ctx context.Context) error {
+		semaphore := make(chan struct{}, maxConcurrency)
+		limitedTaskFns := make([]flow.TaskFn, 0, len(taskFns))
+		for _, taskFn := range taskFns {
+			limitedTaskFns = append(limitedTaskFns, func(ctx context.Context) error {
+				select {
+				case semaphore <- struct{}{}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				defer func() { <-semaphore }()
+				return taskFn(ctx)
+			})
+		}
+		return flow.Parallel(limitedTaskFns...)(ctx)
+	}
+}
+
 func (c *creator) doCreateTaskFn(namespace string, resInfo scalableResourceInfo) flow.TaskFn {
 	return func(ctx context.Context) error {
 		var operation string
@@ -97,20 +133,41 @@ func (c *creator) doCreateTaskFn(namespace string, resInfo scalableResourceInfo)
 		} else {
 			operation = fmt.Sprintf("scaleDown-resource-%s.%s", namespace, resInfo.ref.Name)
 		}
-		resScaler := newResourceScaler(c.client, c.scaler, c.logger, c.options, namespace, resInfo)
-		result := util.Retry(ctx, c.logger,
+		logger := util.LoggerWithProbeCycleID(ctx, c.logger)
+		resScaler := newResourceScaler(c.client, c.scaler, c.statuses, c.scaledDownSince, logger, c.options, namespace, resInfo)
+		retryOptions := []retry.Option{
+			retry.WithAttemptHook(func(_ int, err error) {
+				if err != nil {
+					metrics.ScaleAttemptFailuresTotal.WithLabelValues(resInfo.operation.String()).Inc()
+				}
+				if apierrors.IsConflict(err) {
+					metrics.ScaleConflictsTotal.WithLabelValues(resInfo.operation.String()).Inc()
+				}
+			}),
+		}
+		if *c.options.honorRetryAfter {
+			retryOptions = append(retryOptions, retry.WithRetryAfter())
+		}
+		result := retry.Retry(ctx, logger,
 			operation,
 			func() (interface{}, error) {
 				err := resScaler.scale(ctx)
 				return nil, err
 			},
 			defaultMaxResourceScalingAttempts,
-			*c.options.scaleResourceBackOff,
-			util.AlwaysRetry)
+			retry.ExponentialBackoff{Initial: *c.options.scaleResourceBackOff, Factor: scaleResourceBackOffFactor, JitterFactor: scaleResourceBackOffJitterFactor},
+			canRetryScale,
+			retryOptions...)
 		return result.Err
 	}
 }
 
+// canRetryScale checks if a failed scale operation can be retried. A scale update which has been rejected by its server-side dry-run is not retried
+// as it would most likely be rejected again.
+func canRetryScale(err error) bool {
+	return !errors.Is(err, ErrScaleRejected)
+}
+
 type scaleFlow struct {
 	flow          *flow.Flow
 	flowStepInfos []scaleStepInfo
@@ -118,6 +175,7 @@ type scaleFlow struct {
 
 type scaleStepInfo struct {
 	taskID           flow.TaskID
+	level            int
 	dependentTaskIDs flow.TaskIDs
 	waitOnResources  []autoscalingv1.CrossVersionObjectReference
 }
@@ -128,9 +186,10 @@ func newScaleFlow() *scaleFlow {
 	}
 }
 
-func (sf *scaleFlow) addScaleStepInfo(id flow.TaskID, dependentTaskIDs flow.TaskIDs, waitOnResourceInfos []scalableResourceInfo) {
+func (sf *scaleFlow) addScaleStepInfo(id flow.TaskID, level int, dependentTaskIDs flow.TaskIDs, waitOnResourceInfos []scalableResourceInfo) {
 	sf.flowStepInfos = append(sf.flowStepInfos, scaleStepInfo{
 		taskID:           id,
+		level:            level,
 		dependentTaskIDs: dependentTaskIDs.Copy(),
 		waitOnResources:  mapToCrossVersionObjectRef(waitOnResourceInfos),
 	})
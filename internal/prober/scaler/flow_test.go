@@ -7,14 +7,20 @@
 package scaler
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 
 	"github.com/gardener/gardener/pkg/utils/flow"
 	. "github.com/onsi/gomega"
+	fakescale "k8s.io/client-go/scale/fake"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/util"
 )
 
 var flowTestLogger logr.Logger
@@ -32,7 +38,7 @@ func TestCreateScaleUpSequentialFlow(t *testing.T) {
 	flowName := "testCreateSequentialFlow"
 	namespace := "test-sequential"
 
-	fc := newFlowCreator(nil, nil, flowTestLogger, &scalerOptions{}, depResInfos)
+	fc := newFlowCreator(nil, nil, nil, &scaledDownSince{}, flowTestLogger, &scalerOptions{}, depResInfos)
 	f := fc.createFlow(flowName, namespace, scaleUp)
 	g.Expect(f.flowStepInfos).To(HaveLen(3))
 
@@ -66,7 +72,7 @@ func TestCreateScaleDownSequentialAndConcurrentFlow(t *testing.T) {
 	flowName := "testCreateSequentialAndConcurrentFlow"
 	namespace := "test-sequential-and-concurrent"
 
-	fc := newFlowCreator(nil, nil, flowTestLogger, &scalerOptions{}, depResInfos)
+	fc := newFlowCreator(nil, nil, nil, &scaledDownSince{}, flowTestLogger, &scalerOptions{}, depResInfos)
 	f := fc.createFlow(flowName, namespace, scaleDown)
 	g.Expect(f.flowStepInfos).To(HaveLen(2))
 
@@ -85,3 +91,90 @@ func TestCreateScaleDownSequentialAndConcurrentFlow(t *testing.T) {
 		previousDepTaskIDs = append(previousDepTaskIDs, currentTaskStep.taskID)
 	}
 }
+
+func TestRebuildShouldReplaceScaleFlows(t *testing.T) {
+	g := NewWithT(t)
+	depResInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 1, nil, nil, false)}
+	ds := NewScaler("test-rebuild", depResInfos, nil, &fakescale.FakeScaleClient{}, flowTestLogger).(*scaleFlowRunner)
+	scaleUpFlow, scaleDownFlow := ds.getFlows()
+	g.Expect(scaleUpFlow.Len()).To(Equal(1))
+	g.Expect(scaleDownFlow.Len()).To(Equal(1))
+
+	depResInfos = append(depResInfos,
+		createTestDeploymentDependentResourceInfo(mcmObjectRef.Name, 1, 0, nil, nil, false),
+		createTestDeploymentDependentResourceInfo(caObjectRef.Name, 2, 0, nil, nil, true))
+	ds.Rebuild(depResInfos)
+	rebuiltScaleUpFlow, rebuiltScaleDownFlow := ds.getFlows()
+	g.Expect(rebuiltScaleUpFlow.Len()).To(Equal(3), "each level of the rebuilt scale-up flow should be a separate task")
+	g.Expect(rebuiltScaleDownFlow.Len()).To(Equal(2), "resources on the same level should be scaled down by a single task")
+	g.Expect(scaleUpFlow.Len()).To(Equal(1), "flows which have been obtained before the rebuild should not be modified")
+}
+
+func TestParallelWithLimitShouldLimitConcurrentTasks(t *testing.T) {
+	g := NewWithT(t)
+	var running, maxRunning, completed atomic.Int32
+	taskFns := make([]flow.TaskFn, 0, 5)
+	for i := 0; i < 5; i++ {
+		taskFns = append(taskFns, func(_ context.Context) error {
+			current := running.Add(1)
+			for {
+				observed := maxRunning.Load()
+				if current <= observed || maxRunning.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			completed.Add(1)
+			return nil
+		})
+	}
+
+	g.Expect(parallelWithLimit(2, taskFns...)(context.Background())).To(Succeed())
+	g.Expect(completed.Load()).To(Equal(int32(5)), "all tasks should be run")
+	g.Expect(maxRunning.Load()).To(Equal(int32(2)), "no more than the configured number of tasks should run concurrently")
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+	g.Expect(parallelWithLimit(2, taskFns...)(ctx)).To(HaveOccurred(), "tasks should not be started once the context has been cancelled")
+}
+
+func TestRunFlowShouldCancelFlowOnceFlowTimeoutHasExpired(t *testing.T) {
+	g := NewWithT(t)
+	err := (&scaleFlowRunner{options: &scalerOptions{flowTimeout: 20 * time.Millisecond}}).runFlow(context.Background(), newBlockingFlow())
+	g.Expect(errors.Is(err, util.ErrTimeout)).To(BeTrue(), "the flow should be cancelled once the flow timeout has expired")
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelFn()
+	err = (&scaleFlowRunner{options: &scalerOptions{}}).runFlow(ctx, newBlockingFlow())
+	g.Expect(err).To(HaveOccurred(), "without a flow timeout the flow should be bounded by the parent context")
+	g.Expect(errors.Is(err, util.ErrTimeout)).To(BeFalse())
+}
+
+// newBlockingFlow creates a flow with a single task which blocks until its context is cancelled.
+func newBlockingFlow() *flow.Flow {
+	g := flow.NewGraph("blocking")
+	g.Add(flow.Task{Name: "block", Fn: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	return g.Compile()
+}
+
+func TestScaleShouldNotRunFlowsInDryRunMode(t *testing.T) {
+	g := NewWithT(t)
+	dryRun := true
+	ds := &scaleFlowRunner{
+		logger:        logr.Discard(),
+		options:       buildScalerOptions(WithDryRun(func() bool { return dryRun })),
+		scaleUpFlow:   newBlockingFlow(),
+		scaleDownFlow: newBlockingFlow(),
+	}
+	g.Expect(ds.ScaleUp(context.Background())).To(MatchError(ErrDryRun))
+	g.Expect(ds.ScaleDown(context.Background())).To(MatchError(ErrDryRun))
+
+	dryRun = false
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelFn()
+	g.Expect(ds.runFlow(ctx, ds.scaleDownFlow)).ToNot(MatchError(ErrDryRun), "the flow should be run once the dry-run mode has been disabled")
+}
@@ -6,15 +6,22 @@ package scaler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 
 	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/gardener/dependency-watchdog/pkg/retry"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	scalev1 "k8s.io/client-go/scale"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -22,11 +29,19 @@ import (
 const (
 	// ignoreScalingAnnotationKey is the key for an annotation if present on a resource will suspend any scaling action for that resource.
 	ignoreScalingAnnotationKey = "dependency-watchdog.gardener.cloud/ignore-scaling"
-	// replicasAnnotationKey is the key for an annotation whose value captures the current spec.replicas prior to scale down for that resource.
+	// DefaultReplicasAnnotationKey is the default key for an annotation whose value captures the current spec.replicas prior to scale down for that resource.
 	// This is used when DWD attempts to restore the state of the resource it scale down.
-	replicasAnnotationKey = "dependency-watchdog.gardener.cloud/replicas"
+	DefaultReplicasAnnotationKey = "dependency-watchdog.gardener.cloud/replicas"
+	// ScaledDownAtAnnotationKey is the key for an annotation whose value captures the time in RFC 3339 format at which a resource has been scaled down.
+	// It is removed once the resource has been scaled up again.
+	ScaledDownAtAnnotationKey = "dependency-watchdog.gardener.cloud/scaled-down-at"
+	// ScaledDownUIDAnnotationKey is the key for an annotation whose value captures the UID of a resource at the time it has been scaled down. It is
+	// only set for resources whose UID is verified before they are scaled up and is removed once the resource has been scaled up again.
+	ScaledDownUIDAnnotationKey = "dependency-watchdog.gardener.cloud/scaled-down-uid"
 	// defaultScaleUpReplicas is the default value of number of replicas for a scale-up operation by a probe when the external probe transitions from failed to success.
 	defaultScaleUpReplicas int32 = 1
+	// resourceCheckJitterFactor spreads the polls of the ready replicas of the dependent resources which are waited for concurrently.
+	resourceCheckJitterFactor = 0.2
 	// defaultScaleDownReplicas is the default value of number of replicas for a scale-down operation by a probe when the external probe transitions from success to failed.
 	defaultScaleDownReplicas int32 = 0
 )
@@ -36,29 +51,34 @@ type resourceScaler interface {
 }
 
 type resScaler struct {
-	client       client.Client
-	scaler       scalev1.ScaleInterface
-	logger       logr.Logger
-	namespace    string
-	resourceInfo scalableResourceInfo
-	opts         *scalerOptions
+	client          client.Client
+	scaler          scalev1.ScaleInterface
+	statuses        *statusCache
+	scaledDownSince *scaledDownSince
+	logger          logr.Logger
+	namespace       string
+	resourceInfo    scalableResourceInfo
+	opts            *scalerOptions
 }
 
-func newResourceScaler(client client.Client, scaler scalev1.ScaleInterface, logger logr.Logger, opts *scalerOptions, namespace string, resourceInfo scalableResourceInfo) resourceScaler {
+func newResourceScaler(client client.Client, scaler scalev1.ScaleInterface, statuses *statusCache, scaledDownSince *scaledDownSince, logger logr.Logger, opts *scalerOptions, namespace string, resourceInfo scalableResourceInfo) resourceScaler {
 	resLogger := logger.WithValues("resNamespace", namespace, "kind", resourceInfo.ref.Kind, "apiVersion", resourceInfo.ref.APIVersion, "name", resourceInfo.ref.Name, "level", resourceInfo.level)
 	return &resScaler{
-		client:       client,
-		scaler:       scaler,
-		logger:       resLogger,
-		namespace:    namespace,
-		resourceInfo: resourceInfo,
-		opts:         opts,
+		client:          client,
+		scaler:          scaler,
+		statuses:        statuses,
+		scaledDownSince: scaledDownSince,
+		logger:          resLogger,
+		namespace:       namespace,
+		resourceInfo:    resourceInfo,
+		opts:            opts,
 	}
 }
 
 func (r *resScaler) scale(ctx context.Context) error {
 	var (
 		err           error
+		resourceMeta  *metav1.PartialObjectMetadata
 		resourceAnnot map[string]string
 	)
 	// sleep for initial delay
@@ -67,7 +87,7 @@ func (r *resScaler) scale(ctx context.Context) error {
 		return err
 	}
 
-	if resourceAnnot, err = util.GetResourceAnnotations(ctx, r.client, r.namespace, r.resourceInfo.ref); err != nil {
+	if resourceMeta, err = util.GetResourceMetadata(ctx, r.client, r.namespace, r.resourceInfo.ref); err != nil {
 		if apierrors.IsNotFound(err) && r.resourceInfo.optional {
 			r.logger.Info("Resource not found. Ignoring this resource as its existence is marked as optional")
 			return nil
@@ -75,29 +95,42 @@ func (r *resScaler) scale(ctx context.Context) error {
 		r.logger.Error(err, "Error trying to get annotations for resource")
 		return err
 	}
+	// a resource which is being deleted is treated like a resource which does not exist, scaling it would only be retried until the timeout
+	if resourceMeta.DeletionTimestamp != nil {
+		r.logger.Info("Resource is being deleted. Ignoring this resource", "deletionTimestamp", resourceMeta.DeletionTimestamp)
+		return nil
+	}
+	resourceAnnot = resourceMeta.Annotations
 
 	if ignoreScaling(resourceAnnot) {
 		r.logger.Info("Scaling ignored due to explicit instruction via annotation", "annotation", ignoreScalingAnnotationKey)
 		return nil
 	}
 
-	_, scaleSubRes, err := util.GetScaleResource(ctx, r.client, r.scaler, r.logger, r.resourceInfo.ref, r.resourceInfo.timeout)
+	gr, scaleSubRes, err := util.GetScaleResource(ctx, r.client, r.scaler, r.logger, r.resourceInfo.ref, r.resourceInfo.timeout)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
+		switch {
+		case errors.Is(err, util.ErrScaleSubresourceNotFound):
 			r.logger.Error(err, "Resource does not have a scale subresource. Skipping scaling of dependent resources. Invalid config file")
+		case errors.Is(err, util.ErrMappingNotFound):
+			r.logger.Error(err, "Kind of resource is not known to the API server. Skipping scaling of dependent resources. Invalid config file")
 		}
 		return err
 	}
 
-	if r.resourceInfo.operation.shouldScaleReplicas(scaleSubRes.Spec.Replicas) {
-		if err := r.updateResourceAndScale(ctx, scaleSubRes, resourceAnnot); err != nil {
+	if r.resourceInfo.operation == scaleUp && r.isRecreatedSinceScaleDown(resourceAnnot, scaleSubRes.UID) {
+		return nil
+	}
+
+	if r.resourceInfo.operation.shouldScaleReplicas(scaleSubRes.Spec.Replicas, r.resourceInfo.scaleDownReplicas) {
+		if err := r.updateResourceAndScale(ctx, *gr, scaleSubRes, resourceAnnot); err != nil {
 			return err
 		}
 	} else {
 		if r.resourceInfo.operation == scaleUp {
-			r.logger.Info("Skipping scale-up for resource as current spec replicas > 0")
+			r.logger.Info("Skipping scale-up for resource as current spec replicas > scale-down replicas", "scaleDownReplicas", r.resourceInfo.scaleDownReplicas)
 		} else {
-			r.logger.Info("Skipping scale-down for resource as current spec replicas == 0")
+			r.logger.Info("Skipping scale-down for resource as current spec replicas <= scale-down replicas", "scaleDownReplicas", r.resourceInfo.scaleDownReplicas)
 		}
 	}
 
@@ -105,47 +138,69 @@ func (r *resScaler) scale(ctx context.Context) error {
 }
 
 func (r *resScaler) waitTillMinTargetReplicasReached(ctx context.Context) error {
-	var minTargetReplicas int32
-	if r.resourceInfo.operation == scaleUp {
-		minTargetReplicas = 1
-	}
+	minTargetReplicas := r.resourceInfo.operation.getMinTargetReplicas(r.resourceInfo.scaleDownReplicas)
 	r.logger.Info("Waiting for resource to reach minimum target replicas", "minTargetReplicas", minTargetReplicas)
 	opDesc := fmt.Sprintf("wait for resource to reach minimum required target replicas %d", minTargetReplicas)
-	resMinTargetReached := util.RetryUntilPredicate(ctx, r.logger, opDesc, func() bool {
-		readyReplicas, err := util.GetResourceReadyReplicas(ctx, r.client, r.namespace, r.resourceInfo.ref)
+	resMinTargetReached := retry.RetryUntilPredicate(ctx, r.logger, opDesc, func() bool {
+		status, err := r.statuses.get(ctx, r.resourceInfo.ref)
 		if err != nil {
 			return false
 		}
-		if r.resourceInfo.operation.minTargetReplicasReached(readyReplicas) {
+		// the ready replicas are only meaningful once the controller of the resource has acted upon the updated replicas
+		if !status.GenerationObserved {
+			r.logger.V(4).Info("Controller of resource has not yet observed its latest generation")
+			return false
+		}
+		if r.resourceInfo.operation.minTargetReplicasReached(status.ReadyReplicas, r.resourceInfo.scaleDownReplicas) {
 			r.logger.Info("Resource has reached desired replicas", "minTargetReplicas", minTargetReplicas)
 			return true
 		}
 		return false
-	}, *r.opts.resourceCheckTimeout, *r.opts.resourceCheckInterval)
+	}, *r.opts.resourceCheckTimeout, retry.ExponentialBackoff{Initial: *r.opts.resourceCheckInterval, JitterFactor: resourceCheckJitterFactor})
 	if !resMinTargetReached {
-		return fmt.Errorf("timed out waiting for {namespace: %s, resource: %s} to reach minTargetReplicas %d", r.namespace, r.resourceInfo.ref.Name, minTargetReplicas)
+		return fmt.Errorf("%w waiting for {namespace: %s, resource: %s} to reach minTargetReplicas %d", util.ErrTimeout, r.namespace, r.resourceInfo.ref.Name, minTargetReplicas)
 	}
 	return nil
 }
 
-func (r *resScaler) updateResourceAndScale(ctx context.Context, scaleSubRes *autoscalingv1.Scale, annot map[string]string) error {
+func (r *resScaler) updateResourceAndScale(ctx context.Context, groupResource schema.GroupResource, scaleSubRes *autoscalingv1.Scale, annot map[string]string) error {
 	childCtx, cancelFn := context.WithTimeout(ctx, r.resourceInfo.timeout)
 	defer cancelFn()
 
+	targetReplicas, err := r.determineTargetReplicas(annot)
+	if err != nil {
+		return err
+	}
+
+	// the update is dry-run before the resource is annotated by a scale-down, so that a rejected update leaves no trace on the resource
+	if r.opts.dryRunScaleUpdates {
+		if err := r.dryRunScaleUpdate(childCtx, groupResource, scaleSubRes, targetReplicas); err != nil {
+			return err
+		}
+	}
+
 	// update the annotation capturing the current spec.replicas as the annotation value if the operation is scale down.
 	// This allows restoration of the resource to the same replica count when a subsequent scale up operation is triggered.
+	// The time of the scale down is captured as well so that the duration of the scale down can be observed once the resource is scaled up.
+	// The annotations are only patched if the resource has not been changed since its replicas have been read, so that the replicas of a
+	// concurrent scale operation are not clobbered. A conflict fails the attempt, which is then retried with the current replicas.
 	if r.resourceInfo.operation == scaleDown {
-		patchBytes := []byte(fmt.Sprintf("{\"metadata\":{\"annotations\":{\"%s\":\"%s\"}}}", replicasAnnotationKey, strconv.Itoa(int(scaleSubRes.Spec.Replicas))))
-		err := util.PatchResourceAnnotations(ctx, r.client, r.namespace, r.resourceInfo.ref, patchBytes)
+		patchBytes, err := r.createScaleDownAnnotationPatch(scaleSubRes.Spec.Replicas, scaleSubRes.UID, scaleSubRes.ResourceVersion, time.Now())
+		if err != nil {
+			return err
+		}
+		err = util.PatchResourceAnnotations(ctx, r.client, r.namespace, r.resourceInfo.ref, patchBytes)
 		if err != nil {
+			invalidateCachedScale(r.scaler, groupResource, r.resourceInfo.ref.Name)
+			if apierrors.IsConflict(err) {
+				r.logger.Info("Resource has been changed since its replicas have been read, scale-down will be re-attempted", "resourceVersion", scaleSubRes.ResourceVersion)
+				return err
+			}
 			r.logger.Error(err, "Failed to update annotation to capture the current replicas before scaling it down")
 			return err
 		}
-	}
-
-	targetReplicas, err := r.determineTargetReplicas(annot)
-	if err != nil {
-		return err
+		// patching the annotations changes the resource version of the scale subresource.
+		invalidateCachedScale(r.scaler, groupResource, r.resourceInfo.ref.Name)
 	}
 
 	// need the updated scale subresource
@@ -163,22 +218,134 @@ func (r *resScaler) updateResourceAndScale(ctx context.Context, scaleSubRes *aut
 	if _, err = r.scaler.Update(childCtx, *gr, scaleSubRes, metav1.UpdateOptions{}); err != nil {
 		return err
 	}
+	r.statuses.invalidate(r.resourceInfo.ref)
+	if r.resourceInfo.operation == scaleUp {
+		r.recordAndRemoveScaledDownAt(ctx, annot)
+	}
 	return nil
 }
 
+// dryRunScaleUpdate runs a server-side dry-run of the update of the given scale subresource to the target replicas. If the dry-run is rejected, e.g.
+// by an admission webhook, then ErrScaleRejected is returned. Other errors, e.g. conflicts, are returned as is, so that the scale operation is
+// retried.
+func (r *resScaler) dryRunScaleUpdate(ctx context.Context, groupResource schema.GroupResource, scaleSubRes *autoscalingv1.Scale, targetReplicas int32) error {
+	dryRunScale := scaleSubRes.DeepCopy()
+	dryRunScale.Spec.Replicas = targetReplicas
+	_, err := r.scaler.Update(ctx, groupResource, dryRunScale, metav1.UpdateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err == nil {
+		return nil
+	}
+	if apierrors.IsForbidden(err) || apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) {
+		r.logger.Error(err, "Scale update has been rejected by its server-side dry-run, it will not be retried", "targetReplicas", targetReplicas)
+		return fmt.Errorf("%w: %w", ErrScaleRejected, err)
+	}
+	return err
+}
+
+// recordAndRemoveScaledDownAt records the time at which the resource has been scaled down, if it has been captured in the ScaledDownAtAnnotationKey
+// annotation, and removes the annotation along with the ScaledDownUIDAnnotationKey annotation. The annotations are only removed if the
+// ScaledDownAtAnnotationKey annotation has not been changed in the meantime, e.g. by a concurrent scale-down whose annotations must be retained.
+// Failures are only logged as they do not affect the scale-up.
+func (r *resScaler) recordAndRemoveScaledDownAt(ctx context.Context, annotations map[string]string) {
+	scaledDownAtStr, ok := annotations[ScaledDownAtAnnotationKey]
+	if !ok {
+		return
+	}
+	if scaledDownAt, err := time.Parse(time.RFC3339, scaledDownAtStr); err != nil {
+		r.logger.Error(err, "Ignoring invalid value of annotation", "annotationKey", ScaledDownAtAnnotationKey)
+	} else {
+		r.logger.Info("Scaled up resource which has been scaled down", "scaledDownAt", scaledDownAtStr, "scaledDownDuration", time.Since(scaledDownAt).Round(time.Second))
+		r.scaledDownSince.record(scaledDownAt)
+	}
+	patchBytes, err := createRemoveScaledDownAnnotationsPatch(annotations)
+	if err == nil {
+		err = util.PatchResourceMetadata(ctx, r.client, r.namespace, r.resourceInfo.ref, client.RawPatch(types.JSONPatchType, patchBytes))
+	}
+	if err != nil {
+		r.logger.Error(err, "Failed to remove annotation, it might have been changed by a concurrent scale-down", "annotationKey", ScaledDownAtAnnotationKey)
+	}
+}
+
+// createRemoveScaledDownAnnotationsPatch creates a JSON patch which removes the ScaledDownAtAnnotationKey annotation and, if present, the
+// ScaledDownUIDAnnotationKey annotation. The patch fails if the value of the ScaledDownAtAnnotationKey annotation differs from the given one.
+func createRemoveScaledDownAnnotationsPatch(annotations map[string]string) ([]byte, error) {
+	patch := []map[string]interface{}{
+		{"op": "test", "path": annotationPath(ScaledDownAtAnnotationKey), "value": annotations[ScaledDownAtAnnotationKey]},
+		{"op": "remove", "path": annotationPath(ScaledDownAtAnnotationKey)},
+	}
+	if _, ok := annotations[ScaledDownUIDAnnotationKey]; ok {
+		patch = append(patch, map[string]interface{}{"op": "remove", "path": annotationPath(ScaledDownUIDAnnotationKey)})
+	}
+	return json.Marshal(patch)
+}
+
+// annotationPath returns the JSON pointer to the annotation with the given key.
+func annotationPath(key string) string {
+	return "/metadata/annotations/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// isRecreatedSinceScaleDown checks if the UID of the resource is verified and differs from the UID captured in the ScaledDownUIDAnnotationKey
+// annotation when it has been scaled down. This is the case if the resource has been deleted and re-created, e.g. from a manifest which carries the
+// annotations of the previous resource, whose replicas must then not be restored onto it.
+func (r *resScaler) isRecreatedSinceScaleDown(annotations map[string]string, uid types.UID) bool {
+	if !r.resourceInfo.verifyUID {
+		return false
+	}
+	scaledDownUID, ok := annotations[ScaledDownUIDAnnotationKey]
+	if !ok || types.UID(scaledDownUID) == uid {
+		return false
+	}
+	r.logger.Info("Skipping scale-up for resource as it has been re-created since it has been scaled down", "scaledDownUID", scaledDownUID, "uid", uid)
+	return true
+}
+
 func (r *resScaler) determineTargetReplicas(annotations map[string]string) (int32, error) {
 	if r.resourceInfo.operation == scaleDown {
-		return defaultScaleDownReplicas, nil
+		return r.resourceInfo.scaleDownReplicas, nil
 	}
-	if replicasStr, ok := annotations[replicasAnnotationKey]; ok {
-		replicas, err := strconv.Atoi(replicasStr)
-		if err != nil {
-			return 0, fmt.Errorf("unexpected and invalid replicasStr set as value for annotation: %s for resource, Err: %w", replicasAnnotationKey, err)
+	// The replicas captured in the DefaultReplicasAnnotationKey annotation are used as a fallback so that resources which have been scaled down
+	// prior to configuring a different annotation key are restored correctly.
+	for _, key := range r.getReplicasAnnotationKeys() {
+		if replicasStr, ok := annotations[key]; ok {
+			replicas, err := strconv.Atoi(replicasStr)
+			if err != nil {
+				return 0, fmt.Errorf("unexpected and invalid replicasStr set as value for annotation: %s for resource, Err: %w", key, err)
+			}
+			return int32(replicas), nil
 		}
-		return int32(replicas), nil
 	}
-	r.logger.Info("Replicas annotation not found, falling back to default scale-up replicas", "operation", r.resourceInfo.operation, "annotationKey", replicasAnnotationKey, "default-replicas", defaultScaleUpReplicas)
-	return defaultScaleUpReplicas, nil
+	// a resource which is scaled down to more replicas than the default scale-up replicas must not be scaled down by a scale-up
+	replicas := max(defaultScaleUpReplicas, r.resourceInfo.scaleDownReplicas)
+	r.logger.Info("Replicas annotation not found, falling back to default scale-up replicas", "operation", r.resourceInfo.operation, "annotationKey", r.opts.replicasAnnotationKey, "default-replicas", replicas)
+	return replicas, nil
+}
+
+// getReplicasAnnotationKeys returns the keys of the annotations which capture the replicas of the resource in the order of their precedence.
+func (r *resScaler) getReplicasAnnotationKeys() []string {
+	if r.opts.replicasAnnotationKey == DefaultReplicasAnnotationKey {
+		return []string{DefaultReplicasAnnotationKey}
+	}
+	return []string{r.opts.replicasAnnotationKey, DefaultReplicasAnnotationKey}
+}
+
+// createScaleDownAnnotationPatch creates a merge patch which captures the given replicas in the configured replicas annotation and, if dual write
+// is enabled, additionally in the DefaultReplicasAnnotationKey annotation. The given time of the scale down is captured in the ScaledDownAtAnnotationKey
+// annotation and, if the UID of the resource is verified, the given UID in the ScaledDownUIDAnnotationKey annotation. A given resourceVersion is
+// added to the patch as a precondition.
+func (r *resScaler) createScaleDownAnnotationPatch(replicas int32, uid types.UID, resourceVersion string, scaledDownAt time.Time) ([]byte, error) {
+	replicasStr := strconv.Itoa(int(replicas))
+	annotations := map[string]string{r.opts.replicasAnnotationKey: replicasStr, ScaledDownAtAnnotationKey: scaledDownAt.UTC().Format(time.RFC3339)}
+	if r.opts.dualWriteReplicasAnnotation {
+		annotations[DefaultReplicasAnnotationKey] = replicasStr
+	}
+	if r.resourceInfo.verifyUID {
+		annotations[ScaledDownUIDAnnotationKey] = string(uid)
+	}
+	metadata := map[string]interface{}{"annotations": annotations}
+	if resourceVersion != "" {
+		metadata["resourceVersion"] = resourceVersion
+	}
+	return json.Marshal(map[string]interface{}{"metadata": metadata})
 }
 
 func ignoreScaling(annotations map[string]string) bool {
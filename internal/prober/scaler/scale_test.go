@@ -0,0 +1,461 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package scaler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const (
+	customReplicasAnnotationKey = "gitops.example.com/replicas"
+	testScaledDownAt            = "2024-03-01T10:00:00Z"
+)
+
+func TestCreateScaleDownAnnotationPatch(t *testing.T) {
+	tests := []struct {
+		name                string
+		options             []scalerOption
+		expectedAnnotations map[string]string
+	}{
+		{"default annotation key should be used if none is configured", nil, map[string]string{DefaultReplicasAnnotationKey: "3", ScaledDownAtAnnotationKey: testScaledDownAt}},
+		{"configured annotation key should be used", []scalerOption{WithReplicasAnnotationKey(customReplicasAnnotationKey, false)}, map[string]string{customReplicasAnnotationKey: "3", ScaledDownAtAnnotationKey: testScaledDownAt}},
+		{"both annotation keys should be used if dual write is enabled", []scalerOption{WithReplicasAnnotationKey(customReplicasAnnotationKey, true)}, map[string]string{customReplicasAnnotationKey: "3", DefaultReplicasAnnotationKey: "3", ScaledDownAtAnnotationKey: testScaledDownAt}},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := &resScaler{opts: buildScalerOptions(entry.options...), logger: logr.Discard()}
+			patchBytes, err := r.createScaleDownAnnotationPatch(3, "", "", time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC))
+			g.Expect(err).ToNot(HaveOccurred())
+			patch := struct {
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+			}{}
+			g.Expect(json.Unmarshal(patchBytes, &patch)).To(Succeed())
+			g.Expect(patch.Metadata.Annotations).To(Equal(entry.expectedAnnotations))
+		})
+	}
+}
+
+func TestDetermineScaleUpTargetReplicas(t *testing.T) {
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		expectedReplicas int32
+		expectErr        bool
+	}{
+		{"replicas should be read from the configured annotation", map[string]string{customReplicasAnnotationKey: "3", DefaultReplicasAnnotationKey: "2"}, 3, false},
+		{"replicas should be read from the default annotation if the configured one is missing", map[string]string{DefaultReplicasAnnotationKey: "2"}, 2, false},
+		{"default scale up replicas should be used if no annotation is present", nil, defaultScaleUpReplicas, false},
+		{"invalid annotation value should result in an error", map[string]string{customReplicasAnnotationKey: "bingo"}, 0, true},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := &resScaler{
+				opts:         buildScalerOptions(WithReplicasAnnotationKey(customReplicasAnnotationKey, false)),
+				logger:       logr.Discard(),
+				resourceInfo: scalableResourceInfo{operation: scaleUp},
+			}
+			replicas, err := r.determineTargetReplicas(entry.annotations)
+			if entry.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(replicas).To(Equal(entry.expectedReplicas))
+		})
+	}
+}
+
+func TestScaledDownSinceShouldTrackEarliestScaleDown(t *testing.T) {
+	g := NewWithT(t)
+	since := &scaledDownSince{}
+	_, ok := since.get()
+	g.Expect(ok).To(BeFalse())
+
+	earliest := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	since.record(earliest.Add(time.Minute))
+	since.record(earliest)
+	since.record(earliest.Add(2 * time.Minute))
+	scaledDownAt, ok := since.get()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(scaledDownAt).To(Equal(earliest))
+
+	since.reset()
+	_, ok = since.get()
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestWaitTillMinTargetReplicasReachedShouldWaitForObservedGeneration(t *testing.T) {
+	tests := []struct {
+		name               string
+		observedGeneration int64
+		expectErr          bool
+	}{
+		{"should succeed once the controller has observed the latest generation", 2, false},
+		{"should time out if the controller has not observed the latest generation", 1, true},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager", Namespace: "test", Generation: 2},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: entry.observedGeneration, ReadyReplicas: 1},
+			}
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment).Build()
+			r := &resScaler{
+				client:       cl,
+				statuses:     newStatusCache(cl, deployment.Namespace, 0),
+				opts:         buildScalerOptions(withResourceCheckTimeout(50*time.Millisecond), withResourceCheckInterval(10*time.Millisecond)),
+				logger:       logr.Discard(),
+				namespace:    deployment.Namespace,
+				resourceInfo: scalableResourceInfo{ref: &autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: deployment.Name, APIVersion: "apps/v1"}, operation: scaleUp},
+			}
+			err := r.waitTillMinTargetReplicasReached(context.Background())
+			if entry.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestScaleShouldTimeOutIfMinTargetReplicasAreNotReached(t *testing.T) {
+	dependentResourceInfos := []papi.DependentResourceInfo{
+		createTestDeploymentDependentResourceInfo(mcmObjectRef.Name, 2, 0, nil, pointer.Duration(0), false),
+		createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 1, 0, nil, pointer.Duration(0), false),
+		createTestDeploymentDependentResourceInfo(caObjectRef.Name, 0, 1, nil, pointer.Duration(0), true),
+	}
+	tests := []struct {
+		name                      string
+		replicas                  int32
+		op                        operation
+		expectedScaledMCMReplicas int32
+		expectedScaledKCMReplicas int32
+		expectedScaledCAReplicas  int32
+	}{
+		{"scale-up should stop at the first level whose resources do not become ready", 0, scaleUp, 0, 0, 1},
+		{"scale-down should stop at the first level whose resources do not scale down", 2, scaleDown, 0, 0, 2},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			cl := newFakeClientWithDeployments(entry.replicas, mcmObjectRef.Name, kcmObjectRef.Name, caObjectRef.Name)
+			scalesGetter := test.NewFakeScalesGetterBuilder(cl).WithFrozenStatus().Build()
+			ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(),
+				withResourceCheckTimeout(50*time.Millisecond), withResourceCheckInterval(10*time.Millisecond), withScaleResourceBackOff(10*time.Millisecond))
+
+			scaleFn := ds.ScaleDown
+			if entry.op == scaleUp {
+				scaleFn = ds.ScaleUp
+			}
+			err := scaleFn(context.Background())
+			g.Expect(err).To(MatchError(ContainSubstring("timed out waiting")))
+			g.Expect(getSpecReplicas(g, cl, mcmObjectRef.Name)).To(Equal(entry.expectedScaledMCMReplicas))
+			g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(entry.expectedScaledKCMReplicas))
+			g.Expect(getSpecReplicas(g, cl, caObjectRef.Name)).To(Equal(entry.expectedScaledCAReplicas))
+		})
+	}
+}
+
+func TestScaleShouldReturnErrorIfScaleSubresourceCannotBeUpdated(t *testing.T) {
+	g := NewWithT(t)
+	dependentResourceInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)}
+	cl := newFakeClientWithDeployments(2, kcmObjectRef.Name)
+	updateErr := apierrors.NewConflict(deploymentsGR, kcmObjectRef.Name, errors.New("object has been modified"))
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).RecordError(test.ScaleVerbUpdate, deploymentsGR, updateErr).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(), withScaleResourceBackOff(10*time.Millisecond))
+
+	g.Expect(ds.ScaleDown(context.Background())).ToNot(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(2)))
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).ToNot(BeEmpty())
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(HaveEach(Equal(int32(0))))
+}
+
+func TestScaleShouldDryRunScaleUpdatesIfEnabled(t *testing.T) {
+	g := NewWithT(t)
+	dependentResourceInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)}
+	cl := newFakeClientWithDeployments(2, kcmObjectRef.Name)
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(), withResourceCheckInterval(10*time.Millisecond), WithDryRunScaleUpdates(true))
+
+	g.Expect(ds.ScaleDown(context.Background())).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(0)))
+	g.Expect(scalesGetter.DryRunUpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(Equal([]int32{0}))
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(Equal([]int32{0}))
+
+	g.Expect(ds.ScaleUp(context.Background())).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(2)))
+	g.Expect(scalesGetter.DryRunUpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(Equal([]int32{0, 2}))
+}
+
+func TestScaleShouldNotBeRetriedIfScaleUpdateIsRejectedByDryRun(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	dependentResourceInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)}
+	cl := newFakeClientWithDeployments(2, kcmObjectRef.Name)
+	rejectErr := apierrors.NewForbidden(deploymentsGR, kcmObjectRef.Name, errors.New(`admission webhook "validation.example.com" denied the request`))
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).RecordError(test.ScaleVerbDryRunUpdate, deploymentsGR, rejectErr).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(), withScaleResourceBackOff(10*time.Millisecond), WithDryRunScaleUpdates(true))
+
+	err := ds.ScaleDown(ctx)
+	g.Expect(err).To(MatchError(ErrScaleRejected))
+	g.Expect(scalesGetter.DryRunUpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(HaveLen(1), "a rejected scale update should not be retried")
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(BeEmpty())
+	deployment := &appsv1.Deployment{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+	g.Expect(deployment.Annotations).ToNot(HaveKey(DefaultReplicasAnnotationKey), "a rejected scale-down should not annotate the resource")
+	g.Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+}
+
+func TestScaleShouldNotWaitForRetryAfterOfThrottledScaleUpdateIfDisabled(t *testing.T) {
+	g := NewWithT(t)
+	dependentResourceInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)}
+	cl := newFakeClientWithDeployments(2, kcmObjectRef.Name)
+	throttleErr := apierrors.NewTooManyRequests("too many requests", 60)
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).RecordError(test.ScaleVerbUpdate, deploymentsGR, throttleErr).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(), withScaleResourceBackOff(10*time.Millisecond), WithHonorRetryAfter(false))
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+
+	err := ds.ScaleDown(ctx)
+	g.Expect(apierrors.IsTooManyRequests(err)).To(BeTrue(), "the retries should use the scale resource backoff instead of waiting for the suggested Retry-After")
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(HaveLen(defaultMaxResourceScalingAttempts))
+}
+
+func TestScaleDownThenScaleUpShouldRestoreReplicas(t *testing.T) {
+	g := NewWithT(t)
+	dependentResourceInfos := []papi.DependentResourceInfo{
+		createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 1, nil, pointer.Duration(0), false),
+		createTestDeploymentDependentResourceInfo(mcmObjectRef.Name, 1, 0, nil, pointer.Duration(0), false),
+	}
+	cl := newFakeClientWithDeployments(2, kcmObjectRef.Name, mcmObjectRef.Name)
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(), withResourceCheckInterval(10*time.Millisecond))
+
+	g.Expect(ds.ScaleDown(context.Background())).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(0)))
+	g.Expect(getSpecReplicas(g, cl, mcmObjectRef.Name)).To(Equal(int32(0)))
+
+	g.Expect(ds.ScaleUp(context.Background())).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(2)))
+	g.Expect(getSpecReplicas(g, cl, mcmObjectRef.Name)).To(Equal(int32(2)))
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(Equal([]int32{0, 2}))
+}
+
+func TestScaleUpShouldSkipResourceWhichHasBeenRecreatedSinceScaleDown(t *testing.T) {
+	tests := []struct {
+		name             string
+		recreate         bool
+		expectedReplicas int32
+	}{
+		{"resource should be restored if it has not been re-created", false, 2},
+		{"resource should not be restored if it has been re-created", true, 0},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			dependentResourceInfo := createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)
+			dependentResourceInfo.VerifyUID = pointer.Bool(true)
+			cl := newFakeClientWithDeployments(2, kcmObjectRef.Name)
+			deployment := &appsv1.Deployment{}
+			g.Expect(cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+			deployment.UID = "initial-uid"
+			g.Expect(cl.Update(context.Background(), deployment)).To(Succeed())
+			scalesGetter := test.NewFakeScalesGetterBuilder(cl).Build()
+			ds := NewScaler("test", []papi.DependentResourceInfo{dependentResourceInfo}, cl, scalesGetter, logr.Discard(), withResourceCheckInterval(10*time.Millisecond))
+
+			g.Expect(ds.ScaleDown(context.Background())).To(Succeed())
+			g.Expect(cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+			g.Expect(deployment.Annotations).To(HaveKeyWithValue(ScaledDownUIDAnnotationKey, "initial-uid"))
+			if entry.recreate {
+				g.Expect(cl.Delete(context.Background(), deployment)).To(Succeed())
+				deployment.ResourceVersion = ""
+				deployment.UID = "recreated-uid"
+				g.Expect(cl.Create(context.Background(), deployment)).To(Succeed())
+			}
+
+			g.Expect(ds.ScaleUp(context.Background())).To(Succeed())
+			g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(entry.expectedReplicas))
+			g.Expect(cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+			if entry.recreate {
+				g.Expect(deployment.Annotations).To(HaveKey(ScaledDownUIDAnnotationKey), "the annotations of a re-created resource should not be touched")
+			} else {
+				g.Expect(deployment.Annotations).ToNot(HaveKey(ScaledDownUIDAnnotationKey), "the captured UID should be removed once the resource has been scaled up")
+			}
+		})
+	}
+}
+
+func TestScaleDownShouldKeepConfiguredReplicas(t *testing.T) {
+	g := NewWithT(t)
+	dependentResourceInfo := createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)
+	dependentResourceInfo.ScaleDownInfo.Replicas = pointer.Int32(1)
+	cl := newFakeClientWithDeployments(3, kcmObjectRef.Name)
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).Build()
+	ds := NewScaler("test", []papi.DependentResourceInfo{dependentResourceInfo}, cl, scalesGetter, logr.Discard(), withResourceCheckInterval(10*time.Millisecond))
+
+	g.Expect(ds.ScaleDown(context.Background())).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(1)), "resource should be scaled down to the configured replicas")
+	g.Expect(ds.ScaleDown(context.Background())).To(Succeed())
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(Equal([]int32{1}), "resource which has already been scaled down should be skipped")
+
+	g.Expect(ds.ScaleUp(context.Background())).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(3)), "replicas prior to the scale down should be restored")
+	g.Expect(ds.ScaleUp(context.Background())).To(Succeed())
+	g.Expect(scalesGetter.UpdatedReplicas(deploymentsGR, kcmObjectRef.Name)).To(Equal([]int32{1, 3}), "resource with more than the scale down replicas should not be scaled up")
+}
+
+func TestDetermineScaleUpTargetReplicasShouldNotFallBelowScaleDownReplicas(t *testing.T) {
+	g := NewWithT(t)
+	r := &resScaler{
+		opts:         buildScalerOptions(),
+		logger:       logr.Discard(),
+		resourceInfo: scalableResourceInfo{operation: scaleUp, scaleDownReplicas: 2},
+	}
+	g.Expect(r.determineTargetReplicas(nil)).To(Equal(int32(2)))
+}
+
+func TestScaleShouldSkipResourceWhichIsBeingDeleted(t *testing.T) {
+	g := NewWithT(t)
+	dependentResourceInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)}
+	cl := newFakeClientWithDeployments(2, kcmObjectRef.Name)
+	deployment := &appsv1.Deployment{}
+	g.Expect(cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+	deployment.Finalizers = []string{"test.gardener.cloud/finalizer"}
+	g.Expect(cl.Update(context.Background(), deployment)).To(Succeed())
+	g.Expect(cl.Delete(context.Background(), deployment)).To(Succeed())
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard())
+
+	g.Expect(ds.ScaleDown(context.Background())).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(2)))
+	g.Expect(scalesGetter.Actions()).To(BeEmpty(), "the scale subresource of a resource which is being deleted should not be touched")
+}
+
+func TestScaleDownShouldBeRetriedIfResourceIsChangedConcurrently(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	dependentResourceInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)}
+	var changed bool
+	cl := interceptor.NewClient(newFakeClientWithDeployments(2, kcmObjectRef.Name).(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if !changed {
+				// another actor scales the resource after its replicas have been read by the scale-down
+				changed = true
+				deployment := &appsv1.Deployment{}
+				g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+				deployment.Spec.Replicas = pointer.Int32(3)
+				g.Expect(c.Update(ctx, deployment)).To(Succeed())
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(), withScaleResourceBackOff(10*time.Millisecond), withResourceCheckInterval(10*time.Millisecond))
+	conflictsBefore := testutil.ToFloat64(metrics.ScaleConflictsTotal.WithLabelValues(scaleDown.String()))
+
+	g.Expect(ds.ScaleDown(ctx)).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(0)))
+	deployment := &appsv1.Deployment{}
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+	g.Expect(deployment.Annotations).To(HaveKeyWithValue(DefaultReplicasAnnotationKey, "3"), "the replicas of the concurrent scale operation should be captured")
+	g.Expect(testutil.ToFloat64(metrics.ScaleConflictsTotal.WithLabelValues(scaleDown.String()))).To(Equal(conflictsBefore + 1))
+}
+
+func TestScaleUpShouldRetainAnnotationsOfConcurrentScaleDown(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	const concurrentScaledDownAt = "2024-03-01T11:00:00Z"
+	dependentResourceInfos := []papi.DependentResourceInfo{createTestDeploymentDependentResourceInfo(kcmObjectRef.Name, 0, 0, nil, pointer.Duration(0), false)}
+	base := newFakeClientWithDeployments(0, kcmObjectRef.Name)
+	deployment := &appsv1.Deployment{}
+	g.Expect(base.Get(ctx, client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+	deployment.Annotations = map[string]string{DefaultReplicasAnnotationKey: "2", ScaledDownAtAnnotationKey: testScaledDownAt}
+	g.Expect(base.Update(ctx, deployment)).To(Succeed())
+	cl := interceptor.NewClient(base.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() == types.JSONPatchType {
+				// a concurrent scale-down annotates the resource after it has been scaled up
+				current := &appsv1.Deployment{}
+				g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, current)).To(Succeed())
+				current.Annotations[ScaledDownAtAnnotationKey] = concurrentScaledDownAt
+				g.Expect(c.Update(ctx, current)).To(Succeed())
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+	scalesGetter := test.NewFakeScalesGetterBuilder(cl).Build()
+	ds := NewScaler("test", dependentResourceInfos, cl, scalesGetter, logr.Discard(), withResourceCheckInterval(10*time.Millisecond))
+
+	g.Expect(ds.ScaleUp(ctx)).To(Succeed())
+	g.Expect(getSpecReplicas(g, cl, kcmObjectRef.Name)).To(Equal(int32(2)))
+	g.Expect(cl.Get(ctx, client.ObjectKey{Namespace: "test", Name: kcmObjectRef.Name}, deployment)).To(Succeed())
+	g.Expect(deployment.Annotations).To(HaveKeyWithValue(ScaledDownAtAnnotationKey, concurrentScaledDownAt), "the annotations of the concurrent scale-down should be retained")
+}
+
+func TestCreateRemoveScaledDownAnnotationsPatch(t *testing.T) {
+	g := NewWithT(t)
+	patchBytes, err := createRemoveScaledDownAnnotationsPatch(map[string]string{ScaledDownAtAnnotationKey: testScaledDownAt, ScaledDownUIDAnnotationKey: "uid"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(patchBytes)).To(MatchJSON(`[
+		{"op": "test", "path": "/metadata/annotations/dependency-watchdog.gardener.cloud~1scaled-down-at", "value": "` + testScaledDownAt + `"},
+		{"op": "remove", "path": "/metadata/annotations/dependency-watchdog.gardener.cloud~1scaled-down-at"},
+		{"op": "remove", "path": "/metadata/annotations/dependency-watchdog.gardener.cloud~1scaled-down-uid"}
+	]`))
+}
+
+// newFakeClientWithDeployments creates a fake client with ready deployments of the given names and replicas whose RESTMapper knows deployments.
+func newFakeClientWithDeployments(replicas int32, names ...string) client.Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind(deploymentKind), meta.RESTScopeNamespace)
+	builder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper)
+	for _, name := range names {
+		builder.WithObjects(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32(replicas)},
+			Status:     appsv1.DeploymentStatus{Replicas: replicas, ReadyReplicas: replicas},
+		})
+	}
+	return builder.Build()
+}
+
+func getSpecReplicas(g *WithT, cl client.Client, name string) int32 {
+	deployment := &appsv1.Deployment{}
+	g.Expect(cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: name}, deployment)).To(Succeed())
+	return pointer.Int32Deref(deployment.Spec.Replicas, 0)
+}
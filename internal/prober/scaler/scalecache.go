@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package scaler
+
+import (
+	"context"
+	"sync"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	scalev1 "k8s.io/client-go/scale"
+)
+
+// scaleKey identifies a scale subresource within a namespace.
+type scaleKey struct {
+	groupResource schema.GroupResource
+	name          string
+}
+
+// scaleCache is a scalev1.ScaleInterface for a single namespace which caches the scale subresources that it has fetched or updated, keyed by
+// the group resource and the name of the dependent resource. It is reset at the start of every scale flow run, so that the scale subresource of
+// a dependent resource is only fetched once within a scale flow run even if it is looked up several times, e.g. to check its replicas before and
+// after scaling it. It does not deduplicate the lookups of different dependent resources of the same group resource. The discovery of the group
+// resources is not cached here, it is already shared by all probers via the cached discovery information of the RESTMapper of the ScalesGetter
+// and the one of the seed client. Scale subresources are returned as deep copies so that callers cannot modify the cache.
+type scaleCache struct {
+	scalev1.ScaleInterface
+	mu     sync.Mutex
+	scales map[scaleKey]*autoscalingv1.Scale
+}
+
+func newScaleCache(delegate scalev1.ScaleInterface) *scaleCache {
+	return &scaleCache{
+		ScaleInterface: delegate,
+		scales:         make(map[scaleKey]*autoscalingv1.Scale),
+	}
+}
+
+func (c *scaleCache) Get(ctx context.Context, resource schema.GroupResource, name string, opts metav1.GetOptions) (*autoscalingv1.Scale, error) {
+	key := scaleKey{groupResource: resource, name: name}
+	c.mu.Lock()
+	cached, ok := c.scales[key]
+	c.mu.Unlock()
+	if ok {
+		return cached.DeepCopy(), nil
+	}
+	s, err := c.ScaleInterface.Get(ctx, resource, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, s)
+	return s, nil
+}
+
+func (c *scaleCache) Update(ctx context.Context, resource schema.GroupResource, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error) {
+	key := scaleKey{groupResource: resource, name: scale.Name}
+	s, err := c.ScaleInterface.Update(ctx, resource, scale, opts)
+	if err != nil {
+		// the cached scale subresource might be stale, e.g. in case of a conflict, so it should be fetched again by a retry.
+		c.invalidate(resource, scale.Name)
+		return nil, err
+	}
+	// the result of a dry-run has not been persisted and must not be cached
+	if len(opts.DryRun) == 0 {
+		c.store(key, s)
+	}
+	return s, nil
+}
+
+func (c *scaleCache) Patch(ctx context.Context, gvr schema.GroupVersionResource, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*autoscalingv1.Scale, error) {
+	c.invalidate(gvr.GroupResource(), name)
+	return c.ScaleInterface.Patch(ctx, gvr, name, pt, data, opts)
+}
+
+// invalidate removes the cached scale subresource for the given resource. It should be called whenever the resource has been changed
+// without using the scaleCache, e.g. when its annotations have been patched, as this changes the resource version of the scale subresource.
+func (c *scaleCache) invalidate(resource schema.GroupResource, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.scales, scaleKey{groupResource: resource, name: name})
+}
+
+// reset removes all cached scale subresources.
+func (c *scaleCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.scales)
+}
+
+func (c *scaleCache) store(key scaleKey, s *autoscalingv1.Scale) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scales[key] = s.DeepCopy()
+}
+
+// invalidateCachedScale invalidates the cached scale subresource for the given resource if scaler is a scaleCache.
+func invalidateCachedScale(scaler scalev1.ScaleInterface, resource schema.GroupResource, name string) {
+	if c, ok := scaler.(*scaleCache); ok {
+		c.invalidate(resource, name)
+	}
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package scaler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakescale "k8s.io/client-go/scale/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var deploymentsGR = schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+func TestScaleCacheShouldFetchScaleOnlyOnceUntilReset(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	fakeClient, getCount := newFakeScaleClient(nil)
+	cache := newScaleCache(fakeClient.Scales("default"))
+
+	for i := 0; i < 3; i++ {
+		s, err := cache.Get(ctx, deploymentsGR, "kube-controller-manager", metav1.GetOptions{})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(s.Spec.Replicas).To(Equal(int32(1)))
+		s.Spec.Replicas = 5 // modifying the returned scale subresource should not modify the cache
+	}
+	g.Expect(*getCount).To(Equal(1))
+
+	cache.reset()
+	s, err := cache.Get(ctx, deploymentsGR, "kube-controller-manager", metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(*getCount).To(Equal(2))
+}
+
+func TestScaleCacheShouldRefetchInvalidatedScale(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	fakeClient, getCount := newFakeScaleClient(nil)
+	cache := newScaleCache(fakeClient.Scales("default"))
+
+	_, err := cache.Get(ctx, deploymentsGR, "kube-controller-manager", metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	invalidateCachedScale(cache, deploymentsGR, "kube-controller-manager")
+	_, err = cache.Get(ctx, deploymentsGR, "kube-controller-manager", metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*getCount).To(Equal(2))
+}
+
+func TestScaleCacheShouldInvalidateScaleOnFailedUpdate(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	fakeClient, getCount := newFakeScaleClient(errors.New("conflict"))
+	cache := newScaleCache(fakeClient.Scales("default"))
+
+	s, err := cache.Get(ctx, deploymentsGR, "kube-controller-manager", metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = cache.Update(ctx, deploymentsGR, s, metav1.UpdateOptions{})
+	g.Expect(err).To(HaveOccurred())
+	_, err = cache.Get(ctx, deploymentsGR, "kube-controller-manager", metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(*getCount).To(Equal(2))
+}
+
+// newFakeScaleClient creates a fake scale client which always returns a scale subresource with 1 replica for a get and
+// updateErr for an update. It additionally returns a pointer to the number of get calls.
+func newFakeScaleClient(updateErr error) (*fakescale.FakeScaleClient, *int) {
+	var getCount int
+	fakeClient := &fakescale.FakeScaleClient{}
+	fakeClient.AddReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		getCount++
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &autoscalingv1.Scale{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: action.GetNamespace()}, Spec: autoscalingv1.ScaleSpec{Replicas: 1}}, nil
+	})
+	fakeClient.AddReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if updateErr != nil {
+			return true, nil, updateErr
+		}
+		return true, action.(k8stesting.UpdateAction).GetObject(), nil
+	})
+	return fakeClient, &getCount
+}
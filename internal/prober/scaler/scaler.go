@@ -6,10 +6,14 @@ package scaler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/util"
 	"github.com/gardener/gardener/pkg/utils/flow"
 	"github.com/go-logr/logr"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
@@ -17,6 +21,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var (
+	// ErrDryRun is returned by the Scaler instead of running a scale flow while the dry-run mode is enabled.
+	ErrDryRun = errors.New("scale flow has not been run as the dry-run mode is enabled")
+	// ErrScaleRejected is returned by the Scaler if the update of a scale subresource has been rejected by its server-side dry-run, e.g. by an
+	// admission webhook. See WithDryRunScaleUpdates.
+	ErrScaleRejected = errors.New("scale update has been rejected by its server-side dry-run")
+)
+
 // operation denotes either a scale up or scale down action initiated by DWD.
 type operation uint8
 
@@ -35,83 +47,201 @@ type Scaler interface {
 	ScaleUp(ctx context.Context) error
 	// ScaleDown scales down a kubernetes scalable resource to 0.
 	ScaleDown(ctx context.Context) error
+	// Rebuild recreates the scale flows for the given dependent resources, so that changes to them take effect without recreating the Scaler.
+	// Scale operations which are already running complete with the flows they have been started with.
+	Rebuild(dependentResourceInfos []papi.DependentResourceInfo)
 }
 
 // NewScaler creates an instance of Scaler.
 func NewScaler(namespace string, dependentResourceInfos []papi.DependentResourceInfo, client client.Client, scalerGetter scalev1.ScalesGetter, logger logr.Logger, options ...scalerOption) Scaler {
 	opts := buildScalerOptions(options...)
-
-	fc := newFlowCreator(client, scalerGetter.Scales(namespace), logger, opts, dependentResourceInfos)
-	scaleUpFlow := fc.createFlow(fmt.Sprintf("scale-up-%s", namespace), namespace, scaleUp)
-	logger.V(1).Info("Created scaleUpFlow", "flowStepInfos", scaleUpFlow.flowStepInfos)
-	scaleDownFlow := fc.createFlow(fmt.Sprintf("scale-down-%s", namespace), namespace, scaleDown)
-	logger.V(1).Info("Created scaleDownFlow", "flowStepInfos", scaleDownFlow.flowStepInfos)
-
-	return &scaleFlowRunner{
-		namespace:     namespace,
-		options:       opts,
-		scaleUpFlow:   scaleUpFlow.flow,
-		scaleDownFlow: scaleDownFlow.flow,
+	ds := &scaleFlowRunner{
+		namespace: namespace,
+		client:    client,
+		logger:    logger,
+		options:   opts,
+		scales:    newScaleCache(scalerGetter.Scales(namespace)),
+		// the statuses are shared by the polls of the resources within half a check interval, which is less than the jittered check interval
+		statuses:        newStatusCache(client, namespace, *opts.resourceCheckInterval/2),
+		scaledDownSince: &scaledDownSince{},
 	}
+	ds.Rebuild(dependentResourceInfos)
+	return ds
 }
 
 type scaleFlowRunner struct {
-	namespace     string
+	namespace       string
+	client          client.Client
+	logger          logr.Logger
+	options         *scalerOptions
+	scales          *scaleCache
+	statuses        *statusCache
+	scaledDownSince *scaledDownSince
+	// flowsMu guards the flows which are swapped by Rebuild.
+	flowsMu       sync.RWMutex
 	scaleDownFlow *flow.Flow
 	scaleUpFlow   *flow.Flow
-	options       *scalerOptions
+}
+
+func (ds *scaleFlowRunner) Rebuild(dependentResourceInfos []papi.DependentResourceInfo) {
+	fc := newFlowCreator(ds.client, ds.scales, ds.statuses, ds.scaledDownSince, ds.logger, ds.options, dependentResourceInfos)
+	scaleUpFlow := fc.createFlow(flowName(ds.namespace, scaleUp), ds.namespace, scaleUp)
+	ds.logger.V(1).Info("Created scaleUpFlow", "flowStepInfos", scaleUpFlow.flowStepInfos)
+	scaleDownFlow := fc.createFlow(flowName(ds.namespace, scaleDown), ds.namespace, scaleDown)
+	ds.logger.V(1).Info("Created scaleDownFlow", "flowStepInfos", scaleDownFlow.flowStepInfos)
+
+	ds.flowsMu.Lock()
+	defer ds.flowsMu.Unlock()
+	ds.scaleUpFlow = scaleUpFlow.flow
+	ds.scaleDownFlow = scaleDownFlow.flow
+}
+
+// flowName returns the name of the scale flow of the given operation for the dependent resources in the given namespace.
+func flowName(namespace string, opType operation) string {
+	if opType == scaleUp {
+		return fmt.Sprintf("scale-up-%s", namespace)
+	}
+	return fmt.Sprintf("scale-down-%s", namespace)
+}
+
+// getFlows returns the current scale-up and scale-down flows.
+func (ds *scaleFlowRunner) getFlows() (scaleUpFlow, scaleDownFlow *flow.Flow) {
+	ds.flowsMu.RLock()
+	defer ds.flowsMu.RUnlock()
+	return ds.scaleUpFlow, ds.scaleDownFlow
 }
 
 func (ds *scaleFlowRunner) ScaleDown(ctx context.Context) error {
-	return ds.scaleDownFlow.Run(ctx, flow.Opts{})
+	_, scaleDownFlow := ds.getFlows()
+	if ds.isDryRun(ctx, scaleDownFlow) {
+		return ErrDryRun
+	}
+	ds.scales.reset()
+	ds.statuses.reset()
+	return ds.runFlow(ctx, scaleDownFlow)
 }
 
+// ScaleUp runs the scale-up flow. If any of the dependent resources has been scaled up from a scale-down which has been captured on the resource,
+// then the duration since the earliest of these scale-downs is observed once the flow has succeeded.
 func (ds *scaleFlowRunner) ScaleUp(ctx context.Context) error {
-	return ds.scaleUpFlow.Run(ctx, flow.Opts{})
+	scaleUpFlow, _ := ds.getFlows()
+	if ds.isDryRun(ctx, scaleUpFlow) {
+		return ErrDryRun
+	}
+	ds.scales.reset()
+	ds.statuses.reset()
+	ds.scaledDownSince.reset()
+	if err := ds.runFlow(ctx, scaleUpFlow); err != nil {
+		return err
+	}
+	if since, ok := ds.scaledDownSince.get(); ok {
+		metrics.ShootDependentsScaledDownDurationSeconds.WithLabelValues(ds.namespace).Observe(time.Since(since).Seconds())
+	}
+	return nil
+}
+
+// isDryRun checks if the given flow must not be run as the dry-run mode is enabled.
+func (ds *scaleFlowRunner) isDryRun(ctx context.Context, f *flow.Flow) bool {
+	if ds.options.isDryRun == nil || !ds.options.isDryRun() {
+		return false
+	}
+	util.LoggerWithProbeCycleID(ctx, ds.logger).Info("Dry-run mode is enabled, not running scale flow", "flow", f.Name())
+	return true
+}
+
+// runFlow runs the given flow. If a flow timeout has been configured then the flow is cancelled once it has expired.
+func (ds *scaleFlowRunner) runFlow(ctx context.Context, f *flow.Flow) error {
+	if ds.options.flowTimeout <= 0 {
+		return f.Run(ctx, flow.Opts{})
+	}
+	flowCtx, cancelFn := context.WithTimeout(ctx, ds.options.flowTimeout)
+	defer cancelFn()
+	err := f.Run(flowCtx, flow.Opts{})
+	if err != nil && ctx.Err() == nil && errors.Is(flowCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s has not completed within %s: %w", util.ErrTimeout, f.Name(), ds.options.flowTimeout, err)
+	}
+	return err
+}
+
+// scaledDownSince tracks the earliest time at which any of the dependent resources which are scaled up by a scale-up flow has been scaled down.
+// It is shared by all resource scalers of a Scaler.
+type scaledDownSince struct {
+	mu    sync.Mutex
+	since *time.Time
+}
+
+// record records the given time of a scale-down if it is earlier than the ones recorded so far.
+func (s *scaledDownSince) record(scaledDownAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since == nil || scaledDownAt.Before(*s.since) {
+		s.since = &scaledDownAt
+	}
+}
+
+// get returns the earliest recorded time of a scale-down and whether any has been recorded.
+func (s *scaledDownSince) get() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since == nil {
+		return time.Time{}, false
+	}
+	return *s.since, true
+}
+
+func (s *scaledDownSince) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = nil
 }
 
-// getMinTargetReplicas gets the minimum target replicas based on the operation.
+// getMinTargetReplicas gets the minimum target replicas based on the operation and the replicas the resource is scaled down to.
 // The target replicas for a resource are captured as annotation value. It is however possible that another actor
 // HPA or HVPA changes the replicas of the resource (scales it down or scales it up) causing the target replica annotation
 // value to differ from the spec.replicas for the resource. DWD is not a `horizontal-pod-autoscaler` but its intention
-// is only to restore the resource to the last captured replicas when it attempts to scale up the resource which was previously scaled-down to 0 by DWD.
+// is only to restore the resource to the last captured replicas when it attempts to scale up the resource which was previously scaled-down by DWD.
 // Therefore, the minimum target can never be the value captured in the annotation, specially for a scaleUp operation.
-func (i operation) getMinTargetReplicas() int32 {
+func (i operation) getMinTargetReplicas(scaleDownReplicas int32) int32 {
 	if i == scaleUp {
 		return 1
 	}
-	return 0
+	return scaleDownReplicas
 }
 
-// shouldScaleReplicas checks if scaling should be done for a resource given the current number of replicas.
-func (i operation) shouldScaleReplicas(currentReplicas int32) bool {
+// shouldScaleReplicas checks if scaling should be done for a resource given the current number of replicas and the replicas the resource is
+// scaled down to.
+func (i operation) shouldScaleReplicas(currentReplicas, scaleDownReplicas int32) bool {
 	if i == scaleUp {
-		return currentReplicas == 0
+		return currentReplicas <= scaleDownReplicas
 	} else {
-		return currentReplicas > 0
+		return currentReplicas > scaleDownReplicas
 	}
 }
 
 // minTargetReplicasReached checks if scaling of the resource is complete based on the current and minimum target replica count.
 // This is used during the scale up for a resource which was previously scaled down by DWD. If the decision is to scale the resource
 // then this predicate checks if the wait for scaling a resource is complete.
-func (i operation) minTargetReplicasReached(currentReplicas int32) bool {
-	minTargetReplicas := i.getMinTargetReplicas()
+func (i operation) minTargetReplicasReached(currentReplicas, scaleDownReplicas int32) bool {
+	minTargetReplicas := i.getMinTargetReplicas(scaleDownReplicas)
 	if i == scaleUp {
 		return currentReplicas >= minTargetReplicas
 	} else {
-		return currentReplicas == minTargetReplicas
+		return currentReplicas <= minTargetReplicas
 	}
 }
 
 // scalableResourceInfo captures scaling configuration for a DependentResourceInfo.
 type scalableResourceInfo struct {
-	ref          *autoscalingv1.CrossVersionObjectReference
-	optional     bool
-	level        int
-	initialDelay time.Duration
-	timeout      time.Duration
-	operation    operation
+	ref       *autoscalingv1.CrossVersionObjectReference
+	optional  bool
+	verifyUID bool
+	// scaleDownReplicas are the replicas the resource is scaled down to. They are also known to a scale-up, which restores a resource only if it
+	// has not more replicas than these.
+	scaleDownReplicas int32
+	level             int
+	initialDelay      time.Duration
+	timeout           time.Duration
+	operation         operation
 }
 
 func (r scalableResourceInfo) String() string {
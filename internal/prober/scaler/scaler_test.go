@@ -50,7 +50,6 @@ func TestScalerSuite(t *testing.T) {
 	}{
 		{"test getting scale subresource times out", testGettingScaleSubResourceTimesOut},
 		{"test scaling when kind of a resource is invalid", testScalingWhenKindOfResourceIsInvalid},
-		//{"test waitTillMinTargetReplicasReached returns an error", testWaitTillMinTargetReplicasReachedReturnsError},
 		{"test scaling when mandatory resource(optional is false in resourceInfo) is not found", testScalingWhenMandatoryResourceNotFound},
 		{"test scaling when optional resource(optional is true in resourceInfo) is not found", testScalingWhenOptionalResourceNotFound},
 		{"test scale down then scale up when ignore scaling annotation is not present", testScaleDownThenScaleUpWhenIgnoreScalingAnnotationIsNotPresent},
@@ -306,52 +305,13 @@ func testScalingWhenKindOfResourceIsInvalid(t *testing.T) {
 	t.Log("scaling when res has invalid kind test finished")
 }
 
-// TODO: (rishabh-11) Move this test to use a fake client. Commented this test as it is flaky.
-//func testWaitTillMinTargetReplicasReachedReturnsError(t *testing.T) {
-//	g := NewWithT(t)
-//	probeCfg := createProbeConfig(nil)
-//	ds := createScaler(g, probeCfg, 0*time.Millisecond, 1*time.Millisecond, 1*time.Millisecond)
-//
-//	table := []struct {
-//		mcmReplicas               int32
-//		kcmReplicas               int32
-//		caReplicas                int32
-//		expectedScaledMCMReplicas int32
-//		expectedScaledKCMReplicas int32
-//		expectedScaledCAReplicas  int32
-//		scalingFn                 func(context.Context) error
-//		op                        operation
-//		errorString               string
-//	}{
-//		{0, 0, 0, 0, 0, 1, ds.ScaleUp, scaleUp, fmt.Sprintf("timed out waiting for {namespace: %s, resource: %s} to reach minTargetReplicas", namespace, caObjectRef.Name)},
-//		{2, 2, 2, expectedSpecReplicasAfterSuccessfulScaleDownTest, expectedSpecReplicasAfterSuccessfulScaleDownTest, 2, ds.ScaleDown, scaleDown, "timed out waiting"}, // mcm or kcm can return error hence short string is used
-//	}
-//
-//	for _, entry := range table {
-//		createDeployment(g, namespace, mcmObjectRef.Name, deploymentImageName, entry.mcmReplicas, nil)
-//		createDeployment(g, namespace, caObjectRef.Name, deploymentImageName, entry.caReplicas, nil)
-//		createDeployment(g, namespace, kcmObjectRef.Name, deploymentImageName, entry.kcmReplicas, nil)
-//
-//		err := entry.scalingFn(context.Background())
-//		g.Expect(err).ToNot(BeNil())
-//		g.Expect(err.Error()).To(ContainSubstring(entry.errorString))
-//		matchSpecReplicas(g, namespace, caObjectRef.Name, entry.expectedScaledCAReplicas)
-//		matchSpecReplicas(g, namespace, kcmObjectRef.Name, entry.expectedScaledKCMReplicas)
-//		matchSpecReplicas(g, namespace, mcmObjectRef.Name, entry.expectedScaledMCMReplicas)
-//
-//		err = kindTestEnv.DeleteAllDeployments(namespace)
-//		g.Expect(err).ToNot(HaveOccurred())
-//	}
-//	t.Log("WaitTillMinTargetReplicasReached returns error test finished")
-//}
-
 func testResourceShouldNotScaleUpIfCurrentReplicaCountIsPositive(t *testing.T) {
 	g := NewWithT(t)
 	probeCfg := createProbeConfig(nil)
 	ds := createDefaultScaler(g, probeCfg.DependentResourceInfos)
 	createDeployment(g, namespace, mcmObjectRef.Name, deploymentImageName, 0, nil)
 	createDeployment(g, namespace, caObjectRef.Name, deploymentImageName, 0, nil)
-	createDeployment(g, namespace, kcmObjectRef.Name, deploymentImageName, 1, map[string]string{replicasAnnotationKey: "2"})
+	createDeployment(g, namespace, kcmObjectRef.Name, deploymentImageName, 1, map[string]string{DefaultReplicasAnnotationKey: "2"})
 
 	err := ds.ScaleUp(context.Background())
 	g.Expect(err).ToNot(HaveOccurred())
@@ -370,7 +330,7 @@ func testScaleUpShouldReturnErrorWhenReplicasAnnotationsHasInvalidValue(t *testi
 	ds := createDefaultScaler(g, probeCfg.DependentResourceInfos)
 	createDeployment(g, namespace, mcmObjectRef.Name, deploymentImageName, 0, nil)
 	createDeployment(g, namespace, caObjectRef.Name, deploymentImageName, 0, nil)
-	createDeployment(g, namespace, kcmObjectRef.Name, deploymentImageName, 0, map[string]string{replicasAnnotationKey: "foo"})
+	createDeployment(g, namespace, kcmObjectRef.Name, deploymentImageName, 0, map[string]string{DefaultReplicasAnnotationKey: "foo"})
 
 	err := ds.ScaleUp(context.Background())
 	g.Expect(err).ToNot(BeNil())
@@ -432,6 +392,7 @@ func checkScaleSuccess(g *WithT, opType operation, namespace, name string, expec
 	deploy := matchSpecReplicas(g, namespace, name, expectedSpecReplicas)
 	if opType == scaleUp {
 		g.Expect(deploy.Status.ReadyReplicas).To(BeNumerically(">=", 1))
+		g.Expect(deploy.Annotations).ToNot(HaveKey(ScaledDownAtAnnotationKey), "the time of the scale-down should be removed once the resource is scaled up")
 	} else {
 		g.Expect(deploy.Status.ReadyReplicas).To(BeNumerically("==", 0))
 	}
@@ -22,6 +22,21 @@ type scalerOptions struct {
 	resourceCheckTimeout  *time.Duration
 	resourceCheckInterval *time.Duration
 	scaleResourceBackOff  *time.Duration
+	// replicasAnnotationKey is the key of the annotation which captures the replicas of a resource prior to a scale-down.
+	replicasAnnotationKey string
+	// dualWriteReplicasAnnotation additionally captures the replicas in the DefaultReplicasAnnotationKey annotation if replicasAnnotationKey differs from it.
+	dualWriteReplicasAnnotation bool
+	// maxConcurrentScalesPerLevel is the maximum number of resources on the same level which are scaled concurrently. 0 means that there is no limit.
+	maxConcurrentScalesPerLevel int
+	// flowTimeout is the maximum duration of a complete scale flow. 0 means that there is no overall timeout.
+	flowTimeout time.Duration
+	// isDryRun is evaluated before every scale flow. If it returns true then the scale flow is not run. It can be nil.
+	isDryRun func() bool
+	// dryRunScaleUpdates enables a server-side dry-run of every update of a scale subresource before the actual update.
+	dryRunScaleUpdates bool
+	// honorRetryAfter makes the retries of a failed scale of a resource wait for the duration suggested by the Retry-After information of a
+	// throttled request instead of the scale resource backoff. It defaults to true.
+	honorRetryAfter *bool
 }
 
 func buildScalerOptions(options ...scalerOption) *scalerOptions {
@@ -51,6 +66,55 @@ func withScaleResourceBackOff(interval time.Duration) scalerOption {
 	}
 }
 
+// WithReplicasAnnotationKey sets the key of the annotation which captures the replicas of a resource prior to a scale-down. If dualWrite is true
+// and the key differs from DefaultReplicasAnnotationKey then the replicas are captured in both annotations.
+func WithReplicasAnnotationKey(key string, dualWrite bool) scalerOption {
+	return func(options *scalerOptions) {
+		options.replicasAnnotationKey = key
+		options.dualWriteReplicasAnnotation = dualWrite
+	}
+}
+
+// WithMaxConcurrentScalesPerLevel limits the number of resources on the same level which are scaled concurrently. If maxConcurrentScales is 0 then
+// all resources on a level are scaled concurrently.
+func WithMaxConcurrentScalesPerLevel(maxConcurrentScales int) scalerOption {
+	return func(options *scalerOptions) {
+		options.maxConcurrentScalesPerLevel = maxConcurrentScales
+	}
+}
+
+// WithFlowTimeout sets the maximum duration of a complete scale flow after which its scale operations are cancelled. If timeout is 0 then a scale
+// flow only ends once all its scale operations have ended.
+func WithFlowTimeout(timeout time.Duration) scalerOption {
+	return func(options *scalerOptions) {
+		options.flowTimeout = timeout
+	}
+}
+
+// WithDryRun sets a function which is evaluated before every scale flow. While it returns true, scale flows are not run and ErrDryRun is returned
+// instead.
+func WithDryRun(isDryRun func() bool) scalerOption {
+	return func(options *scalerOptions) {
+		options.isDryRun = isDryRun
+	}
+}
+
+// WithDryRunScaleUpdates enables a server-side dry-run of every update of a scale subresource before the actual update. An update which is
+// rejected by the dry-run fails with ErrScaleRejected and is not retried.
+func WithDryRunScaleUpdates(enabled bool) scalerOption {
+	return func(options *scalerOptions) {
+		options.dryRunScaleUpdates = enabled
+	}
+}
+
+// WithHonorRetryAfter defines if the retries of a failed scale of a resource wait for the duration suggested by the Retry-After information of a
+// throttled request instead of the scale resource backoff.
+func WithHonorRetryAfter(enabled bool) scalerOption {
+	return func(options *scalerOptions) {
+		options.honorRetryAfter = &enabled
+	}
+}
+
 func fillDefaultsOptions(options *scalerOptions) {
 	if options.resourceCheckTimeout == nil {
 		options.resourceCheckTimeout = pointer.Duration(defaultResourceCheckTimeout)
@@ -61,4 +125,10 @@ func fillDefaultsOptions(options *scalerOptions) {
 	if options.scaleResourceBackOff == nil {
 		options.scaleResourceBackOff = pointer.Duration(defaultScaleResourceBackoff)
 	}
+	if options.replicasAnnotationKey == "" {
+		options.replicasAnnotationKey = DefaultReplicasAnnotationKey
+	}
+	if options.honorRetryAfter == nil {
+		options.honorRetryAfter = pointer.Bool(true)
+	}
 }
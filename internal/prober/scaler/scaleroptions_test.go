@@ -54,4 +54,11 @@ func TestBuildScalerOptionsShouldFillDefaultValues(t *testing.T) {
 	opts := buildScalerOptions()
 	g.Expect(*opts.resourceCheckInterval).To(Equal(defaultResourceCheckInterval))
 	g.Expect(*opts.resourceCheckTimeout).To(Equal(defaultResourceCheckTimeout))
+	g.Expect(*opts.honorRetryAfter).To(BeTrue())
+}
+
+func TestWithHonorRetryAfter(t *testing.T) {
+	g := NewWithT(t)
+	opts := buildScalerOptions(WithHonorRetryAfter(false))
+	g.Expect(*opts.honorRetryAfter).To(BeFalse())
 }
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package scaler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gardener/dependency-watchdog/internal/util"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// statusKey identifies the kind of the resources whose statuses are listed together.
+type statusKey struct {
+	apiVersion string
+	kind       string
+}
+
+// statusSnapshot are the statuses of all resources of a kind, keyed by their names, as they have been listed at fetchedAt.
+type statusSnapshot struct {
+	statuses  map[string]util.ResourceStatus
+	fetchedAt time.Time
+}
+
+// statusCache batches the checks of the ready replicas of the dependent resources of a namespace. Instead of getting every resource on every
+// poll, the statuses of all resources of a kind are listed at once and the list is shared by all polls within maxAge, e.g. the polls of the
+// dependent resources on the same level which are waited for concurrently. Like the scaleCache it is reset at the start of every scale flow run.
+type statusCache struct {
+	client    client.Client
+	namespace string
+	maxAge    time.Duration
+	// mu is held while a list call is made, so that concurrent polls for the same kind share a single list call.
+	mu        sync.Mutex
+	snapshots map[statusKey]statusSnapshot
+}
+
+func newStatusCache(client client.Client, namespace string, maxAge time.Duration) *statusCache {
+	return &statusCache{
+		client:    client,
+		namespace: namespace,
+		maxAge:    maxAge,
+		snapshots: make(map[statusKey]statusSnapshot),
+	}
+}
+
+// get returns the status of the referenced resource from the latest list of the resources of its kind, which is refreshed if it is older than
+// maxAge. A NotFound error is returned if the resource is not part of the list.
+func (c *statusCache) get(ctx context.Context, ref *autoscalingv1.CrossVersionObjectReference) (util.ResourceStatus, error) {
+	key := statusKey{apiVersion: ref.APIVersion, kind: ref.Kind}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot, ok := c.snapshots[key]
+	if !ok || time.Since(snapshot.fetchedAt) > c.maxAge {
+		statuses, err := util.ListResourceStatuses(ctx, c.client, c.namespace, ref.APIVersion, ref.Kind)
+		if err != nil {
+			return util.ResourceStatus{}, err
+		}
+		snapshot = statusSnapshot{statuses: statuses, fetchedAt: time.Now()}
+		c.snapshots[key] = snapshot
+	}
+	status, ok := snapshot.statuses[ref.Name]
+	if !ok {
+		gv, _ := schema.ParseGroupVersion(ref.APIVersion) // the API version has already been parsed successfully when listing the resources
+		return util.ResourceStatus{}, apierrors.NewNotFound(gv.WithResource(ref.Kind).GroupResource(), ref.Name)
+	}
+	return status, nil
+}
+
+// invalidate removes the statuses of the resources of the kind of the referenced resource. It should be called whenever the referenced resource has
+// been scaled, so that a list which has been fetched before is not mistaken for the status after the scaling.
+func (c *statusCache) invalidate(ref *autoscalingv1.CrossVersionObjectReference) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.snapshots, statusKey{apiVersion: ref.APIVersion, kind: ref.Kind})
+}
+
+// reset removes all cached statuses.
+func (c *statusCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.snapshots)
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package scaler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestStatusCacheShouldShareListUntilInvalidated(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	var listCount int
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newStatusTestDeployment("kube-controller-manager", 1),
+		newStatusTestDeployment("machine-controller-manager", 0),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			listCount++
+			return c.List(ctx, list, opts...)
+		},
+	}).Build()
+	cache := newStatusCache(cl, "test", time.Hour)
+	kcmRef := &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "kube-controller-manager"}
+	mcmRef := &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "machine-controller-manager"}
+
+	status, err := cache.get(ctx, kcmRef)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.ReadyReplicas).To(Equal(int32(1)))
+	status, err = cache.get(ctx, mcmRef)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.ReadyReplicas).To(Equal(int32(0)))
+	g.Expect(listCount).To(Equal(1), "the statuses of resources of the same kind should be listed once")
+
+	_, err = cache.get(ctx, &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "cluster-autoscaler"})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	g.Expect(listCount).To(Equal(1))
+
+	cache.invalidate(kcmRef)
+	_, err = cache.get(ctx, mcmRef)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(listCount).To(Equal(2), "the statuses should be listed again once they have been invalidated")
+
+	cache.reset()
+	_, err = cache.get(ctx, mcmRef)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(listCount).To(Equal(3), "the statuses should be listed again once the cache has been reset")
+}
+
+func TestStatusCacheShouldRelistExpiredStatuses(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	deployment := newStatusTestDeployment("kube-controller-manager", 0)
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment).Build()
+	cache := newStatusCache(cl, "test", 0)
+	ref := &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: deployment.Name}
+
+	status, err := cache.get(ctx, ref)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.ReadyReplicas).To(Equal(int32(0)))
+	deployment.Status.ReadyReplicas = 2
+	g.Expect(cl.Status().Update(ctx, deployment)).To(Succeed())
+	status, err = cache.get(ctx, ref)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.ReadyReplicas).To(Equal(int32(2)))
+}
+
+func newStatusTestDeployment(name string, readyReplicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: readyReplicas},
+	}
+}
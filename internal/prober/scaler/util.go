@@ -12,6 +12,7 @@ import (
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/utils/pointer"
 )
 
 // createScalableResourceInfos creates slice of scalableResourceInfo from an operation and slice of papi.DependentResourceInfo.
@@ -32,18 +33,28 @@ func createScalableResourceInfos(op operation, dependentResourceInfos []papi.Dep
 			timeout = depResInfo.ScaleDownInfo.Timeout.Duration
 		}
 		resInfo := scalableResourceInfo{
-			ref:          depResInfo.Ref,
-			optional:     depResInfo.Optional,
-			level:        level,
-			initialDelay: initialDelay,
-			timeout:      timeout,
-			operation:    op,
+			ref:               depResInfo.Ref,
+			optional:          depResInfo.Optional,
+			verifyUID:         pointer.BoolDeref(depResInfo.VerifyUID, false),
+			scaleDownReplicas: getScaleDownReplicas(depResInfo),
+			level:             level,
+			initialDelay:      initialDelay,
+			timeout:           timeout,
+			operation:         op,
 		}
 		resourceInfos = append(resourceInfos, resInfo)
 	}
 	return resourceInfos
 }
 
+// getScaleDownReplicas returns the replicas the dependent resource is scaled down to, which default to defaultScaleDownReplicas.
+func getScaleDownReplicas(depResInfo papi.DependentResourceInfo) int32 {
+	if depResInfo.ScaleDownInfo == nil {
+		return defaultScaleDownReplicas
+	}
+	return pointer.Int32Deref(depResInfo.ScaleDownInfo.Replicas, defaultScaleDownReplicas)
+}
+
 func sortAndGetUniqueLevels(resourceInfos []scalableResourceInfo) []int {
 	var levels []int
 	keys := make(map[int]bool)
@@ -5,13 +5,18 @@
 package shoot
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	"k8s.io/client-go/discovery"
 
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/gardener/dependency-watchdog/pkg/retry"
 	"github.com/go-logr/logr"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -21,6 +26,10 @@ import (
 const (
 	defaultGetSecretBackoff     = 100 * time.Millisecond
 	defaultGetSecretMaxAttempts = 3
+
+	// clientKindClient and clientKindDiscovery are the kinds of shoot clients whose creation is observed via metrics.
+	clientKindClient    = "client"
+	clientKindDiscovery = "discovery"
 )
 
 // ClientCreator provides a facade to create kubernetes client targeting a shoot.
@@ -29,53 +38,218 @@ type ClientCreator interface {
 	CreateClient(ctx context.Context, logger logr.Logger, connectionTimeout time.Duration) (client.Client, error)
 	// CreateDiscoveryClient creates a new discovery.DiscoveryInterface to connect to the Kube ApiServer running in the passed-in shoot control namespace.
 	CreateDiscoveryClient(ctx context.Context, logger logr.Logger, connectionTimeout time.Duration) (discovery.DiscoveryInterface, error)
+	// CreateDiscoveryClientWithOverrides creates a new discovery.DiscoveryInterface to connect to the Kube ApiServer running in the passed-in shoot control namespace
+	// after applying the given overrides, e.g. a different host, to the connection configured via the kubeconfig.
+	CreateDiscoveryClientWithOverrides(ctx context.Context, logger logr.Logger, connectionTimeout time.Duration, overrides util.ConnectionOverrides) (discovery.DiscoveryInterface, error)
+}
+
+// CacheInvalidator is implemented by a ClientCreator which caches the clients it creates.
+type CacheInvalidator interface {
+	// InvalidateCache drops all cached clients so that the next client is created afresh from the current kubeconfig.
+	InvalidateCache()
 }
 
-// NewClientCreator creates an instance of ClientCreator.
-func NewClientCreator(namespace string, secretName string, client client.Client) ClientCreator {
+// NewClientCreator creates an instance of ClientCreator. The created clients are configured with the given options, e.g. their rate limits. They are
+// cached and reused as long as the kubeconfig in the secret and the connection timeout do not change.
+func NewClientCreator(namespace string, secretName string, client client.Client, opts util.ClientOptions) ClientCreator {
 	return &clientCreator{
 		namespace:  namespace,
 		secretName: secretName,
 		client:     client,
+		opts:       opts,
+	}
+}
+
+// NewTokenClientCreator creates an instance of ClientCreator which does not rely on long-lived credentials in the kubeconfig secret. The kubeconfig
+// secret only has to provide the connection information, e.g. the generic token kubeconfig of the shoot control namespace, while the client
+// authenticates with the token of the token secret. The token is expected to be requested and renewed by the gardener-resource-manager. Clients are
+// cached like the ones of the ClientCreator created via NewClientCreator and are created afresh once the token has been renewed.
+func NewTokenClientCreator(namespace string, secretName string, tokenSecretName string, client client.Client, opts util.ClientOptions) ClientCreator {
+	return &clientCreator{
+		namespace:       namespace,
+		secretName:      secretName,
+		tokenSecretName: tokenSecretName,
+		client:          client,
+		opts:            opts,
 	}
 }
 
 type clientCreator struct {
 	namespace  string
 	secretName string
-	client     client.Client
+	// tokenSecretName is the name of the secret whose token replaces the credentials of the kubeconfig. It is empty if the credentials of the
+	// kubeconfig are used.
+	tokenSecretName string
+	client          client.Client
+	opts            util.ClientOptions
+	mu              sync.Mutex
+	shootClient     *cachedClient[client.Client]
+	discoveryClient *cachedClient[discovery.DiscoveryInterface]
+}
+
+// cachedClient is a client together with the kubeconfig and the connection timeout it has been created with.
+type cachedClient[T any] struct {
+	kubeConfigBytes   []byte
+	connectionTimeout time.Duration
+	client            T
+}
+
+func (c *cachedClient[T]) matches(kubeConfigBytes []byte, connectionTimeout time.Duration) bool {
+	return c != nil && c.connectionTimeout == connectionTimeout && bytes.Equal(c.kubeConfigBytes, kubeConfigBytes)
 }
 
 func (s *clientCreator) CreateClient(ctx context.Context, logger logr.Logger, connectionTimeout time.Duration) (client.Client, error) {
+	start := time.Now()
 	kubeConfigBytes, err := s.getKubeConfigBytesFromSecret(ctx, logger)
 	if err != nil {
-		return nil, err
+		return nil, observeClientCreation(clientKindClient, start, getSecretFailureReason(err), err)
 	}
-	return util.CreateClientFromKubeConfigBytes(kubeConfigBytes, connectionTimeout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shootClient.matches(kubeConfigBytes, connectionTimeout) {
+		return s.shootClient.client, observeClientCreation(clientKindClient, start, "", nil)
+	}
+	shootClient, err := util.CreateClientFromKubeConfigBytes(kubeConfigBytes, connectionTimeout, s.opts)
+	if err != nil {
+		return nil, observeClientCreation(clientKindClient, start, metrics.ReasonInvalidKubeConfig, err)
+	}
+	s.shootClient = &cachedClient[client.Client]{kubeConfigBytes: kubeConfigBytes, connectionTimeout: connectionTimeout, client: shootClient}
+	return shootClient, observeClientCreation(clientKindClient, start, "", nil)
 }
 
 func (s *clientCreator) CreateDiscoveryClient(ctx context.Context, logger logr.Logger, connectionTimeout time.Duration) (discovery.DiscoveryInterface, error) {
+	start := time.Now()
 	kubeConfigBytes, err := s.getKubeConfigBytesFromSecret(ctx, logger)
+	if err != nil {
+		return nil, observeClientCreation(clientKindDiscovery, start, getSecretFailureReason(err), err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discoveryClient.matches(kubeConfigBytes, connectionTimeout) {
+		return s.discoveryClient.client, observeClientCreation(clientKindDiscovery, start, "", nil)
+	}
+	discoveryClient, err := util.CreateDiscoveryInterfaceFromKubeConfigBytes(kubeConfigBytes, connectionTimeout, s.opts)
+	if err != nil {
+		return nil, observeClientCreation(clientKindDiscovery, start, metrics.ReasonInvalidKubeConfig, err)
+	}
+	s.discoveryClient = &cachedClient[discovery.DiscoveryInterface]{kubeConfigBytes: kubeConfigBytes, connectionTimeout: connectionTimeout, client: discoveryClient}
+	return discoveryClient, observeClientCreation(clientKindDiscovery, start, "", nil)
+}
+
+// CreateDiscoveryClientWithOverrides creates a discovery client with the given overrides. Unlike the clients created via CreateClient and
+// CreateDiscoveryClient it is not cached.
+func (s *clientCreator) CreateDiscoveryClientWithOverrides(ctx context.Context, logger logr.Logger, connectionTimeout time.Duration, overrides util.ConnectionOverrides) (discovery.DiscoveryInterface, error) {
+	start := time.Now()
+	kubeConfigBytes, err := s.getKubeConfigBytesFromSecret(ctx, logger)
+	if err != nil {
+		return nil, observeClientCreation(clientKindDiscovery, start, getSecretFailureReason(err), err)
+	}
+	discoveryClient, err := util.CreateDiscoveryInterfaceWithOverridesFromKubeConfigBytes(kubeConfigBytes, overrides, connectionTimeout, s.opts)
+	if err != nil {
+		return nil, observeClientCreation(clientKindDiscovery, start, metrics.ReasonInvalidKubeConfig, err)
+	}
+	return discoveryClient, observeClientCreation(clientKindDiscovery, start, "", nil)
+}
+
+// observeClientCreation observes the duration of the creation of a shoot client of the given kind which has been started at the given time and
+// counts its failure with the given reason if the given error is not nil. It returns the given error.
+func observeClientCreation(clientKind string, start time.Time, failureReason string, err error) error {
+	metrics.ShootClientCreationDurationSeconds.WithLabelValues(clientKind).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.ShootClientCreationFailuresTotal.WithLabelValues(clientKind, failureReason).Inc()
+	}
+	return err
+}
+
+// getSecretFailureReason classifies the given error which has occurred while reading the kubeconfig or the token of a shoot client from the seed.
+func getSecretFailureReason(err error) string {
+	switch {
+	case apierrors.IsNotFound(err):
+		return metrics.ReasonSecretNotFound
+	case apierrors.IsUnauthorized(err):
+		return metrics.ReasonUnauthorized
+	case apierrors.IsForbidden(err):
+		return metrics.ReasonForbidden
+	default:
+		return metrics.ReasonSecretGetFailed
+	}
+}
+
+func (s *clientCreator) InvalidateCache() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shootClient = nil
+	s.discoveryClient = nil
+}
+
+// NewKubeConfigFileClientCreator creates a ClientCreator which connects to the Kube ApiServer using the kubeconfig read from the given file.
+// Unlike the ClientCreator created via NewClientCreator it does not require access to the shoot control namespace in the seed.
+func NewKubeConfigFileClientCreator(kubeConfigPath string) ClientCreator {
+	return &kubeConfigFileClientCreator{kubeConfigPath: kubeConfigPath}
+}
+
+type kubeConfigFileClientCreator struct {
+	kubeConfigPath string
+}
+
+func (k *kubeConfigFileClientCreator) CreateClient(_ context.Context, _ logr.Logger, connectionTimeout time.Duration) (client.Client, error) {
+	kubeConfigBytes, err := os.ReadFile(k.kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return util.CreateClientFromKubeConfigBytes(kubeConfigBytes, connectionTimeout, util.ClientOptions{})
+}
+
+func (k *kubeConfigFileClientCreator) CreateDiscoveryClient(_ context.Context, _ logr.Logger, connectionTimeout time.Duration) (discovery.DiscoveryInterface, error) {
+	kubeConfigBytes, err := os.ReadFile(k.kubeConfigPath)
 	if err != nil {
 		return nil, err
 	}
-	return util.CreateDiscoveryInterfaceFromKubeConfigBytes(kubeConfigBytes, connectionTimeout)
+	return util.CreateDiscoveryInterfaceFromKubeConfigBytes(kubeConfigBytes, connectionTimeout, util.ClientOptions{})
+}
+
+func (k *kubeConfigFileClientCreator) CreateDiscoveryClientWithOverrides(_ context.Context, _ logr.Logger, connectionTimeout time.Duration, overrides util.ConnectionOverrides) (discovery.DiscoveryInterface, error) {
+	kubeConfigBytes, err := os.ReadFile(k.kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return util.CreateDiscoveryInterfaceWithOverridesFromKubeConfigBytes(kubeConfigBytes, overrides, connectionTimeout, util.ClientOptions{})
 }
 
 func (s *clientCreator) getKubeConfigBytesFromSecret(ctx context.Context, logger logr.Logger) ([]byte, error) {
 	operation := fmt.Sprintf("get-secret-%s-for-namespace-%s", s.secretName, s.namespace)
-	retryResult := util.Retry(ctx, logger,
+	retryResult := retry.Retry(ctx, logger,
 		operation,
 		func() ([]byte, error) {
 			return util.GetKubeConfigFromSecret(ctx, s.namespace, s.secretName, s.client, logger)
 		},
 		defaultGetSecretMaxAttempts,
-		defaultGetSecretBackoff,
+		retry.ConstantBackoff(defaultGetSecretBackoff),
 		canRetrySecretGet)
 	if retryResult.Err != nil {
 		return nil, retryResult.Err
 	}
-	return retryResult.Value, nil
+	if s.tokenSecretName == "" {
+		return retryResult.Value, nil
+	}
+	token, err := s.getTokenFromSecret(ctx, logger)
+	if err != nil {
+		return nil, err
+	}
+	return util.SetBearerTokenInKubeConfig(retryResult.Value, token)
+}
+
+func (s *clientCreator) getTokenFromSecret(ctx context.Context, logger logr.Logger) (string, error) {
+	operation := fmt.Sprintf("get-secret-%s-for-namespace-%s", s.tokenSecretName, s.namespace)
+	retryResult := retry.Retry(ctx, logger,
+		operation,
+		func() (string, error) {
+			return util.GetTokenFromSecret(ctx, s.namespace, s.tokenSecretName, s.client, logger)
+		},
+		defaultGetSecretMaxAttempts,
+		retry.ConstantBackoff(defaultGetSecretBackoff),
+		canRetrySecretGet)
+	return retryResult.Value, retryResult.Err
 }
 
 func canRetrySecretGet(err error) bool {
@@ -16,11 +16,16 @@ import (
 
 	"github.com/gardener/dependency-watchdog/internal/prober/fakes/k8s"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 	"github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/gardener/dependency-watchdog/internal/util"
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
@@ -43,6 +48,8 @@ func TestSuite(t *testing.T) {
 		{"testConfigNotFound", "kubeconfig not found", testConfigNotFound},
 		{"testCreateShootClient", "shootclient should be created", testCreateShootClient},
 		{"testCreateDiscoveryClient", "discoveryclient should be created", testCreateDiscoveryClient},
+		{"testCachedShootClient", "shootclient should be reused until the cache is invalidated", testCachedShootClient},
+		{"testTokenShootClient", "shootclient should authenticate with the token of the token secret", testTokenShootClient},
 	}
 	g.Expect(err).ToNot(HaveOccurred())
 	t.Parallel()
@@ -59,21 +66,27 @@ func TestSuite(t *testing.T) {
 
 func testSecretNotFound(ctx context.Context, t *testing.T, namespace string, k8sClient client.Client) {
 	g := NewWithT(t)
-	cc := NewClientCreator(namespace, "does-not-exist", k8sClient)
+	failures := metrics.ShootClientCreationFailuresTotal.WithLabelValues(clientKindClient, metrics.ReasonSecretNotFound)
+	failuresBefore := promtestutil.ToFloat64(failures)
+	cc := NewClientCreator(namespace, "does-not-exist", k8sClient, util.ClientOptions{})
 	k8sInterface, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
 	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
 	g.Expect(k8sInterface).To(BeNil())
+	g.Expect(promtestutil.ToFloat64(failures)).To(Equal(failuresBefore+1), "a missing secret should be counted as a seed-side failure")
 }
 
 func testConfigNotFound(ctx context.Context, t *testing.T, namespace string, k8sClient client.Client) {
 	g := NewWithT(t)
 	secretName, cleanupFn := createSecret(ctx, g, secretPath, namespace, nil, k8sClient)
 	defer cleanupFn()
-	cc := NewClientCreator(namespace, secretName, k8sClient)
+	failures := metrics.ShootClientCreationFailuresTotal.WithLabelValues(clientKindClient, metrics.ReasonSecretGetFailed)
+	failuresBefore := promtestutil.ToFloat64(failures)
+	cc := NewClientCreator(namespace, secretName, k8sClient, util.ClientOptions{})
 	shootClient, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
 	g.Expect(err).To(HaveOccurred())
 	g.Expect(apierrors.IsNotFound(err)).To(BeFalse())
 	g.Expect(shootClient).To(BeNil())
+	g.Expect(promtestutil.ToFloat64(failures)).To(Equal(failuresBefore + 1))
 }
 
 func testCreateShootClient(ctx context.Context, t *testing.T, namespace string, k8sClient client.Client) {
@@ -85,7 +98,7 @@ func testCreateShootClient(ctx context.Context, t *testing.T, namespace string,
 	secretName, cleanupFn := createSecret(ctx, g, secretPath, namespace, map[string][]byte{"kubeconfig": kubeConfig.Bytes()}, k8sClient)
 	defer cleanupFn()
 
-	cc := NewClientCreator(namespace, secretName, k8sClient)
+	cc := NewClientCreator(namespace, secretName, k8sClient, util.ClientOptions{})
 	shootClient, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(shootClient).ToNot(BeNil())
@@ -100,12 +113,72 @@ func testCreateDiscoveryClient(ctx context.Context, t *testing.T, namespace stri
 	secretName, cleanupFn := createSecret(ctx, g, secretPath, namespace, map[string][]byte{"kubeconfig": kubeConfig.Bytes()}, k8sClient)
 	defer cleanupFn()
 
-	cc := NewClientCreator(namespace, secretName, k8sClient)
+	cc := NewClientCreator(namespace, secretName, k8sClient, util.ClientOptions{})
 	discoveryClient, err := cc.CreateDiscoveryClient(ctx, logr.Discard(), time.Second)
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(discoveryClient).ToNot(BeNil())
 }
 
+func testCachedShootClient(ctx context.Context, t *testing.T, namespace string, k8sClient client.Client) {
+	g := NewWithT(t)
+
+	kubeConfig, err := test.ReadFile(kubeConfigPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	secretName, cleanupFn := createSecret(ctx, g, secretPath, namespace, map[string][]byte{"kubeconfig": kubeConfig.Bytes()}, k8sClient)
+	defer cleanupFn()
+
+	cc := NewClientCreator(namespace, secretName, k8sClient, util.ClientOptions{})
+	shootClient, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	cachedShootClient, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cachedShootClient).To(BeIdenticalTo(shootClient), "client should be reused if the kubeconfig and the timeout have not changed")
+
+	otherTimeoutShootClient, err := cc.CreateClient(ctx, logr.Discard(), 2*time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(otherTimeoutShootClient).ToNot(BeIdenticalTo(shootClient), "client should be created afresh if the timeout has changed")
+
+	cc.(CacheInvalidator).InvalidateCache()
+	newShootClient, err := cc.CreateClient(ctx, logr.Discard(), 2*time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(newShootClient).ToNot(BeIdenticalTo(otherTimeoutShootClient), "client should be created afresh after the cache has been invalidated")
+}
+
+func testTokenShootClient(ctx context.Context, t *testing.T, namespace string, k8sClient client.Client) {
+	g := NewWithT(t)
+
+	kubeConfig, err := test.ReadFile(kubeConfigPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	secretName, cleanupFn := createSecret(ctx, g, secretPath, namespace, map[string][]byte{"kubeconfig": kubeConfig.Bytes()}, k8sClient)
+	defer cleanupFn()
+
+	cc := NewTokenClientCreator(namespace, secretName, "shoot-access-dwd", k8sClient, util.ClientOptions{})
+	_, err = cc.CreateClient(ctx, logr.Discard(), time.Second)
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "client should not be created without the token secret")
+
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "shoot-access-dwd", Namespace: namespace},
+		Data:       map[string][]byte{"token": []byte("token-1")},
+	}
+	g.Expect(k8sClient.Create(ctx, tokenSecret)).To(Succeed())
+	shootClient, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(shootClient).ToNot(BeNil())
+	kubeConfigBytes, err := cc.(*clientCreator).getKubeConfigBytesFromSecret(ctx, logr.Discard())
+	g.Expect(err).ToNot(HaveOccurred())
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeConfigBytes)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(restConfig.BearerToken).To(Equal("token-1"))
+	g.Expect(restConfig.Username).To(BeEmpty(), "the credentials of the kubeconfig should be replaced by the token")
+
+	// renewing the token should result in a new client
+	tokenSecret.Data["token"] = []byte("token-2")
+	g.Expect(k8sClient.Update(ctx, tokenSecret)).To(Succeed())
+	renewedShootClient, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(renewedShootClient).ToNot(BeIdenticalTo(shootClient), "client should be created afresh once the token has been renewed")
+}
+
 func createSecret(ctx context.Context, g *WithT, path, namespace string, data map[string][]byte, k8sClient client.Client) (secretName string, cleanupFn func()) {
 	test.FileExistsOrFail(path)
 	secret, err := test.GetStructured[corev1.Secret](path)
@@ -122,3 +195,20 @@ func createSecret(ctx context.Context, g *WithT, path, namespace string, data ma
 	}
 	return
 }
+
+func TestKubeConfigFileClientCreator(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	cc := NewKubeConfigFileClientCreator(kubeConfigPath)
+	shootClient, err := cc.CreateClient(ctx, logr.Discard(), time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(shootClient).ToNot(BeNil())
+	discoveryClient, err := cc.CreateDiscoveryClient(ctx, logr.Discard(), time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(discoveryClient).ToNot(BeNil())
+
+	cc = NewKubeConfigFileClientCreator(filepath.Join("testdata", "does-not-exist.yaml"))
+	_, err = cc.CreateClient(ctx, logr.Discard(), time.Second)
+	g.Expect(err).To(HaveOccurred())
+}
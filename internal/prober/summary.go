@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prober
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const proberMetricsSubsystem = "prober"
+
+var (
+	shootsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, proberMetricsSubsystem, "shoots"),
+		"Number of shoots which are probed.", nil, nil)
+	shootsWithFailedAPIServerProbeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, proberMetricsSubsystem, "shoots_api_server_probe_failed"),
+		"Number of shoots for which the most recent API server probe has failed.", nil, nil)
+	shootsWithFailedLeaseProbeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, proberMetricsSubsystem, "shoots_lease_probe_failed"),
+		"Number of shoots for which the most recent node lease probe has failed.", nil, nil)
+	shootsWithScaledDownDependentsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, proberMetricsSubsystem, "shoots_dependents_scaled_down"),
+		"Number of shoots for which the dependent resources are currently scaled down.", nil, nil)
+)
+
+// seedProbeSummaryCollector is a prometheus.Collector which computes the SeedProbeSummary of a Manager whenever metrics are collected.
+type seedProbeSummaryCollector struct {
+	mgr Manager
+}
+
+// NewSeedProbeSummaryCollector creates a prometheus.Collector which exposes the SeedProbeSummary of the given Manager as gauges.
+func NewSeedProbeSummaryCollector(mgr Manager) prometheus.Collector {
+	return &seedProbeSummaryCollector{mgr: mgr}
+}
+
+func (c *seedProbeSummaryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- shootsDesc
+	ch <- shootsWithFailedAPIServerProbeDesc
+	ch <- shootsWithFailedLeaseProbeDesc
+	ch <- shootsWithScaledDownDependentsDesc
+}
+
+func (c *seedProbeSummaryCollector) Collect(ch chan<- prometheus.Metric) {
+	summary := c.mgr.GetSeedProbeSummary()
+	ch <- prometheus.MustNewConstMetric(shootsDesc, prometheus.GaugeValue, float64(summary.Shoots))
+	ch <- prometheus.MustNewConstMetric(shootsWithFailedAPIServerProbeDesc, prometheus.GaugeValue, float64(summary.ShootsWithFailedAPIServerProbe))
+	ch <- prometheus.MustNewConstMetric(shootsWithFailedLeaseProbeDesc, prometheus.GaugeValue, float64(summary.ShootsWithFailedLeaseProbe))
+	ch <- prometheus.MustNewConstMetric(shootsWithScaledDownDependentsDesc, prometheus.GaugeValue, float64(summary.ShootsWithScaledDownDependents))
+}
+
+// NewSeedProbeSummaryHandler creates a http.Handler which serves the SeedProbeSummary of the given Manager as JSON.
+func NewSeedProbeSummaryHandler(mgr Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(mgr.GetSeedProbeSummary()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
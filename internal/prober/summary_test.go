@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSeedProbeSummaryCollector(t *testing.T) {
+	g := NewWithT(t)
+	mgr := NewManager()
+	p := NewProber(context.Background(), nil, nil, "shoot--p--s1", &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+	g.Expect(mgr.Register(*p)).To(BeTrue())
+	defer mgr.Unregister(p.namespace, metrics.ReasonShootDeletion)
+	p.setAPIServerProbeFailed(true)
+
+	expected := `
+# HELP dependency_watchdog_prober_shoots Number of shoots which are probed.
+# TYPE dependency_watchdog_prober_shoots gauge
+dependency_watchdog_prober_shoots 1
+# HELP dependency_watchdog_prober_shoots_api_server_probe_failed Number of shoots for which the most recent API server probe has failed.
+# TYPE dependency_watchdog_prober_shoots_api_server_probe_failed gauge
+dependency_watchdog_prober_shoots_api_server_probe_failed 1
+# HELP dependency_watchdog_prober_shoots_lease_probe_failed Number of shoots for which the most recent node lease probe has failed.
+# TYPE dependency_watchdog_prober_shoots_lease_probe_failed gauge
+dependency_watchdog_prober_shoots_lease_probe_failed 0
+# HELP dependency_watchdog_prober_shoots_dependents_scaled_down Number of shoots for which the dependent resources are currently scaled down.
+# TYPE dependency_watchdog_prober_shoots_dependents_scaled_down gauge
+dependency_watchdog_prober_shoots_dependents_scaled_down 0
+`
+	g.Expect(testutil.CollectAndCompare(NewSeedProbeSummaryCollector(mgr), strings.NewReader(expected))).To(Succeed())
+}
+
+func TestSeedProbeSummaryHandler(t *testing.T) {
+	g := NewWithT(t)
+	mgr := NewManager()
+	p := NewProber(context.Background(), nil, nil, "shoot--p--s1", &papi.Config{}, nil, nil, nil, nil, nil, nil, pmLogger)
+	g.Expect(mgr.Register(*p)).To(BeTrue())
+	defer mgr.Unregister(p.namespace, metrics.ReasonShootDeletion)
+	p.setDependentsScaledDown(true)
+
+	rec := httptest.NewRecorder()
+	NewSeedProbeSummaryHandler(mgr).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/probe-summary", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+	summary := SeedProbeSummary{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &summary)).To(Succeed())
+	g.Expect(summary).To(Equal(SeedProbeSummary{Shoots: 1, ShootsWithScaledDownDependents: 1, ConfigHashes: map[string]int{p.ConfigHash(): 1}}))
+}
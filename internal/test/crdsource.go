@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	ociScheme = "oci://"
+	// helmChartContentMediaType is the media type of the layer of an OCI artifact which holds the packaged helm chart.
+	helmChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	ociManifestMediaType      = "application/vnd.oci.image.manifest.v1+json"
+	// chartCRDsDirName is the name of the directory of a helm chart which holds its CRDs.
+	chartCRDsDirName = "crds"
+)
+
+// DownloadCRDs downloads the CRDs from the given sources into a new temporary directory, which can be passed as one of the crdDirectoryPaths to
+// CreateControllerTestEnv. This allows tests to seed an envtest with CRDs, e.g. the ones of machine-controller-manager or etcd-druid, without
+// vendoring copies of them as testdata. A source is either
+//   - the URL of a YAML file holding one or more CRDs, e.g. https://raw.githubusercontent.com/<org>/<repo>/<ref>/<path>.yaml,
+//   - the URL of a packaged helm chart ending with .tgz or .tar.gz, of which the files in the crds directory are used,
+//   - or a reference to a helm chart in an OCI registry as oci://<registry>/<repository>:<tag>, of which the files in the crds directory are used.
+//
+// OCI registries are accessed anonymously. The returned function removes the temporary directory again.
+func DownloadCRDs(ctx context.Context, sources ...string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "dwd-crds-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	d := crdDownloader{httpClient: http.DefaultClient, dir: dir}
+	for i, source := range sources {
+		if err := d.download(ctx, i, source); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("failed to download CRDs from %s: %w", source, err)
+		}
+	}
+	return dir, cleanup, nil
+}
+
+type crdDownloader struct {
+	httpClient *http.Client
+	dir        string
+}
+
+// download downloads the CRDs of a single source. The files are prefixed with the index of the source, so that equally named files of different
+// sources do not overwrite each other.
+func (d crdDownloader) download(ctx context.Context, index int, source string) error {
+	prefix := fmt.Sprintf("%02d-", index)
+	switch {
+	case strings.HasPrefix(source, ociScheme):
+		chart, err := d.fetchOCIChart(ctx, strings.TrimPrefix(source, ociScheme))
+		if err != nil {
+			return err
+		}
+		defer chart.Close()
+		return d.extractChartCRDs(chart, prefix)
+	case strings.HasSuffix(source, ".tgz") || strings.HasSuffix(source, ".tar.gz"):
+		chart, err := d.get(ctx, source, nil)
+		if err != nil {
+			return err
+		}
+		defer chart.Close()
+		return d.extractChartCRDs(chart, prefix)
+	default:
+		body, err := d.get(ctx, source, nil)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		return d.write(prefix+path.Base(source), body)
+	}
+}
+
+// extractChartCRDs writes the YAML files in the crds directory of the packaged helm chart to the target directory.
+func (d crdDownloader) extractChartCRDs(chart io.Reader, prefix string) error {
+	gzipReader, err := gzip.NewReader(chart)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	found := false
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || !isChartCRDFile(header.Name) {
+			continue
+		}
+		if err := d.write(prefix+strings.ReplaceAll(header.Name, "/", "_"), tarReader); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("chart does not contain any CRDs in its %s directory", chartCRDsDirName)
+	}
+	return nil
+}
+
+// isChartCRDFile checks if the file with the given name in a packaged helm chart is a YAML file in the crds directory of the chart or one of its
+// sub charts.
+func isChartCRDFile(name string) bool {
+	ext := path.Ext(name)
+	return (ext == ".yaml" || ext == ".yml") && slices.Contains(strings.Split(path.Dir(name), "/"), chartCRDsDirName)
+}
+
+func (d crdDownloader) write(name string, content io.Reader) error {
+	f, err := os.Create(filepath.Join(d.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, content)
+	return err
+}
+
+// fetchOCIChart fetches the packaged helm chart of the given OCI reference, which has the form <registry>/<repository>:<tag>.
+func (d crdDownloader) fetchOCIChart(ctx context.Context, ref string) (io.ReadCloser, error) {
+	registry, repositoryAndTag, ok := strings.Cut(ref, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid OCI reference %s, it must have the form <registry>/<repository>:<tag>", ref)
+	}
+	repository, tag, ok := strings.Cut(repositoryAndTag, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid OCI reference %s, it must have a tag", ref)
+	}
+	baseURL := fmt.Sprintf("https://%s/v2/%s", registry, repository)
+	manifestBody, err := d.getFromRegistry(ctx, baseURL+"/manifests/"+tag, ociManifestMediaType, repository)
+	if err != nil {
+		return nil, err
+	}
+	defer manifestBody.Close()
+	manifest := struct {
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+			Digest    string `json:"digest"`
+		} `json:"layers"`
+	}{}
+	if err := json.NewDecoder(manifestBody).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == helmChartContentMediaType {
+			return d.getFromRegistry(ctx, baseURL+"/blobs/"+layer.Digest, "", repository)
+		}
+	}
+	return nil, fmt.Errorf("OCI artifact %s is not a helm chart", ref)
+}
+
+// getFromRegistry gets the given URL of an OCI registry. If the registry requires a bearer token, then an anonymous token for pulling from the
+// repository is requested from the token service announced by the registry.
+func (d crdDownloader) getFromRegistry(ctx context.Context, rawURL, accept, repository string) (io.ReadCloser, error) {
+	header := http.Header{}
+	if accept != "" {
+		header.Set("Accept", accept)
+	}
+	resp, err := d.do(ctx, rawURL, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return checkResponse(resp)
+	}
+	_ = resp.Body.Close()
+	token, err := d.getAnonymousToken(ctx, resp.Header.Get("WWW-Authenticate"), repository)
+	if err != nil {
+		return nil, err
+	}
+	header.Set("Authorization", "Bearer "+token)
+	return d.get(ctx, rawURL, header)
+}
+
+// getAnonymousToken requests an anonymous pull token for the repository from the token service given by the WWW-Authenticate challenge.
+func (d crdDownloader) getAnonymousToken(ctx context.Context, challenge, repository string) (string, error) {
+	params, ok := parseBearerChallenge(challenge)
+	if !ok || params["realm"] == "" {
+		return "", fmt.Errorf("registry requires unsupported authentication %q", challenge)
+	}
+	query := url.Values{"scope": {fmt.Sprintf("repository:%s:pull", repository)}}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	body, err := d.get(ctx, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+// parseBearerChallenge parses the parameters of a WWW-Authenticate challenge of the form Bearer realm="...",service="...".
+func parseBearerChallenge(challenge string) (map[string]string, bool) {
+	scheme, rawParams, ok := strings.Cut(challenge, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for _, param := range strings.Split(rawParams, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	return params, true
+}
+
+func (d crdDownloader) get(ctx context.Context, rawURL string, header http.Header) (io.ReadCloser, error) {
+	resp, err := d.do(ctx, rawURL, header)
+	if err != nil {
+		return nil, err
+	}
+	return checkResponse(resp)
+}
+
+func (d crdDownloader) do(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		req.Header = header
+	}
+	return d.httpClient.Do(req)
+}
+
+func checkResponse(resp *http.Response) (io.ReadCloser, error) {
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s for %s", resp.Status, resp.Request.URL)
+	}
+	return resp.Body, nil
+}
@@ -43,12 +43,34 @@ Utilities in testenv.go: Tests that require a controller-runtime envtest should
 	// to create a controller-runtime test environment using custom scheme and crdDirectoryPaths
 	ctrlTestEnv, err:= test.CreateControllerTestEnv(scheme, crdDirectoryPaths)
 
+	// to download CRDs from a YAML file URL, a packaged helm chart URL or a helm chart in an OCI registry and use them in the test environment
+	crdDir, cleanup, err := test.DownloadCRDs(ctx, "oci://<registry>/<repository>:<tag>")
+	defer cleanup()
+	ctrlTestEnv, err := test.CreateControllerTestEnv(scheme, []string{crdDir}, nil)
+
 	// to stop the controller-runtime test environment
 	ctrlTestEnv.Delete()
 
 	// to get client.Client for the test environment
 	k8sClient := ctrlTestEnv.GetClient()
 
+```
+
+Utilities in fakescale.go: Unit tests of scaling logic which should not require a KIND cluster should use utilities inside this file
+```
+
+	// to create a scale.ScalesGetter whose scale subresources are backed by the objects of a (fake) client
+	scalesGetter := test.NewFakeScalesGetterBuilder(k8sClient).Build()
+
+	// to inject an error for all updates of scale subresources of deployments
+	scalesGetter := test.NewFakeScalesGetterBuilder(k8sClient).RecordError(test.ScaleVerbUpdate, schema.GroupResource{Group: "apps", Resource: "deployments"}, err).Build()
+
+	// to emulate controllers which do not act upon updated replicas
+	scalesGetter := test.NewFakeScalesGetterBuilder(k8sClient).WithFrozenStatus().Build()
+
+	// to get the replicas of all updates of the scale subresource of a deployment
+	replicas := scalesGetter.UpdatedReplicas(schema.GroupResource{Group: "apps", Resource: "deployments"}, "kube-controller-manager")
+
 ```
 */
 package test
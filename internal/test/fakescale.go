@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"context"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/scale"
+	fakescale "k8s.io/client-go/scale/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScaleVerb is a verb of a call to a scale.ScaleInterface for which an error can be recorded.
+type ScaleVerb string
+
+const (
+	// ScaleVerbGet is the verb of the Get method on scale.ScaleInterface.
+	ScaleVerbGet ScaleVerb = "get"
+	// ScaleVerbUpdate is the verb of the Update method on scale.ScaleInterface.
+	ScaleVerbUpdate ScaleVerb = "update"
+	// ScaleVerbDryRunUpdate is the verb of the Update method on scale.ScaleInterface with a server-side dry-run. Errors which are recorded for
+	// ScaleVerbUpdate are returned for dry-run updates as well.
+	ScaleVerbDryRunUpdate ScaleVerb = "dry-run-update"
+)
+
+// scaleErrorKey identifies the calls for which an error is recorded.
+type scaleErrorKey struct {
+	verb          ScaleVerb
+	groupResource schema.GroupResource
+}
+
+// FakeScalesGetterBuilder builds a FakeScalesGetter which will also react to the configured errors.
+type FakeScalesGetterBuilder struct {
+	client       client.Client
+	errorRecords map[scaleErrorKey]error
+	freezeStatus bool
+}
+
+// NewFakeScalesGetterBuilder creates a new instance of FakeScalesGetterBuilder. The scale subresources of the built FakeScalesGetter are backed
+// by the objects of the given client, typically a fake client. The RESTMapper of the client has to know the kinds of the scaled objects.
+func NewFakeScalesGetterBuilder(client client.Client) *FakeScalesGetterBuilder {
+	return &FakeScalesGetterBuilder{
+		client:       client,
+		errorRecords: make(map[scaleErrorKey]error),
+	}
+}
+
+// RecordError records an error which is returned for every call of the given verb for a scale subresource of the given GroupResource.
+func (b *FakeScalesGetterBuilder) RecordError(verb ScaleVerb, groupResource schema.GroupResource, err error) *FakeScalesGetterBuilder {
+	// this method records error, so if nil error is passed then there is no need to create any error record.
+	if err == nil {
+		return b
+	}
+	b.errorRecords[scaleErrorKey{verb: verb, groupResource: groupResource}] = err
+	return b
+}
+
+// WithFrozenStatus prevents the status of a scaled object from being updated, which emulates a controller of the object that does not act upon
+// the updated replicas, e.g. because its pods cannot be scheduled.
+func (b *FakeScalesGetterBuilder) WithFrozenStatus() *FakeScalesGetterBuilder {
+	b.freezeStatus = true
+	return b
+}
+
+// Build creates a new instance of FakeScalesGetter which will react to the configured errors.
+func (b *FakeScalesGetterBuilder) Build() *FakeScalesGetter {
+	f := &FakeScalesGetter{
+		FakeScaleClient: &fakescale.FakeScaleClient{},
+		client:          b.client,
+		errorRecords:    b.errorRecords,
+		freezeStatus:    b.freezeStatus,
+	}
+	f.AddReactor(string(ScaleVerbGet), "*", f.reactToGet)
+	f.AddReactor(string(ScaleVerbUpdate), "*", f.reactToUpdate)
+	return f
+}
+
+// FakeScalesGetter is a scale.ScalesGetter whose scale subresources are backed by the objects of a client.Client. Getting a scale subresource
+// returns the replicas of the object. Updating a scale subresource sets spec.replicas of the object and, emulating the controller of the object,
+// its status replicas. All calls are recorded and can be inspected via Actions. Calls are serialized by the underlying k8stesting.Fake.
+type FakeScalesGetter struct {
+	*fakescale.FakeScaleClient
+	client       client.Client
+	errorRecords map[scaleErrorKey]error
+	freezeStatus bool
+}
+
+// Scales returns a scale.ScaleInterface for the given namespace. Unlike the one of the embedded FakeScaleClient, it passes the options of an update
+// on, so that dry-run updates can be told apart from actual updates.
+func (f *FakeScalesGetter) Scales(namespace string) scale.ScaleInterface {
+	return &fakeNamespacedScales{ScaleInterface: f.FakeScaleClient.Scales(namespace), fake: f.FakeScaleClient, namespace: namespace}
+}
+
+// UpdatedReplicas returns the replicas of all updates of the scale subresource of the named object of the given GroupResource in the order in
+// which they have been made, including the updates which have failed. Dry-run updates are not included.
+func (f *FakeScalesGetter) UpdatedReplicas(groupResource schema.GroupResource, name string) []int32 {
+	return f.updatedReplicas(groupResource, name, false)
+}
+
+// DryRunUpdatedReplicas returns the replicas of all dry-run updates of the scale subresource of the named object of the given GroupResource in the
+// order in which they have been made, including the dry-run updates which have failed.
+func (f *FakeScalesGetter) DryRunUpdatedReplicas(groupResource schema.GroupResource, name string) []int32 {
+	return f.updatedReplicas(groupResource, name, true)
+}
+
+func (f *FakeScalesGetter) updatedReplicas(groupResource schema.GroupResource, name string, dryRun bool) []int32 {
+	var replicas []int32
+	for _, action := range f.Actions() {
+		updateAction, ok := action.(k8stesting.UpdateAction)
+		if !ok || action.GetResource().GroupResource() != groupResource || isDryRun(action) != dryRun {
+			continue
+		}
+		if s, ok := updateAction.GetObject().(*autoscalingv1.Scale); ok && s.Name == name {
+			replicas = append(replicas, s.Spec.Replicas)
+		}
+	}
+	return replicas
+}
+
+func (f *FakeScalesGetter) reactToGet(action k8stesting.Action) (bool, runtime.Object, error) {
+	groupResource := action.GetResource().GroupResource()
+	if err := f.errorRecords[scaleErrorKey{verb: ScaleVerbGet, groupResource: groupResource}]; err != nil {
+		return true, nil, err
+	}
+	obj, err := f.getObject(groupResource, action.GetNamespace(), action.(k8stesting.GetAction).GetName())
+	if err != nil {
+		return true, nil, err
+	}
+	return true, toScale(obj), nil
+}
+
+func (f *FakeScalesGetter) reactToUpdate(action k8stesting.Action) (bool, runtime.Object, error) {
+	groupResource := action.GetResource().GroupResource()
+	if err := f.errorRecords[scaleErrorKey{verb: ScaleVerbUpdate, groupResource: groupResource}]; err != nil {
+		return true, nil, err
+	}
+	dryRun := isDryRun(action)
+	if err := f.errorRecords[scaleErrorKey{verb: ScaleVerbDryRunUpdate, groupResource: groupResource}]; dryRun && err != nil {
+		return true, nil, err
+	}
+	s := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+	obj, err := f.getObject(groupResource, action.GetNamespace(), s.Name)
+	if err != nil {
+		return true, nil, err
+	}
+	if err = unstructured.SetNestedField(obj.Object, int64(s.Spec.Replicas), "spec", "replicas"); err != nil {
+		return true, nil, err
+	}
+	if dryRun {
+		return true, toScale(obj), nil
+	}
+	if err = f.client.Update(context.Background(), obj); err != nil {
+		return true, nil, err
+	}
+	if !f.freezeStatus {
+		if err = f.updateStatus(obj, s.Spec.Replicas); err != nil {
+			return true, nil, err
+		}
+	}
+	return true, toScale(obj), nil
+}
+
+// updateStatus sets the status replicas of the object to the given replicas and marks its latest generation as observed.
+func (f *FakeScalesGetter) updateStatus(obj *unstructured.Unstructured, replicas int32) error {
+	for _, field := range []string{"replicas", "readyReplicas", "availableReplicas", "updatedReplicas"} {
+		if err := unstructured.SetNestedField(obj.Object, int64(replicas), "status", field); err != nil {
+			return err
+		}
+	}
+	if err := unstructured.SetNestedField(obj.Object, obj.GetGeneration(), "status", "observedGeneration"); err != nil {
+		return err
+	}
+	return f.client.Status().Update(context.Background(), obj)
+}
+
+func (f *FakeScalesGetter) getObject(groupResource schema.GroupResource, namespace, name string) (*unstructured.Unstructured, error) {
+	gvk, err := f.client.RESTMapper().KindFor(groupResource.WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err = f.client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func toScale(obj *unstructured.Unstructured) *autoscalingv1.Scale {
+	specReplicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	statusReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "replicas")
+	return &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            obj.GetName(),
+			Namespace:       obj.GetNamespace(),
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		},
+		Spec:   autoscalingv1.ScaleSpec{Replicas: int32(specReplicas)},
+		Status: autoscalingv1.ScaleStatus{Replicas: int32(statusReplicas)},
+	}
+}
+
+// fakeNamespacedScales passes the options of an update on to the reactors of the fake, see FakeScalesGetter.Scales.
+type fakeNamespacedScales struct {
+	scale.ScaleInterface
+	fake      *fakescale.FakeScaleClient
+	namespace string
+}
+
+func (f *fakeNamespacedScales) Update(_ context.Context, resource schema.GroupResource, s *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error) {
+	obj, err := f.fake.Invokes(k8stesting.NewUpdateSubresourceActionWithOptions(resource.WithVersion(""), "scale", f.namespace, s, opts), &autoscalingv1.Scale{})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*autoscalingv1.Scale), nil
+}
+
+// isDryRun checks if the given action is an update with a server-side dry-run.
+func isDryRun(action k8stesting.Action) bool {
+	updateAction, ok := action.(k8stesting.UpdateActionImpl)
+	return ok && len(updateAction.GetUpdateOptions().DryRun) > 0
+}
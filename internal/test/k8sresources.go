@@ -1,6 +1,7 @@
 package test
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
@@ -13,10 +14,13 @@ import (
 
 // NodeSpec is a specification for a node.
 type NodeSpec struct {
-	Name        string
-	Annotations map[string]string
-	Labels      map[string]string
-	Conditions  []corev1.NodeCondition
+	Name              string
+	Annotations       map[string]string
+	Labels            map[string]string
+	Conditions        []corev1.NodeCondition
+	CreationTimestamp metav1.Time
+	Unschedulable     bool
+	Taints            []corev1.Taint
 }
 
 // NodeLeaseSpec is a specification for a node lease.
@@ -78,9 +82,14 @@ func GenerateNodes(nodeSpecs []NodeSpec) []*corev1.Node {
 	for _, nodeSpec := range nodeSpecs {
 		nodes = append(nodes, &corev1.Node{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:        nodeSpec.Name,
-				Annotations: nodeSpec.Annotations,
-				Labels:      nodeSpec.Labels,
+				Name:              nodeSpec.Name,
+				Annotations:       nodeSpec.Annotations,
+				Labels:            nodeSpec.Labels,
+				CreationTimestamp: nodeSpec.CreationTimestamp,
+			},
+			Spec: corev1.NodeSpec{
+				Unschedulable: nodeSpec.Unschedulable,
+				Taints:        nodeSpec.Taints,
 			},
 			Status: corev1.NodeStatus{
 				Conditions: nodeSpec.Conditions,
@@ -135,3 +144,33 @@ func createNodeLease(name string, isExpired bool) *coordinationv1.Lease {
 	}
 	return &lease
 }
+
+// SyntheticCluster holds the nodes, node leases and machines of a synthetic cluster generated via GenerateSyntheticCluster.
+type SyntheticCluster struct {
+	Nodes      []*corev1.Node
+	NodeLeases []*coordinationv1.Lease
+	Machines   []*v1alpha1.Machine
+}
+
+// GenerateSyntheticCluster generates a cluster of the given number of nodes, e.g. for benchmarks. Every node is managed by MCM and backed by a
+// running machine in the given namespace. Every expiredLeaseInterval-th node lease is expired, none is expired if expiredLeaseInterval is 0.
+func GenerateSyntheticCluster(nodeCount, expiredLeaseInterval int, namespace string) SyntheticCluster {
+	nodeSpecs := make([]NodeSpec, 0, nodeCount)
+	leaseSpecs := make([]NodeLeaseSpec, 0, nodeCount)
+	machineSpecs := make([]MachineSpec, 0, nodeCount)
+	for i := 0; i < nodeCount; i++ {
+		nodeName := fmt.Sprintf("node-%d", i)
+		nodeSpecs = append(nodeSpecs, NodeSpec{Name: nodeName})
+		leaseSpecs = append(leaseSpecs, NodeLeaseSpec{Name: nodeName, IsExpired: expiredLeaseInterval > 0 && i%expiredLeaseInterval == 0})
+		machineSpecs = append(machineSpecs, MachineSpec{
+			Name:          fmt.Sprintf("machine-%d", i),
+			Labels:        map[string]string{v1alpha1.NodeLabelKey: nodeName},
+			CurrentStatus: v1alpha1.CurrentStatus{Phase: v1alpha1.MachineRunning},
+		})
+	}
+	return SyntheticCluster{
+		Nodes:      GenerateNodes(nodeSpecs),
+		NodeLeases: GenerateNodeLeases(leaseSpecs),
+		Machines:   GenerateMachines(machineSpecs, namespace),
+	}
+}
@@ -39,6 +39,7 @@ func CreateDefaultControllerTestEnv(scheme *runtime.Scheme, apiServerFlags map[s
 }
 
 // CreateControllerTestEnv creates a controller-runtime testEnv using the provided scheme and crdDirectoryPaths and provides access to the convenience interface to interact with it.
+// CRDs from remote sources can be downloaded into one of the crdDirectoryPaths with DownloadCRDs.
 func CreateControllerTestEnv(scheme *runtime.Scheme, crdDirectoryPaths []string, apiServerFlags map[string]string) (ControllerTestEnv, error) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths:     crdDirectoryPaths,
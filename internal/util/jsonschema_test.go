@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerateJSONSchemaForProberConfig(t *testing.T) {
+	g := NewWithT(t)
+	schemaBytes, err := GenerateJSONSchema("prober config", papi.Config{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	schema := map[string]interface{}{}
+	g.Expect(json.Unmarshal(schemaBytes, &schema)).To(Succeed())
+	g.Expect(schema).To(HaveKeyWithValue("title", "prober config"))
+	g.Expect(schema).To(HaveKeyWithValue("additionalProperties", false))
+	g.Expect(schema["required"]).To(ConsistOf("kubeConfigSecretName", "dependentResourceInfos"))
+
+	properties := schema["properties"].(map[string]interface{})
+	g.Expect(properties["probeInterval"]).To(HaveKeyWithValue("type", "string"))
+	g.Expect(properties["probeInterval"]).To(HaveKey("pattern"))
+	g.Expect(properties["nodeLeaseFailureFraction"]).To(HaveKeyWithValue("type", "number"))
+
+	depResInfos := properties["dependentResourceInfos"].(map[string]interface{})
+	g.Expect(depResInfos).To(HaveKeyWithValue("type", "array"))
+	depResInfo := depResInfos["items"].(map[string]interface{})
+	g.Expect(depResInfo["required"]).To(ConsistOf("ref"))
+	g.Expect(depResInfo["properties"]).To(HaveKey("scaleUp"))
+	g.Expect(depResInfo["properties"].(map[string]interface{})["optional"]).To(HaveKeyWithValue("type", "boolean"))
+}
+
+func TestGenerateJSONSchemaForMapsAndEmbeddedStructs(t *testing.T) {
+	g := NewWithT(t)
+	type embedded struct {
+		Inlined string `json:"inlined"`
+	}
+	type config struct {
+		embedded   `json:",inline"`
+		Values     map[string]int `json:"values,omitempty"`
+		Ignored    string         `json:"-"`
+		unexported string
+	}
+	schemaBytes, err := GenerateJSONSchema("test", config{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	schema := map[string]interface{}{}
+	g.Expect(json.Unmarshal(schemaBytes, &schema)).To(Succeed())
+	properties := schema["properties"].(map[string]interface{})
+	g.Expect(properties).To(HaveLen(2))
+	g.Expect(properties["inlined"]).To(HaveKeyWithValue("type", "string"))
+	g.Expect(properties["values"]).To(HaveKeyWithValue("additionalProperties", HaveKeyWithValue("type", "integer")))
+	g.Expect(schema["required"]).To(ConsistOf("inlined"))
+}
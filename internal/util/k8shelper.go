@@ -6,14 +6,19 @@ package util
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"k8s.io/client-go/discovery"
 
 	"github.com/go-logr/logr"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,6 +37,20 @@ import (
 
 const (
 	kubeConfigSecretKey = "kubeconfig"
+	// tokenSecretKey is the key of the token in a secret whose token is requested and renewed by the gardener-resource-manager.
+	tokenSecretKey = "token"
+	// defaultDialKeepAlive is the TCP keep-alive period of a dialer with a custom dial timeout. It matches the one of http.DefaultTransport.
+	defaultDialKeepAlive = 30 * time.Second
+)
+
+var (
+	// ErrScaleSubresourceNotFound is returned if the scale subresource of a resource cannot be found, either because the resource does not exist
+	// or because it does not have a scale subresource.
+	ErrScaleSubresourceNotFound = errors.New("scale subresource not found")
+	// ErrMappingNotFound is returned if the kind of a resource cannot be mapped to a resource, e.g. because its CRD has not been installed.
+	ErrMappingNotFound = errors.New("resource mapping not found")
+	// ErrTimeout is returned if a request has not completed within its timeout.
+	ErrTimeout = errors.New("timed out")
 )
 
 // GetKubeConfigFromSecret extracts kubeconfig from a k8s secret with name secretName in namespace
@@ -55,10 +74,68 @@ func GetKubeConfigFromSecret(ctx context.Context, namespace, secretName string,
 	return kubeConfig, nil
 }
 
+// GetTokenFromSecret extracts the token from a k8s secret with name secretName in namespace, e.g. a secret whose token is requested and renewed by
+// the gardener-resource-manager.
+func GetTokenFromSecret(ctx context.Context, namespace, secretName string, client client.Client, logger logr.Logger) (string, error) {
+	secret := corev1.Secret{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, &secret); err != nil {
+		logger.Error(err, "Failed to retrieve token secret, will not be able to create shoot client", "secretName", secretName)
+		return "", err
+	}
+	token, ok := secret.Data[tokenSecretKey]
+	if !ok || len(token) == 0 {
+		return "", fmt.Errorf("expected key: %s in secret: %s/%s is missing or empty", tokenSecretKey, namespace, secretName)
+	}
+	return string(token), nil
+}
+
+// SetBearerTokenInKubeConfig sets the given bearer token as the only credentials of the user of the current context of the kubeconfig, replacing any
+// other credentials, e.g. a token file which is only valid in a pod which mounts it. It returns the resulting kubeconfig.
+func SetBearerTokenInKubeConfig(kubeConfigBytes []byte, token string) ([]byte, error) {
+	kubeConfig, err := clientcmd.Load(kubeConfigBytes)
+	if err != nil {
+		return nil, err
+	}
+	kubeContext, ok := kubeConfig.Contexts[kubeConfig.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("current context %q of the kubeconfig is not defined", kubeConfig.CurrentContext)
+	}
+	authInfo, ok := kubeConfig.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return nil, fmt.Errorf("user %q of the current context of the kubeconfig is not defined", kubeContext.AuthInfo)
+	}
+	authInfo.ClientCertificate, authInfo.ClientCertificateData = "", nil
+	authInfo.ClientKey, authInfo.ClientKeyData = "", nil
+	authInfo.Username, authInfo.Password = "", ""
+	authInfo.TokenFile = ""
+	authInfo.Exec, authInfo.AuthProvider = nil, nil
+	authInfo.Token = token
+	return clientcmd.Write(*kubeConfig)
+}
+
+// RateLimits configures the client-side rate limiting of a client. A zero QPS or Burst falls back to the default of client-go.
+type RateLimits struct {
+	// QPS is the maximum number of queries per second to the Kube ApiServer.
+	QPS float32
+	// Burst is the maximum burst over the QPS.
+	Burst int
+}
+
+// ClientOptions configures a client which is created from a kubeconfig. Options which are not set fall back to the defaults of client-go and net/http.
+type ClientOptions struct {
+	RateLimits
+	// DialTimeout is the timeout for establishing a TCP connection to the Kube ApiServer.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout is the timeout for the TLS handshake with the Kube ApiServer.
+	TLSHandshakeTimeout time.Duration
+	// UserAgent is the user agent which is sent with every request to the Kube ApiServer, e.g. to attribute the requests in its audit logs.
+	UserAgent string
+}
+
 // CreateClientFromKubeConfigBytes creates a client to connect to the Kube ApiServer using the kubeConfigBytes passed as a parameter
-// It will also set a connection timeout and will disable KeepAlive.
-func CreateClientFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeout time.Duration) (client.Client, error) {
-	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, connectionTimeout)
+// It will also set a connection timeout, apply the given options and will disable KeepAlive.
+func CreateClientFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeout time.Duration, opts ClientOptions) (client.Client, error) {
+	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, connectionTimeout, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -66,9 +143,9 @@ func CreateClientFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeout t
 }
 
 // CreateDiscoveryInterfaceFromKubeConfigBytes creates a discovery interface to connect to the Kube ApiServer using the kubeConfigBytes passed as a parameter
-// It will also set a connection timeout and will disable KeepAlive.
-func CreateDiscoveryInterfaceFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeout time.Duration) (discovery.DiscoveryInterface, error) {
-	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, connectionTimeout)
+// It will also set a connection timeout, apply the given options and will disable KeepAlive.
+func CreateDiscoveryInterfaceFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeout time.Duration, opts ClientOptions) (discovery.DiscoveryInterface, error) {
+	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, connectionTimeout, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +156,85 @@ func CreateDiscoveryInterfaceFromKubeConfigBytes(kubeConfigBytes []byte, connect
 	return clientSet.Discovery(), nil
 }
 
-func createRestConfigFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeout time.Duration) (*rest.Config, error) {
+// ConnectionOverrides captures overrides for the connection to a Kube ApiServer which is otherwise configured via a kubeconfig.
+type ConnectionOverrides struct {
+	// Host replaces the server of the kubeconfig. The serving certificate is still verified against the host name of the server of the kubeconfig
+	// unless ServerName is set.
+	Host string
+	// ServerName is the server name which is sent via TLS SNI and against which the serving certificate is verified.
+	ServerName string
+	// CAData is the PEM encoded bundle of CA certificates used to verify the serving certificate instead of the CA of the kubeconfig.
+	CAData []byte
+}
+
+// IsEmpty returns true if none of the overrides is set.
+func (o ConnectionOverrides) IsEmpty() bool {
+	return o.Host == "" && o.ServerName == "" && len(o.CAData) == 0
+}
+
+// CreateDiscoveryInterfaceWithOverridesFromKubeConfigBytes creates a discovery interface to connect to the Kube ApiServer using the kubeConfigBytes
+// passed as a parameter after applying the given overrides.
+// It will also set a connection timeout, apply the given options and will disable KeepAlive.
+func CreateDiscoveryInterfaceWithOverridesFromKubeConfigBytes(kubeConfigBytes []byte, overrides ConnectionOverrides, connectionTimeout time.Duration, opts ClientOptions) (discovery.DiscoveryInterface, error) {
+	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, connectionTimeout, opts, withOverrides(overrides))
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return clientSet.Discovery(), nil
+}
+
+// withOverrides returns a function which applies the given overrides to a rest.Config.
+func withOverrides(overrides ConnectionOverrides) func(config *rest.Config) error {
+	return func(config *rest.Config) error {
+		if overrides.Host != "" {
+			if err := withHost(overrides.Host)(config); err != nil {
+				return err
+			}
+		}
+		if overrides.ServerName != "" {
+			config.TLSClientConfig.ServerName = overrides.ServerName
+		}
+		if len(overrides.CAData) > 0 {
+			config.TLSClientConfig.CAData = overrides.CAData
+			config.TLSClientConfig.CAFile = ""
+		}
+		return nil
+	}
+}
+
+// withRateLimits returns a function which sets the given rate limits on a rest.Config. Rate limits which are not set are left untouched.
+func withRateLimits(rateLimits RateLimits) func(config *rest.Config) error {
+	return func(config *rest.Config) error {
+		if rateLimits.QPS > 0 {
+			config.QPS = rateLimits.QPS
+		}
+		if rateLimits.Burst > 0 {
+			config.Burst = rateLimits.Burst
+		}
+		return nil
+	}
+}
+
+// withHost returns a function which changes the host of a rest.Config while retaining the server name used to verify the serving certificate.
+func withHost(host string) func(config *rest.Config) error {
+	return func(config *rest.Config) error {
+		if config.TLSClientConfig.ServerName == "" {
+			serverURL, err := url.Parse(config.Host)
+			if err != nil {
+				return err
+			}
+			config.TLSClientConfig.ServerName = serverURL.Hostname()
+		}
+		config.Host = host
+		return nil
+	}
+}
+
+func createRestConfigFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeout time.Duration, opts ClientOptions, mutateFns ...func(config *rest.Config) error) (*rest.Config, error) {
 	clientConfig, err := clientcmd.NewClientConfigFromBytes(kubeConfigBytes)
 	if err != nil {
 		return nil, err
@@ -88,8 +243,19 @@ func createRestConfigFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeo
 	if err != nil {
 		return nil, err
 	}
+	for _, mutateFn := range mutateFns {
+		if err = mutateFn(config); err != nil {
+			return nil, err
+		}
+	}
+	if err = withRateLimits(opts.RateLimits)(config); err != nil {
+		return nil, err
+	}
+	if opts.UserAgent != "" {
+		config.UserAgent = opts.UserAgent
+	}
 	config.Timeout = connectionTimeout
-	transport, err := createTransportWithDisabledKeepAlive(config)
+	transport, err := createTransportWithDisabledKeepAlive(config, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +269,8 @@ func createRestConfigFromKubeConfigBytes(kubeConfigBytes []byte, connectionTimeo
 // that the broken TCP connections are not kept alive for longer duration resulting in unwanted
 // scale down of critical control plane components.
 // See https://github.com/gardener/dependency-watchdog/issues/61
-func createTransportWithDisabledKeepAlive(config *rest.Config) (*http.Transport, error) {
+// The dial and TLS handshake timeouts of the transport are taken from the given options if they are set.
+func createTransportWithDisabledKeepAlive(config *rest.Config, opts ClientOptions) (*http.Transport, error) {
 	tlsConfig, err := rest.TLSConfigFor(config)
 	if err != nil {
 		return nil, err
@@ -115,6 +282,12 @@ func createTransportWithDisabledKeepAlive(config *rest.Config) (*http.Transport,
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.DisableKeepAlives = true
 	transport.TLSClientConfig = tlsConfig
+	if opts.DialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: opts.DialTimeout, KeepAlive: defaultDialKeepAlive}).DialContext
+	}
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
 	return transport, nil
 }
 
@@ -126,11 +299,13 @@ func CreateScalesGetter(config *rest.Config) (scale.ScalesGetter, error) {
 	}
 	discoveryClient := clientSet.Discovery()
 	resolver := scale.NewDiscoveryScaleKindResolver(discoveryClient)
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	mapper := newResettingRESTMapper(restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)))
 	return scale.New(clientSet.RESTClient(), mapper, dynamic.LegacyAPIPathResolverFunc, resolver), nil
 }
 
-// GetScaleResource returns a kubernetes scale subresource.
+// GetScaleResource returns a kubernetes scale subresource. Errors are wrapped with ErrScaleSubresourceNotFound, ErrMappingNotFound or ErrTimeout
+// where applicable. The RESTMapper of a ScalesGetter created via CreateScalesGetter is reset
+// and the lookup retried in case a mapping for the resource cannot be found in its cached discovery information.
 func GetScaleResource(ctx context.Context, client client.Client, scaler scale.ScaleInterface, logger logr.Logger, resourceRef *autoscalingv1.CrossVersionObjectReference, timeout time.Duration) (*schema.GroupResource, *autoscalingv1.Scale, error) {
 	gr, err := getGroupResource(client, logger, resourceRef)
 	if err != nil {
@@ -141,7 +316,25 @@ func GetScaleResource(ctx context.Context, client client.Client, scaler scale.Sc
 		defer cancelFn()
 		return scaler.Get(childCtx, gr, resourceRef.Name, metav1.GetOptions{})
 	}()
-	return &gr, scaleRes, err
+	if apierrors.IsNotFound(err) {
+		return &gr, nil, fmt.Errorf("%w: %w", ErrScaleSubresourceNotFound, err)
+	}
+	return &gr, scaleRes, wrapWithTypedError(err)
+}
+
+// wrapWithTypedError wraps the given error with ErrMappingNotFound or ErrTimeout if it is caused by a missing resource mapping or a timeout,
+// so that callers can branch on it via errors.Is. The original error is retained in the chain.
+func wrapWithTypedError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case meta.IsNoMatchError(err):
+		return fmt.Errorf("%w: %w", ErrMappingNotFound, err)
+	case errors.Is(err, context.DeadlineExceeded) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	default:
+		return err
+	}
 }
 
 // getGroupResource returns a schema.GroupResource for the given resourceRef.
@@ -154,13 +347,22 @@ func getGroupResource(client client.Client, logger logr.Logger, resourceRef *aut
 	mapping, err := client.RESTMapper().RESTMapping(gk, gv.Version)
 	if err != nil {
 		logger.Error(err, "Failed to get RESTMapping for resource")
-		return schema.GroupResource{}, err
+		return schema.GroupResource{}, wrapWithTypedError(err)
 	}
 	return mapping.Resource.GroupResource(), nil
 }
 
 // GetResourceAnnotations gets the annotations for a resource identified by resourceRef withing the given namespace.
 func GetResourceAnnotations(ctx context.Context, client client.Client, namespace string, resourceRef *autoscalingv1.CrossVersionObjectReference) (map[string]string, error) {
+	partialObjMeta, err := GetResourceMetadata(ctx, client, namespace, resourceRef)
+	if err != nil {
+		return nil, err
+	}
+	return partialObjMeta.Annotations, nil
+}
+
+// GetResourceMetadata gets the metadata, e.g. the annotations and the deletion timestamp, of a resource identified by resourceRef within the given namespace.
+func GetResourceMetadata(ctx context.Context, client client.Client, namespace string, resourceRef *autoscalingv1.CrossVersionObjectReference) (*metav1.PartialObjectMetadata, error) {
 	partialObjMeta := &metav1.PartialObjectMetadata{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       resourceRef.Kind,
@@ -169,13 +371,20 @@ func GetResourceAnnotations(ctx context.Context, client client.Client, namespace
 	}
 	err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: resourceRef.Name}, partialObjMeta)
 	if err != nil {
-		return nil, fmt.Errorf("error getting annotations for resource. Err: %w", err)
+		return nil, fmt.Errorf("error getting annotations for resource. Err: %w", wrapWithTypedError(err))
 	}
-	return partialObjMeta.Annotations, nil
+	return partialObjMeta, nil
 }
 
-// PatchResourceAnnotations patches the resource annotation with patchBytes. It uses StrategicMergePatchType strategy so the consumers should only provide changes to the annotations.
+// PatchResourceAnnotations patches the resource annotation with patchBytes. It uses MergePatchType strategy so the consumers should only provide changes to the annotations.
+// A resourceVersion which is part of the patch serves as a precondition, the patch then fails with a Conflict error if the resource has been changed since.
 func PatchResourceAnnotations(ctx context.Context, cl client.Client, namespace string, resourceRef *autoscalingv1.CrossVersionObjectReference, patchBytes []byte) error {
+	return PatchResourceMetadata(ctx, cl, namespace, resourceRef, client.RawPatch(types.MergePatchType, patchBytes))
+}
+
+// PatchResourceMetadata patches the metadata of the resource identified by resourceRef within the given namespace with the given patch, e.g. a JSON
+// patch which tests the value of an annotation before it removes it.
+func PatchResourceMetadata(ctx context.Context, cl client.Client, namespace string, resourceRef *autoscalingv1.CrossVersionObjectReference, patch client.Patch) error {
 	partialObjMeta := &metav1.PartialObjectMetadata{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       resourceRef.Kind,
@@ -186,17 +395,32 @@ func PatchResourceAnnotations(ctx context.Context, cl client.Client, namespace s
 			Namespace: namespace,
 		},
 	}
-	return cl.Patch(ctx, partialObjMeta, client.RawPatch(types.MergePatchType, patchBytes))
+	return cl.Patch(ctx, partialObjMeta, patch)
 }
 
 // GetResourceReadyReplicas gets spec.replicas for any resource identified via resourceRef withing the given namespace.
 // It is an error if there is no spec.replicas or if there is an error fetching the resource.
 func GetResourceReadyReplicas(ctx context.Context, cli client.Client, namespace string, resourceRef *autoscalingv1.CrossVersionObjectReference) (int32, error) {
+	status, err := GetResourceStatus(ctx, cli, namespace, resourceRef)
+	return status.ReadyReplicas, err
+}
+
+// ResourceStatus is the status of a scalable resource as far as it is relevant to decide if a scaling of the resource has completed.
+type ResourceStatus struct {
+	// ReadyReplicas is status.readyReplicas of the resource, 0 if it is not set.
+	ReadyReplicas int32
+	// GenerationObserved is true if the controller of the resource has observed its latest spec, i.e. status.observedGeneration >=
+	// metadata.generation. Resources whose status does not have an observedGeneration are considered to be observed.
+	GenerationObserved bool
+}
+
+// GetResourceStatus gets the ResourceStatus of any resource identified via resourceRef within the given namespace.
+func GetResourceStatus(ctx context.Context, cli client.Client, namespace string, resourceRef *autoscalingv1.CrossVersionObjectReference) (ResourceStatus, error) {
 	resObj := unstructured.Unstructured{}
 
 	groupVersion, err := schema.ParseGroupVersion(resourceRef.APIVersion)
 	if err != nil {
-		return 0, err
+		return ResourceStatus{}, err
 	}
 	resObj.SetGroupVersionKind(schema.GroupVersionKind{
 		Group:   groupVersion.Group,
@@ -205,17 +429,47 @@ func GetResourceReadyReplicas(ctx context.Context, cli client.Client, namespace
 	})
 	err = cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: resourceRef.Name}, &resObj)
 	if err != nil {
-		return 0, err
-	}
-	readyReplicas, found, err := unstructured.NestedInt64(resObj.Object, "status", "readyReplicas")
-	if !found {
-		return 0, nil
+		return ResourceStatus{}, wrapWithTypedError(err)
 	}
+	return getResourceStatus(&resObj)
+}
+
+// ListResourceStatuses gets the ResourceStatus of all resources of the given kind within the given namespace with a single list call. The statuses
+// are keyed by the names of the resources.
+func ListResourceStatuses(ctx context.Context, cli client.Client, namespace string, apiVersion, kind string) (map[string]ResourceStatus, error) {
+	groupVersion, err := schema.ParseGroupVersion(apiVersion)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	resList := unstructured.UnstructuredList{}
+	resList.SetGroupVersionKind(groupVersion.WithKind(kind + "List"))
+	if err = cli.List(ctx, &resList, client.InNamespace(namespace)); err != nil {
+		return nil, wrapWithTypedError(err)
 	}
+	statuses := make(map[string]ResourceStatus, len(resList.Items))
+	for i := range resList.Items {
+		status, err := getResourceStatus(&resList.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		statuses[resList.Items[i].GetName()] = status
+	}
+	return statuses, nil
+}
 
-	return int32(readyReplicas), nil
+func getResourceStatus(resObj *unstructured.Unstructured) (ResourceStatus, error) {
+	readyReplicas, _, err := unstructured.NestedInt64(resObj.Object, "status", "readyReplicas")
+	if err != nil {
+		return ResourceStatus{}, err
+	}
+	observedGeneration, found, err := unstructured.NestedInt64(resObj.Object, "status", "observedGeneration")
+	if err != nil {
+		return ResourceStatus{}, err
+	}
+	return ResourceStatus{
+		ReadyReplicas:      int32(readyReplicas),
+		GenerationObserved: !found || observedGeneration >= resObj.GetGeneration(),
+	}, nil
 }
 
 // CreateClientSetFromRestConfig creates a kubernetes.Clientset from rest.Config.
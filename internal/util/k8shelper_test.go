@@ -9,6 +9,7 @@ package util
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -75,6 +76,10 @@ func TestSuitForK8sHelper(t *testing.T) {
 		{"extract KubeConfig from secret", testExtractKubeConfigFromSecret},
 		{"secret with no KubeConfig", testExtractKubeConfigFromSecretWithNoKubeConfig},
 		{"create client from KubeConfig", testCreateClientFromKubeConfigBytes},
+		{"create rest config for a different host from KubeConfig", testCreateRestConfigForHostFromKubeConfigBytes},
+		{"create rest config with connection overrides from KubeConfig", testCreateRestConfigWithOverridesFromKubeConfigBytes},
+		{"create rest config with rate limits from KubeConfig", testCreateRestConfigWithRateLimitsFromKubeConfigBytes},
+		{"create rest config with user agent from KubeConfig", testCreateRestConfigWithUserAgentFromKubeConfigBytes},
 		{"create transport with keep-alive disabled", testCreateTransportWithDisabledKeepAlive},
 		{"create scales getter", testCreateScalesGetter},
 		{"get scale resource", testGetScaleResource},
@@ -135,18 +140,73 @@ func testCreateClientFromKubeConfigBytes(t *testing.T) {
 	g := NewWithT(t)
 	kubeConfigBytes := getKubeConfigBytes(g, kubeConfigPath)
 
-	cfg, err := CreateClientFromKubeConfigBytes(kubeConfigBytes, time.Second)
+	cfg, err := CreateClientFromKubeConfigBytes(kubeConfigBytes, time.Second, ClientOptions{})
 	g.Expect(err).Should(BeNil())
 	g.Expect(cfg).ShouldNot(BeNil())
 }
 
+func testCreateRestConfigForHostFromKubeConfigBytes(t *testing.T) {
+	g := NewWithT(t)
+	kubeConfigBytes := getKubeConfigBytes(g, kubeConfigPath)
+
+	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, time.Second, ClientOptions{}, withHost("https://kube-apiserver-0.shoot--p--s.svc:443"))
+	g.Expect(err).Should(BeNil())
+	g.Expect(config.Host).Should(Equal("https://kube-apiserver-0.shoot--p--s.svc:443"))
+	g.Expect(config.TLSClientConfig.ServerName).Should(Equal("localhost"), "serving certificate should be verified against the server of the kubeconfig")
+}
+
+func testCreateRestConfigWithOverridesFromKubeConfigBytes(t *testing.T) {
+	g := NewWithT(t)
+	config := getRestConfig(g, kubeConfigPath)
+	overrides := ConnectionOverrides{Host: "https://api.shoot.example.com:443", ServerName: "api.shoot.example.com", CAData: []byte("ca-bundle")}
+
+	g.Expect(withOverrides(overrides)(config)).Should(Succeed())
+	g.Expect(config.Host).Should(Equal("https://api.shoot.example.com:443"))
+	g.Expect(config.TLSClientConfig.ServerName).Should(Equal("api.shoot.example.com"))
+	g.Expect(config.TLSClientConfig.CAData).Should(Equal([]byte("ca-bundle")))
+	g.Expect(config.TLSClientConfig.CAFile).Should(BeEmpty())
+}
+
+func testCreateRestConfigWithRateLimitsFromKubeConfigBytes(t *testing.T) {
+	g := NewWithT(t)
+	kubeConfigBytes := getKubeConfigBytes(g, kubeConfigPath)
+
+	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, time.Second, ClientOptions{RateLimits: RateLimits{QPS: 20, Burst: 40}})
+	g.Expect(err).Should(BeNil())
+	g.Expect(config.QPS).Should(Equal(float32(20)))
+	g.Expect(config.Burst).Should(Equal(40))
+
+	config, err = createRestConfigFromKubeConfigBytes(kubeConfigBytes, time.Second, ClientOptions{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(config.QPS).Should(BeZero(), "client-go should fall back to its default QPS")
+	g.Expect(config.Burst).Should(BeZero(), "client-go should fall back to its default burst")
+}
+
+func testCreateRestConfigWithUserAgentFromKubeConfigBytes(t *testing.T) {
+	g := NewWithT(t)
+	kubeConfigBytes := getKubeConfigBytes(g, kubeConfigPath)
+
+	config, err := createRestConfigFromKubeConfigBytes(kubeConfigBytes, time.Second, ClientOptions{UserAgent: "dependency-watchdog-prober/v1.4.0 seed=aws"})
+	g.Expect(err).Should(BeNil())
+	g.Expect(config.UserAgent).Should(Equal("dependency-watchdog-prober/v1.4.0 seed=aws"))
+
+	config, err = createRestConfigFromKubeConfigBytes(kubeConfigBytes, time.Second, ClientOptions{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(config.UserAgent).Should(BeEmpty(), "client-go should fall back to its default user agent")
+}
+
 func testCreateTransportWithDisabledKeepAlive(t *testing.T) {
 	g := NewWithT(t)
 	config := getRestConfig(g, kubeConfigPath)
 
-	transport, err := createTransportWithDisabledKeepAlive(config)
+	transport, err := createTransportWithDisabledKeepAlive(config, ClientOptions{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(transport.DisableKeepAlives).To(Equal(true))
+
+	transport, err = createTransportWithDisabledKeepAlive(config, ClientOptions{TLSHandshakeTimeout: 3 * time.Second})
 	g.Expect(err).Should(BeNil())
 	g.Expect(transport.DisableKeepAlives).To(Equal(true))
+	g.Expect(transport.TLSHandshakeTimeout).To(Equal(3 * time.Second))
 }
 
 func testCreateScalesGetter(t *testing.T) {
@@ -204,6 +264,7 @@ func testGetScaleResourceForUnsupportedGKV(t *testing.T) {
 	scaler := scalesGetter.Scales("default")
 	_, _, err = GetScaleResource(ctx, k8sClient, scaler, k8sHelperTestLogger, resourceRef, 20*time.Second)
 	g.Expect(err).ToNot(BeNil())
+	g.Expect(errors.Is(err, ErrMappingNotFound)).To(BeTrue())
 }
 
 func testGetReadyReplicasForNonExistingResource(t *testing.T) {
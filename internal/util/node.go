@@ -2,6 +2,7 @@ package util
 
 import (
 	"slices"
+	"time"
 
 	"github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	"github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
@@ -14,6 +15,10 @@ const (
 	WorkerPoolLabel                  = "worker.gardener.cloud/pool"
 	nodeNameLabel                    = "node"
 	nodeNotManagedByMCMAnnotationKey = "node.machine.sapcloud.io/not-managed-by-mcm"
+	// TriggerDeletionByMCMAnnotationKey is the key of the annotation which is set on a node to trigger its deletion by MCM.
+	TriggerDeletionByMCMAnnotationKey = "node.machine.sapcloud.io/trigger-deletion-by-mcm"
+	// ToBeDeletedByClusterAutoscalerTaintKey is the key of the taint which is set by the cluster-autoscaler on a node which it is about to delete.
+	ToBeDeletedByClusterAutoscalerTaintKey = "ToBeDeletedByClusterAutoscaler"
 )
 
 // DefaultUnhealthyNodeConditions are the default node conditions which indicate that the node is unhealthy.
@@ -38,6 +43,35 @@ func IsNodeManagedByMCM(node *corev1.Node) bool {
 	return !metav1.HasAnnotation(node.ObjectMeta, nodeNotManagedByMCMAnnotationKey)
 }
 
+// IsNodeYoungerThan determines if the node has been created less than minAge ago.
+func IsNodeYoungerThan(node *corev1.Node, minAge time.Duration) bool {
+	return time.Since(node.CreationTimestamp.Time) < minAge
+}
+
+// HasAnyTaint determines if the node has a taint with any of the given taintKeys. A node which is marked unschedulable is considered to have the
+// node.kubernetes.io/unschedulable taint even if it has not been set yet.
+func HasAnyTaint(node *corev1.Node, taintKeys []string) bool {
+	if node.Spec.Unschedulable && slices.Contains(taintKeys, corev1.TaintNodeUnschedulable) {
+		return true
+	}
+	return slices.ContainsFunc(node.Spec.Taints, func(taint corev1.Taint) bool {
+		return slices.Contains(taintKeys, taint.Key)
+	})
+}
+
+// HasAnyAnnotation determines if the node has an annotation with any of the given annotationKeys.
+func HasAnyAnnotation(node *corev1.Node, annotationKeys []string) bool {
+	return slices.ContainsFunc(annotationKeys, func(key string) bool {
+		return metav1.HasAnnotation(node.ObjectMeta, key)
+	})
+}
+
+// IsNodeInAnyWorkerPool determines if the node belongs to any of the given worker pools, as identified by its WorkerPoolLabel.
+func IsNodeInAnyWorkerPool(node *corev1.Node, workerPools []string) bool {
+	poolName, ok := node.Labels[WorkerPoolLabel]
+	return ok && slices.Contains(workerPools, poolName)
+}
+
 // GetEffectiveNodeConditionsForWorkers initializes the node conditions per worker.
 func GetEffectiveNodeConditionsForWorkers(shoot *v1beta1.Shoot) map[string][]string {
 	workerNodeConditions := make(map[string][]string)
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"fmt"
+
+	multierr "github.com/hashicorp/go-multierror"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResourcePermission identifies a verb on a resource which is required by dependency-watchdog.
+type ResourcePermission struct {
+	// Verb is the kubernetes verb, e.g. get, list, watch, update.
+	Verb string
+	// Group is the API group of the resource.
+	Group string
+	// Resource is the plural name of the resource.
+	Resource string
+	// Subresource is the subresource, e.g. scale. It is empty if the permission is required for the resource itself.
+	Subresource string
+	// Namespace is the namespace in which the permission is required. It is empty if the permission is required in all namespaces.
+	Namespace string
+}
+
+func (r ResourcePermission) String() string {
+	resource := r.Resource
+	if r.Group != "" {
+		resource = fmt.Sprintf("%s.%s", r.Resource, r.Group)
+	}
+	if r.Subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, r.Subresource)
+	}
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = "<all namespaces>"
+	}
+	return fmt.Sprintf("%s %s in %s", r.Verb, resource, namespace)
+}
+
+// NewResourcePermissions creates a ResourcePermission for each of the given verbs on the given resource in all namespaces.
+func NewResourcePermissions(group, resource string, verbs ...string) []ResourcePermission {
+	permissions := make([]ResourcePermission, 0, len(verbs))
+	for _, verb := range verbs {
+		permissions = append(permissions, ResourcePermission{Verb: verb, Group: group, Resource: resource})
+	}
+	return permissions
+}
+
+// CheckPermissions verifies via SelfSubjectAccessReviews that all the given permissions are granted to the identity which is used by the client.
+// It returns an error listing all the permissions which are not granted.
+func CheckPermissions(ctx context.Context, cl client.Client, permissions []ResourcePermission) error {
+	var missing []string
+	for _, permission := range permissions {
+		ssar := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   permission.Namespace,
+					Verb:        permission.Verb,
+					Group:       permission.Group,
+					Resource:    permission.Resource,
+					Subresource: permission.Subresource,
+				},
+			},
+		}
+		if err := cl.Create(ctx, ssar); err != nil {
+			return fmt.Errorf("failed to review permission to %s: %w", permission, err)
+		}
+		if !ssar.Status.Allowed {
+			missing = append(missing, permission.String())
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	var err error
+	for _, m := range missing {
+		err = multierr.Append(err, fmt.Errorf("missing permission to %s", m))
+	}
+	return fmt.Errorf("%d of %d required permissions are not granted: %w", len(missing), len(permissions), err)
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// createAccessReviewClient creates a fake client which allows all SelfSubjectAccessReviews except the ones for the given denied verbs.
+func createAccessReviewClient(deniedVerbs ...string) client.Client {
+	denied := make(map[string]bool, len(deniedVerbs))
+	for _, verb := range deniedVerbs {
+		denied[verb] = true
+	}
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+			ssar := obj.(*authorizationv1.SelfSubjectAccessReview)
+			ssar.Status.Allowed = !denied[ssar.Spec.ResourceAttributes.Verb]
+			return nil
+		},
+	}).Build()
+}
+
+func TestCheckPermissionsShouldSucceedIfAllPermissionsAreGranted(t *testing.T) {
+	g := NewWithT(t)
+	permissions := NewResourcePermissions("", "pods", "get", "list", "delete")
+	g.Expect(CheckPermissions(context.Background(), createAccessReviewClient(), permissions)).To(Succeed())
+}
+
+func TestCheckPermissionsShouldReportAllMissingPermissions(t *testing.T) {
+	g := NewWithT(t)
+	permissions := append(NewResourcePermissions("", "pods", "get", "delete"),
+		ResourcePermission{Verb: "update", Group: "apps", Resource: "deployments", Subresource: "scale", Namespace: "shoot--p--s"})
+	err := CheckPermissions(context.Background(), createAccessReviewClient("delete", "update"), permissions)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("2 of 3 required permissions are not granted"))
+	g.Expect(err.Error()).To(ContainSubstring("missing permission to delete pods in <all namespaces>"))
+	g.Expect(err.Error()).To(ContainSubstring("missing permission to update deployments.apps/scale in shoot--p--s"))
+	g.Expect(err.Error()).ToNot(ContainSubstring("get pods"))
+}
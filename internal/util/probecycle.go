@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+)
+
+// ProbeCycleIDLogKey is the key under which the ID of a probe cycle is logged.
+const ProbeCycleIDLogKey = "probeCycleID"
+
+type probeCycleIDContextKey struct{}
+
+// ContextWithProbeCycleID returns a copy of the context which carries the ID of the probe cycle it has been created for.
+func ContextWithProbeCycleID(ctx context.Context, probeCycleID string) context.Context {
+	return context.WithValue(ctx, probeCycleIDContextKey{}, probeCycleID)
+}
+
+// ProbeCycleIDFromContext returns the ID of the probe cycle carried by the context, if any.
+func ProbeCycleIDFromContext(ctx context.Context) (string, bool) {
+	probeCycleID, ok := ctx.Value(probeCycleIDContextKey{}).(string)
+	return probeCycleID, ok
+}
+
+// LoggerWithProbeCycleID adds the ID of the probe cycle carried by the context, if any, to the logger, so that all log lines which are written
+// on behalf of a probe cycle, e.g. by the scaler, can be correlated.
+func LoggerWithProbeCycleID(ctx context.Context, logger logr.Logger) logr.Logger {
+	if probeCycleID, ok := ProbeCycleIDFromContext(ctx); ok {
+		return logger.WithValues(ProbeCycleIDLogKey, probeCycleID)
+	}
+	return logger
+}
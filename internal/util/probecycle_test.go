@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoggerWithProbeCycleID(t *testing.T) {
+	g := NewWithT(t)
+	var lines []string
+	logger := funcr.New(func(_, args string) { lines = append(lines, args) }, funcr.Options{})
+
+	_, ok := ProbeCycleIDFromContext(context.Background())
+	g.Expect(ok).To(BeFalse())
+	LoggerWithProbeCycleID(context.Background(), logger).Info("without probe cycle")
+
+	ctx := ContextWithProbeCycleID(context.Background(), "bingo")
+	probeCycleID, ok := ProbeCycleIDFromContext(ctx)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(probeCycleID).To(Equal("bingo"))
+	LoggerWithProbeCycleID(ctx, logger).Info("with probe cycle")
+
+	g.Expect(lines).To(HaveLen(2))
+	g.Expect(lines[0]).ToNot(ContainSubstring(ProbeCycleIDLogKey))
+	g.Expect(lines[1]).To(ContainSubstring(`"probeCycleID"="bingo"`))
+}
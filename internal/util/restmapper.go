@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// resettingRESTMapper wraps a meta.ResettableRESTMapper. If a mapping cannot be found then the wrapped mapper is reset and the lookup is
+// retried once. The discovery information cached by the wrapped mapper can otherwise be stale, e.g. when CRD backed scale targets have been
+// added after the mapper has been initialized, resulting in "no matches for kind" errors.
+type resettingRESTMapper struct {
+	meta.ResettableRESTMapper
+}
+
+func newResettingRESTMapper(delegate meta.ResettableRESTMapper) meta.ResettableRESTMapper {
+	return &resettingRESTMapper{ResettableRESTMapper: delegate}
+}
+
+func (m *resettingRESTMapper) KindFor(resource schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	return resetAndRetryOnNoMatch(m, func() (schema.GroupVersionKind, error) { return m.ResettableRESTMapper.KindFor(resource) })
+}
+
+func (m *resettingRESTMapper) KindsFor(resource schema.GroupVersionResource) ([]schema.GroupVersionKind, error) {
+	return resetAndRetryOnNoMatch(m, func() ([]schema.GroupVersionKind, error) { return m.ResettableRESTMapper.KindsFor(resource) })
+}
+
+func (m *resettingRESTMapper) ResourceFor(input schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+	return resetAndRetryOnNoMatch(m, func() (schema.GroupVersionResource, error) { return m.ResettableRESTMapper.ResourceFor(input) })
+}
+
+func (m *resettingRESTMapper) ResourcesFor(input schema.GroupVersionResource) ([]schema.GroupVersionResource, error) {
+	return resetAndRetryOnNoMatch(m, func() ([]schema.GroupVersionResource, error) { return m.ResettableRESTMapper.ResourcesFor(input) })
+}
+
+func (m *resettingRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	return resetAndRetryOnNoMatch(m, func() (*meta.RESTMapping, error) { return m.ResettableRESTMapper.RESTMapping(gk, versions...) })
+}
+
+func (m *resettingRESTMapper) RESTMappings(gk schema.GroupKind, versions ...string) ([]*meta.RESTMapping, error) {
+	return resetAndRetryOnNoMatch(m, func() ([]*meta.RESTMapping, error) { return m.ResettableRESTMapper.RESTMappings(gk, versions...) })
+}
+
+func resetAndRetryOnNoMatch[T any](m *resettingRESTMapper, fn func() (T, error)) (T, error) {
+	res, err := fn()
+	if meta.IsNoMatchError(err) {
+		m.Reset()
+		metrics.RESTMapperResetsTotal.Inc()
+		res, err = fn()
+	}
+	return res, err
+}
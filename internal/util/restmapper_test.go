@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package util
+
+import (
+	"testing"
+
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var testCRDGroupVersion = schema.GroupVersion{Group: "druid.gardener.cloud", Version: "v1alpha1"}
+
+// staleRESTMapper only learns about the Etcd kind once it has been reset, simulating a CRD which has been added after the mapper has been initialized.
+type staleRESTMapper struct {
+	*meta.DefaultRESTMapper
+	resetCount int
+}
+
+func (m *staleRESTMapper) Reset() {
+	m.resetCount++
+	m.Add(testCRDGroupVersion.WithKind("Etcd"), meta.RESTScopeNamespace)
+}
+
+func TestResettingRESTMapperShouldResetAndRetryOnNoMatch(t *testing.T) {
+	g := NewWithT(t)
+	delegate := &staleRESTMapper{DefaultRESTMapper: meta.NewDefaultRESTMapper([]schema.GroupVersion{testCRDGroupVersion})}
+	mapper := newResettingRESTMapper(delegate)
+	resetsBefore := testutil.ToFloat64(metrics.RESTMapperResetsTotal)
+
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: testCRDGroupVersion.Group, Kind: "Etcd"}, testCRDGroupVersion.Version)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mapping.Resource.Resource).To(Equal("etcds"))
+	g.Expect(delegate.resetCount).To(Equal(1))
+
+	gvr, err := mapper.ResourceFor(testCRDGroupVersion.WithResource("etcds"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(gvr.Resource).To(Equal("etcds"))
+	g.Expect(delegate.resetCount).To(Equal(1), "mapper should not be reset if the mapping is found")
+	g.Expect(testutil.ToFloat64(metrics.RESTMapperResetsTotal) - resetsBefore).To(Equal(1.0))
+}
+
+func TestResettingRESTMapperShouldReturnErrorIfMappingIsStillNotFound(t *testing.T) {
+	g := NewWithT(t)
+	delegate := &staleRESTMapper{DefaultRESTMapper: meta.NewDefaultRESTMapper([]schema.GroupVersion{testCRDGroupVersion})}
+	mapper := newResettingRESTMapper(delegate)
+
+	_, err := mapper.KindFor(testCRDGroupVersion.WithResource("bingos"))
+	g.Expect(meta.IsNoMatchError(err)).To(BeTrue())
+	g.Expect(delegate.resetCount).To(Equal(1))
+}
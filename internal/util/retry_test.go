@@ -1,202 +0,0 @@
-// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
-//
-// SPDX-License-Identifier: Apache-2.0
-
-//go:build !kind_tests
-
-package util
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"sync"
-	"testing"
-	"time"
-
-	"github.com/go-logr/logr"
-	. "github.com/onsi/gomega"
-)
-
-var (
-	list            []string
-	numAttempts     = 3
-	backoff         = 10 * time.Millisecond
-	interval        = 2 * time.Millisecond
-	timeout         = 10 * time.Millisecond
-	retryTestLogger = logr.Discard()
-)
-
-func TestNoErrorIfTaskEventuallySucceeds(t *testing.T) {
-	g := NewWithT(t)
-	result := Retry(context.Background(), retryTestLogger, "", passEventually(), numAttempts, backoff, AlwaysRetry)
-	g.Expect(result.Err).ShouldNot(HaveOccurred())
-	g.Expect(result.Value).Should(Equal("appendPass"))
-	g.Expect(list).Should(HaveLen(3))
-	g.Expect(list[0:2]).Should(ConsistOf("appendFail", "appendFail"))
-	g.Expect(list[2]).To(Equal("appendPass"))
-	emptyList()
-}
-
-func TestErrorIfExceedsAttempts(t *testing.T) {
-	g := NewWithT(t)
-	result := Retry(context.Background(), retryTestLogger, "", appendFail, numAttempts, backoff, AlwaysRetry)
-	g.Expect(list).Should(HaveLen(numAttempts))
-	g.Expect(result.Err.Error()).Should(Equal("appendFail"))
-	g.Expect(result.Value).Should(Equal("appendFail"))
-	emptyList()
-}
-
-func TestCanRetryReturnsFalse(t *testing.T) {
-	g := NewWithT(t)
-	var hasRunOnce bool
-	runOnceFn := func(error) bool {
-		if !hasRunOnce {
-			hasRunOnce = true
-			return true
-		}
-		return false
-	}
-	result := Retry(context.Background(), retryTestLogger, "", passEventually(), numAttempts, backoff, runOnceFn)
-	g.Expect(list).Should(HaveLen(2))
-	g.Expect(list[0:2]).Should(ConsistOf("appendFail", "appendFail"))
-	g.Expect(result.Err.Error()).Should(Equal("appendFail"))
-	g.Expect(result.Value).Should(Equal(""))
-	emptyList()
-}
-
-func TestContextCancelledBeforeTaskIsRun(t *testing.T) {
-	g := NewWithT(t)
-	ctx, cancelFn := context.WithCancel(context.Background())
-	var result RetryResult[string]
-	var wg sync.WaitGroup
-	wg.Add(1)
-	cancelFn()
-	go func() {
-		defer wg.Done()
-		result = Retry(ctx, retryTestLogger, "", appendPass, numAttempts, backoff, AlwaysRetry)
-		g.Expect(result.Err).Should(Equal(ctx.Err()))
-		g.Expect(result.Value).Should(Equal(""))
-		g.Expect(len(list)).Should(BeNumerically("<=", numAttempts))
-	}()
-	wg.Wait()
-	emptyList()
-}
-
-func TestContextCancelledBeforeBackoffBegins(t *testing.T) {
-	g := NewWithT(t)
-	var result RetryResult[string]
-	var wg sync.WaitGroup
-	list := make([]string, 0, 1)
-	ctx, cancelFn := context.WithCancel(context.Background())
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		result = Retry(ctx, retryTestLogger, "", func() (string, error) {
-			list = append(list, "appendFail")
-			cancelFn()
-			return "", fmt.Errorf("appendFail")
-		}, numAttempts, backoff, AlwaysRetry)
-
-		g.Expect(result.Err).Should(Equal(context.Canceled))
-		g.Expect(result.Value).Should(Equal(""))
-		g.Expect(list).Should(HaveLen(1))
-	}()
-	wg.Wait()
-	emptyList()
-}
-
-func TestRetryUntilPredicateForContextCancelled(t *testing.T) {
-	g := NewWithT(t)
-	ctx, cancelFn := context.WithCancel(context.Background())
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		result := RetryUntilPredicate(ctx, retryTestLogger, "", func() bool { return false }, timeout, interval)
-		g.Expect(result).Should(BeFalse())
-	}()
-	cancelFn()
-	wg.Wait()
-}
-
-func TestRetryUntilPredicateWithBackgroundContext(t *testing.T) {
-	table := []struct {
-		predicateFn    func() bool
-		expectedResult bool
-	}{
-		{func() bool { return false }, false},
-		{func() bool { return true }, true},
-	}
-	for _, entry := range table {
-		g := NewWithT(t)
-		var wg sync.WaitGroup
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := RetryUntilPredicate(context.Background(), retryTestLogger, "", entry.predicateFn, timeout, interval)
-			g.Expect(result).Should(Equal(entry.expectedResult))
-		}()
-		wg.Wait()
-	}
-}
-
-func TestRetryOnError(t *testing.T) {
-	g := NewWithT(t)
-	counter := 0
-	fn := func() error {
-		counter++
-		if counter < 3 {
-			return errors.New("counter is less than 3. Returning an error")
-		}
-		return nil
-	}
-	RetryOnError(context.Background(), retryTestLogger, "", fn, 10*time.Millisecond)
-	g.Expect(counter).To(Equal(3))
-}
-
-func TestRetryOnErrorWhenContextIsCancelled(t *testing.T) {
-	g := NewWithT(t)
-	ctx, cancelFn := context.WithCancel(context.Background())
-	counter := 0
-	fn := func() error {
-		for {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-			counter++
-		}
-	}
-	go RetryOnError(context.Background(), retryTestLogger, "", fn, 10*time.Millisecond)
-	time.Sleep(20 * time.Millisecond) //forcing counter to be incremented
-	cancelFn()
-	g.Expect(counter).To(BeNumerically(">", 0))
-	g.Expect(ctx.Err()).ToNot(Succeed())
-}
-
-func appendFail() (string, error) {
-	list = append(list, "appendFail")
-	return "appendFail", fmt.Errorf("appendFail")
-}
-
-func appendPass() (string, error) {
-	list = append(list, "appendPass")
-	return "appendPass", nil
-}
-
-func passEventually() func() (string, error) {
-	var runCounter = 0
-	return func() (string, error) {
-		runCounter++
-		if runCounter%3 == 0 {
-			return appendPass()
-		}
-		return appendFail()
-	}
-}
-
-func emptyList() {
-	list = nil
-}
@@ -6,12 +6,18 @@ package util
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"os"
 	"time"
 
 	"sigs.k8s.io/yaml"
 )
 
+// configHashLength is the number of hex characters of a config hash, which is sufficient to tell configs apart.
+const configHashLength = 16
+
 // SleepWithContext sleeps until sleepFor duration has expired or the context has been cancelled.
 func SleepWithContext(ctx context.Context, sleepFor time.Duration) error {
 	for {
@@ -26,12 +32,22 @@ func SleepWithContext(ctx context.Context, sleepFor time.Duration) error {
 
 // ReadAndUnmarshall reads file and Unmarshall the contents in a generic type
 func ReadAndUnmarshall[T any](filename string) (*T, error) {
+	return readAndUnmarshall[T](filename, yaml.Unmarshal)
+}
+
+// ReadAndUnmarshallStrict reads file and Unmarshall the contents in a generic type. Unlike ReadAndUnmarshall
+// it returns an error if the file contains fields which are not known to T or if it has duplicate fields.
+func ReadAndUnmarshallStrict[T any](filename string) (*T, error) {
+	return readAndUnmarshall[T](filename, yaml.UnmarshalStrict)
+}
+
+func readAndUnmarshall[T any](filename string, unmarshalFn func([]byte, interface{}, ...yaml.JSONOpt) error) (*T, error) {
 	configBytes, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 	t := new(T)
-	err = yaml.Unmarshal(configBytes, t)
+	err = unmarshalFn(configBytes, t)
 	if err != nil {
 		return nil, err
 	}
@@ -58,3 +74,14 @@ func GetSliceOrDefault[T any](val []T, defaultVal []T) []T {
 	}
 	return val
 }
+
+// ComputeConfigHash computes a deterministic hash of the given config, e.g. to identify the config a prober or a weeder is running with.
+// The config is hashed via its JSON serialization, in which the keys of maps are sorted. An empty string is returned if the config cannot be serialized.
+func ComputeConfigHash(config any) string {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(configBytes)
+	return hex.EncodeToString(sum[:])[:configHashLength]
+}
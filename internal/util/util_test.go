@@ -8,12 +8,23 @@ package util
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakescale "k8s.io/client-go/scale/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	papi "github.com/gardener/dependency-watchdog/api/prober"
 	. "github.com/onsi/gomega"
@@ -74,6 +85,18 @@ func TestReadAndUnmarshall(t *testing.T) {
 	}
 }
 
+func TestReadAndUnmarshallStrictShouldRejectUnknownFields(t *testing.T) {
+	g := NewWithT(t)
+	type config struct {
+		Name    string
+		Version string
+	}
+	configPath := filepath.Join("testdata", "test-config.yaml")
+	_, err := ReadAndUnmarshallStrict[config](configPath)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("unknown field"))
+}
+
 func TestEqualOrBeforeNow(t *testing.T) {
 	g := NewWithT(t)
 	g.Expect(EqualOrBeforeNow(time.Now())).To(BeTrue())
@@ -112,3 +135,47 @@ func TestGetSliceOrDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestComputeConfigHash(t *testing.T) {
+	g := NewWithT(t)
+	config := &papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.6)}
+	hash := ComputeConfigHash(config)
+
+	g.Expect(hash).To(HaveLen(16))
+	g.Expect(ComputeConfigHash(&papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.6)})).To(Equal(hash), "equal configs should have the same hash")
+	g.Expect(ComputeConfigHash(&papi.Config{KubeConfigSecretName: "bingo", NodeLeaseFailureFraction: pointer.Float64(0.5)})).ToNot(Equal(hash), "different configs should have different hashes")
+	g.Expect(ComputeConfigHash(map[string]int{"a": 1, "b": 2})).To(Equal(ComputeConfigHash(map[string]int{"b": 2, "a": 1})), "the hash should not depend on the order of map keys")
+}
+
+func TestGetScaleResourceShouldReturnTypedErrors(t *testing.T) {
+	deploymentRef := &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "kube-apiserver"}
+	unknownKindRef := &autoscalingv1.CrossVersionObjectReference{APIVersion: "druid.gardener.cloud/v1alpha1", Kind: "Etcd", Name: "etcd-main"}
+	tests := []struct {
+		name          string
+		resourceRef   *autoscalingv1.CrossVersionObjectReference
+		getErr        error
+		expectedErr   error
+		isAPINotFound bool
+	}{
+		{"missing scale subresource should return ErrScaleSubresourceNotFound", deploymentRef, apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "kube-apiserver"), ErrScaleSubresourceNotFound, true},
+		{"timeout should return ErrTimeout", deploymentRef, apierrors.NewTimeoutError("request timed out", 1), ErrTimeout, false},
+		{"exceeded deadline should return ErrTimeout", deploymentRef, context.DeadlineExceeded, ErrTimeout, false},
+		{"unknown kind should return ErrMappingNotFound", unknownKindRef, nil, ErrMappingNotFound, false},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			scaleClient := &fakescale.FakeScaleClient{}
+			scaleClient.AddReactor("get", "*", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, entry.getErr
+			})
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+			cl := fake.NewClientBuilder().WithRESTMapper(mapper).Build()
+			_, _, err := GetScaleResource(context.Background(), cl, scaleClient.Scales("default"), logr.Discard(), entry.resourceRef, time.Second)
+			g.Expect(errors.Is(err, entry.expectedErr)).To(BeTrue(), "GetScaleResource should return %v but returned %v", entry.expectedErr, err)
+			g.Expect(apierrors.IsNotFound(err)).To(Equal(entry.isAPINotFound), "the original error should be retained")
+		})
+	}
+}
@@ -6,6 +6,7 @@ package util
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"strings"
 
@@ -15,6 +16,8 @@ import (
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/cert"
 )
 
 // Validator is a struct to store all validation errors.
@@ -61,6 +64,88 @@ func (v *Validator) MustNotBeZeroDuration(key string, duration metav1.Duration)
 	return true
 }
 
+// MustBePositiveDuration checks whether the given duration is greater than zero. It returns false if it is zero or negative.
+func (v *Validator) MustBePositiveDuration(key string, duration metav1.Duration) bool {
+	if duration.Duration <= 0 {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must be a positive duration", key))
+		return false
+	}
+	return true
+}
+
+// MustNotBeNegative checks whether the given value is negative. It returns false if it is negative.
+func (v *Validator) MustNotBeNegative(key string, value int) bool {
+	if value < 0 {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must not be negative", key))
+		return false
+	}
+	return true
+}
+
+// MustNotBeNegativeFloat checks whether the given floating point value is negative. It returns false if it is negative.
+func (v *Validator) MustNotBeNegativeFloat(key string, value float64) bool {
+	if value < 0 {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must not be negative", key))
+		return false
+	}
+	return true
+}
+
+// MustBeInRange checks whether the given value is greater than or equal to minValue and less than or equal to maxValue. It returns false otherwise.
+func (v *Validator) MustBeInRange(key string, value, minValue, maxValue int) bool {
+	if value < minValue || value > maxValue {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must be between %d and %d", key, minValue, maxValue))
+		return false
+	}
+	return true
+}
+
+// MustBeFraction checks whether the given value is greater than zero and less than or equal to one. It returns false otherwise.
+func (v *Validator) MustBeFraction(key string, value float64) bool {
+	if value <= 0 || value > 1 {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must be greater than 0 and less than or equal to 1", key))
+		return false
+	}
+	return true
+}
+
+// MustBeQualifiedName checks whether the given value is a valid qualified name, e.g. an annotation key. It returns false if it is not.
+func (v *Validator) MustBeQualifiedName(key string, value string) bool {
+	if errs := validation.IsQualifiedName(value); len(errs) > 0 {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must be a qualified name: %s", key, strings.Join(errs, "; ")))
+		return false
+	}
+	return true
+}
+
+// MustBeHTTPSURL checks whether the given value is an absolute URL with the https scheme. It returns false if it is not.
+func (v *Validator) MustBeHTTPSURL(key string, value string) bool {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must be an absolute URL with the https scheme", key))
+		return false
+	}
+	return true
+}
+
+// MustBeAbsolutePath checks whether the given value is an absolute URL path. It returns false if it is not.
+func (v *Validator) MustBeAbsolutePath(key string, value string) bool {
+	if !strings.HasPrefix(value, "/") {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must be an absolute path", key))
+		return false
+	}
+	return true
+}
+
+// MustBePEMEncodedCertificates checks whether the given value contains at least one PEM encoded certificate and nothing else. It returns false otherwise.
+func (v *Validator) MustBePEMEncodedCertificates(key string, value string) bool {
+	if _, err := cert.ParseCertsPEM([]byte(value)); err != nil {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("value for key %s must be PEM encoded certificates: %w", key, err))
+		return false
+	}
+	return true
+}
+
 // MustNotBeNil checks whether the given value is nil and returns false if it is nil.
 func (v *Validator) MustNotBeNil(key string, value interface{}) bool {
 	if value == nil || reflect.ValueOf(value).IsNil() {
@@ -15,6 +15,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/cert"
 )
 
 func TestMustNotBeEmpty(t *testing.T) {
@@ -65,6 +66,202 @@ func TestMustNotBeZeroDuration(t *testing.T) {
 	}
 }
 
+func TestMustBePositiveDuration(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  metav1.Duration
+		result bool
+	}{
+		{"k1", metav1.Duration{}, false},
+		{"k2", metav1.Duration{Duration: -100}, false},
+		{"k3", metav1.Duration{Duration: 100}, true},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustBePositiveDuration(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustNotBeNegative(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  int
+		result bool
+	}{
+		{"k1", -1, false},
+		{"k2", 0, true},
+		{"k3", 10, true},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustNotBeNegative(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustNotBeNegativeFloat(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  float64
+		result bool
+	}{
+		{"k1", -0.5, false},
+		{"k2", 0, true},
+		{"k3", 2.5, true},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustNotBeNegativeFloat(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustBeInRange(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  int
+		result bool
+	}{
+		{"k1", 0, false},
+		{"k2", 1, true},
+		{"k3", 3, true},
+		{"k4", 4, false},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustBeInRange(entry.key, entry.value, 1, 3)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustBeFraction(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  float64
+		result bool
+	}{
+		{"k1", -0.5, false},
+		{"k2", 0, false},
+		{"k3", 0.5, true},
+		{"k4", 1, true},
+		{"k5", 1.5, false},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustBeFraction(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustBeQualifiedName(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  string
+		result bool
+	}{
+		{"k1", "", false},
+		{"k2", "replicas", true},
+		{"k3", "argocd.argoproj.io/replicas", true},
+		{"k4", "invalid/prefix/replicas", false},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustBeQualifiedName(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustBeHTTPSURL(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  string
+		result bool
+	}{
+		{"k1", "", false},
+		{"k2", "https://api.shoot.example.com:443", true},
+		{"k3", "http://api.shoot.example.com", false},
+		{"k4", "api.shoot.example.com", false},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustBeHTTPSURL(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustBeAbsolutePath(t *testing.T) {
+	g := NewWithT(t)
+	tests := []struct {
+		key    string
+		value  string
+		result bool
+	}{
+		{"k1", "", false},
+		{"k2", "/readyz", true},
+		{"k3", "readyz", false},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustBeAbsolutePath(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
+func TestMustBePEMEncodedCertificates(t *testing.T) {
+	g := NewWithT(t)
+	certPEM, _, err := cert.GenerateSelfSignedCertKey("api.shoot.example.com", nil, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	tests := []struct {
+		key    string
+		value  string
+		result bool
+	}{
+		{"k1", "", false},
+		{"k2", string(certPEM), true},
+		{"k3", "not a certificate", false},
+	}
+	for _, entry := range tests {
+		v := Validator{}
+		actualResult := v.MustBePEMEncodedCertificates(entry.key, entry.value)
+		g.Expect(entry.result).To(Equal(actualResult))
+		if !actualResult {
+			g.Expect(v.Error).To(HaveOccurred())
+		}
+	}
+}
+
 func TestMustNotBeNil(t *testing.T) {
 	g := NewWithT(t)
 	var ch chan struct{}
@@ -0,0 +1,10 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package version provides the version of the dependency-watchdog binary.
+package version
+
+// Version is the version of the binary. It is set at build time via
+// -ldflags "-X github.com/gardener/dependency-watchdog/internal/version.Version=<version>".
+var Version = "unknown"
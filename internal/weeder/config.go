@@ -5,6 +5,8 @@
 package weeder
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	wapi "github.com/gardener/dependency-watchdog/api/weeder"
@@ -12,7 +14,12 @@ import (
 
 	multierr "github.com/hashicorp/go-multierror"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/pointer"
 )
 
 const (
@@ -20,10 +27,23 @@ const (
 	defaultWatchDuration = 5 * time.Minute
 )
 
+var supportedPodPhases = []corev1.PodPhase{corev1.PodPending, corev1.PodRunning, corev1.PodSucceeded, corev1.PodFailed, corev1.PodUnknown}
+
+var supportedWeedingStrategies = []wapi.WeedingStrategy{wapi.WeedingStrategyDeletePod, wapi.WeedingStrategyRolloutRestart, wapi.WeedingStrategyDeletePodAndRolloutRestart}
+
 // LoadConfig reads the weeder configuration from a file, unmarshalls it, fills in the default values and
 // validates the unmarshalled configuration. If all validations pass it will return papi.Config else it will return an error.
-func LoadConfig(filename string) (*wapi.Config, error) {
-	config, err := util.ReadAndUnmarshall[wapi.Config](filename)
+// If strict is true then any field in the file which is not known to wapi.Config will result in an error.
+func LoadConfig(filename string, strict bool) (*wapi.Config, error) {
+	var (
+		config *wapi.Config
+		err    error
+	)
+	if strict {
+		config, err = util.ReadAndUnmarshallStrict[wapi.Config](filename)
+	} else {
+		config, err = util.ReadAndUnmarshall[wapi.Config](filename)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -39,19 +59,122 @@ func validate(c *wapi.Config) error {
 	v := new(util.Validator)
 	// Check the mandatory config parameters for which a default will not be set
 	v.MustNotBeEmpty("serviceAndDependantSelectors", c.ServicesAndDependantSelectors)
-	for _, ds := range c.ServicesAndDependantSelectors {
+	v.MustBePositiveDuration("watchDuration", *c.WatchDuration)
+	if c.MaxPriorityClassName != nil {
+		v.MustNotBeEmpty("maxPriorityClassName", *c.MaxPriorityClassName)
+	}
+	for _, priorityClassName := range c.WeedablePriorityClassNames {
+		v.MustNotBeEmpty("weedablePriorityClassNames", priorityClassName)
+	}
+	for svc, ds := range c.ServicesAndDependantSelectors {
 		v.MustNotBeEmpty("podSelectors", ds.PodSelectors)
+		if ds.WatchDuration != nil {
+			v.MustBePositiveDuration(fmt.Sprintf("servicesAndDependantSelectors.%s.watchDuration", svc), *ds.WatchDuration)
+		}
+		if gracePeriod := getGracePeriod(c, ds); (c.GracePeriod != nil || ds.GracePeriod != nil) && (gracePeriod < 0 || gracePeriod >= getWatchDuration(c, ds)) {
+			v.Error = multierr.Append(v.Error, fmt.Errorf("servicesAndDependantSelectors.%s.gracePeriod: grace period %s must not be negative and must be shorter than the watch duration %s", svc, gracePeriod, getWatchDuration(c, ds)))
+		}
+		podSelectorRequirements := make([]sets.Set[string], 0, len(ds.PodSelectors))
 		for _, selector := range ds.PodSelectors {
-			_, err := metav1.LabelSelectorAsSelector(selector)
+			s, err := metav1.LabelSelectorAsSelector(selector)
 			if err != nil {
 				v.Error = multierr.Append(v.Error, err)
+				podSelectorRequirements = append(podSelectorRequirements, nil)
 				continue
 			}
+			podSelectorRequirements = append(podSelectorRequirements, getRequirements(s))
+		}
+		validatePodSelectorsDoNotOverlap(v, svc, podSelectorRequirements)
+		if ds.Namespace != nil {
+			validateNamespace(v, svc, *ds.Namespace, podSelectorRequirements)
+		}
+		for _, of := range ds.OwnerFilters {
+			v.MustNotBeEmpty("ownerFilters.kind", of.Kind)
+			v.MustNotBeEmpty("ownerFilters.name", of.Name)
+		}
+		if ds.Predicates != nil {
+			validatePredicates(v, svc, ds.Predicates)
+		}
+		if ds.WeedingStrategy != nil && !slices.Contains(supportedWeedingStrategies, *ds.WeedingStrategy) {
+			v.Error = multierr.Append(v.Error, fmt.Errorf("servicesAndDependantSelectors.%s.weedingStrategy: unsupported weeding strategy %q, supported strategies are %v", svc, *ds.WeedingStrategy, supportedWeedingStrategies))
 		}
 	}
 	return v.Error
 }
 
+// validatePodSelectorsDoNotOverlap rejects pod selectors of the same service of which one selects all pods which are selected by another one, i.e.
+// whose requirements are a subset of the requirements of the other one, e.g. two identical selectors. Every pod selector is watched by a pod
+// watcher of its own, so the pods selected by both selectors would be weeded twice. The requirements of invalid selectors are nil and skipped.
+func validatePodSelectorsDoNotOverlap(v *util.Validator, svc string, podSelectorRequirements []sets.Set[string]) {
+	for i, requirements := range podSelectorRequirements {
+		for j := i + 1; j < len(podSelectorRequirements); j++ {
+			other := podSelectorRequirements[j]
+			if requirements == nil || other == nil || (!requirements.IsSuperset(other) && !other.IsSuperset(requirements)) {
+				continue
+			}
+			v.Error = multierr.Append(v.Error, fmt.Errorf("servicesAndDependantSelectors.%s.podSelectors: selectors %d and %d overlap as all pods selected by one of them are selected by the other one, which would weed them twice", svc, i, j))
+		}
+	}
+}
+
+// validateNamespace validates the namespace of the dependant pods of a service, which has to be a valid namespace name. As the dependant pods
+// of a service in a different namespace are typically shared by many shoots, e.g. istio-ingress, a pod selector which selects all pods of the
+// namespace is rejected.
+func validateNamespace(v *util.Validator, svc, namespace string, podSelectorRequirements []sets.Set[string]) {
+	for _, msg := range validation.IsDNS1123Label(namespace) {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("servicesAndDependantSelectors.%s.namespace: invalid namespace %q: %s", svc, namespace, msg))
+	}
+	for i, requirements := range podSelectorRequirements {
+		if requirements != nil && requirements.Len() == 0 {
+			v.Error = multierr.Append(v.Error, fmt.Errorf("servicesAndDependantSelectors.%s.podSelectors: selector %d selects all pods of namespace %q", svc, i, namespace))
+		}
+	}
+}
+
+// DependantNamespaces returns the namespaces of the dependant pods which differ from the namespaces of their services, sorted by name.
+func DependantNamespaces(config *wapi.Config) []string {
+	namespaces := sets.New[string]()
+	for _, ds := range config.ServicesAndDependantSelectors {
+		if ds.Namespace != nil {
+			namespaces.Insert(*ds.Namespace)
+		}
+	}
+	return sets.List(namespaces)
+}
+
+// getDependantsNamespace returns the namespace of the dependant pods of a service, falling back to the namespace of the service.
+func getDependantsNamespace(namespace string, dependantSelectors wapi.DependantSelectors) string {
+	return pointer.StringDeref(dependantSelectors.Namespace, namespace)
+}
+
+// getRequirements returns the requirements of the given selector in their string representation. It returns an empty set for a selector which
+// selects everything and nil for a selector which selects nothing.
+func getRequirements(selector labels.Selector) sets.Set[string] {
+	requirements, selectable := selector.Requirements()
+	if !selectable {
+		return nil
+	}
+	requirementStrings := sets.New[string]()
+	for _, requirement := range requirements {
+		requirementStrings.Insert(requirement.String())
+	}
+	return requirementStrings
+}
+
+func validatePredicates(v *util.Validator, svc string, predicates *wapi.PodPredicates) {
+	for _, reason := range predicates.ContainerStateReasons {
+		v.MustNotBeEmpty(fmt.Sprintf("servicesAndDependantSelectors.%s.predicates.containerStateReasons", svc), reason)
+	}
+	if predicates.MinRestartCount != nil && *predicates.MinRestartCount < 0 {
+		v.Error = multierr.Append(v.Error, fmt.Errorf("servicesAndDependantSelectors.%s.predicates.minRestartCount: must not be negative, found %d", svc, *predicates.MinRestartCount))
+	}
+	for _, phase := range predicates.Phases {
+		if !slices.Contains(supportedPodPhases, phase) {
+			v.Error = multierr.Append(v.Error, fmt.Errorf("servicesAndDependantSelectors.%s.predicates.phases: unsupported pod phase %q, supported phases are %v", svc, phase, supportedPodPhases))
+		}
+	}
+}
+
 func fillDefaultValues(c *wapi.Config) {
 	if c.WatchDuration == nil {
 		c.WatchDuration = &metav1.Duration{
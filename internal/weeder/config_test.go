@@ -9,7 +9,9 @@ package weeder
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
+	wapi "github.com/gardener/dependency-watchdog/api/weeder"
 	testutil "github.com/gardener/dependency-watchdog/internal/test"
 	multierr "github.com/hashicorp/go-multierror"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,7 +23,7 @@ const testdataPath = "testdata"
 
 func TestConfigFileNotFound(t *testing.T) {
 	g := NewWithT(t)
-	config, err := LoadConfig(filepath.Join(testdataPath, "notfound.yaml"))
+	config, err := LoadConfig(filepath.Join(testdataPath, "notfound.yaml"), true)
 	g.Expect(err).To(HaveOccurred(), "LoadConfig should give error if config file is not found")
 	g.Expect(config).To(BeNil(), "LoadConfig should return a nil config if config file is not found")
 	g.Expect(err.Error()).To(ContainSubstring("no such file or directory"), "LoadConfig did not load all the dependent resources")
@@ -33,7 +35,7 @@ func TestCheckIfDefaultValuesAreSetForAllOptionalMissingValues(t *testing.T) {
 
 	configPath := filepath.Join(testdataPath, "config_missing_optional_values.yaml")
 	testutil.ValidateIfFileExists(configPath, t)
-	config, err := LoadConfig(configPath)
+	config, err := LoadConfig(configPath, true)
 
 	g.Expect(err).ToNot(HaveOccurred(), "LoadConfig should not give any error for a valid config file")
 	g.Expect(config).ToNot(BeNil(), "LoadConfig should not return nil for a valid config file")
@@ -48,6 +50,13 @@ func TestMissingMandatoryFieldsShouldReturnErrorAndNilConfig(t *testing.T) {
 	}{
 		{"config_missing_mandatory_values.yaml", 1},
 		{"config_missing_pod_selectors.yaml", 1},
+		{"config_missing_owner_filter_name.yaml", 1},
+		{"config_invalid_watch_duration.yaml", 2},
+		{"config_invalid_weeding_strategy.yaml", 1},
+		{"config_invalid_grace_period.yaml", 2},
+		{"config_invalid_predicates.yaml", 3},
+		{"config_overlapping_pod_selectors.yaml", 1},
+		{"config_invalid_namespace.yaml", 2},
 	}
 
 	for _, entry := range table {
@@ -55,7 +64,7 @@ func TestMissingMandatoryFieldsShouldReturnErrorAndNilConfig(t *testing.T) {
 		testutil.ValidateIfFileExists(testdataPath, t)
 		configPath := filepath.Join(testdataPath, entry.fileName)
 		testutil.ValidateIfFileExists(configPath, t)
-		config, err := LoadConfig(configPath)
+		config, err := LoadConfig(configPath, true)
 
 		g.Expect(err).To(HaveOccurred(), "LoadConfig should return error for a config with missing mandatory values")
 		g.Expect(config).To(BeNil(), "LoadConfig should return a nil config for a file with missing mandatory values")
@@ -72,10 +81,32 @@ func TestValidConfigShouldPassAllValidations(t *testing.T) {
 
 	configPath := filepath.Join(testdataPath, "valid_config.yaml")
 	testutil.ValidateIfFileExists(configPath, t)
-	config, err := LoadConfig(configPath)
+	config, err := LoadConfig(configPath, true)
 	g.Expect(err).ToNot(HaveOccurred(), "LoadConfig should not give error for a valid config")
 	g.Expect(config).ToNot(BeNil(), "LoadConfig should got nil config for a valid file")
 	g.Expect(config.ServicesAndDependantSelectors).To(HaveLen(2), "LoadConfig did not load all the dependent resources")
+	g.Expect(config.ServicesAndDependantSelectors["etcd-main-client"].OwnerFilters).To(ConsistOf(wapi.OwnerFilter{Kind: "Deployment", Name: "kube-apiserver"}), "LoadConfig did not load the owner filters")
+	g.Expect(config.ServicesAndDependantSelectors["kube-apiserver"].WatchDuration).To(Equal(&metav1.Duration{Duration: 3 * time.Minute}), "LoadConfig did not load the watchDuration override")
+	g.Expect(config.ServicesAndDependantSelectors["etcd-main-client"].WatchDuration).To(BeNil(), "LoadConfig should not default the watchDuration override")
+	g.Expect(config.ServicesAndDependantSelectors["kube-apiserver"].GracePeriod).To(Equal(&metav1.Duration{Duration: 30 * time.Second}), "LoadConfig did not load the gracePeriod override")
+	g.Expect(config.ServicesAndDependantSelectors["kube-apiserver"].Predicates.ContainerStateReasons).To(ConsistOf("CrashLoopBackOff", "CreateContainerConfigError"), "LoadConfig did not load the predicates")
+	g.Expect(config.ServicesAndDependantSelectors["kube-apiserver"].WeedingStrategy).To(HaveValue(Equal(wapi.WeedingStrategyRolloutRestart)), "LoadConfig did not load the weedingStrategy")
+	g.Expect(config.MaxPriorityClassName).To(HaveValue(Equal("gardener-system-500")), "LoadConfig did not load the maxPriorityClassName")
+	g.Expect(config.WeedablePriorityClassNames).To(ConsistOf("gardener-system-critical"), "LoadConfig did not load the weedablePriorityClassNames")
 
 	t.Log("Valid config is loaded correctly")
 }
+
+func TestUnknownFieldsShouldReturnErrorInStrictMode(t *testing.T) {
+	g := NewWithT(t)
+	configPath := filepath.Join(testdataPath, "config_with_unknown_fields.yaml")
+	testutil.ValidateIfFileExists(configPath, t)
+	config, err := LoadConfig(configPath, true)
+	g.Expect(err).To(HaveOccurred(), "LoadConfig should return error for a config with unknown fields in strict mode")
+	g.Expect(config).To(BeNil(), "LoadConfig should return a nil config for a config with unknown fields in strict mode")
+	g.Expect(err.Error()).To(ContainSubstring("unknown field \"watchDurations\""), "LoadConfig should report the unknown field")
+
+	config, err = LoadConfig(configPath, false)
+	g.Expect(err).ToNot(HaveOccurred(), "LoadConfig should ignore unknown fields in relaxed mode")
+	g.Expect(*config.WatchDuration).To(Equal(metav1.Duration{Duration: defaultWatchDuration}), "LoadConfig should use the default for a field which has been mis-typed")
+}
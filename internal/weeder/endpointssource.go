@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package weeder
+
+// EndpointsSource defines from which resources it is determined that a service under surveillance has recovered.
+type EndpointsSource string
+
+const (
+	// EndpointsSourceEndpoints determines the readiness of a service from its core/v1 Endpoints.
+	EndpointsSourceEndpoints EndpointsSource = "Endpoints"
+	// EndpointsSourceEndpointSlices determines the readiness of a service from its discovery/v1 EndpointSlices.
+	EndpointsSourceEndpointSlices EndpointsSource = "EndpointSlices"
+	// EndpointsSourceBoth watches core/v1 Endpoints as well as discovery/v1 EndpointSlices, e.g. during version transitions of a seed where some
+	// components still publish Endpoints only. The EndpointSlices of a service take precedence over its Endpoints if both exist.
+	EndpointsSourceBoth EndpointsSource = "Both"
+)
+
+// SupportedEndpointsSources are the supported values of EndpointsSource.
+var SupportedEndpointsSources = []EndpointsSource{EndpointsSourceEndpoints, EndpointsSourceEndpointSlices, EndpointsSourceBoth}
+
+// WatchesEndpoints returns true if core/v1 Endpoints are watched for the EndpointsSource.
+func (s EndpointsSource) WatchesEndpoints() bool {
+	return s != EndpointsSourceEndpointSlices
+}
+
+// WatchesEndpointSlices returns true if discovery/v1 EndpointSlices are watched for the EndpointsSource.
+func (s EndpointsSource) WatchesEndpointSlices() bool {
+	return s == EndpointsSourceEndpointSlices || s == EndpointsSourceBoth
+}
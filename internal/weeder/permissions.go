@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package weeder
+
+import (
+	wapi "github.com/gardener/dependency-watchdog/api/weeder"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	appsv1 "k8s.io/api/apps/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+)
+
+// RequiredSeedPermissions returns the permissions which the weeder requires in the seed for the given config and EndpointsSource. Reads are served
+// from an informer cache and therefore require list and watch in addition to get.
+func RequiredSeedPermissions(config *wapi.Config, endpointsSource EndpointsSource) []util.ResourcePermission {
+	var permissions []util.ResourcePermission
+	if endpointsSource.WatchesEndpoints() {
+		permissions = append(permissions, util.NewResourcePermissions("", "endpoints", "get", "list", "watch")...)
+	}
+	if endpointsSource.WatchesEndpointSlices() {
+		permissions = append(permissions, util.NewResourcePermissions(discoveryv1.GroupName, "endpointslices", "get", "list", "watch")...)
+	}
+	// the services backing the endpoints are watched to cancel weeders once a service has been deleted.
+	permissions = append(permissions, util.NewResourcePermissions("", "services", "get", "list", "watch")...)
+	permissions = append(permissions, util.NewResourcePermissions("", "pods", "get", "list", "watch", "delete")...)
+	if hasOwnerFilters(config) {
+		// the controllers of the pods are looked up to match them against the owner filters.
+		for _, resource := range []string{"replicasets", "deployments", "statefulsets"} {
+			permissions = append(permissions, util.NewResourcePermissions(appsv1.GroupName, resource, "get", "list", "watch")...)
+		}
+	}
+	if config.MaxPriorityClassName != nil {
+		// the max PriorityClass is looked up to compare its value against the priority of the pods.
+		permissions = append(permissions, util.NewResourcePermissions(schedulingv1.GroupName, "priorityclasses", "get", "list", "watch")...)
+	}
+	if hasRolloutRestarts(config) {
+		// the Deployments owning the pods are looked up via their ReplicaSets and restarted.
+		permissions = append(permissions, util.NewResourcePermissions(appsv1.GroupName, "replicasets", "get", "list", "watch")...)
+		permissions = append(permissions, util.NewResourcePermissions(appsv1.GroupName, "deployments", "patch")...)
+	}
+	return permissions
+}
+
+func hasOwnerFilters(config *wapi.Config) bool {
+	for _, dependantSelectors := range config.ServicesAndDependantSelectors {
+		if len(dependantSelectors.OwnerFilters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRolloutRestarts(config *wapi.Config) bool {
+	for _, dependantSelectors := range config.ServicesAndDependantSelectors {
+		if getWeedingStrategy(dependantSelectors) != wapi.WeedingStrategyDeletePod {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package weeder
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// maxPodNamesInSummary is the maximum number of pod names which are listed per outcome in the summary of a weeder run.
+	maxPodNamesInSummary = 10
+
+	skipReasonNotOwned                = "not controlled by a configured owner"
+	skipReasonPriority                = "protected by priority"
+	skipReasonDryRun                  = "dry-run mode"
+	skipReasonRecovered               = "recovered within the grace period"
+	skipReasonRecoveredBeforeDeletion = "recovered before deletion"
+)
+
+// weedingSummary records the outcome for the dependant pods which have been considered for weeding during a run of a weeder. It is shared by all
+// pod watchers of a weeder. As a pod is considered on every event, the outcomes are recorded per pod name.
+type weedingSummary struct {
+	sync.Mutex
+	weeded sets.Set[string]
+	// skipped are the names of the pods which have not been weeded per reason.
+	skipped map[string]sets.Set[string]
+}
+
+func newWeedingSummary() *weedingSummary {
+	return &weedingSummary{
+		weeded:  sets.New[string](),
+		skipped: make(map[string]sets.Set[string]),
+	}
+}
+
+// recordWeeded records that the pod has been weeded. A pod which has been skipped before is no longer reported as skipped.
+func (s *weedingSummary) recordWeeded(podName string) {
+	s.Lock()
+	defer s.Unlock()
+	s.weeded.Insert(podName)
+	for _, podNames := range s.skipped {
+		podNames.Delete(podName)
+	}
+}
+
+// recordSkipped records that the pod has not been weeded for the given reason, unless it has already been weeded.
+func (s *weedingSummary) recordSkipped(podName, reason string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.weeded.Has(podName) {
+		return
+	}
+	if _, ok := s.skipped[reason]; !ok {
+		s.skipped[reason] = sets.New[string]()
+	}
+	s.skipped[reason].Insert(podName)
+}
+
+// isEmpty checks if any pod has been weeded or skipped.
+func (s *weedingSummary) isEmpty() bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.weeded.Len() > 0 {
+		return false
+	}
+	for _, podNames := range s.skipped {
+		if podNames.Len() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a human-readable summary which lists how many and which pods have been weeded and skipped, the latter per reason.
+func (s *weedingSummary) String() string {
+	s.Lock()
+	defer s.Unlock()
+	summary := fmt.Sprintf("Weeded %d pod(s)", s.weeded.Len())
+	if s.weeded.Len() > 0 {
+		summary += ": " + formatPodNames(s.weeded)
+	}
+	var skipped []string
+	skippedCount := 0
+	for _, reason := range slices.Sorted(maps.Keys(s.skipped)) {
+		if podNames := s.skipped[reason]; podNames.Len() > 0 {
+			skippedCount += podNames.Len()
+			skipped = append(skipped, fmt.Sprintf("%d %s (%s)", podNames.Len(), reason, formatPodNames(podNames)))
+		}
+	}
+	if skippedCount > 0 {
+		summary += fmt.Sprintf(". Skipped %d pod(s): %s", skippedCount, strings.Join(skipped, ", "))
+	}
+	return summary
+}
+
+// formatPodNames lists the sorted pod names, of which at most maxPodNamesInSummary are named.
+func formatPodNames(podNames sets.Set[string]) string {
+	names := sets.List(podNames)
+	if len(names) <= maxPodNamesInSummary {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxPodNamesInSummary], ", "), len(names)-maxPodNamesInSummary)
+}
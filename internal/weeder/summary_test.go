@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package weeder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+	testclock "k8s.io/utils/clock/testing"
+)
+
+func TestWeedingSummaryShouldListWeededAndSkippedPods(t *testing.T) {
+	g := NewWithT(t)
+	s := newWeedingSummary()
+	g.Expect(s.isEmpty()).To(BeTrue())
+
+	s.recordSkipped("kcm-1", skipReasonRecovered)
+	s.recordSkipped("kcm-2", skipReasonRecovered)
+	s.recordSkipped("kcm-2", skipReasonRecovered)
+	s.recordSkipped("scheduler-1", skipReasonNotOwned)
+	s.recordSkipped("kcm-3", skipReasonDryRun)
+	s.recordWeeded("kcm-3")
+	s.recordWeeded("kcm-4")
+	s.recordSkipped("kcm-4", skipReasonPriority)
+
+	g.Expect(s.isEmpty()).To(BeFalse())
+	g.Expect(s.String()).To(Equal("Weeded 2 pod(s): kcm-3, kcm-4. Skipped 3 pod(s): 1 not controlled by a configured owner (scheduler-1), 2 recovered within the grace period (kcm-1, kcm-2)"))
+}
+
+func TestWeedingSummaryShouldLimitListedPodNames(t *testing.T) {
+	g := NewWithT(t)
+	s := newWeedingSummary()
+	for i := 0; i < maxPodNamesInSummary+2; i++ {
+		s.recordWeeded(fmt.Sprintf("pod-%02d", i))
+	}
+	g.Expect(s.String()).To(Equal("Weeded 12 pod(s): pod-00, pod-01, pod-02, pod-03, pod-04, pod-05, pod-06, pod-07, pod-08, pod-09 and 2 more"))
+}
+
+func TestSummaryEventShouldOnlyBeRecordedOnceTheWatchDurationHasExpired(t *testing.T) {
+	tests := []struct {
+		name          string
+		cancel        bool
+		weeded        bool
+		expectedEvent bool
+	}{
+		{"summary event should be recorded once the watch duration has expired", false, true, true},
+		{"summary event should not be recorded if the weeder has been cancelled", true, true, false},
+		{"summary event should not be recorded if no pod has been considered for weeding", false, false, false},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			recorder := record.NewFakeRecorder(10)
+			fakeClock := testclock.NewFakeClock(time.Now())
+			w := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, recorder, logr.Discard(), WithClock(fakeClock))
+			if entry.weeded {
+				w.summary.recordWeeded("kcm-1")
+			}
+			go w.recordSummaryOnExpiry()
+			if entry.cancel {
+				w.cancelFn()
+			}
+			fakeClock.Step(testWeederConfig.WatchDuration.Duration)
+			if entry.expectedEvent {
+				g.Eventually(recorder.Events).Should(Receive(Equal("Normal WeedingSummary Weeded 1 pod(s): kcm-1 within the watch duration")))
+				return
+			}
+			g.Consistently(recorder.Events, 200*time.Millisecond).ShouldNot(Receive())
+		})
+	}
+}
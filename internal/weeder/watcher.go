@@ -9,10 +9,13 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/gardener/dependency-watchdog/internal/util"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/pkg/retry"
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,7 +23,8 @@ import (
 
 const watchCreationRetryInterval = 500 * time.Millisecond
 
-type podEventHandler func(ctx context.Context, log logr.Logger, crClient client.Client, targetPod *v1.Pod) error
+// podEventHandler handles an event of a watched pod. The logger of the weeder, which carries its correlation ID, can be taken from the context.
+type podEventHandler func(ctx context.Context, crClient client.Client, targetPod *v1.Pod) error
 
 // podWatcher watches a pod for status changes
 type podWatcher struct {
@@ -50,7 +54,7 @@ func (pw *podWatcher) close() {
 func (pw *podWatcher) watch() {
 	defer pw.close()
 	pw.createK8sWatch(pw.weeder.ctx)
-	pw.log.Info("Watching for pods in CrashLoopBackoff")
+	pw.log.Info("Watching for pods in CrashLoopBackoff", "namespace", pw.weeder.podNamespace)
 	for {
 		select {
 		case <-pw.weeder.ctx.Done():
@@ -58,45 +62,67 @@ func (pw *podWatcher) watch() {
 			return
 		case event, ok := <-pw.k8sWatch.ResultChan():
 			if !ok {
-				pw.log.V(3).Info("Watch has stopped, recreating kubernetes watch", "namespace", pw.weeder.namespace, "endpoint", pw.weeder.endpoints.Name, "selector", pw.selector.String())
-				pw.createK8sWatch(pw.weeder.ctx)
+				pw.recreateK8sWatch(metrics.ReasonWatchClosed, "watch has been closed, e.g. as its timeout has expired")
+				continue
+			}
+			if event.Type == watch.Error {
+				pw.recreateK8sWatch(metrics.ReasonWatchError, apierrors.FromObject(event.Object).Error())
 				continue
 			}
 			if !canProcessEvent(event) {
 				continue
 			}
 			targetPod := event.Object.(*v1.Pod)
-			if err := pw.eventHandlerFn(pw.weeder.ctx, pw.log, pw.weeder.ctrlClient, targetPod); err != nil {
-				pw.log.Error(err, "Error processing pod", "namespace", pw.weeder.namespace, "podName", targetPod.Name)
+			if err := pw.eventHandlerFn(pw.weeder.ctx, pw.weeder.ctrlClient, targetPod); err != nil {
+				pw.log.Error(err, "Error processing pod", "namespace", targetPod.Namespace, "podName", targetPod.Name)
 			}
 		}
 	}
 }
 
+// recreateK8sWatch stops the current kubernetes watch and creates a new one. Watches are regularly closed by the API server, but excessive
+// recreations, e.g. due to a low min-request-timeout of the API server, should be visible, which is why they are counted per reason.
+func (pw *podWatcher) recreateK8sWatch(reason, message string) {
+	metrics.WeederWatchRecreationsTotal.WithLabelValues(reason).Inc()
+	pw.log.Info("Recreating kubernetes watch", "namespace", pw.weeder.namespace, "endpoint", pw.weeder.endpoints.Name, "selector", pw.selector.String(), "reason", reason, "message", message)
+	pw.close()
+	pw.createK8sWatch(pw.weeder.ctx)
+}
+
 func (pw *podWatcher) createK8sWatch(ctx context.Context) {
-	operation := fmt.Sprintf("Creating kubernetes watch for namespace %s, service %s with selector %s", pw.weeder.namespace, pw.weeder.endpoints.Name, pw.selector)
-	util.RetryOnError(ctx, pw.log, operation, func() error {
-		w, err := doCreateK8sWatch(ctx, pw.weeder.watchClient, pw.weeder.namespace, pw.selector)
+	operation := fmt.Sprintf("Creating kubernetes watch for namespace %s, service %s in namespace %s with selector %s", pw.weeder.podNamespace, pw.weeder.endpoints.Name, pw.weeder.namespace, pw.selector)
+	retry.RetryOnError(ctx, pw.log, operation, func() error {
+		w, err := doCreateK8sWatch(ctx, pw.weeder.podWatchSource, pw.weeder.podNamespace, pw.selector)
 		if err != nil {
 			return err
 		}
 		pw.k8sWatch = w
 		return nil
-	}, watchCreationRetryInterval)
+	}, retry.ConstantBackoff(watchCreationRetryInterval))
 }
 
-func doCreateK8sWatch(ctx context.Context, client kubernetes.Interface, namespace string, lSelector *metav1.LabelSelector) (watch.Interface, error) {
+func doCreateK8sWatch(ctx context.Context, source podWatchSource, namespace string, lSelector *metav1.LabelSelector) (watch.Interface, error) {
 	selector, err := metav1.LabelSelectorAsSelector(lSelector)
 	if err != nil {
 		return nil, err
 	}
-	w, err := client.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{
-		LabelSelector: selector.String(),
-	})
-	if err != nil {
-		return nil, err
-	}
-	return w, nil
+	return source.watchPods(ctx, namespace, selector)
+}
+
+// podWatchSource creates the watches of the pods for the pod watchers of a weeder. It decouples the pod watchers from the API server, so that
+// the events of the watched pods can be fed by a fake in tests.
+type podWatchSource interface {
+	// watchPods watches the pods in the given namespace which match the given selector.
+	watchPods(ctx context.Context, namespace string, selector labels.Selector) (watch.Interface, error)
+}
+
+// clientSetPodWatchSource is the podWatchSource which watches the pods via the API server.
+type clientSetPodWatchSource struct {
+	client kubernetes.Interface
+}
+
+func (s clientSetPodWatchSource) watchPods(ctx context.Context, namespace string, selector labels.Selector) (watch.Interface, error) {
+	return s.client.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{LabelSelector: selector.String()})
 }
 
 func canProcessEvent(ev watch.Event) bool {
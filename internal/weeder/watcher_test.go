@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package weeder
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	wapi "github.com/gardener/dependency-watchdog/api/weeder"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	weederapi "github.com/gardener/dependency-watchdog/pkg/weeder/api"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	testclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPodWatcherShouldRecreateWatchAndCountRecreations(t *testing.T) {
+	g := NewWithT(t)
+	var mu sync.Mutex
+	var fakeWatches []*watch.FakeWatcher
+	seedClient := fake.NewSimpleClientset()
+	seedClient.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		w := watch.NewFake()
+		fakeWatches = append(fakeWatches, w)
+		return true, w, nil
+	})
+	getWatches := func() []*watch.FakeWatcher {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*watch.FakeWatcher(nil), fakeWatches...)
+	}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"role": "dependant"}}
+	config := &wapi.Config{
+		WatchDuration: &metav1.Duration{Duration: time.Minute},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{
+			"etcd-main-client": {PodSelectors: []*metav1.LabelSelector{selector}},
+		},
+	}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: "test"}}
+	w := NewWeeder(ctx, "test", config, nil, seedClient, ep, nil, nil, logr.Discard())
+	closedBefore := testutil.ToFloat64(metrics.WeederWatchRecreationsTotal.WithLabelValues(metrics.ReasonWatchClosed))
+	erroredBefore := testutil.ToFloat64(metrics.WeederWatchRecreationsTotal.WithLabelValues(metrics.ReasonWatchError))
+
+	go newPodWatcher(w, selector, w.shootPodIfNecessary).watch()
+	g.Eventually(getWatches).Should(HaveLen(1))
+
+	getWatches()[0].Stop()
+	g.Eventually(getWatches).Should(HaveLen(2), "a closed watch should be recreated")
+	g.Expect(testutil.ToFloat64(metrics.WeederWatchRecreationsTotal.WithLabelValues(metrics.ReasonWatchClosed))).To(Equal(closedBefore + 1))
+
+	getWatches()[1].Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+	g.Eventually(getWatches).Should(HaveLen(3), "a watch which has received an error should be recreated")
+	g.Expect(testutil.ToFloat64(metrics.WeederWatchRecreationsTotal.WithLabelValues(metrics.ReasonWatchError))).To(Equal(erroredBefore + 1))
+	g.Expect(getWatches()[1].IsStopped()).To(BeTrue(), "a watch which has received an error should be stopped before it is recreated")
+}
+
+func TestPodWatcherShouldOnlyWeedCrashLoopingPodsMatchingTheSelector(t *testing.T) {
+	g := NewWithT(t)
+	crashLoopBackOff := v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+	healthyPod, crashingPod, crashingPodOfOtherComponent := createPod("healthy"), createPod("crashing"), createPod("crashing-other-component")
+	healthyPod.Labels, crashingPod.Labels, crashingPodOfOtherComponent.Labels = map[string]string{"role": "dependant"}, map[string]string{"role": "dependant"}, map[string]string{"role": "other"}
+	crashingPod.Status, crashingPodOfOtherComponent.Status = crashLoopBackOff, crashLoopBackOff
+	crClient := fakeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(healthyPod, crashingPod, crashingPodOfOtherComponent).Build()
+	source := &fakePodWatchSource{}
+	config := &wapi.Config{
+		WatchDuration: &metav1.Duration{Duration: time.Minute},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{
+			"etcd-main-client": {PodSelectors: []*metav1.LabelSelector{{MatchLabels: map[string]string{"role": "dependant"}}}},
+		},
+	}
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+	w := NewWeeder(context.Background(), namespace, config, crClient, nil, ep, nil, nil, logr.Discard(), withPodWatchSource(source))
+	defer w.cancelFn()
+	w.Start()
+	g.Eventually(source.getWatches).Should(HaveLen(1))
+
+	// the events of a watch are processed in order, once the last pod has been weeded all previous events have been processed
+	source.emit(watch.Modified, healthyPod)
+	source.emit(watch.Modified, crashingPodOfOtherComponent)
+	source.emit(watch.Deleted, crashingPod)
+	source.emit(watch.Modified, crashingPod)
+	g.Eventually(func() bool {
+		return apierrors.IsNotFound(crClient.Get(context.Background(), client.ObjectKeyFromObject(crashingPod), &v1.Pod{}))
+	}).Should(BeTrue(), "crashing pod matching the selector should be weeded")
+	g.Expect(crClient.Get(context.Background(), client.ObjectKeyFromObject(healthyPod), &v1.Pod{})).To(Succeed(), "healthy pod should not be weeded")
+	g.Expect(crClient.Get(context.Background(), client.ObjectKeyFromObject(crashingPodOfOtherComponent), &v1.Pod{})).To(Succeed(), "pod not matching the selector should not be weeded")
+}
+
+func TestPodWatcherShouldWeedCrashLoopingPodsInDependantsNamespace(t *testing.T) {
+	g := NewWithT(t)
+	crashLoopBackOff := v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+	sharedPod, podInServiceNamespace := createPod("istio-ingressgateway"), createPod("crashing")
+	sharedPod.Namespace = "istio-ingress"
+	sharedPod.Labels, podInServiceNamespace.Labels = map[string]string{"app": "istio-ingressgateway"}, map[string]string{"app": "istio-ingressgateway"}
+	sharedPod.Status, podInServiceNamespace.Status = crashLoopBackOff, crashLoopBackOff
+	crClient := fakeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(sharedPod, podInServiceNamespace).Build()
+	source := &fakePodWatchSource{}
+	config := &wapi.Config{
+		WatchDuration: &metav1.Duration{Duration: time.Minute},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{
+			"kube-apiserver": {Namespace: pointer.String("istio-ingress"), PodSelectors: []*metav1.LabelSelector{{MatchLabels: map[string]string{"app": "istio-ingressgateway"}}}},
+		},
+	}
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver", Namespace: namespace}}
+	w := NewWeeder(context.Background(), namespace, config, crClient, nil, ep, nil, nil, logr.Discard(), withPodWatchSource(source))
+	defer w.cancelFn()
+	w.Start()
+	g.Eventually(source.getWatches).Should(HaveLen(1))
+	g.Expect(source.getWatches()[0].namespace).To(Equal("istio-ingress"), "the pods should be watched in the namespace of the dependants")
+
+	source.emit(watch.Modified, podInServiceNamespace)
+	source.emit(watch.Modified, sharedPod)
+	g.Eventually(func() bool {
+		return apierrors.IsNotFound(crClient.Get(context.Background(), client.ObjectKeyFromObject(sharedPod), &v1.Pod{}))
+	}).Should(BeTrue(), "crashing pod in the namespace of the dependants should be weeded")
+	g.Expect(crClient.Get(context.Background(), client.ObjectKeyFromObject(podInServiceNamespace), &v1.Pod{})).To(Succeed(), "pod in the namespace of the service should not be weeded")
+}
+
+func TestPodWatcherShouldStopWatchOnceWeederHasEnded(t *testing.T) {
+	g := NewWithT(t)
+	source := &fakePodWatchSource{}
+	w := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard(), withPodWatchSource(source))
+	w.Start()
+	g.Eventually(source.getWatches).Should(HaveLen(1))
+	g.Expect(source.getWatches()[0].IsStopped()).To(BeFalse())
+
+	w.cancelFn()
+	g.Eventually(func() bool { return source.getWatches()[0].IsStopped() }).Should(BeTrue(), "the watch should be stopped once the weeder has ended")
+	g.Expect(source.getWatches()).To(HaveLen(1), "no watch should be created once the weeder has ended")
+}
+
+func TestPodWatcherShouldWeedPodsViaRecreatedWatchBeforeWatchDurationHasExpired(t *testing.T) {
+	g := NewWithT(t)
+	pod := createPod("crashing")
+	pod.Labels = map[string]string{"role": "dependant"}
+	pod.Status = v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+	crClient := fakeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(pod).Build()
+	source := &fakePodWatchSource{}
+	config := &wapi.Config{
+		WatchDuration: &metav1.Duration{Duration: time.Minute},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{
+			"etcd-main-client": {PodSelectors: []*metav1.LabelSelector{{MatchLabels: pod.Labels}}},
+		},
+	}
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+	fakeClock := testclock.NewFakeClock(time.Now())
+	w := NewWeeder(context.Background(), namespace, config, crClient, nil, ep, nil, nil, logr.Discard(), withPodWatchSource(source), WithClock(fakeClock))
+	defer w.cancelFn()
+	w.Start()
+	g.Eventually(source.getWatches).Should(HaveLen(1))
+
+	// the API server ends a watch once its request timeout has elapsed, which is usually shorter than the watch duration
+	fakeClock.Step(config.WatchDuration.Duration / 2)
+	source.getWatches()[0].Stop()
+	g.Eventually(source.getWatches).Should(HaveLen(2), "a watch which has been closed by the API server should be recreated")
+
+	source.emit(watch.Modified, pod)
+	g.Eventually(func() bool {
+		return apierrors.IsNotFound(crClient.Get(context.Background(), client.ObjectKeyFromObject(pod), &v1.Pod{}))
+	}).Should(BeTrue(), "crashing pod should be weeded via the recreated watch")
+}
+
+// fakePodWatchSource is a podWatchSource which feeds the events emitted via emit to the watches whose namespace and selector match the pod, like
+// the API server would do.
+type fakePodWatchSource struct {
+	mu      sync.Mutex
+	watches []*fakePodWatch
+}
+
+type fakePodWatch struct {
+	*watch.FakeWatcher
+	namespace string
+	selector  labels.Selector
+}
+
+func (s *fakePodWatchSource) watchPods(_ context.Context, namespace string, selector labels.Selector) (watch.Interface, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := &fakePodWatch{FakeWatcher: watch.NewFakeWithChanSize(10, false), namespace: namespace, selector: selector}
+	s.watches = append(s.watches, w)
+	return w, nil
+}
+
+// emit sends an event for the pod to all watches which have not been stopped and whose namespace and selector match the pod.
+func (s *fakePodWatchSource) emit(eventType watch.EventType, pod *v1.Pod) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.watches {
+		if !w.IsStopped() && w.namespace == pod.Namespace && w.selector.Matches(labels.Set(pod.Labels)) {
+			w.Action(eventType, pod.DeepCopy())
+		}
+	}
+}
+
+func (s *fakePodWatchSource) getWatches() []*fakePodWatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*fakePodWatch(nil), s.watches...)
+}
@@ -6,81 +6,486 @@ package weeder
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
 
 	wapi "github.com/gardener/dependency-watchdog/api/weeder"
+	"github.com/gardener/dependency-watchdog/internal/lifecycle"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	weederapi "github.com/gardener/dependency-watchdog/pkg/weeder/api"
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const crashLoopBackOff = "CrashLoopBackOff"
+const (
+	// maxOwnerChainDepth is the maximum number of controllers that are traversed upwards from a pod while matching owner filters.
+	maxOwnerChainDepth = 3
+	// restartedAtAnnotationKey is the key of the pod template annotation which is set to trigger a rollout restart of a Deployment. It is the same
+	// annotation which is set by `kubectl rollout restart`.
+	restartedAtAnnotationKey = "kubectl.kubernetes.io/restartedAt"
+	// eventReasonWeedingSummary is the reason of the event which summarizes the outcome of a weeder run once its watch duration has expired.
+	eventReasonWeedingSummary = "WeedingSummary"
+)
 
 // Weeder represents an actor which will be responsible for watching dependent pods and weeding them out if they
 // are in CrashLoopBackOff.
 type Weeder struct {
-	namespace          string
-	endpoints          *v1.Endpoints
-	ctrlClient         client.Client
-	watchClient        kubernetes.Interface
+	namespace string
+	// podNamespace is the namespace of the dependant pods. It is the namespace of the service unless the dependantSelectors specify another one.
+	podNamespace string
+	endpoints    *v1.Endpoints
+	ctrlClient   client.Client
+	// podWatchSource creates the watches of the pod watchers, it defaults to watching the pods via the seed client.
+	podWatchSource     podWatchSource
 	dependantSelectors wapi.DependantSelectors
-	ctx                context.Context
-	cancelFn           context.CancelFunc
-	logger             logr.Logger
+	// isUnhealthy is compiled from the predicates of the dependantSelectors and decides which dependant pods are weeded.
+	isUnhealthy weederapi.PodPredicate
+	ctx         context.Context
+	cancelFn    context.CancelFunc
+	logger      logr.Logger
+	// restartedDeployments are the Deployments which have already been restarted by this weeder. Each Deployment is restarted
+	// at most once per weeder as all of its pods are replaced by a single rollout.
+	restartedDeployments *restartedDeployments
+	// podWatchers run one pod watcher per PodSelector of the dependantSelectors.
+	podWatchers []*lifecycle.Subsystem
+	// configHash is the hash of the config the weeder has been created with.
+	configHash string
+	// createdAt is the time at which the weeder has been created.
+	createdAt time.Time
+	// gracePeriodEnd is the time until which dependant pods in CrashLoopBackOff are not weeded.
+	gracePeriodEnd time.Time
+	// deferredPods are the pods whose weeding has been deferred until the end of the grace period.
+	deferredPods *deferredPods
+	// runtimeOverrides are checked before a pod is weeded, no pod is weeded while the dry-run mode is enabled.
+	runtimeOverrides *overrides.Overrides
+	// maxPriorityClassName is the name of the PriorityClass whose value is the highest priority of dependant pods which are weeded. It is empty
+	// if dependant pods of any priority are weeded.
+	maxPriorityClassName string
+	// weedablePriorityClassNames are the PriorityClasses of dependant pods which are weeded regardless of the maxPriorityClassName.
+	weedablePriorityClassNames []string
+	// correlationID uniquely identifies a run of a weeder. It is added to every log line of the weeder so that the logs of concurrent weeders
+	// for the same namespace can be told apart.
+	correlationID string
+	// summary records the outcome for the dependant pods which have been considered for weeding. It is recorded as an event once the watch
+	// duration has expired.
+	summary *weedingSummary
+	// recorder is used to record the summary event. It is optional and can be nil.
+	recorder record.EventRecorder
+	// clock is used for the watch duration, the grace period and the rollout restarts of the weeder. It defaults to the real clock.
+	clock clock.Clock
+}
+
+// deferredPods records the pods whose weeding has been deferred until the end of the grace period. It is shared by all pod watchers of a weeder.
+type deferredPods struct {
+	sync.Mutex
+	keys sets.Set[types.NamespacedName]
+}
+
+// restartedDeployments records the names of the Deployments which have been restarted. It is shared by all pod watchers of a weeder.
+type restartedDeployments struct {
+	sync.Mutex
+	names sets.Set[string]
+}
+
+// weederOption customizes a Weeder, e.g. to replace its dependencies in tests.
+type weederOption func(w *Weeder)
+
+// withPodWatchSource sets the podWatchSource via which the pod watchers of the Weeder watch the dependant pods instead of the seed client.
+func withPodWatchSource(source podWatchSource) weederOption {
+	return func(w *Weeder) {
+		w.podWatchSource = source
+	}
+}
+
+// WithClock sets the clock which the Weeder uses to measure its watch duration and grace period instead of the real clock.
+func WithClock(clock clock.Clock) weederOption {
+	return func(w *Weeder) {
+		w.clock = clock
+	}
 }
 
-// NewWeeder creates a new Weeder for a service/endpoint.
-func NewWeeder(parentCtx context.Context, namespace string, config *wapi.Config, ctrlClient client.Client, seedClient kubernetes.Interface, ep *v1.Endpoints, logger logr.Logger) *Weeder {
-	wLogger := logger.WithValues("weederRunning", true, "watchDuration", (*config.WatchDuration).String())
-	ctx, cancelFn := context.WithTimeout(parentCtx, config.WatchDuration.Duration)
+// NewWeeder creates a new Weeder for a service/endpoint. The runtimeOverrides and the recorder are optional and can be nil.
+func NewWeeder(parentCtx context.Context, namespace string, config *wapi.Config, ctrlClient client.Client, seedClient kubernetes.Interface, ep *v1.Endpoints, runtimeOverrides *overrides.Overrides, recorder record.EventRecorder, logger logr.Logger, options ...weederOption) *Weeder {
 	dependantSelectors := config.ServicesAndDependantSelectors[ep.Name]
-	return &Weeder{
-		namespace:          namespace,
-		endpoints:          ep,
-		ctrlClient:         ctrlClient,
-		watchClient:        seedClient,
-		dependantSelectors: dependantSelectors,
-		ctx:                ctx,
-		cancelFn:           cancelFn,
-		logger:             wLogger,
+	watchDuration := getWatchDuration(config, dependantSelectors)
+	correlationID := string(uuid.NewUUID())
+	wLogger := logger.WithValues("weederRunning", true, "watchDuration", watchDuration.String(), "correlationID", correlationID)
+	ctx, cancelCauseFn := context.WithCancelCause(logr.NewContext(parentCtx, wLogger))
+	w := &Weeder{
+		namespace:                  namespace,
+		podNamespace:               getDependantsNamespace(namespace, dependantSelectors),
+		endpoints:                  ep,
+		ctrlClient:                 ctrlClient,
+		podWatchSource:             clientSetPodWatchSource{client: seedClient},
+		dependantSelectors:         dependantSelectors,
+		isUnhealthy:                getUnhealthyPodPredicate(dependantSelectors),
+		ctx:                        ctx,
+		cancelFn:                   func() { cancelCauseFn(context.Canceled) },
+		logger:                     wLogger,
+		restartedDeployments:       &restartedDeployments{names: sets.New[string]()},
+		configHash:                 util.ComputeConfigHash(config),
+		deferredPods:               &deferredPods{keys: sets.New[types.NamespacedName]()},
+		runtimeOverrides:           runtimeOverrides,
+		maxPriorityClassName:       pointer.StringDeref(config.MaxPriorityClassName, ""),
+		weedablePriorityClassNames: config.WeedablePriorityClassNames,
+		correlationID:              correlationID,
+		summary:                    newWeedingSummary(),
+		recorder:                   recorder,
+		clock:                      clock.RealClock{},
 	}
+	for _, opt := range options {
+		opt(w)
+	}
+	w.createdAt = w.clock.Now()
+	w.gracePeriodEnd = w.createdAt.Add(getGracePeriod(config, dependantSelectors))
+	w.expireAfter(watchDuration, cancelCauseFn)
+	for _, ps := range dependantSelectors.PodSelectors {
+		pw := newPodWatcher(w, ps, w.shootPodIfNecessary)
+		w.podWatchers = append(w.podWatchers, lifecycle.New(ctx, "pod-watcher", func(_ context.Context) { pw.watch() }, wLogger.WithValues("selector", ps.String())))
+	}
+	wLogger.Info("Created weeder", "configHash", w.configHash)
+	return w
 }
 
-// Run runs the Weeder which will intern create one go-routine for dependents identified by respective PodSelector.
-func (w *Weeder) Run() {
-	for _, ps := range w.dependantSelectors.PodSelectors {
-		go newPodWatcher(w, ps, shootPodIfNecessary).watch()
+// expireAfter cancels the context of the weeder with context.DeadlineExceeded as its cause once the watch duration has elapsed on the clock of the
+// weeder. The timer is created before this function returns, so that a fake clock which is stepped afterwards expires the weeder.
+func (w *Weeder) expireAfter(watchDuration time.Duration, cancelCauseFn context.CancelCauseFunc) {
+	timer := w.clock.NewTimer(watchDuration)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			cancelCauseFn(context.DeadlineExceeded)
+		case <-w.ctx.Done():
+		}
+	}()
+}
+
+// hasExpired checks if the context of a weeder has been cancelled because its watch duration has expired.
+func hasExpired(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), context.DeadlineExceeded)
+}
+
+// getWatchDuration returns the watch duration configured for the dependants of a service, falling back to the global watch duration.
+func getWatchDuration(config *wapi.Config, dependantSelectors wapi.DependantSelectors) time.Duration {
+	if dependantSelectors.WatchDuration != nil {
+		return dependantSelectors.WatchDuration.Duration
+	}
+	return config.WatchDuration.Duration
+}
+
+// getGracePeriod returns the grace period configured for the dependants of a service, falling back to the global grace period. If neither is
+// configured then there is no grace period.
+func getGracePeriod(config *wapi.Config, dependantSelectors wapi.DependantSelectors) time.Duration {
+	if dependantSelectors.GracePeriod != nil {
+		return dependantSelectors.GracePeriod.Duration
 	}
+	if config.GracePeriod != nil {
+		return config.GracePeriod.Duration
+	}
+	return 0
+}
+
+// Start starts the Weeder which will intern start one pod watcher for dependents identified by respective PodSelector. Each pod watcher is run
+// as a lifecycle.Subsystem, which restarts it after a backoff should it panic. Once the watch duration has expired, a summary of the weeder run
+// is recorded as an event.
+func (w *Weeder) Start() {
+	for _, pw := range w.podWatchers {
+		pw.Start()
+	}
+	go w.recordSummaryOnExpiry()
+}
+
+// recordSummaryOnExpiry records a single event for the endpoints which summarizes how many and which dependant pods have been weeded and skipped
+// during the run of the weeder, once its watch duration has expired. No event is recorded if the weeder has been cancelled or if no dependant pod
+// has been considered for weeding.
+func (w *Weeder) recordSummaryOnExpiry() {
+	<-w.ctx.Done()
+	if w.recorder == nil || !hasExpired(w.ctx) || w.summary.isEmpty() {
+		return
+	}
+	w.recorder.Eventf(&v1.ObjectReference{APIVersion: "v1", Kind: "Endpoints", Name: w.endpoints.Name, Namespace: w.namespace}, v1.EventTypeNormal,
+		eventReasonWeedingSummary, "%s within the watch duration", w.summary)
+}
+
+// CorrelationID returns the ID which uniquely identifies this run of the Weeder and which is part of all of its log lines.
+func (w *Weeder) CorrelationID() string {
+	return w.correlationID
+}
+
+// Run starts the Weeder and blocks until its context expires.
+func (w *Weeder) Run() {
+	w.Start()
 	// weeder should wait till the context expires
 	<-w.ctx.Done()
 }
 
-func shootPodIfNecessary(ctx context.Context, log logr.Logger, crClient client.Client, targetPod *v1.Pod) error {
-	if !shouldDeletePod(targetPod) {
+// shootPodIfNecessary weeds the target pod if it is unhealthy. The logger of the weeder is taken from the context.
+func (w *Weeder) shootPodIfNecessary(ctx context.Context, crClient client.Client, targetPod *v1.Pod) error {
+	log := logr.FromContextOrDiscard(ctx)
+	if !weederapi.ShouldWeedPodMatching(targetPod, w.isUnhealthy) {
+		return nil
+	}
+	owned, err := isControlledByAnyOf(ctx, crClient, targetPod, w.dependantSelectors.OwnerFilters)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		w.summary.recordSkipped(targetPod.Name, skipReasonNotOwned)
+		log.V(4).Info("Skipping deletion of pod as it is not controlled by any of the configured owners", "namespace", targetPod.Namespace, "podName", targetPod.Name)
+		return nil
+	}
+	protected, err := w.isProtectedByPriority(ctx, crClient, targetPod)
+	if err != nil {
+		return err
+	}
+	if protected {
+		w.summary.recordSkipped(targetPod.Name, skipReasonPriority)
+		log.Info("Skipping deletion of pod as its priority is higher than the one of the max priority class", "namespace", targetPod.Namespace, "podName", targetPod.Name,
+			"priorityClassName", targetPod.Spec.PriorityClassName, "maxPriorityClassName", w.maxPriorityClassName)
+		return nil
+	}
+	if remaining := w.gracePeriodEnd.Sub(w.clock.Now()); remaining > 0 {
+		w.deferWeeding(ctx, crClient, targetPod, remaining)
+		return nil
+	}
+	if w.runtimeOverrides.IsDryRun() {
+		w.summary.recordSkipped(targetPod.Name, skipReasonDryRun)
+		log.Info("Dry-run mode is enabled, not weeding pod", "namespace", targetPod.Namespace, "podName", targetPod.Name)
 		return nil
 	}
-	log.Info("Deleting pod", "namespace", targetPod.Namespace, "podName", targetPod.Name)
-	return crClient.Delete(ctx, targetPod)
+	weedingStrategy := getWeedingStrategy(w.dependantSelectors)
+	if weedingStrategy != wapi.WeedingStrategyDeletePod {
+		ownedByDeployment, err := w.rolloutRestartOwningDeployment(ctx, log, crClient, targetPod)
+		if err != nil {
+			return err
+		}
+		if ownedByDeployment && weedingStrategy == wapi.WeedingStrategyRolloutRestart {
+			w.summary.recordWeeded(targetPod.Name)
+			return nil
+		}
+	}
+	deleted, err := w.deletePodIfStillUnhealthy(ctx, log, crClient, targetPod)
+	if err != nil || !deleted {
+		return err
+	}
+	w.summary.recordWeeded(targetPod.Name)
+	return nil
 }
 
-// shouldDeletePod checks if a pod should be deleted for quicker recovery. A pod can be deleted
-// only if it is not marked for deletion and is currently in CrashLoopBackOff state
-func shouldDeletePod(pod *v1.Pod) bool {
-	podNotMarkedForDeletion := pod.DeletionTimestamp == nil
-	return podNotMarkedForDeletion && isPodInCrashloopBackoff(pod.Status)
+// deletePodIfStillUnhealthy re-fetches the pod right before deleting it, as it might have recovered since the event which has triggered its
+// weeding, and only deletes it if it is still unhealthy. The deletion is preconditioned on the resource version of the re-fetched pod, so that a
+// pod which has changed in the meantime is not deleted, its next event decides again. It returns true if the pod has been deleted.
+func (w *Weeder) deletePodIfStillUnhealthy(ctx context.Context, log logr.Logger, crClient client.Client, pod *v1.Pod) (bool, error) {
+	latestPod := &v1.Pod{}
+	if err := crClient.Get(ctx, client.ObjectKeyFromObject(pod), latestPod); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if !weederapi.ShouldWeedPodMatching(latestPod, w.isUnhealthy) {
+		metrics.WeederPodDeletionsAvoidedTotal.Inc()
+		w.summary.recordSkipped(pod.Name, skipReasonRecoveredBeforeDeletion)
+		log.Info("Pod has recovered since it has been found unhealthy, skipping its deletion", "namespace", pod.Namespace, "podName", pod.Name)
+		return false, nil
+	}
+	log.Info("Deleting pod", "namespace", pod.Namespace, "podName", pod.Name)
+	if err := crClient.Delete(ctx, latestPod, client.Preconditions{UID: &latestPod.UID, ResourceVersion: &latestPod.ResourceVersion}); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			log.Info("Pod has changed since it has been re-fetched, skipping its deletion", "namespace", pod.Namespace, "podName", pod.Name, "reason", apierrors.ReasonForError(err))
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
-// isPodInCrashloopBackoff checks if any container in a pod is in CrashLoopBackOff
-func isPodInCrashloopBackoff(status v1.PodStatus) bool {
-	for _, containerStatus := range status.ContainerStatuses {
-		if isContainerInCrashLoopBackOff(containerStatus.State) {
-			return true
+// deferWeeding re-evaluates the pod once the grace period has expired. If the pod is still in CrashLoopBackOff then it is weeded, else the avoided
+// deletion is counted. The weeding of a pod is deferred at most once, subsequent events for the same pod within the grace period are ignored.
+func (w *Weeder) deferWeeding(ctx context.Context, crClient client.Client, pod *v1.Pod, delay time.Duration) {
+	log := logr.FromContextOrDiscard(ctx)
+	key := client.ObjectKeyFromObject(pod)
+	w.deferredPods.Lock()
+	defer w.deferredPods.Unlock()
+	if w.deferredPods.keys.Has(key) {
+		return
+	}
+	w.deferredPods.keys.Insert(key)
+	log.Info("Deferring deletion of pod until the grace period has expired", "namespace", pod.Namespace, "podName", pod.Name, "delay", delay)
+	timer := w.clock.NewTimer(delay)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+		}
+		latestPod := &v1.Pod{}
+		if err := crClient.Get(ctx, key, latestPod); err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Error(err, "Failed to get pod after the grace period has expired", "namespace", pod.Namespace, "podName", pod.Name)
+			}
+			return
+		}
+		if !weederapi.ShouldWeedPodMatching(latestPod, w.isUnhealthy) {
+			metrics.WeederPodDeletionsAvoidedTotal.Inc()
+			w.summary.recordSkipped(pod.Name, skipReasonRecovered)
+			log.Info("Pod has recovered within the grace period, skipping its deletion", "namespace", pod.Namespace, "podName", pod.Name)
+			return
 		}
+		if err := w.shootPodIfNecessary(ctx, crClient, latestPod); err != nil {
+			log.Error(err, "Error processing pod after the grace period has expired", "namespace", pod.Namespace, "podName", pod.Name)
+		}
+	}()
+}
+
+// isProtectedByPriority checks if the pod must not be weeded as its priority is higher than the value of the maxPriorityClassName and its
+// PriorityClass is not one of the weedablePriorityClassNames. If the max PriorityClass cannot be found then an error is returned, so that no pod
+// is weeded by mistake.
+func (w *Weeder) isProtectedByPriority(ctx context.Context, crClient client.Client, pod *v1.Pod) (bool, error) {
+	if w.maxPriorityClassName == "" || slices.Contains(w.weedablePriorityClassNames, pod.Spec.PriorityClassName) {
+		return false, nil
 	}
-	return false
+	maxPriorityClass := &schedulingv1.PriorityClass{}
+	if err := crClient.Get(ctx, client.ObjectKey{Name: w.maxPriorityClassName}, maxPriorityClass); err != nil {
+		return false, err
+	}
+	return pointer.Int32Deref(pod.Spec.Priority, 0) > maxPriorityClass.Value, nil
 }
 
-// isContainerInCrashLoopBackOff checks if a container is in CrashLoopBackOff
-func isContainerInCrashLoopBackOff(containerState v1.ContainerState) bool {
-	return containerState.Waiting != nil && containerState.Waiting.Reason == crashLoopBackOff
+// getWeedingStrategy returns the weeding strategy configured for the dependants of a service, falling back to wapi.WeedingStrategyDeletePod.
+func getWeedingStrategy(dependantSelectors wapi.DependantSelectors) wapi.WeedingStrategy {
+	if dependantSelectors.WeedingStrategy != nil {
+		return *dependantSelectors.WeedingStrategy
+	}
+	return wapi.WeedingStrategyDeletePod
+}
+
+// getUnhealthyPodPredicate compiles the predicates of the dependant selectors into a weederapi.PodPredicate. Dependant pods are considered
+// unhealthy if any of their containers is in CrashLoopBackOff unless other container state reasons have been configured.
+func getUnhealthyPodPredicate(dependantSelectors wapi.DependantSelectors) weederapi.PodPredicate {
+	predicates := util.GetValOrDefault(dependantSelectors.Predicates, wapi.PodPredicates{})
+	containerStateReasons := predicates.ContainerStateReasons
+	if len(containerStateReasons) == 0 {
+		containerStateReasons = []string{weederapi.CrashLoopBackOffReason}
+	}
+	return weederapi.NewUnhealthyPodPredicate(containerStateReasons, pointer.Int32Deref(predicates.MinRestartCount, 0), predicates.Phases)
+}
+
+// rolloutRestartOwningDeployment performs a rollout restart of the Deployment owning the pod unless it has already been restarted by this weeder.
+// It returns true if the pod is owned by a Deployment.
+func (w *Weeder) rolloutRestartOwningDeployment(ctx context.Context, log logr.Logger, crClient client.Client, pod *v1.Pod) (bool, error) {
+	deploymentName, err := getOwningDeploymentName(ctx, crClient, pod)
+	if err != nil || deploymentName == "" {
+		return false, err
+	}
+	if !w.markDeploymentRestarted(deploymentName) {
+		log.V(4).Info("Skipping rollout restart of deployment as it has already been restarted", "namespace", pod.Namespace, "deploymentName", deploymentName)
+		return true, nil
+	}
+	log.Info("Restarting deployment owning pod", "namespace", pod.Namespace, "deploymentName", deploymentName, "podName", pod.Name)
+	if err = rolloutRestartDeployment(ctx, crClient, pod.Namespace, deploymentName, w.clock.Now()); err != nil {
+		// allow a subsequent event to retry the rollout restart
+		w.unmarkDeploymentRestarted(deploymentName)
+		return true, err
+	}
+	return true, nil
+}
+
+// markDeploymentRestarted records that a Deployment is restarted by this weeder. It returns false if it has already been recorded before.
+func (w *Weeder) markDeploymentRestarted(deploymentName string) bool {
+	w.restartedDeployments.Lock()
+	defer w.restartedDeployments.Unlock()
+	if w.restartedDeployments.names.Has(deploymentName) {
+		return false
+	}
+	w.restartedDeployments.names.Insert(deploymentName)
+	return true
+}
+
+func (w *Weeder) unmarkDeploymentRestarted(deploymentName string) {
+	w.restartedDeployments.Lock()
+	defer w.restartedDeployments.Unlock()
+	w.restartedDeployments.names.Delete(deploymentName)
+}
+
+// getOwningDeploymentName returns the name of the Deployment controlling the ReplicaSet which controls the pod. If the pod is not controlled by
+// a Deployment then an empty name is returned.
+func getOwningDeploymentName(ctx context.Context, crClient client.Client, pod *v1.Pod) (string, error) {
+	rsRef := metav1.GetControllerOf(pod)
+	if rsRef == nil || rsRef.Kind != "ReplicaSet" {
+		return "", nil
+	}
+	rs := &metav1.PartialObjectMetadata{}
+	rs.SetGroupVersionKind(schema.FromAPIVersionAndKind(rsRef.APIVersion, rsRef.Kind))
+	if err := crClient.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: rsRef.Name}, rs); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	deploymentRef := metav1.GetControllerOf(rs)
+	if deploymentRef == nil || deploymentRef.Kind != "Deployment" {
+		return "", nil
+	}
+	return deploymentRef.Name, nil
+}
+
+// rolloutRestartDeployment triggers a rollout restart of a Deployment by setting restartedAtAnnotationKey in its pod template to restartedAt.
+func rolloutRestartDeployment(ctx context.Context, crClient client.Client, namespace, name string, restartedAt time.Time) error {
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]string{restartedAtAnnotationKey: restartedAt.Format(time.RFC3339)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	return crClient.Patch(ctx, deployment, client.RawPatch(types.MergePatchType, patch))
+}
+
+// isControlledByAnyOf checks if the pod is controlled by one of the owners identified by ownerFilters. The controller chain of the pod is
+// followed upwards (e.g. Pod -> ReplicaSet -> Deployment) for at most maxOwnerChainDepth levels. If no owner filters are given then any pod qualifies.
+func isControlledByAnyOf(ctx context.Context, crClient client.Client, pod *v1.Pod, ownerFilters []wapi.OwnerFilter) (bool, error) {
+	if len(ownerFilters) == 0 {
+		return true, nil
+	}
+	var obj metav1.Object = pod
+	for i := 0; i < maxOwnerChainDepth; i++ {
+		controllerRef := metav1.GetControllerOf(obj)
+		if controllerRef == nil {
+			return false, nil
+		}
+		if slices.Contains(ownerFilters, wapi.OwnerFilter{Kind: controllerRef.Kind, Name: controllerRef.Name}) {
+			return true, nil
+		}
+		owner := &metav1.PartialObjectMetadata{}
+		owner.SetGroupVersionKind(schema.FromAPIVersionAndKind(controllerRef.APIVersion, controllerRef.Kind))
+		if err := crClient.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: controllerRef.Name}, owner); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		obj = owner
+	}
+	return false, nil
 }
@@ -0,0 +1,346 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package weeder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wapi "github.com/gardener/dependency-watchdog/api/weeder"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/overrides"
+	weederapi "github.com/gardener/dependency-watchdog/pkg/weeder/api"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	testclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestIsControlledByAnyOf(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "kube-apiserver-7d4b9c",
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{createControllerRef("apps/v1", "Deployment", "kube-apiserver")},
+		},
+	}
+	podOwnedByRS := createPod("kube-apiserver-7d4b9c-abcde", createControllerRef("apps/v1", "ReplicaSet", rs.Name))
+	podOwnedByStatefulSet := createPod("etcd-main-0", createControllerRef("apps/v1", "StatefulSet", "etcd-main"))
+	podOwnedByMissingRS := createPod("kube-controller-manager-5f6d7-abcde", createControllerRef("apps/v1", "ReplicaSet", "kube-controller-manager-5f6d7"))
+	podWithoutOwner := createPod("standalone")
+	crClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(rs).Build()
+
+	tests := []struct {
+		name         string
+		pod          *v1.Pod
+		ownerFilters []wapi.OwnerFilter
+		expected     bool
+	}{
+		{"no owner filters should match any pod", podWithoutOwner, nil, true},
+		{"direct controller should match", podOwnedByStatefulSet, []wapi.OwnerFilter{{Kind: "StatefulSet", Name: "etcd-main"}}, true},
+		{"transitive controller should match", podOwnedByRS, []wapi.OwnerFilter{{Kind: "Deployment", Name: "kube-apiserver"}}, true},
+		{"controller with different name should not match", podOwnedByRS, []wapi.OwnerFilter{{Kind: "Deployment", Name: "gardener-resource-manager"}}, false},
+		{"controller with different kind should not match", podOwnedByStatefulSet, []wapi.OwnerFilter{{Kind: "Deployment", Name: "etcd-main"}}, false},
+		{"pod without controller should not match", podWithoutOwner, []wapi.OwnerFilter{{Kind: "Deployment", Name: "kube-apiserver"}}, false},
+		{"pod with non-existing controller should not match", podOwnedByMissingRS, []wapi.OwnerFilter{{Kind: "Deployment", Name: "kube-controller-manager"}}, false},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(_ *testing.T) {
+			owned, err := isControlledByAnyOf(ctx, crClient, entry.pod, entry.ownerFilters)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(owned).To(Equal(entry.expected))
+		})
+	}
+}
+
+func TestGetWatchDurationShouldPreferPerServiceOverride(t *testing.T) {
+	g := NewWithT(t)
+	config := &wapi.Config{WatchDuration: &metav1.Duration{Duration: 5 * time.Minute}}
+	g.Expect(getWatchDuration(config, wapi.DependantSelectors{})).To(Equal(5 * time.Minute))
+	g.Expect(getWatchDuration(config, wapi.DependantSelectors{WatchDuration: &metav1.Duration{Duration: 2 * time.Minute}})).To(Equal(2 * time.Minute))
+}
+
+func TestShootPodIfNecessaryShouldHonorPredicates(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	waitingWith := func(reason string) v1.PodStatus {
+		return v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}}}}}
+	}
+	crashingPod, misconfiguredPod := createPod("kube-apiserver-abcde"), createPod("kube-apiserver-fghij")
+	crashingPod.Status, misconfiguredPod.Status = waitingWith(weederapi.CrashLoopBackOffReason), waitingWith("CreateContainerConfigError")
+	crClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(crashingPod, misconfiguredPod).Build()
+	config := &wapi.Config{
+		WatchDuration: &metav1.Duration{Duration: time.Minute},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{
+			"etcd-main-client": {Predicates: &wapi.PodPredicates{ContainerStateReasons: []string{"CreateContainerConfigError"}}},
+		},
+	}
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+	w := NewWeeder(ctx, namespace, config, crClient, nil, ep, nil, nil, logr.Discard())
+	defer w.cancelFn()
+
+	g.Expect(w.shootPodIfNecessary(ctx, crClient, crashingPod)).To(Succeed())
+	g.Expect(w.shootPodIfNecessary(ctx, crClient, misconfiguredPod)).To(Succeed())
+	g.Expect(crClient.Get(ctx, client.ObjectKeyFromObject(crashingPod), &v1.Pod{})).To(Succeed(), "pod which is not matched by the predicates should not be deleted")
+	err := crClient.Get(ctx, client.ObjectKeyFromObject(misconfiguredPod), &v1.Pod{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "pod which is matched by the predicates should be deleted")
+}
+
+func TestShootPodIfNecessaryShouldHonorWeedingStrategy(t *testing.T) {
+	crashLoopBackOffStatus := v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+	deletePod := wapi.WeedingStrategyDeletePod
+	rolloutRestart := wapi.WeedingStrategyRolloutRestart
+	deletePodAndRolloutRestart := wapi.WeedingStrategyDeletePodAndRolloutRestart
+	tests := []struct {
+		name                      string
+		weedingStrategy           *wapi.WeedingStrategy
+		expectPodsDeleted         bool
+		expectDeploymentRestarted bool
+	}{
+		{"pods should only be deleted if no weeding strategy is set", nil, true, false},
+		{"pods should only be deleted for DeletePod", &deletePod, true, false},
+		{"deployment should only be restarted for RolloutRestart", &rolloutRestart, false, true},
+		{"pods should be deleted and deployment should be restarted for DeletePodAndRolloutRestart", &deletePodAndRolloutRestart, true, true},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver", Namespace: namespace}}
+			rs := &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "kube-apiserver-7d4b9c",
+					Namespace:       namespace,
+					OwnerReferences: []metav1.OwnerReference{createControllerRef("apps/v1", "Deployment", deployment.Name)},
+				},
+			}
+			pods := []*v1.Pod{
+				createPod("kube-apiserver-7d4b9c-abcde", createControllerRef("apps/v1", "ReplicaSet", rs.Name)),
+				createPod("kube-apiserver-7d4b9c-fghij", createControllerRef("apps/v1", "ReplicaSet", rs.Name)),
+			}
+			standalonePod := createPod("standalone")
+			for _, pod := range append(pods, standalonePod) {
+				pod.Status = crashLoopBackOffStatus
+			}
+			var deploymentPatchCount int
+			crClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment, rs, pods[0], pods[1], standalonePod).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+						if _, ok := obj.(*appsv1.Deployment); ok {
+							deploymentPatchCount++
+						}
+						return c.Patch(ctx, obj, patch, opts...)
+					},
+				}).Build()
+			config := &wapi.Config{
+				WatchDuration:                 &metav1.Duration{Duration: time.Minute},
+				ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{"etcd-main-client": {WeedingStrategy: entry.weedingStrategy}},
+			}
+			ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+			w := NewWeeder(ctx, namespace, config, crClient, nil, ep, nil, nil, logr.Discard())
+			defer w.cancelFn()
+
+			for _, pod := range append(pods, standalonePod) {
+				g.Expect(w.shootPodIfNecessary(ctx, crClient, pod)).To(Succeed())
+			}
+
+			for _, pod := range pods {
+				err := crClient.Get(ctx, client.ObjectKeyFromObject(pod), &v1.Pod{})
+				g.Expect(apierrors.IsNotFound(err)).To(Equal(entry.expectPodsDeleted))
+			}
+			err := crClient.Get(ctx, client.ObjectKeyFromObject(standalonePod), &v1.Pod{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "pod which is not owned by a deployment should always be deleted")
+
+			g.Expect(crClient.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+			if entry.expectDeploymentRestarted {
+				g.Expect(deployment.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotationKey))
+				g.Expect(deploymentPatchCount).To(Equal(1), "deployment should be restarted only once per weeder")
+			} else {
+				g.Expect(deployment.Spec.Template.Annotations).ToNot(HaveKey(restartedAtAnnotationKey))
+				g.Expect(deploymentPatchCount).To(BeZero())
+			}
+		})
+	}
+}
+
+func TestShootPodIfNecessaryShouldDeferWeedingUntilGracePeriodHasExpired(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	crashLoopBackOffStatus := v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+	crashingPod := createPod("kube-apiserver-abcde")
+	recoveringPod := createPod("kube-apiserver-fghij")
+	for _, pod := range []*v1.Pod{crashingPod, recoveringPod} {
+		pod.Status = crashLoopBackOffStatus
+	}
+	crClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(crashingPod, recoveringPod).Build()
+	config := &wapi.Config{
+		WatchDuration:                 &metav1.Duration{Duration: time.Minute},
+		GracePeriod:                   &metav1.Duration{Duration: 30 * time.Second},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{"etcd-main-client": {}},
+	}
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+	fakeClock := testclock.NewFakeClock(time.Now())
+	w := NewWeeder(ctx, namespace, config, crClient, nil, ep, nil, nil, logr.Discard(), WithClock(fakeClock))
+	defer w.cancelFn()
+	avoidedBefore := testutil.ToFloat64(metrics.WeederPodDeletionsAvoidedTotal)
+
+	for _, pod := range []*v1.Pod{crashingPod, recoveringPod, crashingPod} {
+		g.Expect(w.shootPodIfNecessary(ctx, crClient, pod)).To(Succeed())
+	}
+	g.Expect(crClient.Get(ctx, client.ObjectKeyFromObject(crashingPod), &v1.Pod{})).To(Succeed(), "pod should not be deleted within the grace period")
+	recoveringPod.Status = v1.PodStatus{}
+	g.Expect(crClient.Status().Update(ctx, recoveringPod)).To(Succeed())
+	fakeClock.Step(config.GracePeriod.Duration - time.Second)
+	g.Consistently(func() error {
+		return crClient.Get(ctx, client.ObjectKeyFromObject(crashingPod), &v1.Pod{})
+	}, 50*time.Millisecond).Should(Succeed(), "pod should not be deleted before the grace period has expired")
+	fakeClock.Step(time.Second)
+
+	g.Eventually(func() bool {
+		return apierrors.IsNotFound(crClient.Get(ctx, client.ObjectKeyFromObject(crashingPod), &v1.Pod{}))
+	}).Should(BeTrue(), "pod which is still in CrashLoopBackOff should be deleted once the grace period has expired")
+	g.Eventually(func() float64 { return testutil.ToFloat64(metrics.WeederPodDeletionsAvoidedTotal) }).Should(Equal(avoidedBefore + 1))
+	g.Expect(crClient.Get(ctx, client.ObjectKeyFromObject(recoveringPod), &v1.Pod{})).To(Succeed(), "pod which has recovered within the grace period should not be deleted")
+}
+
+func TestShootPodIfNecessaryShouldVerifyPodIsStillUnhealthyBeforeDeletion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	crashLoopBackOffStatus := v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+	recoveredPod, changedPod := createPod("kube-apiserver-abcde"), createPod("kube-apiserver-fghij")
+	changedPod.Status = crashLoopBackOffStatus
+	var changed bool
+	crClient := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(recoveredPod, changedPod).Build(), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil || key.Name != changedPod.Name || changed {
+				return err
+			}
+			// the pod is changed after it has been re-fetched, e.g. as its container has been restarted
+			changed = true
+			pod := obj.(*v1.Pod).DeepCopy()
+			metav1.SetMetaDataLabel(&pod.ObjectMeta, "restarted", "true")
+			return c.Update(ctx, pod)
+		},
+	})
+	config := &wapi.Config{
+		WatchDuration:                 &metav1.Duration{Duration: time.Minute},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{"etcd-main-client": {}},
+	}
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+	w := NewWeeder(ctx, namespace, config, crClient, nil, ep, nil, nil, logr.Discard())
+	defer w.cancelFn()
+	avoidedBefore := testutil.ToFloat64(metrics.WeederPodDeletionsAvoidedTotal)
+
+	// the events report the pods in CrashLoopBackOff while the recovered pod has recovered in the meantime
+	recoveredPod.Status = crashLoopBackOffStatus
+	g.Expect(w.shootPodIfNecessary(ctx, crClient, recoveredPod)).To(Succeed())
+	g.Expect(crClient.Get(ctx, client.ObjectKeyFromObject(recoveredPod), &v1.Pod{})).To(Succeed(), "pod which has recovered since its event should not be deleted")
+	g.Expect(testutil.ToFloat64(metrics.WeederPodDeletionsAvoidedTotal)).To(Equal(avoidedBefore + 1))
+
+	g.Expect(w.shootPodIfNecessary(ctx, crClient, changedPod)).To(Succeed())
+	g.Expect(crClient.Get(ctx, client.ObjectKeyFromObject(changedPod), &v1.Pod{})).To(Succeed(), "pod which has changed since it has been re-fetched should not be deleted")
+	g.Expect(w.shootPodIfNecessary(ctx, crClient, changedPod)).To(Succeed())
+	err := crClient.Get(ctx, client.ObjectKeyFromObject(changedPod), &v1.Pod{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "pod which is still in CrashLoopBackOff should be deleted by its next event")
+}
+
+func TestShootPodIfNecessaryShouldNotWeedPodsInDryRunMode(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	crashingPod := createPod("kube-apiserver-abcde")
+	crashingPod.Status = v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+	crClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(crashingPod).Build()
+	config := &wapi.Config{
+		WatchDuration:                 &metav1.Duration{Duration: time.Minute},
+		ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{"etcd-main-client": {}},
+	}
+	runtimeOverrides := overrides.New(zap.NewAtomicLevel())
+	runtimeOverrides.Apply(map[string]string{overrides.DryRunAnnotationKey: "true"}, logr.Discard())
+	ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+	w := NewWeeder(ctx, namespace, config, crClient, nil, ep, runtimeOverrides, nil, logr.Discard())
+	defer w.cancelFn()
+
+	g.Expect(w.shootPodIfNecessary(ctx, crClient, crashingPod)).To(Succeed())
+	g.Expect(crClient.Get(ctx, client.ObjectKeyFromObject(crashingPod), &v1.Pod{})).To(Succeed(), "pod should not be deleted in dry-run mode")
+
+	runtimeOverrides.Apply(nil, logr.Discard())
+	g.Expect(w.shootPodIfNecessary(ctx, crClient, crashingPod)).To(Succeed())
+	err := crClient.Get(ctx, client.ObjectKeyFromObject(crashingPod), &v1.Pod{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "pod should be deleted once the dry-run mode has been disabled")
+}
+
+func TestShootPodIfNecessaryShouldNotWeedPodsAboveMaxPriorityClass(t *testing.T) {
+	maxPriorityClass := &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "gardener-system-500"}, Value: 500}
+	tests := []struct {
+		name                       string
+		priorityClassName          string
+		priority                   int32
+		maxPriorityClassName       *string
+		weedablePriorityClassNames []string
+		expectPodDeleted           bool
+		expectErr                  bool
+	}{
+		{"pod of any priority should be deleted if no max priority class is set", "system-cluster-critical", 2000000000, nil, nil, true, false},
+		{"pod with the priority of the max priority class should be deleted", maxPriorityClass.Name, 500, &maxPriorityClass.Name, nil, true, false},
+		{"pod above the max priority class should not be deleted", "system-cluster-critical", 2000000000, &maxPriorityClass.Name, nil, false, false},
+		{"pod above the max priority class should be deleted if its priority class is weedable", "system-cluster-critical", 2000000000, &maxPriorityClass.Name, []string{"system-cluster-critical"}, true, false},
+		{"pod should not be deleted if the max priority class does not exist", "", 0, pointer.String("non-existing"), nil, false, true},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+			crashingPod := createPod("kube-apiserver-abcde")
+			crashingPod.Spec.PriorityClassName, crashingPod.Spec.Priority = entry.priorityClassName, pointer.Int32(entry.priority)
+			crashingPod.Status = v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: weederapi.CrashLoopBackOffReason}}}}}
+			crClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(crashingPod, maxPriorityClass).Build()
+			config := &wapi.Config{
+				WatchDuration:                 &metav1.Duration{Duration: time.Minute},
+				ServicesAndDependantSelectors: map[string]wapi.DependantSelectors{"etcd-main-client": {}},
+				MaxPriorityClassName:          entry.maxPriorityClassName,
+				WeedablePriorityClassNames:    entry.weedablePriorityClassNames,
+			}
+			ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "etcd-main-client", Namespace: namespace}}
+			w := NewWeeder(ctx, namespace, config, crClient, nil, ep, nil, nil, logr.Discard())
+			defer w.cancelFn()
+
+			err := w.shootPodIfNecessary(ctx, crClient, crashingPod)
+			if entry.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			err = crClient.Get(ctx, client.ObjectKeyFromObject(crashingPod), &v1.Pod{})
+			g.Expect(apierrors.IsNotFound(err)).To(Equal(entry.expectPodDeleted))
+		})
+	}
+}
+
+func createPod(name string, ownerRefs ...metav1.OwnerReference) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, OwnerReferences: ownerRefs}}
+}
+
+func createControllerRef(apiVersion, kind, name string) metav1.OwnerReference {
+	return metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: name, Controller: pointer.Bool(true)}
+}
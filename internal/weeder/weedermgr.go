@@ -7,6 +7,11 @@ package weeder
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gardener/dependency-watchdog/internal/lifecycle"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
 )
 
 // Manager provides a single point for registering and unregistering weeders
@@ -15,9 +20,9 @@ type Manager interface {
 	// exists then it will close it and replace it with the new weeder.
 	Register(weeder Weeder) bool
 	// Unregister checks if there is an existing weeder with the key. If it is found then it will close the weeder
-	// and remove it from the manager.
-	Unregister(key string) bool
-	// UnregisterAll unregisters all weeders from the manager.
+	// and remove it from the manager. If the weeder is still running then its cancellation is counted with the given reason.
+	Unregister(key string, reason string) bool
+	// UnregisterAll unregisters all weeders from the manager, e.g. on shutdown.
 	UnregisterAll()
 	// GetWeederRegistration returns a weederRegistration which will give access to the context and the cancelFn to the caller.
 	GetWeederRegistration(key string) (Registration, bool)
@@ -29,6 +34,14 @@ type Registration interface {
 	IsClosed() bool
 	// Close closes the weeder.
 	Close()
+	// Health returns the combined health of the pod watchers of the weeder.
+	Health() lifecycle.Health
+	// ConfigHash returns the hash of the config the weeder has been created with.
+	ConfigHash() string
+	// CreatedAt returns the time at which the weeder has been created.
+	CreatedAt() time.Time
+	// CorrelationID returns the ID which uniquely identifies the run of the weeder in its log lines.
+	CorrelationID() string
 }
 
 type weederManager struct {
@@ -38,8 +51,14 @@ type weederManager struct {
 
 // weederRegistration captures the handle to manage a weeder
 type weederRegistration struct {
-	ctx      context.Context
-	cancelFn context.CancelFunc
+	ctx           context.Context
+	cancelFn      context.CancelFunc
+	podWatchers   []*lifecycle.Subsystem
+	configHash    string
+	createdAt     time.Time
+	correlationID string
+	// closed is set once the weeder is closed via the registration, which tells it apart from a weeder whose parent context has been cancelled.
+	closed *atomic.Bool
 }
 
 func (wr weederRegistration) IsClosed() bool {
@@ -52,9 +71,26 @@ func (wr weederRegistration) IsClosed() bool {
 }
 
 func (wr weederRegistration) Close() {
+	wr.closed.Store(true)
 	wr.cancelFn()
 }
 
+func (wr weederRegistration) Health() lifecycle.Health {
+	return lifecycle.CombinedHealth(wr.podWatchers...)
+}
+
+func (wr weederRegistration) ConfigHash() string {
+	return wr.configHash
+}
+
+func (wr weederRegistration) CreatedAt() time.Time {
+	return wr.createdAt
+}
+
+func (wr weederRegistration) CorrelationID() string {
+	return wr.correlationID
+}
+
 // Register registers the new weeder. If the weeder with the same key (see `createKey` function) exists
 // then it will close the registration (if not already closed) which cancels the weeder.
 // It will then create a new weeder registration which will replace the existing weeder registration.
@@ -63,14 +99,23 @@ func (wm *weederManager) Register(weeder Weeder) bool {
 	defer wm.Unlock()
 	key := createKey(weeder)
 	if wr, exists := wm.weeders[key]; exists {
-		if !wr.IsClosed() {
-			wr.Close()
-		}
+		cancel(wr, metrics.ReasonDuplicate)
 	}
-	wm.weeders[key] = weederRegistration{
-		ctx:      weeder.ctx,
-		cancelFn: weeder.cancelFn,
+	wr := weederRegistration{
+		ctx:           weeder.ctx,
+		cancelFn:      weeder.cancelFn,
+		podWatchers:   weeder.podWatchers,
+		configHash:    weeder.configHash,
+		createdAt:     weeder.createdAt,
+		correlationID: weeder.correlationID,
+		closed:        &atomic.Bool{},
 	}
+	wm.weeders[key] = wr
+	metrics.WeedersStartedTotal.Inc()
+	metrics.WeedersActive.Inc()
+	go observe(wr)
+	metrics.WeederConfigInfo.Reset()
+	metrics.WeederConfigInfo.WithLabelValues(weeder.configHash).Set(1)
 	return true
 }
 
@@ -81,12 +126,12 @@ func NewManager() Manager {
 	}
 }
 
-func (wm *weederManager) Unregister(key string) bool {
+func (wm *weederManager) Unregister(key string, reason string) bool {
 	wm.Lock()
 	defer wm.Unlock()
 	if wr, ok := wm.weeders[key]; ok {
 		delete(wm.weeders, key)
-		wr.Close()
+		cancel(wr, reason)
 		return true
 	}
 	return false
@@ -94,16 +139,45 @@ func (wm *weederManager) Unregister(key string) bool {
 
 func (wm *weederManager) UnregisterAll() {
 	for key := range wm.weeders {
-		_ = wm.Unregister(key)
+		_ = wm.Unregister(key, metrics.ReasonContextCancelled)
+	}
+}
+
+// cancel closes the weeder of the registration. If the weeder is still running then its cancellation is counted with the given reason.
+func cancel(wr weederRegistration, reason string) {
+	if wr.IsClosed() {
+		return
+	}
+	wr.Close()
+	metrics.WeedersCancelledTotal.WithLabelValues(reason).Inc()
+}
+
+// observe waits until the weeder of the registration is done and records whether its watch duration has expired or whether it has been
+// cancelled via its parent context. Cancellations via the registration are counted by the manager.
+func observe(wr weederRegistration) {
+	<-wr.ctx.Done()
+	metrics.WeedersActive.Dec()
+	switch {
+	case hasExpired(wr.ctx):
+		metrics.WeederWatchDurationExpiriesTotal.Inc()
+	case !wr.closed.Load():
+		metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonContextCancelled).Inc()
 	}
 }
 
 func (wm *weederManager) GetWeederRegistration(key string) (Registration, bool) {
+	wm.Lock()
+	defer wm.Unlock()
 	wr, ok := wm.weeders[key]
 	return wr, ok
 }
 
 // createKey creates a key to uniquely identify a weeder
 func createKey(w Weeder) string {
-	return w.namespace + "/" + w.endpoints.Name
+	return CreateKey(w.namespace, w.endpoints.Name)
+}
+
+// CreateKey creates the key which identifies a weeder started for the endpoints with the given name in the namespace.
+func CreateKey(namespace, endpointsName string) string {
+	return namespace + "/" + endpointsName
 }
@@ -12,10 +12,14 @@ import (
 	"time"
 
 	v12 "github.com/gardener/dependency-watchdog/api/weeder"
+	"github.com/gardener/dependency-watchdog/internal/metrics"
+	"github.com/gardener/dependency-watchdog/internal/util"
 	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclock "k8s.io/utils/clock/testing"
 )
 
 const (
@@ -49,7 +53,7 @@ func TestRegisterNewWeederAndIsNotClosed(t *testing.T) {
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	w := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, logr.Discard())
+	w := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard())
 	g.Expect(w).ShouldNot(BeNil(), "NewWeeder should have returned a non nil weeder")
 	g.Expect(mgr.Register(*w)).To(BeTrue(), "mgr.Register should register a new weeder")
 
@@ -57,6 +61,8 @@ func TestRegisterNewWeederAndIsNotClosed(t *testing.T) {
 	foundWeederRegistration, ok := mgr.GetWeederRegistration(key)
 	g.Expect(ok).Should(BeTrue(), "mgr.GetProber should return true for a registered weeder")
 	g.Expect(foundWeederRegistration.IsClosed()).To(BeFalse(), "Registered weeder should be alive")
+	g.Expect(foundWeederRegistration.ConfigHash()).To(Equal(util.ComputeConfigHash(testWeederConfig)), "Registered weeder should expose the hash of its config")
+	g.Expect(testutil.ToFloat64(metrics.WeederConfigInfo.WithLabelValues(foundWeederRegistration.ConfigHash()))).To(Equal(1.0))
 
 	t.Log("Registering a weeder succeeded")
 }
@@ -66,18 +72,20 @@ func TestRegisterWeederWithSameKeyShouldReplaceOldEntry(t *testing.T) {
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	w1 := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, logr.Discard())
+	w1 := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard())
 	g.Expect(mgr.Register(*w1)).To(BeTrue(), "mgr.Register should register the first weeder")
 	key := createKey(*w1)
 	foundWeederRegistration1, _ := mgr.GetWeederRegistration(key)
 	g.Expect(foundWeederRegistration1.IsClosed()).To(BeFalse(), "First Registered weeder should be alive")
 
-	w2 := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, logr.Discard())
+	w2 := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard())
 	g.Expect(mgr.Register(*w2)).To(BeTrue(), "mgr.Register should register the second weeder")
 	foundWeederRegistration2, _ := mgr.GetWeederRegistration(key)
 
 	g.Expect(foundWeederRegistration1.IsClosed()).To(BeTrue(), "First Registered weeder should be cancelled")
 	g.Expect(foundWeederRegistration2.IsClosed()).To(BeFalse(), "Second Registered weeder should be alive")
+	g.Expect(foundWeederRegistration2.CorrelationID()).To(Equal(w2.CorrelationID()), "Registration should expose the correlation ID of the registered weeder")
+	g.Expect(foundWeederRegistration2.CorrelationID()).ToNot(Equal(foundWeederRegistration1.CorrelationID()), "Every weeder run should have its own correlation ID")
 
 	t.Log("Registering a weeder with same key successfully replaced and cancelled old weeder")
 }
@@ -87,13 +95,13 @@ func TestUnregisterExistingWeederShouldCloseItAndRemoveItFromManager(t *testing.
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	w := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, logr.Discard())
+	w := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard())
 	g.Expect(mgr.Register(*w)).To(BeTrue(), "mgr.Register should register the first weeder")
 	key := createKey(*w)
 	foundWeederRegistration, _ := mgr.GetWeederRegistration(key)
 	g.Expect(foundWeederRegistration.IsClosed()).To(BeFalse(), "Registered weeder should be alive")
 
-	g.Expect(mgr.Unregister(key)).To(BeTrue(), "mgr.Unregister should unregister the existing weeder")
+	g.Expect(mgr.Unregister(key, metrics.ReasonEndpointDeleted)).To(BeTrue(), "mgr.Unregister should unregister the existing weeder")
 	_, ok := mgr.GetWeederRegistration(key)
 	g.Expect(ok).To(BeFalse(), "mgr.Unregister should delete the weeder for the corresponding key")
 	g.Eventually(foundWeederRegistration.IsClosed).Should(BeTrue(), "mgr.Unregister should cancel the unregistered weeder")
@@ -106,6 +114,51 @@ func TestUnregisterNonExistingWeederShouldNotFail(t *testing.T) {
 	mgr, tearDownTest := setupMgrTest(t)
 	defer tearDownTest(mgr)
 
-	g.Expect(mgr.Unregister("random-key")).To(BeFalse(), "mgr.Unregister should return false for non existing weeder")
+	g.Expect(mgr.Unregister("random-key", metrics.ReasonEndpointDeleted)).To(BeFalse(), "mgr.Unregister should return false for non existing weeder")
 	t.Log("De-registering a non-existing weeder did not fail")
 }
+
+func TestLifecycleMetricsShouldCaptureHowWeedersEnd(t *testing.T) {
+	g := NewWithT(t)
+	mgr, tearDownTest := setupMgrTest(t)
+	defer tearDownTest(mgr)
+
+	activeWeeders := func() float64 { return testutil.ToFloat64(metrics.WeedersActive) }
+	g.Eventually(activeWeeders).Should(BeZero(), "the weeders of previous tests should have been unregistered")
+	startedBefore := testutil.ToFloat64(metrics.WeedersStartedTotal)
+	duplicatesBefore := testutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonDuplicate))
+	notReadyBefore := testutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonEndpointNotReady))
+	contextCancelsBefore := testutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonContextCancelled))
+	expiriesBefore := testutil.ToFloat64(metrics.WeederWatchDurationExpiriesTotal)
+
+	w1 := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard())
+	g.Expect(mgr.Register(*w1)).To(BeTrue())
+	g.Expect(testutil.ToFloat64(metrics.WeedersStartedTotal)).To(Equal(startedBefore + 1))
+	g.Expect(activeWeeders()).To(Equal(1.0))
+
+	w2 := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard())
+	g.Expect(mgr.Register(*w2)).To(BeTrue())
+	g.Expect(testutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonDuplicate))).To(Equal(duplicatesBefore+1), "a replaced weeder should be counted as duplicate")
+	g.Eventually(activeWeeders).Should(Equal(1.0))
+
+	g.Expect(mgr.Unregister(createKey(*w2), metrics.ReasonEndpointNotReady)).To(BeTrue())
+	g.Expect(mgr.Unregister(createKey(*w2), metrics.ReasonEndpointNotReady)).To(BeFalse())
+	g.Expect(testutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonEndpointNotReady))).To(Equal(notReadyBefore + 1))
+	g.Eventually(activeWeeders).Should(BeZero())
+
+	parentCtx, cancelParent := context.WithCancel(context.Background())
+	w3 := NewWeeder(parentCtx, namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard())
+	g.Expect(mgr.Register(*w3)).To(BeTrue())
+	cancelParent()
+	g.Eventually(func() float64 {
+		return testutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonContextCancelled))
+	}).Should(Equal(contextCancelsBefore + 1))
+
+	fakeClock := testclock.NewFakeClock(time.Now())
+	w4 := NewWeeder(context.Background(), namespace, testWeederConfig, nil, nil, testEp, nil, nil, logr.Discard(), WithClock(fakeClock))
+	g.Expect(mgr.Register(*w4)).To(BeTrue())
+	fakeClock.Step(testWatchDuration)
+	g.Eventually(func() float64 { return testutil.ToFloat64(metrics.WeederWatchDurationExpiriesTotal) }).Should(Equal(expiriesBefore + 1))
+	g.Eventually(activeWeeders).Should(BeZero())
+	g.Expect(testutil.ToFloat64(metrics.WeedersCancelledTotal.WithLabelValues(metrics.ReasonDuplicate))).To(Equal(duplicatesBefore+1), "replacing a weeder which is no longer running should not be counted")
+}
@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package retry provides helpers to retry operations with configurable backoff strategies.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ErrPredicateNotSatisfied is passed to the AttemptHook by RetryUntilPredicate for every attempt in which the predicate has returned false.
+var ErrPredicateNotSatisfied = errors.New("predicate is not satisfied")
+
+// BackoffStrategy determines how long to wait before the next attempt of a retriable operation.
+type BackoffStrategy interface {
+	// Backoff returns the duration to wait after the given attempt has failed. Attempts are counted starting from 1.
+	Backoff(attempt int) time.Duration
+}
+
+// ConstantBackoff returns a BackoffStrategy which always waits for the given duration.
+func ConstantBackoff(backOff time.Duration) BackoffStrategy {
+	return constantBackoff(backOff)
+}
+
+type constantBackoff time.Duration
+
+func (c constantBackoff) Backoff(_ int) time.Duration {
+	return time.Duration(c)
+}
+
+// ExponentialBackoff is a BackoffStrategy which multiplies the backoff by Factor after every failed attempt.
+type ExponentialBackoff struct {
+	// Initial is the backoff after the first failed attempt.
+	Initial time.Duration
+	// Factor is the factor by which the backoff is multiplied after every failed attempt. If it is less than or equal to 1 then the backoff is not increased.
+	Factor float64
+	// Max caps the backoff before jitter is applied. If it is 0 then the backoff is not capped.
+	Max time.Duration
+	// JitterFactor adds a random duration of up to JitterFactor times the backoff. If it is less than or equal to 0 then no jitter is added.
+	JitterFactor float64
+}
+
+// Backoff returns the duration to wait after the given attempt has failed.
+func (e ExponentialBackoff) Backoff(attempt int) time.Duration {
+	backOff := float64(e.Initial)
+	if e.Factor > 1 && attempt > 1 {
+		backOff *= math.Pow(e.Factor, float64(attempt-1))
+	}
+	if e.Max > 0 && backOff > float64(e.Max) {
+		backOff = float64(e.Max)
+	}
+	if e.JitterFactor > 0 {
+		return wait.Jitter(time.Duration(backOff), e.JitterFactor)
+	}
+	return time.Duration(backOff)
+}
+
+// AttemptHook is invoked after every attempt of a retriable operation with the attempt, counted starting from 1, and the error of the attempt.
+// The error is nil if the attempt has succeeded. It can be used to e.g. record metrics.
+type AttemptHook func(attempt int, err error)
+
+// Option configures the retry helpers.
+type Option func(options *options)
+
+type options struct {
+	attemptHook     AttemptHook
+	honorRetryAfter bool
+}
+
+// WithAttemptHook sets a hook which is invoked after every attempt.
+func WithAttemptHook(hook AttemptHook) Option {
+	return func(options *options) {
+		options.attemptHook = hook
+	}
+}
+
+// WithRetryAfter makes Retry and RetryOnError wait for the number of seconds which is suggested by the Retry-After information of a
+// TooManyRequests error instead of the backoff, for better cooperation with the API Priority and Fairness of the API server. The backoff is
+// still used for all other errors.
+func WithRetryAfter() Option {
+	return func(options *options) {
+		options.honorRetryAfter = true
+	}
+}
+
+func buildOptions(opts ...Option) *options {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.attemptHook == nil {
+		o.attemptHook = func(int, error) {}
+	}
+	return o
+}
+
+// backoff returns the duration to wait after the given attempt has failed with the given error.
+func (o *options) backoff(backOff BackoffStrategy, attempt int, err error) time.Duration {
+	if o.honorRetryAfter && apierrors.IsTooManyRequests(err) {
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return backOff.Backoff(attempt)
+}
+
+// RetryResult captures the result of a retriable operation.
+type RetryResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// Retry retries an operation `fn`, `numAttempts` number of times with a backoff determined by `backOff` until one of the conditions is met:
+// 1. Invocation of `fn` succeeds.
+// 2. `canRetry` returns false.
+// 3. `numAttempts` have exhausted.
+// 4. `ctx` (context) has either been cancelled or it has expired.
+// The result is captured eventually in `RetryResult`.
+func Retry[T any](ctx context.Context, logger logr.Logger, operation string, fn func() (T, error), numAttempts int, backOff BackoffStrategy, canRetry func(error) bool, opts ...Option) RetryResult[T] {
+	o := buildOptions(opts...)
+	var result T
+	var err error
+	for i := 1; i <= numAttempts; i++ {
+		select {
+		case <-ctx.Done():
+			logger.Error(ctx.Err(), "Context has been cancelled, stopping retry", "operation", operation)
+			return RetryResult[T]{Err: ctx.Err()}
+		default:
+		}
+		result, err = fn()
+		o.attemptHook(i, err)
+		if err == nil {
+			return RetryResult[T]{Value: result, Err: err}
+		}
+		if !canRetry(err) {
+			logger.Error(err, "Exiting retry as canRetry has returned false", "operation", operation, "exitOnAttempt", i)
+			return RetryResult[T]{Err: err}
+		}
+		if i == numAttempts {
+			break
+		}
+		if !sleep(ctx, o.backoff(backOff, i, err)) {
+			logger.Error(ctx.Err(), "Context has been cancelled, stopping retry", "operation", operation)
+			return RetryResult[T]{Err: ctx.Err()}
+		}
+		logger.Info("Will attempt to retry operation", "operation", operation, "currentAttempt", i, "error", err)
+	}
+	return RetryResult[T]{Value: result, Err: err}
+}
+
+// RetryUntilPredicate retries an operation with a backoff determined by `backOff` until one of the following condition is met:
+// 1. `predicateFn` returns true.
+// 2. `timeout` expires.
+// 3. `ctx` (context) is cancelled or expires.
+// Returns true if the invocation of the `predicateFn` was successful and false otherwise.
+func RetryUntilPredicate(ctx context.Context, logger logr.Logger, operation string, predicateFn func() bool, timeout time.Duration, backOff BackoffStrategy, opts ...Option) bool {
+	o := buildOptions(opts...)
+	timeoutCtx, cancelFn := context.WithTimeout(ctx, timeout)
+	defer cancelFn()
+	for i := 1; ; i++ {
+		if predicateFn() {
+			o.attemptHook(i, nil)
+			return true
+		}
+		o.attemptHook(i, ErrPredicateNotSatisfied)
+		if !sleep(timeoutCtx, backOff.Backoff(i)) {
+			if ctx.Err() != nil {
+				logger.Info("Context has been cancelled, exiting retrying operation", "operation", operation)
+			} else {
+				logger.Info("Timed out waiting for predicateFn to be true", "operation", operation)
+			}
+			return false
+		}
+	}
+}
+
+// RetryOnError retries invoking a function till either the invocation of the function does not return an error or the
+// context has timed-out or has been cancelled. The consumers should ensure that the context passed to it
+// has a proper finite timeout set as there is no other timeout taken as a function argument.
+func RetryOnError(ctx context.Context, logger logr.Logger, operation string, retriableFn func() error, backOff BackoffStrategy, opts ...Option) {
+	o := buildOptions(opts...)
+	for i := 1; ; i++ {
+		select {
+		case <-ctx.Done():
+			logger.Info("Context has either timed-out or has been cancelled", "operation", operation)
+			return
+		default:
+		}
+		err := retriableFn()
+		o.attemptHook(i, err)
+		if err == nil {
+			return
+		}
+		logger.Error(err, "Error encountered during retry. Will re-attempt if possible", "operation", operation)
+		if !sleep(ctx, o.backoff(backOff, i, err)) {
+			logger.Info("Context has either timed-out or has been cancelled", "operation", operation)
+			return
+		}
+	}
+}
+
+// AlwaysRetry always returns true irrespective of the error passed.
+func AlwaysRetry(_ error) bool {
+	return true
+}
+
+// sleep waits for the given duration and returns false if the context has been cancelled or has expired before.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,292 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	list            []string
+	numAttempts     = 3
+	backoff         = 10 * time.Millisecond
+	interval        = 2 * time.Millisecond
+	timeout         = 10 * time.Millisecond
+	retryTestLogger = logr.Discard()
+)
+
+func TestNoErrorIfTaskEventuallySucceeds(t *testing.T) {
+	g := NewWithT(t)
+	result := Retry(context.Background(), retryTestLogger, "", passEventually(), numAttempts, ConstantBackoff(backoff), AlwaysRetry)
+	g.Expect(result.Err).ShouldNot(HaveOccurred())
+	g.Expect(result.Value).Should(Equal("appendPass"))
+	g.Expect(list).Should(HaveLen(3))
+	g.Expect(list[0:2]).Should(ConsistOf("appendFail", "appendFail"))
+	g.Expect(list[2]).To(Equal("appendPass"))
+	emptyList()
+}
+
+func TestErrorIfExceedsAttempts(t *testing.T) {
+	g := NewWithT(t)
+	result := Retry(context.Background(), retryTestLogger, "", appendFail, numAttempts, ConstantBackoff(backoff), AlwaysRetry)
+	g.Expect(list).Should(HaveLen(numAttempts))
+	g.Expect(result.Err.Error()).Should(Equal("appendFail"))
+	g.Expect(result.Value).Should(Equal("appendFail"))
+	emptyList()
+}
+
+func TestCanRetryReturnsFalse(t *testing.T) {
+	g := NewWithT(t)
+	var hasRunOnce bool
+	runOnceFn := func(error) bool {
+		if !hasRunOnce {
+			hasRunOnce = true
+			return true
+		}
+		return false
+	}
+	result := Retry(context.Background(), retryTestLogger, "", passEventually(), numAttempts, ConstantBackoff(backoff), runOnceFn)
+	g.Expect(list).Should(HaveLen(2))
+	g.Expect(list[0:2]).Should(ConsistOf("appendFail", "appendFail"))
+	g.Expect(result.Err.Error()).Should(Equal("appendFail"))
+	g.Expect(result.Value).Should(Equal(""))
+	emptyList()
+}
+
+func TestContextCancelledBeforeTaskIsRun(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	var result RetryResult[string]
+	var wg sync.WaitGroup
+	wg.Add(1)
+	cancelFn()
+	go func() {
+		defer wg.Done()
+		result = Retry(ctx, retryTestLogger, "", appendPass, numAttempts, ConstantBackoff(backoff), AlwaysRetry)
+		g.Expect(result.Err).Should(Equal(ctx.Err()))
+		g.Expect(result.Value).Should(Equal(""))
+		g.Expect(len(list)).Should(BeNumerically("<=", numAttempts))
+	}()
+	wg.Wait()
+	emptyList()
+}
+
+func TestContextCancelledBeforeBackoffBegins(t *testing.T) {
+	g := NewWithT(t)
+	var result RetryResult[string]
+	var wg sync.WaitGroup
+	list := make([]string, 0, 1)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result = Retry(ctx, retryTestLogger, "", func() (string, error) {
+			list = append(list, "appendFail")
+			cancelFn()
+			return "", fmt.Errorf("appendFail")
+		}, numAttempts, ConstantBackoff(backoff), AlwaysRetry)
+
+		g.Expect(result.Err).Should(Equal(context.Canceled))
+		g.Expect(result.Value).Should(Equal(""))
+		g.Expect(list).Should(HaveLen(1))
+	}()
+	wg.Wait()
+	emptyList()
+}
+
+func TestRetryUntilPredicateForContextCancelled(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result := RetryUntilPredicate(ctx, retryTestLogger, "", func() bool { return false }, timeout, ConstantBackoff(interval))
+		g.Expect(result).Should(BeFalse())
+	}()
+	cancelFn()
+	wg.Wait()
+}
+
+func TestRetryUntilPredicateWithBackgroundContext(t *testing.T) {
+	table := []struct {
+		predicateFn    func() bool
+		expectedResult bool
+	}{
+		{func() bool { return false }, false},
+		{func() bool { return true }, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := RetryUntilPredicate(context.Background(), retryTestLogger, "", entry.predicateFn, timeout, ConstantBackoff(interval))
+			g.Expect(result).Should(Equal(entry.expectedResult))
+		}()
+		wg.Wait()
+	}
+}
+
+func TestRetryOnError(t *testing.T) {
+	g := NewWithT(t)
+	counter := 0
+	fn := func() error {
+		counter++
+		if counter < 3 {
+			return errors.New("counter is less than 3. Returning an error")
+		}
+		return nil
+	}
+	RetryOnError(context.Background(), retryTestLogger, "", fn, ConstantBackoff(10*time.Millisecond))
+	g.Expect(counter).To(Equal(3))
+}
+
+func TestRetryOnErrorWhenContextIsCancelled(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	counter := 0
+	fn := func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			counter++
+		}
+	}
+	go RetryOnError(context.Background(), retryTestLogger, "", fn, ConstantBackoff(10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond) //forcing counter to be incremented
+	cancelFn()
+	g.Expect(counter).To(BeNumerically(">", 0))
+	g.Expect(ctx.Err()).ToNot(Succeed())
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	table := []struct {
+		description string
+		backOff     ExponentialBackoff
+		expected    []time.Duration
+	}{
+		{"factor is applied for every attempt", ExponentialBackoff{Initial: 10 * time.Millisecond, Factor: 2}, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}},
+		{"backoff is capped at max", ExponentialBackoff{Initial: 10 * time.Millisecond, Factor: 3, Max: 50 * time.Millisecond}, []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 50 * time.Millisecond}},
+		{"factor of at most 1 is constant", ExponentialBackoff{Initial: 10 * time.Millisecond, Factor: 1}, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond}},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		for i, expected := range entry.expected {
+			g.Expect(entry.backOff.Backoff(i+1)).To(Equal(expected), entry.description)
+		}
+	}
+}
+
+func TestExponentialBackoffWithJitter(t *testing.T) {
+	g := NewWithT(t)
+	backOff := ExponentialBackoff{Initial: 10 * time.Millisecond, Factor: 2, JitterFactor: 0.5}
+	for i := 0; i < 10; i++ {
+		g.Expect(backOff.Backoff(2)).To(And(BeNumerically(">=", 20*time.Millisecond), BeNumerically("<", 30*time.Millisecond)))
+	}
+}
+
+func TestAttemptHookShouldBeInvokedForEveryAttempt(t *testing.T) {
+	g := NewWithT(t)
+	var attempts []int
+	var errs []error
+	hook := WithAttemptHook(func(attempt int, err error) {
+		attempts = append(attempts, attempt)
+		errs = append(errs, err)
+	})
+	result := Retry(context.Background(), retryTestLogger, "", passEventually(), numAttempts, ConstantBackoff(backoff), AlwaysRetry, hook)
+	g.Expect(result.Err).ToNot(HaveOccurred())
+	g.Expect(attempts).To(Equal([]int{1, 2, 3}))
+	g.Expect(errs[0]).To(HaveOccurred())
+	g.Expect(errs[1]).To(HaveOccurred())
+	g.Expect(errs[2]).ToNot(HaveOccurred())
+	emptyList()
+
+	attempts, errs = nil, nil
+	counter := 0
+	g.Expect(RetryUntilPredicate(context.Background(), retryTestLogger, "", func() bool {
+		counter++
+		return counter == 2
+	}, timeout, ConstantBackoff(interval), hook)).To(BeTrue())
+	g.Expect(attempts).To(Equal([]int{1, 2}))
+	g.Expect(errs).To(Equal([]error{ErrPredicateNotSatisfied, nil}))
+}
+
+func TestRetryUntilPredicateShouldNotWaitForBackoffIfContextIsCancelled(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	time.AfterFunc(interval, cancelFn)
+	start := time.Now()
+	result := RetryUntilPredicate(ctx, retryTestLogger, "", func() bool { return false }, time.Minute, ConstantBackoff(time.Minute))
+	g.Expect(result).To(BeFalse())
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second), "RetryUntilPredicate should return as soon as the context is cancelled")
+}
+
+func TestRetryShouldHonorRetryAfterOfThrottlingErrors(t *testing.T) {
+	g := NewWithT(t)
+	throttlingErr := apierrors.NewTooManyRequests("Too many requests", 1)
+	attempts := 0
+	fn := func() (string, error) {
+		attempts++
+		if attempts == 1 {
+			return "", throttlingErr
+		}
+		return "", nil
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	start := time.Now()
+	result := Retry(ctx, retryTestLogger, "", fn, numAttempts, ConstantBackoff(time.Minute), AlwaysRetry, WithRetryAfter())
+	g.Expect(result.Err).ToNot(HaveOccurred())
+	g.Expect(time.Since(start)).To(BeNumerically(">=", time.Second), "Retry should wait for the duration suggested by the throttling error")
+
+	o := buildOptions(WithRetryAfter())
+	g.Expect(o.backoff(ConstantBackoff(backoff), 1, throttlingErr)).To(Equal(time.Second))
+	g.Expect(o.backoff(ConstantBackoff(backoff), 1, apierrors.NewTooManyRequests("Too many requests", 0))).To(Equal(backoff))
+	g.Expect(o.backoff(ConstantBackoff(backoff), 1, fmt.Errorf("wrapped: %w", throttlingErr))).To(Equal(time.Second))
+	g.Expect(o.backoff(ConstantBackoff(backoff), 1, apierrors.NewServerTimeout(schema.GroupResource{Resource: "deployments"}, "get", 1))).To(Equal(backoff), "only throttling errors should be considered")
+	g.Expect(buildOptions().backoff(ConstantBackoff(backoff), 1, throttlingErr)).To(Equal(backoff), "Retry-After should only be honored if enabled")
+}
+
+func appendFail() (string, error) {
+	list = append(list, "appendFail")
+	return "appendFail", fmt.Errorf("appendFail")
+}
+
+func appendPass() (string, error) {
+	list = append(list, "appendPass")
+	return "appendPass", nil
+}
+
+func passEventually() func() (string, error) {
+	var runCounter = 0
+	return func() (string, error) {
+		runCounter++
+		if runCounter%3 == 0 {
+			return appendPass()
+		}
+		return appendFail()
+	}
+}
+
+func emptyList() {
+	list = nil
+}
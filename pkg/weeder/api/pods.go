@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package api provides the logic the weeder uses to select the dependant pods it deletes, so that extensions, e.g. their webhooks, can rely on
+// exactly the same semantics.
+package api
+
+import (
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// CrashLoopBackOffReason is the reason of the waiting state of a container which is in CrashLoopBackOff.
+	CrashLoopBackOffReason = "CrashLoopBackOff"
+	// DoNotWeedAnnotationKey is the key for an annotation which if set to true on a pod will prevent the weeder from ever deleting it.
+	// This allows operators to pin a crashing pod, e.g. to debug it, without the weeder deleting it when the endpoint flaps.
+	DoNotWeedAnnotationKey = "dependency-watchdog.gardener.cloud/do-not-weed"
+)
+
+// MatchesAnyPodSelector checks if the labels of the pod are matched by any of the given pod selectors. An error is returned if a pod selector
+// is invalid.
+func MatchesAnyPodSelector(pod *corev1.Pod, podSelectors []*metav1.LabelSelector) (bool, error) {
+	for _, podSelector := range podSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(podSelector)
+		if err != nil {
+			return false, err
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PodPredicate checks if a pod satisfies a condition, e.g. if it is unhealthy.
+type PodPredicate func(pod *corev1.Pod) bool
+
+// NewUnhealthyPodPredicate creates a PodPredicate which considers a pod unhealthy if it is in one of the given phases and any of its containers
+// is in a waiting or terminated state with one of the given reasons and has been restarted at least minRestartCount times. If no phases are
+// given then pods in any phase are considered.
+func NewUnhealthyPodPredicate(containerStateReasons []string, minRestartCount int32, phases []corev1.PodPhase) PodPredicate {
+	return func(pod *corev1.Pod) bool {
+		if len(phases) > 0 && !slices.Contains(phases, pod.Status.Phase) {
+			return false
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.RestartCount >= minRestartCount && slices.Contains(containerStateReasons, getContainerStateReason(containerStatus.State)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ShouldWeedPod checks if a pod should be deleted for quicker recovery. A pod can be deleted only if it is not marked for deletion, is not
+// protected via DoNotWeedAnnotationKey and is currently in CrashLoopBackOff state.
+func ShouldWeedPod(pod *corev1.Pod) bool {
+	return ShouldWeedPodMatching(pod, func(pod *corev1.Pod) bool { return IsPodInCrashLoopBackOff(pod.Status) })
+}
+
+// ShouldWeedPodMatching is like ShouldWeedPod but considers a pod for deletion if it is matched by the given isUnhealthy predicate instead of
+// if it is in CrashLoopBackOff state.
+func ShouldWeedPodMatching(pod *corev1.Pod, isUnhealthy PodPredicate) bool {
+	podNotMarkedForDeletion := pod.DeletionTimestamp == nil
+	return podNotMarkedForDeletion && !IsPodProtected(pod) && isUnhealthy(pod)
+}
+
+// IsPodProtected checks if the pod has been annotated with DoNotWeedAnnotationKey set to true.
+func IsPodProtected(pod *corev1.Pod) bool {
+	protected, err := strconv.ParseBool(pod.Annotations[DoNotWeedAnnotationKey])
+	return err == nil && protected
+}
+
+// IsPodInCrashLoopBackOff checks if any container in a pod is in CrashLoopBackOff.
+func IsPodInCrashLoopBackOff(status corev1.PodStatus) bool {
+	for _, containerStatus := range status.ContainerStatuses {
+		if IsContainerInCrashLoopBackOff(containerStatus.State) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsContainerInCrashLoopBackOff checks if a container is in CrashLoopBackOff.
+func IsContainerInCrashLoopBackOff(containerState corev1.ContainerState) bool {
+	return containerState.Waiting != nil && containerState.Waiting.Reason == CrashLoopBackOffReason
+}
+
+// getContainerStateReason returns the reason of the waiting or terminated state of a container. It is empty for a running container.
+func getContainerStateReason(containerState corev1.ContainerState) string {
+	switch {
+	case containerState.Waiting != nil:
+		return containerState.Waiting.Reason
+	case containerState.Terminated != nil:
+		return containerState.Terminated.Reason
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !kind_tests
+
+package api
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShouldWeedPod(t *testing.T) {
+	crashLoopBackOffStatus := corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: CrashLoopBackOffReason}}}}}
+	now := metav1.Now()
+
+	tests := []struct {
+		name              string
+		annotations       map[string]string
+		deletionTimestamp *metav1.Time
+		status            corev1.PodStatus
+		expected          bool
+	}{
+		{"pod in CrashLoopBackOff should be weeded", nil, nil, crashLoopBackOffStatus, true},
+		{"pod not in CrashLoopBackOff should not be weeded", nil, nil, corev1.PodStatus{}, false},
+		{"pod marked for deletion should not be weeded", nil, &now, crashLoopBackOffStatus, false},
+		{"pod annotated with do-not-weed should not be weeded", map[string]string{DoNotWeedAnnotationKey: "true"}, nil, crashLoopBackOffStatus, false},
+		{"pod with do-not-weed set to false should be weeded", map[string]string{DoNotWeedAnnotationKey: "false"}, nil, crashLoopBackOffStatus, true},
+		{"pod with invalid do-not-weed value should be weeded", map[string]string{DoNotWeedAnnotationKey: "bingo"}, nil, crashLoopBackOffStatus, true},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver-abcde", Annotations: entry.annotations, DeletionTimestamp: entry.deletionTimestamp}, Status: entry.status}
+			g.Expect(ShouldWeedPod(pod)).To(Equal(entry.expected))
+		})
+	}
+}
+
+func TestNewUnhealthyPodPredicate(t *testing.T) {
+	waitingWith := func(reason string, restartCount int32) corev1.ContainerStatus {
+		return corev1.ContainerStatus{RestartCount: restartCount, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}}
+	}
+	terminatedWith := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason}}}
+	}
+	running := corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	reasons := []string{CrashLoopBackOffReason, "CreateContainerConfigError", "OOMKilled"}
+
+	tests := []struct {
+		name              string
+		minRestartCount   int32
+		phases            []corev1.PodPhase
+		phase             corev1.PodPhase
+		containerStatuses []corev1.ContainerStatus
+		expected          bool
+	}{
+		{"pod with a container waiting with a configured reason should be unhealthy", 0, nil, corev1.PodRunning, []corev1.ContainerStatus{running, waitingWith("CreateContainerConfigError", 0)}, true},
+		{"pod with a container terminated with a configured reason should be unhealthy", 0, nil, corev1.PodRunning, []corev1.ContainerStatus{terminatedWith("OOMKilled")}, true},
+		{"pod with a container waiting with another reason should not be unhealthy", 0, nil, corev1.PodRunning, []corev1.ContainerStatus{waitingWith("ContainerCreating", 0)}, false},
+		{"pod with running containers should not be unhealthy", 0, nil, corev1.PodRunning, []corev1.ContainerStatus{running}, false},
+		{"pod whose container has not been restarted often enough should not be unhealthy", 3, nil, corev1.PodRunning, []corev1.ContainerStatus{waitingWith(CrashLoopBackOffReason, 2)}, false},
+		{"pod whose container has been restarted often enough should be unhealthy", 3, nil, corev1.PodRunning, []corev1.ContainerStatus{waitingWith(CrashLoopBackOffReason, 3)}, true},
+		{"pod in another phase should not be unhealthy", 0, []corev1.PodPhase{corev1.PodPending}, corev1.PodRunning, []corev1.ContainerStatus{waitingWith(CrashLoopBackOffReason, 0)}, false},
+		{"pod in a configured phase should be unhealthy", 0, []corev1.PodPhase{corev1.PodPending, corev1.PodRunning}, corev1.PodRunning, []corev1.ContainerStatus{waitingWith(CrashLoopBackOffReason, 0)}, true},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			pod := &corev1.Pod{Status: corev1.PodStatus{Phase: entry.phase, ContainerStatuses: entry.containerStatuses}}
+			g.Expect(NewUnhealthyPodPredicate(reasons, entry.minRestartCount, entry.phases)(pod)).To(Equal(entry.expected))
+		})
+	}
+}
+
+func TestIsPodInCrashLoopBackOff(t *testing.T) {
+	g := NewWithT(t)
+	running := corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	crashing := corev1.ContainerStatus{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: CrashLoopBackOffReason}}}
+	pulling := corev1.ContainerStatus{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}}
+
+	g.Expect(IsPodInCrashLoopBackOff(corev1.PodStatus{})).To(BeFalse())
+	g.Expect(IsPodInCrashLoopBackOff(corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{running, pulling}})).To(BeFalse())
+	g.Expect(IsPodInCrashLoopBackOff(corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{running, crashing}})).To(BeTrue(), "a single container in CrashLoopBackOff should suffice")
+}
+
+func TestMatchesAnyPodSelector(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kube-apiserver-abcde", Labels: map[string]string{"gardener.cloud/role": "controlplane", "role": "apiserver"}}}
+	apiServerSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"role": "apiserver"}}
+	etcdSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"role": "etcd"}}
+	notMainSelector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "role", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"main"}}}}
+	invalidSelector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "role", Operator: "bingo"}}}
+
+	tests := []struct {
+		name        string
+		selectors   []*metav1.LabelSelector
+		expected    bool
+		expectedErr bool
+	}{
+		{"no selectors should not match", nil, false, false},
+		{"matching labels should match", []*metav1.LabelSelector{etcdSelector, apiServerSelector}, true, false},
+		{"matching expressions should match", []*metav1.LabelSelector{notMainSelector}, true, false},
+		{"non-matching labels should not match", []*metav1.LabelSelector{etcdSelector}, false, false},
+		{"invalid selector should return an error", []*metav1.LabelSelector{invalidSelector}, false, true},
+	}
+
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			g := NewWithT(t)
+			matches, err := MatchesAnyPodSelector(pod, entry.selectors)
+			g.Expect(err != nil).To(Equal(entry.expectedErr))
+			g.Expect(matches).To(Equal(entry.expected))
+		})
+	}
+}
@@ -0,0 +1,7 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package e2e contains end-to-end tests which run the prober against the seed and the shoot of a real Gardener landscape. They are only
+// compiled with the `e2e_tests` build tag, see docs/development/testing.md.
+package e2e
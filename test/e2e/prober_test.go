@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build e2e_tests
+
+package e2e
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	papi "github.com/gardener/dependency-watchdog/api/prober"
+	"github.com/gardener/dependency-watchdog/internal/prober"
+	"github.com/gardener/dependency-watchdog/internal/prober/scaler"
+	"github.com/gardener/dependency-watchdog/internal/prober/shoot"
+	"github.com/gardener/dependency-watchdog/internal/test"
+	"github.com/gardener/dependency-watchdog/internal/util"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	machinev1alpha1 "github.com/gardener/machine-controller-manager/pkg/apis/machine/v1alpha1"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	// seedKubeConfigEnvVar is the environment variable holding the path to the kubeconfig of the seed.
+	seedKubeConfigEnvVar = "SEED_KUBECONFIG"
+	// shootKubeConfigEnvVar is the environment variable holding the path to the kubeconfig of the shoot.
+	shootKubeConfigEnvVar = "SHOOT_KUBECONFIG"
+	// shootNamespaceEnvVar is the environment variable holding the control namespace of the shoot in the seed.
+	shootNamespaceEnvVar = "SHOOT_NAMESPACE"
+
+	canaryImageName        = "registry.k8s.io/pause:3.10"
+	canaryReplicas         = 1
+	nodeLeaseNamespace     = "kube-node-lease"
+	leaseExpiryInterval    = time.Second
+	scaleTimeout           = 5 * time.Minute
+	scaleCheckInterval     = 5 * time.Second
+	canaryReadyTimeout     = 2 * time.Minute
+	canaryDeletionTimeout  = time.Minute
+	proberConfigPath       = "testdata/prober-config.yaml"
+	expectedScaledDownSize = 0
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	localSchemeBuilder := runtime.NewSchemeBuilder(
+		clientgoscheme.AddToScheme,
+		extensionsv1alpha1.AddToScheme,
+		machinev1alpha1.AddToScheme,
+	)
+	utilruntime.Must(localSchemeBuilder.AddToScheme(scheme))
+}
+
+// e2eEnv holds the clients to the seed and the shoot of the landscape the end-to-end tests are run against.
+type e2eEnv struct {
+	seedRestConfig      *rest.Config
+	seedClient          client.Client
+	shootClient         client.Client
+	shootKubeConfigPath string
+	shootNamespace      string
+	proberConfig        *papi.Config
+}
+
+func TestProberE2ESuite(t *testing.T) {
+	g := NewWithT(t)
+	env := setUpE2EEnv(t, g)
+	tearDown := createCanaryDeployments(g, env)
+	defer tearDown()
+
+	tests := []struct {
+		title string
+		run   func(t *testing.T, env *e2eEnv)
+	}{
+		{"dependents should be scaled down once node leases expire and scaled up once they are renewed", testScaleDownAndScaleUpOnLeaseExpiry},
+	}
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			test.run(t, env)
+		})
+	}
+}
+
+func testScaleDownAndScaleUpOnLeaseExpiry(t *testing.T, env *e2eEnv) {
+	g := NewWithT(t)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	logger := zap.New(zap.UseDevMode(true)).WithName("e2e")
+
+	p := createProber(ctx, g, env, logger)
+	p.Start()
+	defer p.Close()
+
+	stopExpiringLeases := expireNodeLeases(ctx, t, env)
+	g.Eventually(func() bool { return areCanariesScaledTo(ctx, env, expectedScaledDownSize) }, scaleTimeout, scaleCheckInterval).
+		Should(BeTrue(), "canary deployments should be scaled down once the node leases have expired")
+	g.Expect(p.AreDependentsScaledDown()).To(BeTrue())
+
+	// once the leases are no longer expired by the test, the kubelets renew them within their renew interval
+	stopExpiringLeases()
+	g.Eventually(func() bool { return areCanariesScaledTo(ctx, env, canaryReplicas) }, scaleTimeout, scaleCheckInterval).
+		Should(BeTrue(), "canary deployments should be scaled up once the node leases have been renewed")
+	g.Expect(p.HasLeaseProbeFailed()).To(BeFalse())
+}
+
+func setUpE2EEnv(t *testing.T, g *WithT) *e2eEnv {
+	seedKubeConfigPath, shootKubeConfigPath, shootNamespace := os.Getenv(seedKubeConfigEnvVar), os.Getenv(shootKubeConfigEnvVar), os.Getenv(shootNamespaceEnvVar)
+	if seedKubeConfigPath == "" || shootKubeConfigPath == "" || shootNamespace == "" {
+		t.Skipf("%s, %s and %s must be set to run the e2e tests", seedKubeConfigEnvVar, shootKubeConfigEnvVar, shootNamespaceEnvVar)
+	}
+	seedRestConfig, err := clientcmd.BuildConfigFromFlags("", seedKubeConfigPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	seedClient, err := client.New(seedRestConfig, client.Options{Scheme: scheme})
+	g.Expect(err).ToNot(HaveOccurred())
+	shootRestConfig, err := clientcmd.BuildConfigFromFlags("", shootKubeConfigPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	shootClient, err := client.New(shootRestConfig, client.Options{Scheme: scheme})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	test.FileExistsOrFail(proberConfigPath)
+	proberConfig, err := prober.LoadConfig(filepath.Clean(proberConfigPath), scheme, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return &e2eEnv{
+		seedRestConfig:      seedRestConfig,
+		seedClient:          seedClient,
+		shootClient:         shootClient,
+		shootKubeConfigPath: shootKubeConfigPath,
+		shootNamespace:      shootNamespace,
+		proberConfig:        proberConfig,
+	}
+}
+
+// createCanaryDeployments creates a deployment in the shoot namespace of the seed for every dependent resource of the prober config and waits
+// until they are available. The returned function deletes the canary deployments again.
+func createCanaryDeployments(g *WithT, env *e2eEnv) func() {
+	ctx := context.Background()
+	canaries := make([]*appsv1.Deployment, 0, len(env.proberConfig.DependentResourceInfos))
+	for _, resInfo := range env.proberConfig.DependentResourceInfos {
+		canary := test.GenerateDeployment(resInfo.Ref.Name, env.shootNamespace, canaryImageName, canaryReplicas, nil)
+		g.Expect(client.IgnoreAlreadyExists(env.seedClient.Create(ctx, canary))).To(Succeed())
+		canaries = append(canaries, canary)
+	}
+	g.Eventually(func() bool { return areCanariesAvailable(ctx, env, canaries) }, canaryReadyTimeout, scaleCheckInterval).Should(BeTrue())
+
+	return func() {
+		for _, canary := range canaries {
+			g.Expect(client.IgnoreNotFound(env.seedClient.Delete(ctx, canary))).To(Succeed())
+		}
+		g.Eventually(func() bool {
+			for _, canary := range canaries {
+				if err := env.seedClient.Get(ctx, client.ObjectKeyFromObject(canary), &appsv1.Deployment{}); !apierrors.IsNotFound(err) {
+					return false
+				}
+			}
+			return true
+		}, canaryDeletionTimeout, scaleCheckInterval).Should(BeTrue())
+	}
+}
+
+func createProber(ctx context.Context, g *WithT, env *e2eEnv, logger logr.Logger) *prober.Prober {
+	scalesGetter, err := util.CreateScalesGetter(env.seedRestConfig)
+	g.Expect(err).ToNot(HaveOccurred())
+	config := env.proberConfig
+	deploymentScaler := scaler.NewScaler(env.shootNamespace, config.DependentResourceInfos, env.seedClient, scalesGetter, logger,
+		scaler.WithReplicasAnnotationKey(*config.ReplicasAnnotationKey, *config.DualWriteReplicasAnnotation),
+		scaler.WithMaxConcurrentScalesPerLevel(pointer.IntDeref(config.MaxConcurrentScalesPerLevel, 0)),
+		scaler.WithFlowTimeout(util.GetValOrDefault(config.ScaleFlowTimeout, metav1.Duration{}).Duration))
+	shootClientCreator := shoot.NewKubeConfigFileClientCreator(env.shootKubeConfigPath)
+	return prober.NewProber(ctx, env.seedClient, env.seedClient, env.shootNamespace, config, nil, deploymentScaler, shootClientCreator, nil, nil, nil, logger)
+}
+
+// expireNodeLeases periodically moves the renew time of all node leases of the shoot into the past until the returned function is called. The
+// kubelets keep renewing their leases, the renew time is therefore reset more often than the kubelets renew them, so that the prober observes
+// the leases as expired.
+func expireNodeLeases(ctx context.Context, t *testing.T, env *e2eEnv) func() {
+	expiredRenewTime := func() *metav1.MicroTime {
+		return &metav1.MicroTime{Time: time.Now().Add(-2 * env.proberConfig.KCMNodeMonitorGraceDuration.Duration)}
+	}
+	ctx, cancelFn := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(leaseExpiryInterval)
+		defer ticker.Stop()
+		for {
+			leases := &coordinationv1.LeaseList{}
+			if err := env.shootClient.List(ctx, leases, client.InNamespace(nodeLeaseNamespace)); err != nil && ctx.Err() == nil {
+				t.Logf("failed to list node leases: %v", err)
+			}
+			for _, lease := range leases.Items {
+				patch := client.MergeFrom(lease.DeepCopy())
+				lease.Spec.RenewTime = expiredRenewTime()
+				if err := env.shootClient.Patch(ctx, &lease, patch); err != nil && ctx.Err() == nil {
+					t.Logf("failed to expire node lease %s: %v", lease.Name, err)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancelFn()
+		wg.Wait()
+	}
+}
+
+func areCanariesScaledTo(ctx context.Context, env *e2eEnv, replicas int32) bool {
+	for _, resInfo := range env.proberConfig.DependentResourceInfos {
+		deployment := &appsv1.Deployment{}
+		if err := env.seedClient.Get(ctx, client.ObjectKey{Namespace: env.shootNamespace, Name: resInfo.Ref.Name}, deployment); err != nil {
+			return false
+		}
+		if pointer.Int32Deref(deployment.Spec.Replicas, 0) != replicas {
+			return false
+		}
+	}
+	return true
+}
+
+func areCanariesAvailable(ctx context.Context, env *e2eEnv, canaries []*appsv1.Deployment) bool {
+	for _, canary := range canaries {
+		deployment := &appsv1.Deployment{}
+		if err := env.seedClient.Get(ctx, client.ObjectKeyFromObject(canary), deployment); err != nil {
+			return false
+		}
+		if deployment.Status.AvailableReplicas != canaryReplicas {
+			return false
+		}
+	}
+	return true
+}